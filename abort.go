@@ -0,0 +1,26 @@
+package migrate
+
+import "context"
+
+// MigrateContext is Migrate, aborting the run gracefully when ctx is done, e.g. on a deadline or a SIGTERM that
+// cancels it. Unlike the timeout of SetTimeout, ctx does not cancel the statement that is executing: the statement,
+// or batch of statements, is finished, the migration is recorded as failed with the position of the next statement,
+// so that ResumeFailed continues with it, the locks of the run are released and an error wrapping ErrAborted is
+// returned. Migrations that have not been started are left pending. Go migrations are not interrupted.
+func (m *Migrator) MigrateContext(ctx context.Context) error {
+	_, err := m.migrateWithResult(ctx, nil)
+	return err
+}
+
+// aborted reports whether the context of the run is done.
+func (m *Migrator) aborted() bool {
+	if m.abort == nil {
+		return false
+	}
+	select {
+	case <-m.abort.Done():
+		return true
+	default:
+		return false
+	}
+}
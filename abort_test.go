@@ -0,0 +1,67 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMigrateContextAborted(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.SetRecovery(ResumeFailed)
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\nCREATE TABLE c (id INT);")
+	m.AddSQLMigration("2", "two", "CREATE TABLE d (id INT);")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.Subscribe(SubscriberFunc(func(e Event) {
+		if e, ok := e.(StatementExecuted); ok && e.Statements == 1 {
+			cancel()
+		}
+	}))
+	if err := m.MigrateContext(ctx); !errors.Is(err, ErrAborted) {
+		t.Fatalf("want aborted, got: %v", err)
+	}
+	if want, got := []string{"CREATE TABLE a (id INT);"}, log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	if rec := s.migrations[0]; len(s.migrations) != 1 || rec.Status != StatusFailed || rec.FailedStatement != 2 {
+		t.Fatalf("want the aborted migration recorded as failed at statement 2, got: %+v", s.migrations)
+	}
+
+	if err := m.MigrateContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"CREATE TABLE a (id INT);", "CREATE TABLE b (id INT);", "CREATE TABLE c (id INT);", "CREATE TABLE d (id INT);"}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
+
+func TestMigrateContextAbortedBetweenMigrations(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	m.AddSQLMigration("2", "two", "CREATE TABLE b (id INT);", IgnoreFailure())
+	m.Subscribe(SubscriberFunc(func(e Event) {
+		if _, ok := e.(MigrationFinished); ok {
+			cancel()
+		}
+	}))
+	if err := m.MigrateContext(ctx); !errors.Is(err, ErrAborted) {
+		t.Fatalf("want aborted, got: %v", err)
+	}
+	if want, got := []string{"CREATE TABLE a (id INT);"}, log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	if len(s.migrations) != 1 || s.migrations[0].Status != StatusSuccess {
+		t.Errorf("want only the first migration recorded, got: %+v", s.migrations)
+	}
+}
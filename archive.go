@@ -0,0 +1,234 @@
+package migrate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// LoadArchive loads the versioned and repeatable SQL migrations of a .zip, .tar, .tar.gz or .tgz archive like LoadDir.
+// Scripts are found in any directory of the archive and are streamed from it whenever they are read.
+func LoadArchive(file string) (Migrations, error) {
+	return loadArchiveFile(file, versionOrder{})
+}
+
+// loadArchiveFile runs LoadArchive, ordering versions with o.
+func loadArchiveFile(file string, o versionOrder) (Migrations, error) {
+	switch {
+	case strings.HasSuffix(file, ".zip"):
+		return loadZipFile(file, o)
+	case strings.HasSuffix(file, ".tar"), strings.HasSuffix(file, ".tar.gz"), strings.HasSuffix(file, ".tgz"):
+		return loadTarFile(file, o)
+	default:
+		return nil, fmt.Errorf("unknown archive format: %s", file)
+	}
+}
+
+// LoadZip loads the migrations of a zip archive held by r like LoadArchive, e.g. an archive that is compiled into the
+// binary and read with bytes.NewReader.
+func LoadZip(r io.ReaderAt, size int64) (Migrations, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return loadArchive(zipNames(zr.File), func(name string) (io.ReadCloser, error) {
+		return openZipEntry(zr.File, name)
+	}, versionOrder{})
+}
+
+// AddArchive adds the migrations loaded from file by LoadArchive. Their versions are ordered like those of the
+// Migrator, see SetVersionComparator.
+func (m *Migrator) AddArchive(file string) error {
+	ms, err := loadArchiveFile(file, m.versionOrdering)
+	if err != nil {
+		return err
+	}
+	for _, mig := range ms {
+		m.Add(mig)
+	}
+	return nil
+}
+
+// archiveEntry is a migration script found in an archive.
+type archiveEntry struct {
+	file MigrationFile
+	name string
+}
+
+// loadArchive creates the migrations of the scripts among the entries names of an archive. open opens an entry. The
+// versions are ordered with o.
+func loadArchive(names []string, open func(name string) (io.ReadCloser, error), o versionOrder) (Migrations, error) {
+	entries := []archiveEntry{}
+	for _, name := range names {
+		if f, ok := ParseMigrationFile(path.Base(name)); ok {
+			entries = append(entries, archiveEntry{file: f, name: name})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return lessMigrationFile(entries[i].file, entries[j].file, o)
+	})
+	fs := make([]MigrationFile, len(entries))
+	for i, e := range entries {
+		fs[i] = e.file
+	}
+	if err := fileConflicts(fs, o); err != nil {
+		return nil, err
+	}
+	ms := Migrations{}
+	for _, e := range entries {
+		if e.file.Prefix == PrefixUndo {
+			continue
+		}
+		name := e.name
+		source := func() (io.ReadCloser, error) {
+			return open(name)
+		}
+		if e.file.Compressed {
+			source = gunzip(source)
+		}
+		mig, err := NewSQLSourceMigration(e.file.Version, e.file.Description, source)
+		if err != nil {
+			return nil, fmt.Errorf("read migration: %s: %+v", name, err)
+		}
+		ms = append(ms, mig)
+	}
+	return ms, nil
+}
+
+func zipNames(files []*zip.File) []string {
+	names := []string{}
+	for _, f := range files {
+		if !f.FileInfo().IsDir() {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+func openZipEntry(files []*zip.File, name string) (io.ReadCloser, error) {
+	for _, f := range files {
+		if f.Name == name {
+			return f.Open()
+		}
+	}
+	return nil, fmt.Errorf("archive entry not found: %s", name)
+}
+
+func loadZipFile(file string, o versionOrder) (Migrations, error) {
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, err
+	}
+	names := zipNames(zr.File)
+	zr.Close()
+	return loadArchive(names, func(name string) (io.ReadCloser, error) {
+		zr, err := zip.OpenReader(file)
+		if err != nil {
+			return nil, err
+		}
+		rc, err := openZipEntry(zr.File, name)
+		if err != nil {
+			zr.Close()
+			return nil, err
+		}
+		return readCloser{Reader: rc, closers: []io.Closer{rc, zr}}, nil
+	}, o)
+}
+
+func loadTarFile(file string, o versionOrder) (Migrations, error) {
+	rc, err := openTar(file)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	names := []string{}
+	tr := tar.NewReader(rc)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if h.Typeflag == tar.TypeReg {
+			names = append(names, h.Name)
+		}
+	}
+	return loadArchive(names, func(name string) (io.ReadCloser, error) {
+		return openTarEntry(file, name)
+	}, o)
+}
+
+// openTar opens the tar archive file, which is decompressed if it has been compressed with gzip.
+func openTar(file string) (io.ReadCloser, error) {
+	open := func() (io.ReadCloser, error) {
+		return os.Open(file)
+	}
+	if strings.HasSuffix(file, ".gz") || strings.HasSuffix(file, ".tgz") {
+		open = gunzip(open)
+	}
+	return open()
+}
+
+// openTarEntry opens the entry name of the tar archive file. Tar archives have no index, so the archive is read up to
+// the entry.
+func openTarEntry(file string, name string) (io.ReadCloser, error) {
+	rc, err := openTar(file)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(rc)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			rc.Close()
+			return nil, fmt.Errorf("archive entry not found: %s", name)
+		}
+		if err != nil {
+			rc.Close()
+			return nil, err
+		}
+		if h.Name == name {
+			return readCloser{Reader: tr, closers: []io.Closer{rc}}, nil
+		}
+	}
+}
+
+// gunzip returns an opener of the decompressed content of the gzip stream opened by open.
+func gunzip(open func() (io.ReadCloser, error)) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		rc, err := open()
+		if err != nil {
+			return nil, err
+		}
+		zr, err := gzip.NewReader(rc)
+		if err != nil {
+			rc.Close()
+			return nil, err
+		}
+		return readCloser{Reader: zr, closers: []io.Closer{zr, rc}}, nil
+	}
+}
+
+// readCloser reads from Reader and closes closers in order.
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (rc readCloser) Close() error {
+	var err error
+	for _, c := range rc.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
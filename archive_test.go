@@ -0,0 +1,150 @@
+package migrate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var archiveScripts = map[string]string{
+	"migrations/V1__create_users.sql": "CREATE TABLE users (id INT);",
+	"migrations/R__users_view.sql":    "CREATE VIEW users_view AS SELECT id FROM users;",
+	"migrations/README.md":            "not a migration",
+}
+
+const seedScript = "INSERT INTO users (id) VALUES (1);"
+
+func gzipped(t *testing.T, text string) []byte {
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	w.Write([]byte(text))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func checkArchiveMigrations(t *testing.T, ms Migrations) {
+	t.Helper()
+	want := []struct {
+		version Version
+		script  string
+	}{
+		{VersionRepeatable, archiveScripts["migrations/R__users_view.sql"]},
+		{"1", archiveScripts["migrations/V1__create_users.sql"]},
+		{"2", seedScript},
+	}
+	if len(ms) != len(want) {
+		t.Fatalf("want %d migrations, got: %v", len(want), ms)
+	}
+	for i, w := range want {
+		mig := ms[i]
+		if mig.Version != w.version || mig.Checksum != ChecksumMD5.Sum(w.script) {
+			t.Errorf("unexpected migration: %s %s", mig, mig.Checksum)
+		}
+		rc, err := mig.Source()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		if string(got) != w.script {
+			t.Errorf("want: %q, got: %q", w.script, got)
+		}
+	}
+}
+
+func TestLoadArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	zipped := &bytes.Buffer{}
+	zw := zip.NewWriter(zipped)
+	for name, script := range archiveScripts {
+		w, _ := zw.Create(name)
+		w.Write([]byte(script))
+	}
+	w, _ := zw.Create("migrations/V2__seed.sql.gz")
+	w.Write(gzipped(t, seedScript))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zipFile := filepath.Join(dir, "migrations.zip")
+	if err := ioutil.WriteFile(zipFile, zipped.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tarred := &bytes.Buffer{}
+	gw := gzip.NewWriter(tarred)
+	tw := tar.NewWriter(gw)
+	add := func(name string, data []byte) {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg})
+		tw.Write(data)
+	}
+	for name, script := range archiveScripts {
+		add(name, []byte(script))
+	}
+	add("migrations/V2__seed.sql.gz", gzipped(t, seedScript))
+	tw.Close()
+	gw.Close()
+	tarFile := filepath.Join(dir, "migrations.tar.gz")
+	if err := ioutil.WriteFile(tarFile, tarred.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("zip", func(t *testing.T) {
+		ms, err := LoadArchive(zipFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkArchiveMigrations(t, ms)
+	})
+	t.Run("zip reader", func(t *testing.T) {
+		ms, err := LoadZip(bytes.NewReader(zipped.Bytes()), int64(zipped.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkArchiveMigrations(t, ms)
+	})
+	t.Run("tar.gz", func(t *testing.T) {
+		ms, err := LoadArchive(tarFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkArchiveMigrations(t, ms)
+	})
+	t.Run("dir", func(t *testing.T) {
+		migrations := filepath.Join(dir, "migrations")
+		os.Mkdir(migrations, 0755)
+		for name, script := range archiveScripts {
+			ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0644)
+		}
+		ioutil.WriteFile(filepath.Join(migrations, "V2__seed.sql.gz"), gzipped(t, seedScript), 0644)
+		ms, err := LoadDir(migrations)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// plain scripts are read into memory
+		for i := range ms {
+			if ms[i].Source == nil {
+				ms[i].Source = func(script string) func() (io.ReadCloser, error) {
+					return func() (io.ReadCloser, error) { return ioutil.NopCloser(strings.NewReader(script)), nil }
+				}(ms[i].Script)
+			}
+		}
+		checkArchiveMigrations(t, ms)
+	})
+	if _, err := LoadArchive(filepath.Join(dir, "migrations.rar")); err == nil {
+		t.Errorf("want error for unknown format")
+	}
+}
@@ -0,0 +1,67 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// SessionInitializer is implemented by Support implementations that require per connection setup, e.g. attached
+// SQLite databases. InitSession is called before the migrations are listed and before each migration is installed.
+// It has to be idempotent.
+type SessionInitializer interface {
+	InitSession(con *sql.DB) error
+}
+
+// SessionInitializerContext is implemented by SessionInitializer implementations that can set up a single connection.
+// InitSessionContext is called on every connection the Migrator takes from the pool, e.g. for a migration that
+// rebuilds tables or runs within a savepoint.
+type SessionInitializerContext interface {
+	SessionInitializer
+	InitSessionContext(ctx context.Context, q Querier) error
+}
+
+// Attachment is a SQLite database file that is attached under Schema.
+type Attachment struct {
+	Schema string
+	File   string
+}
+
+// WithAttachment attaches the database file under schema, so migrations can reference its tables as <schema>.<table>.
+// As ATTACH applies to a single connection only, it is repeated on every connection the Migrator takes from the pool,
+// but statements issued through the pool may still run on a connection without the attachment, so the Migrator should
+// be limited to one connection with SetSingleConnection.
+func WithAttachment(schema string, file string) SupportOption {
+	return func(c *SupportConfig) {
+		c.Attachments = append(c.Attachments, Attachment{Schema: schema, File: file})
+	}
+}
+
+func (m *Migrator) initSession() error {
+	if err := m.setupSession(); err != nil {
+		return err
+	}
+	si, ok := m.support.(SessionInitializer)
+	if !ok {
+		return nil
+	}
+	if err := si.InitSession(m.db); err != nil {
+		return fmt.Errorf("init session: %+v", err)
+	}
+	return nil
+}
+
+// initConn prepares the session of a connection taken from the pool like initSession prepares the pool.
+func (m *Migrator) initConn(ctx context.Context, con *sql.Conn) error {
+	if err := m.setupSessionContext(ctx, con); err != nil {
+		return err
+	}
+	si, ok := m.support.(SessionInitializerContext)
+	if !ok {
+		return nil
+	}
+	if err := si.InitSessionContext(ctx, con); err != nil {
+		return fmt.Errorf("init session: %+v", err)
+	}
+	return nil
+}
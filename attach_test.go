@@ -0,0 +1,75 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+type sessionSupport struct {
+	memSupport
+	sessions int
+}
+
+func (s *sessionSupport) InitSession(con *sql.DB) error {
+	s.sessions++
+	return nil
+}
+
+func TestMigrateInitSession(t *testing.T) {
+	s := &sessionSupport{}
+	m := newTestMigrator(t, s)
+	noop := func(con *sql.DB) error { return nil }
+	m.AddGoMigration("1", "one", noop)
+	m.AddGoMigration("2", "two", noop)
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if s.sessions != 3 {
+		t.Errorf("want 3 session initializations, got %d", s.sessions)
+	}
+}
+
+type attachingSupport struct {
+	foreignKeySupport
+}
+
+func (s *attachingSupport) InitSession(con *sql.DB) error {
+	return nil
+}
+
+func (s *attachingSupport) InitSessionContext(ctx context.Context, q Querier) error {
+	return NewSQLiteSupport(WithAttachment("aux", "aux.db")).InitSessionContext(ctx, q)
+}
+
+func TestMigrateRebuildsTablesAttached(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	log.results = map[string]string{`PRAGMA foreign_keys;`: "1"}
+	s := &attachingSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.SetBatch(Batch{Transaction: true})
+	m.AddSQLMigration("1", "rebuild users", "CREATE TABLE aux.users_new (id INT);", RebuildsTables())
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`PRAGMA database_list;`,
+		`ATTACH DATABASE ? AS "aux";`,
+		`PRAGMA foreign_keys;`,
+	}
+	if got := log.Statements(); len(got) < len(want) || !reflect.DeepEqual(want, got[:len(want)]) {
+		t.Errorf("want the connection to be attached first: %q, got: %q", want, got)
+	}
+}
+
+func TestSQLiteDropStatementAttached(t *testing.T) {
+	s := NewSQLiteSupport(WithAttachment("aux", "aux.db"))
+	if got, want := s.DropStatement(Object{Type: ObjectTable, Name: "users"}), `DROP TABLE IF EXISTS "users";`; got != want {
+		t.Errorf("want: %s, got: %s", want, got)
+	}
+	if got, want := s.DropStatement(Object{Schema: "aux", Type: ObjectView, Name: "v"}), `DROP VIEW IF EXISTS "aux"."v";`; got != want {
+		t.Errorf("want: %s, got: %s", want, got)
+	}
+}
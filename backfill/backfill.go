@@ -0,0 +1,194 @@
+// Package backfill runs large data migrations in chunks of primary key ranges, so that no statement locks a table for
+// long. Every chunk is committed together with a checkpoint, so an interrupted backfill resumes after the last
+// completed chunk. A Backfill is typically run by a Go migration:
+//
+//	m.AddGoMigration("7", "backfill emails", backfill.Backfill{
+//		Name:      "emails",
+//		Table:     "users",
+//		Key:       "id",
+//		Statement: "UPDATE users SET email_lower = LOWER(email) WHERE id > ? AND id <= ?",
+//	}.Command())
+package backfill
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/cognicraft/migrate"
+)
+
+// DefaultChunkSize is the size of the key range of a chunk unless configured otherwise.
+const DefaultChunkSize = 1000
+
+// DefaultCheckpointTable is the table checkpoints are kept in unless configured otherwise.
+const DefaultCheckpointTable = "backfill_checkpoints"
+
+// Backfill executes Statement for consecutive ranges of the integer key of Table.
+type Backfill struct {
+	// Name identifies the checkpoint of the backfill.
+	Name string
+	// Table and Key determine the range of keys to process: from MIN(Key) to MAX(Key) of Table.
+	Table string
+	Key   string
+	// Statement is executed once per chunk with the exclusive lower and the inclusive upper bound of its key range as
+	// arguments, e.g. "UPDATE t SET c = 1 WHERE id > ? AND id <= ?" or "INSERT INTO t2 SELECT * FROM t WHERE id > $1 AND id <= $2".
+	Statement string
+	// ChunkSize is the size of the key range of a chunk. It defaults to DefaultChunkSize.
+	ChunkSize int64
+	// Pause is a pause between chunks to leave room for other load.
+	Pause time.Duration
+	// CheckpointTable is the table checkpoints are kept in. It defaults to DefaultCheckpointTable.
+	CheckpointTable string
+	// Placeholder returns the placeholder of the n-th argument, starting at 1, in the statements of the checkpoints.
+	// It defaults to Question.
+	Placeholder func(n int) string
+	// Progress is called after every committed chunk.
+	Progress func(p Progress)
+}
+
+// Progress reports the state of a running backfill.
+type Progress struct {
+	Name string
+	// Position is the upper bound of the last committed chunk.
+	Position int64
+	// Max is the highest key to process.
+	Max int64
+	// Rows is the number of rows affected so far by this run, or -1 if the driver does not report it.
+	Rows int64
+}
+
+// Question is the placeholder "?", e.g. of SQLite and MySQL.
+func Question(n int) string {
+	return "?"
+}
+
+// Dollar is the placeholder "$n", e.g. of PostgreSQL and CockroachDB.
+func Dollar(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// Command returns the backfill as the function of a Go migration.
+func (b Backfill) Command() migrate.CommandFunc {
+	return func(db *sql.DB) error {
+		return b.Run(context.Background(), db)
+	}
+}
+
+// Run processes the key range of the table in chunks, starting after the last checkpoint of the backfill.
+func (b Backfill) Run(ctx context.Context, db *sql.DB) error {
+	if b.Name == "" || b.Table == "" || b.Key == "" || b.Statement == "" {
+		return fmt.Errorf("backfill: name, table, key and statement are required")
+	}
+	if err := b.createCheckpointTable(ctx, db); err != nil {
+		return fmt.Errorf("backfill: %s: create checkpoint table: %+v", b.Name, err)
+	}
+	var lo, hi sql.NullInt64
+	if err := db.QueryRowContext(ctx, fmt.Sprintf("SELECT MIN(%s), MAX(%s) FROM %s;", b.Key, b.Key, b.Table)).Scan(&lo, &hi); err != nil {
+		return fmt.Errorf("backfill: %s: key range: %+v", b.Name, err)
+	}
+	if !hi.Valid {
+		return nil
+	}
+	position, found, err := b.checkpoint(ctx, db)
+	if err != nil {
+		return fmt.Errorf("backfill: %s: load checkpoint: %+v", b.Name, err)
+	}
+	if !found {
+		position = lo.Int64 - 1
+	}
+	size := b.ChunkSize
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	p := Progress{Name: b.Name, Position: position, Max: hi.Int64}
+	for p.Position < hi.Int64 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		upper := p.Position + size
+		if upper > hi.Int64 {
+			upper = hi.Int64
+		}
+		rows, err := b.chunk(ctx, db, p.Position, upper, found)
+		if err != nil {
+			return fmt.Errorf("backfill: %s: chunk (%d, %d]: %+v", b.Name, p.Position, upper, err)
+		}
+		found = true
+		p.Position = upper
+		if rows < 0 || p.Rows < 0 {
+			p.Rows = -1
+		} else {
+			p.Rows += rows
+		}
+		if b.Progress != nil {
+			b.Progress(p)
+		}
+		if b.Pause > 0 && p.Position < hi.Int64 {
+			time.Sleep(b.Pause)
+		}
+	}
+	return nil
+}
+
+// chunk executes the statement for the key range (lower, upper] and saves the checkpoint in the same transaction.
+func (b Backfill) chunk(ctx context.Context, db *sql.DB, lower int64, upper int64, update bool) (int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, b.Statement, lower, upper)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		rows = -1
+	}
+	if update {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET position = %s WHERE name = %s;", b.checkpointTable(), b.placeholder(1), b.placeholder(2)), upper, b.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (name, position) VALUES (%s, %s);", b.checkpointTable(), b.placeholder(1), b.placeholder(2)), b.Name, upper)
+	}
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("save checkpoint: %+v", err)
+	}
+	return rows, tx.Commit()
+}
+
+func (b Backfill) createCheckpointTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (name VARCHAR(255) NOT NULL PRIMARY KEY, position BIGINT NOT NULL);", b.checkpointTable()))
+	return err
+}
+
+// checkpoint returns the upper bound of the last committed chunk.
+func (b Backfill) checkpoint(ctx context.Context, db *sql.DB) (int64, bool, error) {
+	var position int64
+	err := db.QueryRowContext(ctx, fmt.Sprintf("SELECT position FROM %s WHERE name = %s;", b.checkpointTable(), b.placeholder(1)), b.Name).Scan(&position)
+	switch {
+	case err == sql.ErrNoRows:
+		return 0, false, nil
+	case err != nil:
+		return 0, false, err
+	default:
+		return position, true, nil
+	}
+}
+
+func (b Backfill) checkpointTable() string {
+	if b.CheckpointTable == "" {
+		return DefaultCheckpointTable
+	}
+	return b.CheckpointTable
+}
+
+func (b Backfill) placeholder(n int) string {
+	if b.Placeholder == nil {
+		return Question(n)
+	}
+	return b.Placeholder(n)
+}
@@ -0,0 +1,176 @@
+package backfill
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDB is a table with the keys min to max and the checkpoint table of a single backfill.
+type fakeDB struct {
+	mu         sync.Mutex
+	min, max   int64
+	checkpoint *int64
+	pending    *int64
+	chunks     [][2]int64
+	failAt     int64
+}
+
+var (
+	fakeMu  sync.Mutex
+	fakeDBs = map[string]*fakeDB{}
+)
+
+type fakeDriver struct{}
+
+func init() {
+	sql.Register("backfill-fake", fakeDriver{})
+}
+
+func openFake(t *testing.T, f *fakeDB) *sql.DB {
+	fakeMu.Lock()
+	fakeDBs[t.Name()] = f
+	fakeMu.Unlock()
+	db, err := sql.Open("backfill-fake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	return &fakeConn{db: fakeDBs[name]}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("fake: prepare not supported")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *fakeConn) Commit() error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	if c.db.pending != nil {
+		c.db.checkpoint, c.db.pending = c.db.pending, nil
+	}
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.pending = nil
+	return nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	switch {
+	case strings.HasPrefix(query, "CREATE TABLE"):
+	case strings.HasPrefix(query, "UPDATE users"):
+		lower, upper := args[0].Value.(int64), args[1].Value.(int64)
+		if c.db.failAt != 0 && lower < c.db.failAt && c.db.failAt <= upper {
+			c.db.failAt = 0
+			return nil, fmt.Errorf("fake: interrupted")
+		}
+		c.db.chunks = append(c.db.chunks, [2]int64{lower, upper})
+		return driver.RowsAffected(upper - lower), nil
+	case strings.HasPrefix(query, "UPDATE "+DefaultCheckpointTable):
+		position := args[0].Value.(int64)
+		c.db.pending = &position
+	case strings.HasPrefix(query, "INSERT INTO "+DefaultCheckpointTable):
+		position := args[1].Value.(int64)
+		c.db.pending = &position
+	default:
+		return nil, fmt.Errorf("fake: unexpected statement: %s", query)
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	switch {
+	case strings.HasPrefix(query, "SELECT MIN"):
+		return &fakeRows{rows: [][]driver.Value{{c.db.min, c.db.max}}}, nil
+	case strings.HasPrefix(query, "SELECT position"):
+		if c.db.checkpoint == nil {
+			return &fakeRows{}, nil
+		}
+		return &fakeRows{rows: [][]driver.Value{{*c.db.checkpoint}}}, nil
+	}
+	return nil, fmt.Errorf("fake: unexpected query: %s", query)
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return []string{"a", "b"}[:r.width()] }
+
+func (r *fakeRows) width() int {
+	if len(r.rows) == 0 {
+		return 1
+	}
+	return len(r.rows[0])
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestBackfillResume(t *testing.T) {
+	f := &fakeDB{min: 1, max: 25, failAt: 15}
+	db := openFake(t, f)
+	defer db.Close()
+	b := Backfill{
+		Name:      "emails",
+		Table:     "users",
+		Key:       "id",
+		Statement: "UPDATE users SET email_lower = LOWER(email) WHERE id > ? AND id <= ?",
+		ChunkSize: 10,
+	}
+	if err := b.Run(context.Background(), db); err == nil {
+		t.Fatalf("want interrupted backfill")
+	}
+	progress := []Progress{}
+	b.Progress = func(p Progress) {
+		progress = append(progress, p)
+	}
+	if err := b.Command()(db); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if want := [][2]int64{{0, 10}, {10, 20}, {20, 25}}; !reflect.DeepEqual(want, f.chunks) {
+		t.Errorf("want chunks: %v, got: %v", want, f.chunks)
+	}
+	want := []Progress{{Name: "emails", Position: 20, Max: 25, Rows: 10}, {Name: "emails", Position: 25, Max: 25, Rows: 15}}
+	if !reflect.DeepEqual(want, progress) {
+		t.Errorf("want progress: %v, got: %v", want, progress)
+	}
+	if f.checkpoint == nil || *f.checkpoint != 25 {
+		t.Errorf("want checkpoint at 25, got: %v", f.checkpoint)
+	}
+}
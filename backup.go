@@ -0,0 +1,135 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Backuper is implemented by Support implementations of file-based databases that back up the database to a file and
+// restore it in place, e.g. SQLite.
+type Backuper interface {
+	// Backup writes a consistent copy of the database of con to file.
+	Backup(con *sql.DB, file string) error
+	// RestoreBackup replaces the objects and rows of the database of con with those of the backup in file.
+	RestoreBackup(con *sql.DB, file string) error
+}
+
+// Backup configures the backups that Migrate writes before it applies pending migrations, e.g. for embedded databases
+// without a DBA to restore from.
+type Backup struct {
+	// Dir is the directory the backups are written to, named backup-<UTC timestamp>.db.
+	Dir string
+	// Keep is the number of backups that are retained, older ones are deleted. Zero retains all of them.
+	Keep int
+	// RestoreOnFailure restores the backup of a run that fails, which also removes the records of the migrations the
+	// run has applied or failed.
+	RestoreOnFailure bool
+}
+
+const (
+	backupPrefix = "backup-"
+	backupSuffix = ".db"
+)
+
+// SetBackup makes Migrate back up the database before it applies pending migrations. It requires a Support that is a
+// Backuper.
+func (m *Migrator) SetBackup(b Backup) {
+	m.backup = &b
+}
+
+// Backups returns the paths of the backups in the directory set by SetBackup, oldest first.
+func (m *Migrator) Backups() ([]string, error) {
+	if m.backup == nil {
+		return nil, nil
+	}
+	infos, err := ioutil.ReadDir(m.backup.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	paths := []string{}
+	for _, info := range infos {
+		if !info.IsDir() && strings.HasPrefix(info.Name(), backupPrefix) && strings.HasSuffix(info.Name(), backupSuffix) {
+			paths = append(paths, filepath.Join(m.backup.Dir, info.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// RestoreBackup replaces the database with the backup in file, e.g. one of Backups.
+func (m *Migrator) RestoreBackup(file string) error {
+	b, ok := m.support.(Backuper)
+	if !ok {
+		return fmt.Errorf("support does not back up: %T", m.support)
+	}
+	m.log(LevelInfo, "restore backup", Fields{"backup": file})
+	return b.RestoreBackup(m.db, file)
+}
+
+// backupBeforeMigrate writes a backup if configured by SetBackup and deletes the backups exceeding Keep. It returns the
+// path of the backup that is restored if the run fails, which is empty unless Backup.RestoreOnFailure is set.
+func (m *Migrator) backupBeforeMigrate() (string, error) {
+	if m.backup == nil {
+		return "", nil
+	}
+	b, ok := m.support.(Backuper)
+	if !ok {
+		return "", fmt.Errorf("backup requires a support that backs up: %T", m.support)
+	}
+	if err := os.MkdirAll(m.backup.Dir, 0755); err != nil {
+		return "", err
+	}
+	file := filepath.Join(m.backup.Dir, backupPrefix+m.now().UTC().Format("20060102T150405.000000000Z")+backupSuffix)
+	m.log(LevelInfo, "backup", Fields{"backup": file})
+	if err := b.Backup(m.db, file); err != nil {
+		return "", err
+	}
+	if err := m.pruneBackups(); err != nil {
+		m.log(LevelWarn, "prune backups", Fields{"error": err})
+	}
+	if !m.backup.RestoreOnFailure {
+		return "", nil
+	}
+	return file, nil
+}
+
+// pruneBackups deletes the oldest backups exceeding Backup.Keep, including the files of attached databases.
+func (m *Migrator) pruneBackups() error {
+	if m.backup.Keep <= 0 {
+		return nil
+	}
+	paths, err := m.Backups()
+	if err != nil {
+		return err
+	}
+	for len(paths) > m.backup.Keep {
+		related, err := filepath.Glob(paths[0] + ".*")
+		if err != nil {
+			return err
+		}
+		for _, p := range append([]string{paths[0]}, related...) {
+			if err := os.Remove(p); err != nil {
+				return err
+			}
+		}
+		paths = paths[1:]
+	}
+	return nil
+}
+
+// restoreAfterFailure restores the backup file after the run failed with err and returns err.
+func (m *Migrator) restoreAfterFailure(file string, err error) error {
+	if rErr := m.RestoreBackup(file); rErr != nil {
+		m.log(LevelError, "restore backup", Fields{"backup": file, "error": rErr})
+		return fmt.Errorf("%+v (restore backup %s: %+v)", err, file, rErr)
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// backupSupport backs up the recorded migrations in memory, keyed by file.
+type backupSupport struct {
+	memSupport
+	backups map[string]Migrations
+}
+
+func (s *backupSupport) Backup(con *sql.DB, file string) error {
+	s.backups[file] = append(Migrations{}, s.migrations...)
+	return ioutil.WriteFile(file, nil, 0644)
+}
+
+func (s *backupSupport) RestoreBackup(con *sql.DB, file string) error {
+	ms, ok := s.backups[file]
+	if !ok {
+		return fmt.Errorf("unknown backup: %s", file)
+	}
+	s.migrations = append(Migrations{}, ms...)
+	return nil
+}
+
+func TestMigrateBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate-backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	noop := func(con *sql.DB) error { return nil }
+	s := &backupSupport{backups: map[string]Migrations{}}
+	m := newTestMigrator(t, s)
+	m.SetBackup(Backup{Dir: dir, Keep: 2, RestoreOnFailure: true})
+	m.AddGoMigration("1", "one", noop)
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if backups, _ := m.Backups(); len(backups) != 1 {
+		t.Fatalf("want a backup only for pending migrations, got: %v", backups)
+	}
+	m.AddGoMigration("2", "two", noop)
+	m.AddGoMigration("3", "three", func(con *sql.DB) error { return fmt.Errorf("boom") })
+	if err := m.Migrate(); err == nil {
+		t.Fatal("want error")
+	}
+	if len(s.migrations) != 1 {
+		t.Errorf("want restored migrations, got: %v", s.migrations)
+	}
+	m.AddGoMigration("4", "four", noop)
+	m.Migrate()
+	backups, err := m.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Errorf("want 2 retained backups, got: %v", backups)
+	}
+}
@@ -0,0 +1,46 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// SchemaChecker is implemented by Support implementations that can tell whether a database contains user objects.
+type SchemaChecker interface {
+	// IsSchemaEmpty reports whether the schema contains no tables other than the metadata tables.
+	IsSchemaEmpty(con *sql.DB) (bool, error)
+}
+
+// SetBaselineOnMigrate makes Migrate record a baseline at version when it creates the metadata table
+// for a database that already contains tables. Migrations up to and including version are then ignored.
+func (m *Migrator) SetBaselineOnMigrate(version Version, description string) {
+	m.baselineOnMigrate = &Migration{
+		Version:     version,
+		Description: description,
+	}
+}
+
+// createMigrationsTable creates the metadata table and baselines existing databases if configured.
+func (m *Migrator) createMigrationsTable() error {
+	if m.baselineOnMigrate == nil {
+		return m.newMigrationsTable()
+	}
+	sc, ok := m.support.(SchemaChecker)
+	if !ok {
+		return fmt.Errorf("baseline on migrate requires a support that checks schemas: %T", m.support)
+	}
+	empty, err := sc.IsSchemaEmpty(m.db)
+	if err != nil {
+		return err
+	}
+	if err := m.newMigrationsTable(); err != nil {
+		return err
+	}
+	if empty {
+		return nil
+	}
+	mig := newBaseline(m.baselineOnMigrate.Version, m.baselineOnMigrate.Description, m.now())
+	mig.InstalledBy = m.installedBy()
+	m.log(LevelInfo, "baselining existing database", migrationFields(mig))
+	return m.support.RecordMigration(m.db, mig)
+}
@@ -0,0 +1,336 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Batch configures the execution of SQL migrations.
+type Batch struct {
+	// Size is the number of statements sent to the database with a single Exec.
+	// It requires a driver that supports multiple statements per Exec. Statements with a retry directive are always sent alone.
+	Size int
+	// Transaction executes every SQL migration in a single transaction.
+	Transaction bool
+	// MergeInserts merges up to MergeInserts consecutive single-row INSERT statements into the same table and columns
+	// into one multi-row INSERT, e.g. to speed up seed scripts. A merged INSERT counts as a single statement for Size.
+	// It requires a database that supports multi-row VALUES, which Oracle does not.
+	MergeInserts int
+}
+
+// SetBatch configures the batched execution of SQL migrations. Progress is reported through the logger after every batch.
+func (m *Migrator) SetBatch(batch Batch) {
+	m.batch = batch
+}
+
+// execute runs and verifies the migration. SQL scripts are split with the Splitter of the Migrator and executed in batches if configured.
+// If the migration runs in a transaction and record is not nil, record is called within the transaction after the
+// migration succeeded and execute reports whether it has been called.
+func (m *Migrator) execute(mig Migration, record func(ctx context.Context, q Querier) error) (bool, error) {
+	mig, err := m.render(mig)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := m.runContext(mig)
+	defer cancel()
+	plain := mig.ExecuteContext == nil && (mig.Type != TypeSQL || (mig.Script == "" && mig.Source == nil))
+	fs, rebuilds := m.support.(ForeignKeySupport)
+	rebuilds = rebuilds && mig.Options.RebuildsTables
+	if plain && rebuilds {
+		return false, fmt.Errorf("rebuilding tables requires a SQL migration or a Go migration with a MigrationContext: %s", mig)
+	}
+	if plain && mig.Options.Timeout > 0 {
+		return false, fmt.Errorf("a timeout requires a SQL migration or a Go migration with a MigrationContext: %s", mig)
+	}
+	if plain {
+		if err := mig.Execute(m.db); err != nil {
+			return false, err
+		}
+		return false, m.verifyOrUndo(ctx, mig)
+	}
+	if rebuilds {
+		return m.executeRebuilding(ctx, fs, mig, record)
+	}
+	if !m.batch.Transaction || mig.Options.NoTransaction {
+		if err := m.run(ctx, nil, mig); err != nil {
+			return false, err
+		}
+		return false, m.verifyOrUndo(ctx, mig)
+	}
+	recorded := false
+	err = m.retry(ctx, func() error {
+		var err error
+		recorded, err = m.execTx(ctx, m.db, mig, record)
+		return err
+	})
+	return recorded, err
+}
+
+// txBeginner begins transactions, e.g. a *sql.DB or a *sql.Conn.
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// execTx executes mig in a transaction of db.
+func (m *Migrator) execTx(ctx context.Context, db txBeginner, mig Migration, record func(ctx context.Context, q Querier) error) (bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	if err := m.run(ctx, tx, mig); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	if err := m.verify(ctx, tx, mig); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	if err := m.checkForeignKeys(ctx, tx, mig); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	if record != nil {
+		if err := record(ctx, tx); err != nil {
+			tx.Rollback()
+			return false, fmt.Errorf("record migration: %s: %+v", mig, err)
+		}
+	}
+	return record != nil, tx.Commit()
+}
+
+// run runs a SQL migration or a Go migration with a MigrationContext in tx, or outside of a transaction if tx is nil.
+func (m *Migrator) run(ctx context.Context, tx *sql.Tx, mig Migration) error {
+	if mig.ExecuteContext != nil {
+		return mig.ExecuteContext(m.migrationContext(ctx, tx, mig))
+	}
+	if tx != nil {
+		// a failed transaction is rolled back with the settings of its session
+		restore, err := m.limitSession(ctx, tx, mig)
+		if err != nil {
+			return err
+		}
+		if err := m.execBatched(ctx, tx, mig); err != nil {
+			return err
+		}
+		return restore()
+	}
+	restore, err := m.limitSession(ctx, m.db, mig)
+	if err != nil {
+		return err
+	}
+	if sp, ok := m.support.(Savepointer); ok && m.savepoints {
+		err = m.execSavepoint(ctx, sp, mig)
+	} else {
+		err = m.execBatched(ctx, m.db, mig)
+	}
+	if rErr := restore(); rErr != nil && err == nil {
+		err = rErr
+	}
+	return err
+}
+
+func (m *Migrator) execBatched(ctx context.Context, con execer, mig Migration) error {
+	if mig.Source == nil {
+		return m.execStatements(ctx, con, mig, m.splitter().Parse(mig.Script))
+	}
+	rc, err := mig.Source()
+	if err != nil {
+		return fmt.Errorf("open script: %+v", err)
+	}
+	defer rc.Close()
+	sr := m.splitter().NewReader(rc)
+	if err := m.execStream(ctx, con, mig, sr.Next, sr.Statement, -1); err != nil {
+		return err
+	}
+	return sr.Err()
+}
+
+// execStatements executes the statements of a script held in memory.
+func (m *Migrator) execStatements(ctx context.Context, con execer, mig Migration, stmts []Statement) error {
+	i := -1
+	next := func() bool {
+		i++
+		return i < len(stmts)
+	}
+	current := func() Statement {
+		return stmts[i]
+	}
+	return m.execStream(ctx, con, mig, next, current, len(stmts))
+}
+
+// execStream executes the statements produced by next and current in batches. total is -1 if unknown.
+func (m *Migrator) execStream(ctx context.Context, con execer, mig Migration, next func() bool, current func() Statement, total int) error {
+	size := m.batch.Size
+	if size < 1 {
+		size = 1
+	}
+	buf := &bytes.Buffer{}
+	// pending counts the statements of the script in buf, units the statements sent to the database.
+	pending := 0
+	units := 0
+	done := 0
+	// insert is the prefix of the INSERT in buf that further rows are merged into, rows the number of its rows.
+	insert := ""
+	rows := 0
+	closeInsert := func() {
+		if insert != "" {
+			buf.WriteString(";")
+			insert = ""
+		}
+	}
+	flush := func() error {
+		closeInsert()
+		if pending == 0 {
+			return nil
+		}
+		if m.aborted() {
+			return &StatementError{Index: done, SQL: buf.String(), Err: ErrAborted}
+		}
+		if err := m.execRetrying(ctx, con, mig, buf.String()); err != nil {
+			return &StatementError{Index: done, SQL: buf.String(), Err: err}
+		}
+		done += pending
+		buf.Reset()
+		pending = 0
+		units = 0
+		m.progress(mig, done, total)
+		return nil
+	}
+	for next() {
+		stmt := current()
+		if done < mig.resumeAt-1 {
+			done++
+			continue
+		}
+		if stmt.Retries > 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			if m.aborted() {
+				return &StatementError{Index: done, SQL: stmt.SQL, Err: ErrAborted}
+			}
+			sctx, cancel := m.statementContext(ctx)
+			err := execStatement(sctx, m.logged(con, mig), stmt)
+			cancel()
+			if err != nil {
+				return &StatementError{Index: done, SQL: stmt.SQL, Err: err}
+			}
+			done++
+			m.progress(mig, done, total)
+			continue
+		}
+		prefix, values, mergeable := "", "", false
+		if m.batch.MergeInserts > 1 {
+			prefix, values, mergeable = splitInsert(stmt.SQL)
+		}
+		if mergeable && prefix == insert && rows < m.batch.MergeInserts {
+			buf.WriteString(", ")
+			buf.WriteString(values)
+			pending++
+			rows++
+			continue
+		}
+		closeInsert()
+		if units >= size {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if pending > 0 {
+			buf.WriteString("\n")
+		}
+		if mergeable {
+			buf.WriteString(prefix + " " + values)
+			insert = prefix
+			rows = 1
+		} else {
+			buf.WriteString(stmt.SQL)
+		}
+		pending++
+		units++
+		if units >= size && insert == "" {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// execRetrying executes query and retries retryable failures unless con is a transaction, which has to be retried as a whole.
+func (m *Migrator) execRetrying(ctx context.Context, con execer, mig Migration, query string) error {
+	exec := func() error {
+		sctx, cancel := m.statementContext(ctx)
+		defer cancel()
+		_, err := m.logged(con, mig).ExecContext(sctx, query)
+		return err
+	}
+	if _, ok := con.(*sql.Tx); ok {
+		return exec()
+	}
+	return m.retry(ctx, exec)
+}
+
+func (m *Migrator) progress(mig Migration, done int, total int) {
+	m.emit(StatementExecuted{Migration: mig, Statements: done, Total: total})
+	if m.batch == (Batch{}) {
+		return
+	}
+	fields := migrationFields(mig)
+	fields["statements"] = done
+	if total >= 0 {
+		fields["total"] = total
+	}
+	m.log(LevelInfo, "progress", fields)
+}
+
+// recordInTx returns a function that records mig as successful within the transaction of execute. It returns nil if
+// the Support is not a ContextSupport or a Waiter has to confirm the migration after the transaction.
+func (m *Migrator) recordInTx(mig Migration) func(ctx context.Context, q Querier) error {
+	cs, ok := m.support.(ContextSupport)
+	if !ok || m.waiter != nil {
+		return nil
+	}
+	return func(ctx context.Context, q Querier) error {
+		mig.ExecutionTime = m.since(mig.Date)
+		mig.Status = StatusSuccess
+		return cs.RecordMigrationContext(ctx, q, mig)
+	}
+}
+
+var insertPrefix = regexp.MustCompile(`(?is)^\s*(INSERT\s+INTO\s+[^\s(;']+\s*(?:\([^()';]*\))?\s*VALUES)\s*\(`)
+
+// splitInsert splits an INSERT statement of a single row into its prefix up to VALUES and the row, e.g.
+// "INSERT INTO t (a, b) VALUES" and "(1, 'x')". ok is false for any other statement.
+func splitInsert(stmt string) (prefix string, values string, ok bool) {
+	loc := insertPrefix.FindStringSubmatchIndex(stmt)
+	if loc == nil {
+		return "", "", false
+	}
+	start := loc[1] - 1
+	depth := 0
+	quoted := false
+	end := -1
+	for i := start; i < len(stmt) && end < 0; i++ {
+		switch c := stmt[i]; {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				end = i + 1
+			}
+		}
+	}
+	if end < 0 || strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(stmt[end:]), ";")) != "" {
+		return "", "", false
+	}
+	return stmt[loc[2]:loc[3]], stmt[start:end], true
+}
@@ -0,0 +1,73 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+type recordingExecer struct {
+	queries []string
+}
+
+func (e *recordingExecer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	e.queries = append(e.queries, query)
+	return nil, nil
+}
+
+func TestExecBatched(t *testing.T) {
+	m := newTestMigrator(t, &memSupport{})
+	m.SetBatch(Batch{Size: 2})
+	mig := NewSQLMigration("1", "seed", `
+	INSERT INTO foo VALUES (1);
+	INSERT INTO foo VALUES (2);
+	INSERT INTO foo VALUES (3);
+	-- migrate:retry 1
+	CREATE INDEX foo_bar ON foo (bar);
+	INSERT INTO foo VALUES (4);
+	`)
+	e := &recordingExecer{}
+	if err := m.execBatched(context.Background(), e, mig); err != nil {
+		t.Fatalf("exec: %v", err)
+	}
+	want := []string{
+		"INSERT INTO foo VALUES (1);\nINSERT INTO foo VALUES (2);",
+		"INSERT INTO foo VALUES (3);",
+		"CREATE INDEX foo_bar ON foo (bar);",
+		"INSERT INTO foo VALUES (4);",
+	}
+	if !reflect.DeepEqual(want, e.queries) {
+		t.Errorf("want: %#v, got: %#v", want, e.queries)
+	}
+}
+
+func TestExecMergeInserts(t *testing.T) {
+	m := newTestMigrator(t, &memSupport{})
+	m.SetBatch(Batch{MergeInserts: 3})
+	mig := NewSQLMigration("1", "seed", `
+	INSERT INTO foo (id, name) VALUES (1, 'a;b');
+	INSERT INTO foo (id, name) VALUES (2, 'it''s (x)');
+	INSERT INTO foo (id, name) VALUES (3, 'c');
+	INSERT INTO foo (id, name) VALUES (4, 'd');
+	INSERT INTO bar VALUES (1);
+	INSERT INTO bar VALUES (2) RETURNING id;
+	UPDATE foo SET name = 'e';
+	INSERT INTO bar VALUES (3);
+	`)
+	e := &recordingExecer{}
+	if err := m.execBatched(context.Background(), e, mig); err != nil {
+		t.Fatalf("exec: %v", err)
+	}
+	want := []string{
+		"INSERT INTO foo (id, name) VALUES (1, 'a;b'), (2, 'it''s (x)'), (3, 'c');",
+		"INSERT INTO foo (id, name) VALUES (4, 'd');",
+		"INSERT INTO bar VALUES (1);",
+		"INSERT INTO bar VALUES (2) RETURNING id;",
+		"UPDATE foo SET name = 'e';",
+		"INSERT INTO bar VALUES (3);",
+	}
+	if !reflect.DeepEqual(want, e.queries) {
+		t.Errorf("want: %#v, got: %#v", want, e.queries)
+	}
+}
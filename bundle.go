@@ -0,0 +1,94 @@
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScriptRecorder is implemented by Support implementations that are able to render the statements that maintain
+// the metadata table as SQL text. It is required by Bundle.
+type ScriptRecorder interface {
+	CreateMigrationsTableScript() string
+	RecordMigrationScript(m Migration) string
+}
+
+// Bundle renders the pending migrations into a single annotated SQL script, including the statements that record
+// them in the metadata table, so that a DBA can apply them manually where the application has no DDL rights.
+// The database is only read to determine the pending migrations; it is not needed at all if the Migrator has no
+// *sql.DB. Go migrations cannot be bundled.
+func (m *Migrator) Bundle(w io.Writer) error {
+	sr, ok := m.support.(ScriptRecorder)
+	if !ok {
+		return fmt.Errorf("bundle requires a support that renders metadata statements: %T", m.support)
+	}
+	installed := Migrations{}
+	exists := false
+	if m.db != nil {
+		var err error
+		if exists, err = m.support.ExistsMigrationsTable(m.db); err != nil {
+			return err
+		}
+		if exists {
+			if installed, err = m.findMigrations(MigrationFilter{}); err != nil {
+				return err
+			}
+		}
+	}
+	migrations, repeatable := m.registered()
+	pending := newInfo(migrations, repeatable, installed, m.versionOrdering).Pending()
+	rank := 0
+	for _, mig := range installed {
+		if mig.Rank > rank {
+			rank = mig.Rank
+		}
+	}
+	now := m.now().UTC()
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "-- Migration bundle generated at %s\n", now.Format(time.RFC3339))
+	fmt.Fprintf(b, "-- Pending migrations: %d\n\n", len(pending))
+	if !exists {
+		b.WriteString("-- Metadata table\n")
+		b.WriteString(strings.TrimSpace(sr.CreateMigrationsTableScript()) + "\n\n")
+	}
+	for _, mig := range pending {
+		if mig.Type != TypeSQL || mig.ExecuteContext != nil {
+			return fmt.Errorf("bundle: cannot render %s migration: %s", mig.Type, mig)
+		}
+		stmts, err := m.statements(mig)
+		if err != nil {
+			return fmt.Errorf("bundle: %s: %+v", mig, err)
+		}
+		rank++
+		mig.Rank = rank
+		mig.Date = now
+		mig.Status = StatusSuccess
+		mig.InstalledBy = m.identity
+		version := string(mig.Version)
+		if mig.IsRepeatable() {
+			version = "repeatable"
+		}
+		fmt.Fprintf(b, "-- Migration %s: %s (checksum %s)\n", version, mig.Description, mig.Checksum)
+		for _, stmt := range stmts {
+			b.WriteString(stmt.SQL + "\n")
+		}
+		b.WriteString(sr.RecordMigrationScript(mig) + "\n\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// sqlLiteral renders s as a SQL string literal or NULL if s is empty.
+func sqlLiteral(s string) string {
+	if s == "" {
+		return "NULL"
+	}
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// sqlInt renders i as a SQL integer literal.
+func sqlInt(i int) string {
+	return strconv.Itoa(i)
+}
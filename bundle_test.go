@@ -0,0 +1,40 @@
+package migrate
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestBundle(t *testing.T) {
+	m := NewMigrator(t.Logf, nil, NewSQLiteSupport())
+	m.SetInstalledBy("dba")
+	m.AddSQLMigration("1", "create users", "CREATE TABLE users (id INT);\nCREATE INDEX users_id ON users (id);")
+	m.AddRepeatableSQLMigration("users view", "CREATE VIEW v AS SELECT 'x' FROM users;")
+	buf := &bytes.Buffer{}
+	if err := m.Bundle(buf); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"-- Pending migrations: 2\n",
+		`CREATE TABLE "migrations" (`,
+		"-- Migration 1: create users (checksum " + SQLChecksum("CREATE TABLE users (id INT);\nCREATE INDEX users_id ON users (id);") + ")\n",
+		"CREATE TABLE users (id INT);\nCREATE INDEX users_id ON users (id);\n",
+		`INSERT INTO "migrations" (rank, version, description, type, checksum, date, execution_time, status, installed_by, author, ticket) VALUES (1, '1', 'create users', 'SQL', '`,
+		"-- Migration repeatable: users view",
+		"CREATE VIEW v AS SELECT 'x' FROM users;\n",
+		`VALUES (2, 'R', 'users view', 'SQL', '`,
+		`, 0, 'success', 'dba', NULL, NULL);`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want bundle to contain %q, got:\n%s", want, got)
+		}
+	}
+
+	m.AddGoMigration("2", "backfill", func(con *sql.DB) error { return nil })
+	if err := m.Bundle(&bytes.Buffer{}); err == nil {
+		t.Errorf("want error for Go migration")
+	}
+}
@@ -0,0 +1,101 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CallbackEvent is a lifecycle point at which SQL callbacks are executed.
+type CallbackEvent string
+
+// Lifecycle points of SQL callbacks, named like the scripts that implement them, e.g. beforeMigrate.sql.
+const (
+	CallbackBeforeMigrate     CallbackEvent = "beforeMigrate"
+	CallbackAfterMigrate      CallbackEvent = "afterMigrate"
+	CallbackBeforeEachMigrate CallbackEvent = "beforeEachMigrate"
+	CallbackAfterEachMigrate  CallbackEvent = "afterEachMigrate"
+	CallbackAfterClean        CallbackEvent = "afterClean"
+)
+
+var callbackEvents = []CallbackEvent{
+	CallbackBeforeMigrate,
+	CallbackAfterMigrate,
+	CallbackBeforeEachMigrate,
+	CallbackAfterEachMigrate,
+	CallbackAfterClean,
+}
+
+// Callback is a SQL script executed at a lifecycle point. It is loaded from a file named <event>.sql or
+// <event>__<description>.sql, e.g. afterMigrate__grant_read_access.sql.
+type Callback struct {
+	Event  CallbackEvent
+	Name   string
+	Script string
+}
+
+// ParseCallbackFile parses the base name of a callback script.
+func ParseCallbackFile(name string) (CallbackEvent, bool) {
+	if !strings.HasSuffix(name, fileSuffix) {
+		return "", false
+	}
+	event := strings.SplitN(strings.TrimSuffix(name, fileSuffix), fileSeparator, 2)[0]
+	for _, e := range callbackEvents {
+		if CallbackEvent(event) == e {
+			return e, true
+		}
+	}
+	return "", false
+}
+
+// LoadCallbacks loads the callback scripts found in dir sorted by name.
+func LoadCallbacks(dir string) ([]Callback, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	cs := []Callback{}
+	for _, info := range infos {
+		event, ok := ParseCallbackFile(info.Name())
+		if info.IsDir() || !ok {
+			continue
+		}
+		script, err := ioutil.ReadFile(filepath.Join(dir, info.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read callback: %s: %+v", info.Name(), err)
+		}
+		cs = append(cs, Callback{Event: event, Name: info.Name(), Script: string(script)})
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i].Name < cs[j].Name })
+	return cs, nil
+}
+
+// AddCallback adds a SQL callback. Callbacks of the same event are executed in the order they were added and
+// before the corresponding Go hook.
+func (m *Migrator) AddCallback(c Callback) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.callbacks = append(m.callbacks, c)
+}
+
+// callback executes the SQL callbacks of event.
+func (m *Migrator) callback(event CallbackEvent) error {
+	m.mu.Lock()
+	cs := append([]Callback{}, m.callbacks...)
+	m.mu.Unlock()
+	for _, c := range cs {
+		if c.Event != event {
+			continue
+		}
+		m.log(LevelDebug, "callback", Fields{"event": event, "name": c.Name})
+		for _, stmt := range m.splitter().Parse(c.Script) {
+			if err := execStatement(context.Background(), m.db, stmt); err != nil {
+				return fmt.Errorf("callback: %s: %+v", c.Name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package migrate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMigrateCallbacks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"V1__one.sql":                         "CREATE TABLE a (id INT);",
+		"beforeMigrate.sql":                   "SELECT 'before';",
+		"afterMigrate__grant_read_access.sql": "GRANT SELECT ON a TO reader;",
+		"afterEachMigrate.sql":                "SELECT 'after each';",
+		"afterClean.sql":                      "SELECT 'after clean';",
+		"unknown.sql":                         "SELECT 'ignored';",
+	}
+	for name, script := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, log := openFake(t.Name())
+	defer db.Close()
+	m := NewMigrator(t.Logf, db, &memSupport{})
+	if err := m.AddDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"SELECT 'before';",
+		"CREATE TABLE a (id INT);",
+		"SELECT 'after each';",
+		"GRANT SELECT ON a TO reader;",
+		"SELECT 'after clean';",
+	}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
@@ -0,0 +1,122 @@
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Release is a release of the application that ships the versioned migrations up to and including Version, e.g.
+// Release{Tag: "v2.3.0", Version: "27"}.
+type Release struct {
+	Tag     string
+	Version Version
+}
+
+// ReleaseNotes are the migrations shipped by a release.
+type ReleaseNotes struct {
+	// Release is the release, the zero value for the migrations above the latest release.
+	Release    Release
+	Migrations Migrations
+}
+
+// Changelog lists the schema changes by release, newest release first.
+type Changelog []ReleaseNotes
+
+// Changelog groups the versioned migrations of i, applied and pending ones, by the releases that ship them, e.g. to
+// write the release notes of schema changes. A migration belongs to the release with the lowest version that is not
+// below its own. The releases may be given in any order. Migrations above the latest release are unreleased.
+// Repeatable migrations, baselines and failed migrations are omitted.
+func (i Info) Changelog(releases ...Release) Changelog {
+	return i.changelog(versionOrder{}, releases)
+}
+
+// Changelog is Info.Changelog of the registered and applied migrations ordered by the version ordering of the
+// Migrator.
+func (m *Migrator) Changelog(releases ...Release) Changelog {
+	return m.Info().changelog(m.versionOrdering, releases)
+}
+
+func (i Info) changelog(o versionOrder, releases []Release) Changelog {
+	rs := append([]Release{}, releases...)
+	sort.SliceStable(rs, func(a, b int) bool {
+		return o.compare(rs[a].Version, rs[b].Version) < 0
+	})
+	notes := make([]ReleaseNotes, len(rs)+1)
+	for n, r := range rs {
+		notes[n].Release = r
+	}
+	for _, mig := range i.Migrations {
+		if mig.IsRepeatable() || mig.Type == TypeBaseline || mig.Status == StatusFailed {
+			continue
+		}
+		n := sort.Search(len(rs), func(n int) bool {
+			return o.compare(rs[n].Version, mig.Version) >= 0
+		})
+		notes[n].Migrations = append(notes[n].Migrations, mig)
+	}
+	c := Changelog{}
+	for n := len(notes) - 1; n >= 0; n-- {
+		if n == len(rs) && len(notes[n].Migrations) == 0 {
+			continue
+		}
+		sort.SliceStable(notes[n].Migrations, func(a, b int) bool {
+			return o.compare(notes[n].Migrations[a].Version, notes[n].Migrations[b].Version) < 0
+		})
+		c = append(c, notes[n])
+	}
+	return c
+}
+
+// String returns the Markdown written by Render.
+func (c Changelog) String() string {
+	buf := &bytes.Buffer{}
+	c.Render(buf)
+	return buf.String()
+}
+
+// Render writes the changelog as Markdown to w, a section per release with a line per migration giving its version,
+// description, date of application, author and ticket, as far as they are known.
+func (c Changelog) Render(w io.Writer) error {
+	buf := &bytes.Buffer{}
+	buf.WriteString("# Changelog\n")
+	for _, notes := range c {
+		tag := notes.Release.Tag
+		if tag == "" {
+			tag = "Unreleased"
+		}
+		fmt.Fprintf(buf, "\n## %s\n\n", tag)
+		if len(notes.Migrations) == 0 {
+			buf.WriteString("No schema changes.\n")
+		}
+		for _, mig := range notes.Migrations {
+			fmt.Fprintf(buf, "- **%s** %s", mig.Version, mig.Description)
+			if details := changelogDetails(mig); len(details) > 0 {
+				fmt.Fprintf(buf, " (%s)", strings.Join(details, ", "))
+			}
+			buf.WriteString("\n")
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// changelogDetails returns the known metadata of mig.
+func changelogDetails(mig Migration) []string {
+	details := []string{}
+	switch {
+	case !mig.Date.IsZero():
+		details = append(details, mig.Date.Format("2006-01-02"))
+	case mig.State == StatePending:
+		details = append(details, "pending")
+	}
+	if mig.Author != "" {
+		details = append(details, mig.Author)
+	}
+	if mig.Ticket != "" {
+		details = append(details, mig.Ticket)
+	}
+	return details
+}
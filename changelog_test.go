@@ -0,0 +1,48 @@
+package migrate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInfoChangelog(t *testing.T) {
+	day := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	info := Info{
+		Migrations: Migrations{
+			{Version: "1", Description: "baseline", Type: TypeBaseline, Date: day, Status: StatusSuccess, State: StateBaseline},
+			{Version: "2", Description: "create users", Type: TypeSQL, Date: day, Status: StatusSuccess, State: StateApplied, Author: "jane", Ticket: "OPS-1"},
+			{Version: "10", Description: "create orders", Type: TypeSQL, Date: day.Add(48 * time.Hour), Status: StatusSuccess, State: StateApplied},
+			{Version: "4", Description: "add email", Type: TypeSQL, Date: day.Add(24 * time.Hour), Status: StatusSuccess, State: StateApplied, Author: "joe"},
+			{Version: "11", Description: "broken", Type: TypeSQL, Date: day.Add(72 * time.Hour), Status: StatusFailed, State: StateFailed},
+			{Version: "12", Description: "index orders", Type: TypeSQL, State: StatePending, Author: "jane"},
+			{Version: VersionRepeatable, Description: "users view", Type: TypeSQL, Date: day, Status: StatusSuccess, State: StateApplied},
+		},
+	}
+	got := info.Changelog(Release{Tag: "v1.1.0", Version: "10"}, Release{Tag: "v1.0.0", Version: "4"}, Release{Tag: "v0.9.0", Version: "1"})
+	want := `# Changelog
+
+## Unreleased
+
+- **12** index orders (pending, jane)
+
+## v1.1.0
+
+- **10** create orders (2024-02-02)
+
+## v1.0.0
+
+- **2** create users (2024-01-31, jane, OPS-1)
+- **4** add email (2024-02-01, joe)
+
+## v0.9.0
+
+No schema changes.
+`
+	if got.String() != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+
+	if got := info.Changelog(Release{Tag: "v2.0.0", Version: "12"}); len(got) != 1 || got[0].Release.Tag != "v2.0.0" || len(got[0].Migrations) != 4 {
+		t.Errorf("want every migration in v2.0.0, got: %+v", got)
+	}
+}
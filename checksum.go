@@ -0,0 +1,245 @@
+package migrate
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ChecksumAlgorithm computes the checksums of migrations. Checksums other than MD5 are prefixed with the name of
+// their algorithm, e.g. "sha256:9f86d0...", so checksums recorded under different algorithms can be told apart.
+// MD5 checksums are unprefixed for compatibility with existing metadata tables.
+type ChecksumAlgorithm struct {
+	Name string
+	New  func() hash.Hash
+}
+
+var (
+	// ChecksumMD5 is the default checksum algorithm.
+	ChecksumMD5 = ChecksumAlgorithm{Name: "md5", New: md5.New}
+	// ChecksumSHA256 is the checksum algorithm for deployments that do not accept MD5.
+	ChecksumSHA256 = ChecksumAlgorithm{Name: "sha256", New: sha256.New}
+)
+
+var (
+	checksumAlgorithmsMu sync.RWMutex
+	checksumAlgorithms   = map[string]ChecksumAlgorithm{}
+)
+
+func init() {
+	RegisterChecksumAlgorithm(ChecksumMD5)
+	RegisterChecksumAlgorithm(ChecksumSHA256)
+}
+
+// RegisterChecksumAlgorithm makes a checksum algorithm known by its name, so that recorded checksums with its prefix can be verified.
+// If RegisterChecksumAlgorithm is called twice with the same name or if New is nil, it panics.
+func RegisterChecksumAlgorithm(a ChecksumAlgorithm) {
+	checksumAlgorithmsMu.Lock()
+	defer checksumAlgorithmsMu.Unlock()
+	if a.New == nil {
+		panic("migrate: checksum algorithm has no hash: " + a.Name)
+	}
+	if _, dup := checksumAlgorithms[a.Name]; dup {
+		panic("migrate: RegisterChecksumAlgorithm called twice for " + a.Name)
+	}
+	checksumAlgorithms[a.Name] = a
+}
+
+// checksumAlgorithmOf returns the algorithm a recorded checksum has been computed with.
+func checksumAlgorithmOf(checksum string) (ChecksumAlgorithm, bool) {
+	i := strings.Index(checksum, ":")
+	if i < 0 {
+		return ChecksumMD5, true
+	}
+	checksumAlgorithmsMu.RLock()
+	defer checksumAlgorithmsMu.RUnlock()
+	a, ok := checksumAlgorithms[checksum[:i]]
+	return a, ok
+}
+
+// validChecksum reports whether checksum is a well formed checksum of a registered algorithm.
+func validChecksum(checksum string) bool {
+	a, ok := checksumAlgorithmOf(checksum)
+	if !ok {
+		return false
+	}
+	sum := strings.TrimPrefix(checksum, a.Name+":")
+	if len(sum) != a.New().Size()*2 || strings.ToLower(sum) != sum {
+		return false
+	}
+	_, err := hex.DecodeString(sum)
+	return err == nil
+}
+
+func (a ChecksumAlgorithm) isMD5() bool {
+	return a.New == nil || a.Name == ChecksumMD5.Name
+}
+
+func (a ChecksumAlgorithm) hash() hash.Hash {
+	if a.New == nil {
+		return md5.New()
+	}
+	return a.New()
+}
+
+func (a ChecksumAlgorithm) format(h hash.Hash) string {
+	if a.isMD5() {
+		return fmt.Sprintf("%x", h.Sum(nil))
+	}
+	return fmt.Sprintf("%s:%x", a.Name, h.Sum(nil))
+}
+
+// Sum returns the checksum of script.
+func (a ChecksumAlgorithm) Sum(script string) string {
+	h := a.hash()
+	io.WriteString(h, script)
+	return a.format(h)
+}
+
+// SumReader returns the checksum of the script read from r.
+func (a ChecksumAlgorithm) SumReader(r io.Reader) (string, error) {
+	h := a.hash()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return a.format(h), nil
+}
+
+// migrationChecksum computes the checksum of a local migration from its source, fingerprint or script.
+// Migrations without a checksum, e.g. Go migrations without fingerprint, keep it empty.
+// If n is not nil, the script is normalized with NormalizeScript and n.
+func (a ChecksumAlgorithm) migrationChecksum(mig Migration, n *Normalization) (string, error) {
+	switch {
+	case mig.Source != nil:
+		rc, err := mig.Source()
+		if err != nil {
+			return "", err
+		}
+		defer rc.Close()
+		if n != nil {
+			return a.SumReader(newNormalizingReader(rc, *n))
+		}
+		return a.SumReader(rc)
+	case mig.Options.Fingerprint != "":
+		return a.Sum(mig.Options.Fingerprint), nil
+	case mig.Checksum == "":
+		return "", nil
+	case n != nil:
+		return a.Sum(n.Apply(mig.Script)), nil
+	default:
+		return a.Sum(mig.Script), nil
+	}
+}
+
+// checksumMatches reports whether a recorded checksum matches the local migration.
+// The local migration is rehashed under the algorithm of the recorded checksum, so a change of the algorithm is not
+// reported as a mismatch. If the Migrator normalizes scripts, see SetNormalize, it is rehashed with and without
+// normalization, so neither is a change of the line endings.
+func checksumMatches(recorded string, local Migration) bool {
+	if recorded == local.Checksum {
+		return true
+	}
+	a, ok := checksumAlgorithmOf(recorded)
+	if !ok || local.Checksum == "" {
+		return false
+	}
+	ns := []*Normalization{nil}
+	if local.normalized {
+		ns = append(ns, &Normalization{})
+	}
+	for _, n := range ns {
+		if sum, err := a.migrationChecksum(local, n); err == nil && sum == recorded {
+			return true
+		}
+	}
+	return false
+}
+
+// SetChecksumAlgorithm sets the algorithm used for the checksums of local migrations. The default is ChecksumMD5.
+// Applied migrations keep their recorded checksums until Repair rewrites them under the new algorithm.
+func (m *Migrator) SetChecksumAlgorithm(a ChecksumAlgorithm) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checksumAlgorithm = a
+	m.recomputeChecksums()
+}
+
+// withChecksum recomputes the checksum of mig from its source, fingerprint or script under the current algorithm,
+// normalization and rendering, so that switching any of them off restores the plain checksum. Migrations without
+// anything to hash keep their checksum.
+func (m *Migrator) withChecksum(mig Migration) Migration {
+	mig.normalized = m.checksumNormalization() != nil
+	if mig.Source == nil && mig.Options.Fingerprint == "" && mig.Script == "" {
+		return mig
+	}
+	source := mig
+	if m.checksumRendered() {
+		var err error
+		if source, err = m.render(mig); err != nil {
+			m.log(LevelError, "checksum", Fields{"migration": mig.String(), "error": err})
+			return mig
+		}
+	}
+	sum, err := m.checksumAlgorithm.migrationChecksum(source, m.checksumNormalization())
+	if err != nil {
+		m.log(LevelError, "checksum", Fields{"migration": mig.String(), "error": err})
+		return mig
+	}
+	mig.Checksum = sum
+	return mig
+}
+
+// repairChecksums rewrites the checksums of applied migrations that match their local migration under another algorithm.
+func (m *Migrator) repairChecksums() error {
+	if _, ok := m.support.(MigrationUpdater); !ok {
+		return fmt.Errorf("repair requires a support that updates migrations: %T", m.support)
+	}
+	installed, err := m.listMigrations()
+	if err != nil {
+		return err
+	}
+	migrations, repeatables := m.registered()
+	local := map[Version]Migration{}
+	for _, mig := range migrations {
+		local[mig.Version] = mig
+	}
+	repeatable := map[repeatableKey]Migration{}
+	for _, mig := range repeatables {
+		repeatable[keyOf(mig)] = mig
+	}
+	repairs := Migrations{}
+	detail := &bytes.Buffer{}
+	for _, rec := range installed {
+		l, ok := local[rec.Version]
+		if rec.IsRepeatable() {
+			l, ok = repeatable[keyOf(rec)]
+		}
+		if !ok || rec.Checksum == "" || rec.Checksum == l.Checksum || !checksumMatches(rec.Checksum, l) {
+			continue
+		}
+		fmt.Fprintf(detail, "%s: %s -> %s\n", rec, rec.Checksum, l.Checksum)
+		rec.Checksum = l.Checksum
+		repairs = append(repairs, rec)
+	}
+	if len(repairs) == 0 {
+		return nil
+	}
+	if err := m.confirmed(OpRepair, detail.String()); err != nil {
+		return err
+	}
+	for _, rec := range repairs {
+		if err := m.record(rec, true); err != nil {
+			return fmt.Errorf("repair checksum: %s: %+v", rec, err)
+		}
+		fields := migrationFields(rec)
+		fields["checksum"] = rec.Checksum
+		m.log(LevelInfo, "repaired checksum", fields)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRepairChecksums(t *testing.T) {
+	noop := func(con *sql.DB) error { return nil }
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	m.AddGoMigration("1", "one", noop, Fingerprint("one"))
+	m.AddRepeatableGoMigration("view", noop, Fingerprint("view"))
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	m = newTestMigrator(t, s)
+	m.SetChecksumAlgorithm(ChecksumSHA256)
+	m.AddGoMigration("1", "one", noop, Fingerprint("one"))
+	m.AddRepeatableGoMigration("view", noop, Fingerprint("view"))
+	if err := m.Validate(); err != nil {
+		t.Fatalf("validate before repair: %v", err)
+	}
+	if pending := m.Info().Pending(); len(pending) != 0 {
+		t.Fatalf("want no pending migrations, got: %s", pending)
+	}
+	if err := m.Repair(); err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+	for _, mig := range s.migrations {
+		if mig.Checksum != ChecksumSHA256.Sum(mig.Description) {
+			t.Errorf("want sha256 checksum for %s, got: %s", mig.Description, mig.Checksum)
+		}
+		if !validChecksum(mig.Checksum) {
+			t.Errorf("want valid checksum, got: %s", mig.Checksum)
+		}
+	}
+	if err := m.Validate(); err != nil {
+		t.Fatalf("validate after repair: %v", err)
+	}
+
+	m = newTestMigrator(t, s)
+	m.SetChecksumAlgorithm(ChecksumSHA256)
+	m.AddGoMigration("1", "one", noop, Fingerprint("changed"))
+	if err := m.Repair(); err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+	if err := m.Validate(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("want checksum mismatch, got: %v", err)
+	}
+}
+
+func TestChecksumAlgorithm(t *testing.T) {
+	if got := ChecksumMD5.Sum("SELECT 1;"); got != SQLChecksum("SELECT 1;") || strings.Contains(got, ":") {
+		t.Errorf("want unprefixed md5 checksum, got: %s", got)
+	}
+	if got := ChecksumSHA256.Sum(""); got != "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("unexpected sha256 checksum: %s", got)
+	}
+	for _, cs := range []string{"sha1:abc", "sha256:xyz", "d41d8cd98f00b204e9800998ecf8427"} {
+		if validChecksum(cs) {
+			t.Errorf("want invalid checksum: %s", cs)
+		}
+	}
+}
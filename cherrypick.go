@@ -0,0 +1,76 @@
+package migrate
+
+// SetCherryPick makes Migrate apply only the given versions, e.g. a hotfix that has to be applied out of band,
+// including versions below the last installed one. Repeatable migrations are not installed. Cherry-picked migrations
+// are recorded with StatusCherryPicked, so a subsequent normal run still applies the lower pending versions and
+// skips the cherry-picked ones. Calling it without versions restores normal runs.
+func (m *Migrator) SetCherryPick(versions ...Version) {
+	m.cherryPick = versionSet(versions)
+}
+
+// SetSkipVersions makes Migrate leave the given versions pending. The migrations installed after a skipped one are
+// recorded with StatusCherryPicked, so a subsequent normal run applies the skipped versions. Calling it without
+// versions restores normal runs.
+func (m *Migrator) SetSkipVersions(versions ...Version) {
+	m.skipVersions = versionSet(versions)
+}
+
+func versionSet(versions []Version) map[Version]bool {
+	if len(versions) == 0 {
+		return nil
+	}
+	set := map[Version]bool{}
+	for _, v := range versions {
+		set[v] = true
+	}
+	return set
+}
+
+// excluded reports whether the versioned migration mig is excluded from the run by SetCherryPick, SetSkipVersions,
+// SetOnlyTags or SetExcludeTags.
+func (m *Migrator) excluded(mig Migration) bool {
+	if m.cherryPick != nil && !m.cherryPick[mig.Version] {
+		return true
+	}
+	return m.skipVersions[mig.Version] || m.taggedOut(mig)
+}
+
+// selectPending returns the pending migrations of info and the repeatable migrations that are selected by
+// SetCherryPick, SetSkipVersions, SetOnlyTags and SetExcludeTags. Cherry-picked versions are selected even if they are ignored by normal runs.
+func (m *Migrator) selectPending(info Info, repeatable Migrations) (Migrations, Migrations) {
+	candidates := append(info.Pending(), m.outOfOrderPending(info)...)
+	if m.cherryPick != nil {
+		candidates, repeatable = info.filter(StatePending, StateIgnored), nil
+	}
+	if repeatable != nil && m.filtersTags() {
+		tagged := Migrations{}
+		for _, mig := range repeatable {
+			if !m.taggedOut(mig) {
+				tagged = append(tagged, mig)
+			}
+		}
+		repeatable = tagged
+	}
+	selected := Migrations{}
+	for _, mig := range candidates {
+		if mig.IsRepeatable() {
+			if repeatable != nil && !m.taggedOut(mig) {
+				selected = append(selected, mig)
+			}
+			continue
+		}
+		if !m.excluded(mig) {
+			selected = append(selected, mig)
+		}
+	}
+	return selected, repeatable
+}
+
+// successStatus returns the status of a migration that has been installed successfully. It is StatusCherryPicked if
+// the migration has been installed out of band.
+func (m *Migrator) successStatus() Status {
+	if m.outOfBand {
+		return StatusCherryPicked
+	}
+	return StatusSuccess
+}
@@ -0,0 +1,67 @@
+package migrate
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestCherryPick(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	order := []Version{}
+	step := func(v Version) CommandFunc {
+		return func(con *sql.DB) error {
+			order = append(order, v)
+			return nil
+		}
+	}
+	m.AddGoMigration("1", "one", step("1"))
+	m.AddRepeatableGoMigration("view", step("R"))
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	m.AddGoMigration("2", "two", step("2"))
+	m.AddGoMigration("3", "hotfix", step("3"))
+	m.AddGoMigration("4", "four", step("4"))
+
+	m.SetCherryPick("3")
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.migrations[len(s.migrations)-1]; got.Version != "3" || got.Status != StatusCherryPicked {
+		t.Errorf("want version 3 to be cherry-picked, got: %s %s", got.Version, got.Status)
+	}
+
+	m.SetCherryPick()
+	m.SetSkipVersions("2")
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.migrations[len(s.migrations)-1]; got.Version != "4" || got.Status != StatusCherryPicked {
+		t.Errorf("want version 4 after skipped version 2 to be cherry-picked, got: %s %s", got.Version, got.Status)
+	}
+
+	m.SetSkipVersions()
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []Version{"1", "R", "3", "4", "2"}; !reflect.DeepEqual(want, order) {
+		t.Errorf("want: %v, got: %v", want, order)
+	}
+	if pending := m.Info().Pending(); len(pending) != 0 {
+		t.Errorf("want no pending migrations, got:\n%s", pending)
+	}
+	if err := m.Validate(); err != nil {
+		t.Errorf("want a valid state, got: %v", err)
+	}
+
+	m.AddGoMigration("0", "forgotten", step("0"))
+	m.SetCherryPick("0")
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if last := order[len(order)-1]; last != "0" {
+		t.Errorf("want ignored version 0 to be cherry-picked, got: %v", order)
+	}
+}
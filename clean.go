@@ -0,0 +1,135 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Object types known to CleanOptions.
+const (
+	ObjectTable   = "table"
+	ObjectView    = "view"
+	ObjectIndex   = "index"
+	ObjectTrigger = "trigger"
+)
+
+// Object is a database object that can be dropped by CleanWith.
+type Object struct {
+	// Schema is set for objects of other schemas than the configured one, e.g. attached databases.
+	Schema string
+	Type   string
+	Name   string
+	// Table is the table an index or trigger belongs to.
+	Table string
+}
+
+// Cleaner is implemented by Support implementations that are able to drop individual objects.
+type Cleaner interface {
+	// ListObjects returns the objects of the configured schema.
+	ListObjects(con *sql.DB) ([]Object, error)
+	// DropStatement returns the statement that drops o.
+	DropStatement(o Object) string
+}
+
+// ReportingCleaner is implemented by Support implementations that report the objects dropped by Clean, which the
+// Migrator logs.
+type ReportingCleaner interface {
+	CleanReporting(con *sql.DB, dropped func(o Object)) error
+}
+
+// CleanOptions restrict what is dropped by CleanWith.
+type CleanOptions struct {
+	// Types are the object types to drop. All types are dropped if empty.
+	Types []string
+	// Exclude are the names of objects to keep, e.g. fixture tables. Indexes and triggers of excluded tables are kept as well.
+	Exclude []string
+	// DryRun only returns the statements without executing them.
+	DryRun bool
+}
+
+// cleanOrder is the order in which object types are dropped. Other types are dropped last.
+var cleanOrder = []string{ObjectView, ObjectTrigger, ObjectIndex, ObjectTable}
+
+// CleanWith drops the objects selected by opts and returns the executed statements.
+// Unlike Clean it requires a Support that implements Cleaner.
+func (m *Migrator) CleanWith(opts CleanOptions) ([]string, error) {
+	c, ok := m.support.(Cleaner)
+	if !ok {
+		return nil, fmt.Errorf("support does not clean selectively: %T", m.support)
+	}
+	if err := m.initSession(); err != nil {
+		return nil, err
+	}
+	objects, err := c.ListObjects(m.db)
+	if err != nil {
+		return nil, fmt.Errorf("list objects: %+v", err)
+	}
+	stmts := []string{}
+	for _, o := range opts.filter(objects) {
+		stmts = append(stmts, c.DropStatement(o))
+	}
+	if opts.DryRun {
+		return stmts, nil
+	}
+	if err := m.confirmed(OpClean, strings.Join(stmts, "\n")); err != nil {
+		return nil, err
+	}
+	for i, stmt := range stmts {
+		m.log(LevelDebug, "clean", Fields{"statement": stmt})
+		if _, err := m.db.Exec(stmt); err != nil {
+			return stmts[:i], fmt.Errorf("clean: %s: %+v", stmt, err)
+		}
+	}
+	return stmts, m.callback(CallbackAfterClean)
+}
+
+// filter returns the selected objects in the order they have to be dropped.
+func (opts CleanOptions) filter(objects []Object) []Object {
+	types := map[string]bool{}
+	for _, t := range opts.Types {
+		types[strings.ToLower(t)] = true
+	}
+	excluded := map[string]bool{}
+	for _, name := range opts.Exclude {
+		excluded[name] = true
+	}
+	selected := map[string][]Object{}
+	for _, o := range objects {
+		if len(types) > 0 && !types[o.Type] {
+			continue
+		}
+		if excluded[o.Name] || (o.Table != "" && excluded[o.Table]) {
+			continue
+		}
+		selected[o.Type] = append(selected[o.Type], o)
+	}
+	res := []Object{}
+	for _, t := range cleanOrder {
+		res = append(res, selected[t]...)
+		delete(selected, t)
+	}
+	for _, o := range objects {
+		for _, s := range selected[o.Type] {
+			if s == o {
+				res = append(res, o)
+			}
+		}
+	}
+	return res
+}
+
+// clean wipes the database with the Support, logging the dropped objects if it reports them.
+func (m *Migrator) clean() error {
+	rc, ok := m.support.(ReportingCleaner)
+	if !ok {
+		return m.support.Clean(m.db)
+	}
+	return rc.CleanReporting(m.db, func(o Object) {
+		fields := Fields{"type": o.Type, "name": o.Name}
+		if o.Schema != "" {
+			fields["schema"] = o.Schema
+		}
+		m.log(LevelInfo, "dropped", fields)
+	})
+}
@@ -0,0 +1,69 @@
+package migrate
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+type cleanSupport struct {
+	memSupport
+	objects []Object
+}
+
+func (s *cleanSupport) ListObjects(con *sql.DB) ([]Object, error) {
+	return s.objects, nil
+}
+
+func (s *cleanSupport) DropStatement(o Object) string {
+	return SQLiteSupport{}.DropStatement(o)
+}
+
+func TestCleanWithDryRun(t *testing.T) {
+	s := &cleanSupport{
+		objects: []Object{
+			{Type: ObjectTable, Name: "fixtures"},
+			{Type: ObjectIndex, Name: "fixtures_idx", Table: "fixtures"},
+			{Type: ObjectTable, Name: "migrations"},
+			{Type: ObjectTable, Name: "users"},
+			{Type: ObjectIndex, Name: "users_idx", Table: "users"},
+			{Type: ObjectView, Name: "users_view"},
+		},
+	}
+	m := NewMigrator(t.Logf, nil, s)
+	tests := []struct {
+		name string
+		opts CleanOptions
+		want []string
+	}{
+		{
+			name: "all",
+			opts: CleanOptions{DryRun: true, Exclude: []string{"fixtures"}},
+			want: []string{
+				`DROP VIEW IF EXISTS "users_view";`,
+				`DROP INDEX IF EXISTS "users_idx";`,
+				`DROP TABLE IF EXISTS "migrations";`,
+				`DROP TABLE IF EXISTS "users";`,
+			},
+		},
+		{
+			name: "tables",
+			opts: CleanOptions{DryRun: true, Types: []string{ObjectTable}, Exclude: []string{"migrations"}},
+			want: []string{
+				`DROP TABLE IF EXISTS "fixtures";`,
+				`DROP TABLE IF EXISTS "users";`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := m.CleanWith(test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(test.want, got) {
+				t.Errorf("want: %q, got: %q", test.want, got)
+			}
+		})
+	}
+}
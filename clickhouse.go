@@ -0,0 +1,280 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	_ Support             = ClickHouseSupport{}
+	_ ContextSupport      = ClickHouseSupport{}
+	_ Cleaner             = ClickHouseSupport{}
+	_ MigrationFinder     = ClickHouseSupport{}
+	_ ConfigurableSupport = ClickHouseSupport{}
+	_ Summarizer          = ClickHouseSupport{}
+	_ VersionedMetadata   = ClickHouseSupport{}
+)
+
+// NewClickHouseSupport creates a ClickHouseSupport. The schema is the ClickHouse database and defaults to the
+// current database. The metadata table uses the ReplacingMergeTree engine and is read with FINAL.
+func NewClickHouseSupport(opts ...SupportOption) ClickHouseSupport {
+	return ClickHouseSupport{
+		config: newSupportConfig(opts),
+	}
+}
+
+// Configure returns a copy of the ClickHouseSupport with opts applied to its configuration.
+func (s ClickHouseSupport) Configure(opts ...SupportOption) Support {
+	for _, opt := range opts {
+		opt(&s.config)
+	}
+	return s
+}
+
+type ClickHouseSupport struct {
+	config SupportConfig
+}
+
+// database returns the expression of the configured database and its arguments.
+func (s ClickHouseSupport) database() (string, []interface{}) {
+	if s.config.Schema == "" {
+		return "currentDatabase()", nil
+	}
+	return "?", []interface{}{s.config.Schema}
+}
+
+// Dialects returns clickhouse.
+func (s ClickHouseSupport) Dialects() []string {
+	return []string{"clickhouse"}
+}
+
+// DetectServer returns the version of the ClickHouse server.
+func (s ClickHouseSupport) DetectServer(db *sql.DB) (Server, error) {
+	srv := Server{Product: "ClickHouse"}
+	err := db.QueryRow(`SELECT version()`).Scan(&srv.Version)
+	return srv, err
+}
+
+func (s ClickHouseSupport) ExistsMigrationsTable(db *sql.DB) (bool, error) {
+	return s.ExistsMigrationsTableContext(context.Background(), db)
+}
+
+func (s ClickHouseSupport) ExistsMigrationsTableContext(ctx context.Context, q Querier) (bool, error) {
+	database, args := s.database()
+	var count int
+	row := q.QueryRowContext(ctx, `SELECT count() FROM system.tables WHERE database = `+database+` AND name = ?`, append(args, s.config.TableName(""))...)
+	err := row.Scan(&count)
+	return count > 0, err
+}
+
+func (s ClickHouseSupport) CreateMigrationsTable(db *sql.DB) error {
+	return s.CreateMigrationsTableContext(context.Background(), db)
+}
+
+func (s ClickHouseSupport) CreateMigrationsTableContext(ctx context.Context, q Querier) error {
+	_, err := q.ExecContext(ctx, fmt.Sprintf(clickhouseMigrations, s.config.QualifiedName("")))
+	return err
+}
+
+func (s ClickHouseSupport) MetadataUpgrades() []MetadataUpgrade {
+	addColumns := func(columns ...string) func(db *sql.DB) error {
+		return func(db *sql.DB) error {
+			for _, column := range columns {
+				if _, err := db.Exec(`ALTER TABLE ` + s.config.QualifiedName("") + ` ADD COLUMN IF NOT EXISTS ` + column); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	return []MetadataUpgrade{
+		{Version: 1, Description: "failure diagnostics", Apply: addColumns("failure String", "failed_statement UInt32")},
+		{Version: 2, Description: "installed by", Apply: addColumns("installed_by String")},
+		{Version: 3, Description: "script headers", Apply: addColumns("author String", "ticket String")},
+		{Version: 4, Description: "shell output", Apply: addColumns("output String")},
+	}
+}
+
+func (s ClickHouseSupport) MetadataVersion(db *sql.DB) (int, error) {
+	if _, err := db.Exec(fmt.Sprintf(clickhouseSchemaVersion, s.config.QualifiedName("_schema"))); err != nil {
+		return 0, err
+	}
+	var version uint32
+	err := db.QueryRow(`SELECT version FROM ` + s.config.QualifiedName("_schema") + ` FINAL WHERE id = 1`).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return int(version), err
+}
+
+func (s ClickHouseSupport) SetMetadataVersion(db *sql.DB, version int) error {
+	if _, err := db.Exec(fmt.Sprintf(clickhouseSchemaVersion, s.config.QualifiedName("_schema"))); err != nil {
+		return err
+	}
+	_, err := db.Exec(`INSERT INTO `+s.config.QualifiedName("_schema")+` (id, version) VALUES (1, ?)`, uint32(version))
+	return err
+}
+
+func (s ClickHouseSupport) RecordMigration(db *sql.DB, m Migration) error {
+	return s.RecordMigrationContext(context.Background(), db, m)
+}
+
+func (s ClickHouseSupport) RecordMigrationContext(ctx context.Context, q Querier, m Migration) error {
+	_, err := q.ExecContext(ctx, `INSERT INTO `+s.config.QualifiedName("")+` (rank, version, description, type, checksum, date, execution_time, status, failure, failed_statement, installed_by, author, ticket, output) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		uint32(m.Rank),
+		string(m.Version),
+		m.Description,
+		string(m.Type),
+		m.Checksum,
+		m.Date,
+		uint64(milliseconds(m.ExecutionTime)),
+		string(m.Status),
+		m.Failure,
+		uint32(m.FailedStatement),
+		m.InstalledBy,
+		m.Author,
+		m.Ticket,
+		m.Output,
+	)
+	return err
+}
+
+func (s ClickHouseSupport) ListMigrations(db *sql.DB) (Migrations, error) {
+	return s.ListMigrationsContext(context.Background(), db)
+}
+
+func (s ClickHouseSupport) ListMigrationsContext(ctx context.Context, q Querier) (Migrations, error) {
+	return s.findMigrations(ctx, q, MigrationFilter{})
+}
+
+// FindMigrations lists the recorded migrations selected by f ordered by rank.
+func (s ClickHouseSupport) FindMigrations(db *sql.DB, f MigrationFilter) (Migrations, error) {
+	return s.findMigrations(context.Background(), db, f)
+}
+
+// CountMigrations returns the number of recorded migrations selected by f.
+func (s ClickHouseSupport) CountMigrations(db *sql.DB, f MigrationFilter) (int, error) {
+	where, args := f.where(lowerCaseColumns(s.config.QualifiedName("")+" FINAL"), questionMark)
+	var n uint64
+	err := db.QueryRow(`SELECT count() FROM `+s.config.QualifiedName("")+` FINAL`+where, args...).Scan(&n)
+	return int(n), err
+}
+
+// SummarizeMigrations summarizes the recorded versioned migrations with a single query.
+func (s ClickHouseSupport) SummarizeMigrations(db *sql.DB) (Summary, error) {
+	table := s.config.QualifiedName("")
+	var versioned, failed uint64
+	var latest string
+	row := db.QueryRow(`SELECT count(), countIf(status = ?), (SELECT version FROM `+table+` FINAL WHERE version <> ? ORDER BY rank DESC LIMIT 1) FROM `+table+` FINAL WHERE version <> ?`,
+		string(StatusFailed), string(VersionRepeatable), string(VersionRepeatable))
+	if err := row.Scan(&versioned, &failed, &latest); err != nil {
+		return Summary{}, err
+	}
+	return Summary{Versioned: int(versioned), Failed: int(failed), Latest: Version(latest)}, nil
+}
+
+func (s ClickHouseSupport) findMigrations(ctx context.Context, q Querier, f MigrationFilter) (Migrations, error) {
+	where, args := f.where(lowerCaseColumns(s.config.QualifiedName("")+" FINAL"), questionMark)
+	rows, err := q.QueryContext(ctx, `SELECT rank, version, description, type, checksum, date, execution_time, status, failure, failed_statement, installed_by, author, ticket, output FROM `+s.config.QualifiedName("")+` FINAL`+where+` ORDER BY rank`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ms := []Migration{}
+	for rows.Next() {
+		var m Migration
+		var rank, failedStatement uint32
+		var executionTime uint64
+		var version, typ, status string
+		var date time.Time
+		if err := rows.Scan(&rank, &version, &m.Description, &typ, &m.Checksum, &date, &executionTime, &status, &m.Failure, &failedStatement, &m.InstalledBy, &m.Author, &m.Ticket, &m.Output); err != nil {
+			return nil, err
+		}
+		m.Rank = int(rank)
+		m.Version = Version(version)
+		m.Type = Type(typ)
+		m.Date = date.UTC()
+		m.ExecutionTime = fromMilliseconds(int64(executionTime))
+		m.Status = Status(status)
+		m.FailedStatement = int(failedStatement)
+		ms = append(ms, m)
+	}
+	return ms, rows.Err()
+}
+
+// ListObjects lists the tables, views and materialized views of the configured database. The inner tables of
+// materialized views are dropped with them and are not listed.
+func (s ClickHouseSupport) ListObjects(db *sql.DB) ([]Object, error) {
+	database, args := s.database()
+	rows, err := db.Query(`SELECT name, engine FROM system.tables WHERE database = `+database+` AND NOT is_temporary AND name NOT LIKE '.inner%' ORDER BY name`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	objects := []Object{}
+	for rows.Next() {
+		var name, engine string
+		if err := rows.Scan(&name, &engine); err != nil {
+			return nil, err
+		}
+		o := Object{Type: ObjectTable, Name: name}
+		if engine == "View" || engine == "MaterializedView" {
+			o.Type = ObjectView
+		}
+		objects = append(objects, o)
+	}
+	return objects, rows.Err()
+}
+
+func (s ClickHouseSupport) DropStatement(o Object) string {
+	name := quoteIdent(o.Name)
+	if s.config.Schema != "" {
+		name = quoteIdent(s.config.Schema) + "." + name
+	}
+	return `DROP ` + strings.ToUpper(o.Type) + ` IF EXISTS ` + name
+}
+
+// Clean drops the views, materialized views and tables of the configured database.
+func (s ClickHouseSupport) Clean(db *sql.DB) error {
+	objects, err := s.ListObjects(db)
+	if err != nil {
+		return err
+	}
+	for _, o := range (CleanOptions{}).filter(objects) {
+		if _, err := db.Exec(s.DropStatement(o)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clickhouseMigrations keeps the last row inserted per rank.
+const clickhouseMigrations = `
+CREATE TABLE IF NOT EXISTS %s (
+  rank UInt32,
+  version String,
+  description String,
+  type String,
+  checksum String,
+  date DateTime,
+  execution_time UInt64,
+  status String,
+  failure String,
+  failed_statement UInt32,
+  installed_by String,
+  author String,
+  ticket String,
+  output String
+) ENGINE = ReplacingMergeTree
+ORDER BY rank`
+
+// clickhouseSchemaVersion keeps the last version inserted.
+const clickhouseSchemaVersion = `
+CREATE TABLE IF NOT EXISTS %s (
+  id UInt8,
+  version UInt32
+) ENGINE = ReplacingMergeTree
+ORDER BY id`
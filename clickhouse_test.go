@@ -0,0 +1,53 @@
+package migrate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClickHouseDropStatement(t *testing.T) {
+	s := NewClickHouseSupport(WithSchema("analytics"))
+	objects := (CleanOptions{}).filter([]Object{
+		{Type: ObjectTable, Name: "events"},
+		{Type: ObjectView, Name: "daily"},
+	})
+	want := []string{
+		`DROP VIEW IF EXISTS "analytics"."daily"`,
+		`DROP TABLE IF EXISTS "analytics"."events"`,
+	}
+	for i, o := range objects {
+		if got := s.DropStatement(o); got != want[i] {
+			t.Errorf("want: %s, got: %s", want[i], got)
+		}
+	}
+}
+
+func TestClickHouseUpgradeMetadata(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	log.results = map[string]string{
+		`SELECT count() FROM system.tables WHERE database = currentDatabase() AND name = ?`: "1",
+	}
+	m := NewMigrator(t.Logf, db, NewClickHouseSupport())
+	if err := m.upgradeMetadata(); err != nil {
+		t.Fatal(err)
+	}
+	alters := []string{}
+	for _, stmt := range log.Statements() {
+		if strings.HasPrefix(stmt, "ALTER TABLE") {
+			alters = append(alters, stmt)
+		}
+	}
+	want := []string{
+		`ALTER TABLE "migrations" ADD COLUMN IF NOT EXISTS failure String`,
+		`ALTER TABLE "migrations" ADD COLUMN IF NOT EXISTS failed_statement UInt32`,
+		`ALTER TABLE "migrations" ADD COLUMN IF NOT EXISTS installed_by String`,
+		`ALTER TABLE "migrations" ADD COLUMN IF NOT EXISTS author String`,
+		`ALTER TABLE "migrations" ADD COLUMN IF NOT EXISTS ticket String`,
+		`ALTER TABLE "migrations" ADD COLUMN IF NOT EXISTS output String`,
+	}
+	if !reflect.DeepEqual(want, alters) {
+		t.Errorf("want: %q, got: %q", want, alters)
+	}
+}
@@ -0,0 +1,61 @@
+package migrate
+
+import "time"
+
+// Clock tells the time. The Migrator reads the dates and execution times it records, the times of its events and
+// reports and the generated run tokens from its Clock, see SetClock, so that tests of migrations can be deterministic.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is a function that tells the time.
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock is the Clock of the system, the default of every Migrator.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// SetClock sets the Clock of the Migrator. It is passed to Go migrations through their MigrationContext. A nil Clock
+// restores SystemClock.
+func (m *Migrator) SetClock(c Clock) {
+	m.clock = c
+}
+
+// WithClock sets the Clock of the Migrator, see SetClock.
+func WithClock(c Clock) Option {
+	return func(m *Migrator) error {
+		m.SetClock(c)
+		return nil
+	}
+}
+
+// getClock returns the Clock of the Migrator.
+func (m *Migrator) getClock() Clock {
+	if m.clock == nil {
+		return SystemClock
+	}
+	return m.clock
+}
+
+// now returns the current time of the Clock of the Migrator.
+func (m *Migrator) now() time.Time {
+	return m.getClock().Now()
+}
+
+// since returns the time elapsed since t by the Clock of the Migrator.
+func (m *Migrator) since(t time.Time) time.Duration {
+	return m.now().Sub(t)
+}
+
+// milliseconds returns d in whole milliseconds, the unit execution times are stored in.
+func milliseconds(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
+
+// fromMilliseconds returns the duration of an execution time stored in milliseconds.
+func fromMilliseconds(ms int64) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
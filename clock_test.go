@@ -0,0 +1,49 @@
+package migrate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMigrateClock(t *testing.T) {
+	start := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	now := start
+	s := &memSupport{}
+	m, err := New(nil, s, WithLogger(LogFunc(t.Logf)), WithClock(ClockFunc(func() time.Time { return now })))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetRunLabels(map[string]string{"deploy": "42"})
+	m.AddGoContextMigration("1", "one", func(ctx *MigrationContext) error {
+		if got := ctx.Clock.Now(); !got.Equal(start) {
+			t.Errorf("want: %s, got: %s", start, got)
+		}
+		now = now.Add(1500 * time.Millisecond)
+		return nil
+	})
+	res, err := m.MigrateWithResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1500*time.Millisecond, res.Duration; want != got {
+		t.Errorf("want duration: %s, got: %s", want, got)
+	}
+	rec := s.migrations[0]
+	if !rec.Date.Equal(start) || rec.ExecutionTime != 1500*time.Millisecond {
+		t.Errorf("want installed at %s in 1.5s, got: %s in %s", start, rec.Date, rec.ExecutionTime)
+	}
+	token := "run-1706702400000000000"
+	if r, ok := s.runs[token]; !ok || !r.Started.Equal(start) || !r.Finished.Equal(now) {
+		t.Errorf("want run %s from %s to %s, got: %+v", token, start, now, s.runs)
+	}
+}
+
+func TestExecutionTimeMilliseconds(t *testing.T) {
+	d := 1500*time.Millisecond + 999*time.Microsecond
+	if want, got := int64(1500), milliseconds(d); want != got {
+		t.Errorf("want: %d, got: %d", want, got)
+	}
+	if want, got := 1500*time.Millisecond, fromMilliseconds(milliseconds(d)); want != got {
+		t.Errorf("want: %s, got: %s", want, got)
+	}
+}
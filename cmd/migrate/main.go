@@ -0,0 +1,423 @@
+// Command migrate is a companion tool for the migrate package.
+//
+// Usage:
+//
+//	migrate new [-dir migrations] [-repeatable] [-undo] description...
+//	migrate info [-dir migrations] [-driver name] [-dsn-env variable] [-stats]
+//	migrate bundle [-dir migrations] [-support sqlite] [-installed-by identity]
+//	migrate manifest [-dir migrations] [-key-env variable]
+//	migrate up -driver name [-dir migrations] [-dsn-env variable] [-only-tags a,b] [-exclude-tags a,b] [-report report.json] [-strict] [-allow-newer-schema]
+//	migrate plan -driver name [-dir migrations] [-dsn-env variable]
+//	migrate apply -driver name [-dir migrations] [-dsn-env variable] [-plan plan.json]
+//	migrate watch -driver name [-dir migrations] [-dsn-env variable] [-interval 1s]
+//	migrate rollback -driver name [-dir migrations] [-dsn-env variable] [-target version]
+//	migrate history -driver name [-dir migrations] [-dsn-env variable] [-at 2006-01-02T15:04:05Z]
+//	migrate unlock -driver name [-dsn-env variable]
+//	migrate changelog [-dir migrations] [-driver name] [-dsn-env variable] [-releases tag=version,...]
+//
+// The up, plan, apply, watch, rollback, history and unlock commands, and info and changelog with a driver, connect with the database/sql drivers and connectors registered by
+// the packages the tool is built with, so a build that imports a package calling migrate.RegisterConnector migrates through e.g. an SSH
+// tunnel.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cognicraft/migrate"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "new":
+		err = runNew(os.Args[2:])
+	case "info":
+		err = runInfo(os.Args[2:])
+	case "bundle":
+		err = runBundle(os.Args[2:])
+	case "manifest":
+		err = runManifest(os.Args[2:])
+	case "up":
+		err = runUp(os.Args[2:])
+	case "plan":
+		err = runPlan(os.Args[2:])
+	case "apply":
+		err = runApply(os.Args[2:])
+	case "watch":
+		err = runWatch(os.Args[2:])
+	case "rollback":
+		err = runRollback(os.Args[2:])
+	case "history":
+		err = runHistory(os.Args[2:])
+	case "unlock":
+		err = runUnlock(os.Args[2:])
+	case "changelog":
+		err = runChangelog(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate new [-dir migrations] [-repeatable] [-undo] description...")
+	fmt.Fprintln(os.Stderr, "       migrate info [-dir migrations] [-driver name] [-dsn-env variable] [-stats]")
+	fmt.Fprintln(os.Stderr, "       migrate bundle [-dir migrations] [-support sqlite] [-installed-by identity]")
+	fmt.Fprintln(os.Stderr, "       migrate manifest [-dir migrations] [-key-env variable]")
+	fmt.Fprintln(os.Stderr, "       migrate up -driver name [-dir migrations] [-dsn-env variable] [-only-tags a,b] [-exclude-tags a,b] [-report report.json] [-strict] [-allow-newer-schema]")
+	fmt.Fprintln(os.Stderr, "       migrate plan -driver name [-dir migrations] [-dsn-env variable]")
+	fmt.Fprintln(os.Stderr, "       migrate apply -driver name [-dir migrations] [-dsn-env variable] [-plan plan.json]")
+	fmt.Fprintln(os.Stderr, "       migrate watch -driver name [-dir migrations] [-dsn-env variable] [-interval 1s]")
+	fmt.Fprintln(os.Stderr, "       migrate rollback -driver name [-dir migrations] [-dsn-env variable] [-target version]")
+	fmt.Fprintln(os.Stderr, "       migrate history -driver name [-dir migrations] [-dsn-env variable] [-at 2006-01-02T15:04:05Z]")
+	fmt.Fprintln(os.Stderr, "       migrate unlock -driver name [-dsn-env variable]")
+	fmt.Fprintln(os.Stderr, "       migrate changelog [-dir migrations] [-driver name] [-dsn-env variable] [-releases tag=version,...]")
+	os.Exit(2)
+}
+
+func runNew(args []string) error {
+	fs := flag.NewFlagSet("new", flag.ExitOnError)
+	dir := fs.String("dir", "migrations", "directory of the migration scripts")
+	repeatable := fs.Bool("repeatable", false, "create a repeatable migration")
+	undo := fs.Bool("undo", false, "create an undo script")
+	fs.Parse(args)
+	paths, err := migrate.GenerateMigration(*dir, strings.Join(fs.Args(), " "), migrate.GenerateOptions{
+		Repeatable: *repeatable,
+		Undo:       *undo,
+	})
+	for _, p := range paths {
+		fmt.Println(p)
+	}
+	return err
+}
+
+func runInfo(args []string) error {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	db := databaseFlags(fs)
+	stats := fs.Bool("stats", false, "print statistics about the migrations, with the slowest one if a driver is given")
+	fs.Parse(args)
+	ms, err := db.migrations()
+	if err != nil {
+		return err
+	}
+	if *stats {
+		return ms.Stats().Write(os.Stdout)
+	}
+	for _, m := range ms {
+		fmt.Printf("%s\t%s\t%s\n", m.Version, m.Type, m.Description)
+	}
+	return nil
+}
+
+// runBundle writes all migrations in dir as a SQL script for a database without metadata table to stdout.
+func runBundle(args []string) error {
+	fs := flag.NewFlagSet("bundle", flag.ExitOnError)
+	dir := fs.String("dir", "migrations", "directory of the migration scripts")
+	support := fs.String("support", "sqlite", "name of the database support")
+	installedBy := fs.String("installed-by", "", "identity recorded with the migrations")
+	fs.Parse(args)
+	s, ok := migrate.SupportFor(*support)
+	if !ok {
+		return fmt.Errorf("unknown support: %s", *support)
+	}
+	m := migrate.NewMigrator(func(format string, args ...interface{}) {}, nil, s)
+	m.SetInstalledBy(*installedBy)
+	if err := m.AddDir(*dir); err != nil {
+		return err
+	}
+	return m.Bundle(os.Stdout)
+}
+
+// runManifest writes the manifest of the migrations in dir to stdout, signed with the key held by an environment
+// variable, so that the key does not show up in the command line.
+func runManifest(args []string) error {
+	fs := flag.NewFlagSet("manifest", flag.ExitOnError)
+	dir := fs.String("dir", "migrations", "directory of the migration scripts")
+	keyEnv := fs.String("key-env", "", "environment variable holding the key the manifest is signed with")
+	fs.Parse(args)
+	ms, err := migrate.LoadDir(*dir)
+	if err != nil {
+		return err
+	}
+	var key []byte
+	if *keyEnv != "" {
+		key = []byte(os.Getenv(*keyEnv))
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(migrate.NewManifest(ms, key))
+}
+
+// database holds the flags of the commands that connect to a database.
+type database struct {
+	dir        *string
+	driverName *string
+	dsnEnv     *string
+}
+
+func databaseFlags(fs *flag.FlagSet) database {
+	return database{
+		dir:        fs.String("dir", "migrations", "directory of the migration scripts"),
+		driverName: fs.String("driver", "", "name of the database/sql driver"),
+		dsnEnv:     fs.String("dsn-env", "DATABASE_URL", "environment variable holding the data source name"),
+	}
+}
+
+// open returns a Migrator for the database whose DSN is held by an environment variable, so that credentials do not
+// show up in the command line.
+func (d database) open() (*migrate.Migrator, error) {
+	if *d.driverName == "" {
+		return nil, fmt.Errorf("missing driver")
+	}
+	log := func(format string, args ...interface{}) {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+	}
+	return migrate.NewMigratorFromDSN(log, *d.driverName, os.Getenv(*d.dsnEnv))
+}
+
+// openDir returns the Migrator of open with the migrations in the directory added.
+func (d database) openDir() (*migrate.Migrator, error) {
+	m, err := d.open()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.AddDir(*d.dir); err != nil {
+		m.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// runUp applies the migrations in dir to the database. An interrupt or SIGTERM aborts the run after the statement that
+// is executing.
+func runUp(args []string) error {
+	fs := flag.NewFlagSet("up", flag.ExitOnError)
+	db := databaseFlags(fs)
+	onlyTags := fs.String("only-tags", "", "comma separated tags of the migrations that are applied")
+	excludeTags := fs.String("exclude-tags", "", "comma separated tags of the migrations that are left pending")
+	report := fs.String("report", "", "file the report of the run is written to, as HTML if it ends with .html")
+	strict := fs.Bool("strict", false, "fail if the database contains applied migrations that are not in dir")
+	allowNewer := fs.Bool("allow-newer-schema", false, "accept applied migrations newer than the ones in dir with -strict")
+	fs.Parse(args)
+	m, err := db.openDir()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	m.SetOnlyTags(splitList(*onlyTags)...)
+	m.SetExcludeTags(splitList(*excludeTags)...)
+	m.SetStrict(*strict)
+	m.SetAllowNewerSchema(*allowNewer)
+	if *report != "" {
+		m.SetReportFile(*report)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	terminate := make(chan os.Signal, 1)
+	signal.Notify(terminate, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(terminate)
+	go func() {
+		select {
+		case <-terminate:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return m.MigrateContext(ctx)
+}
+
+// runPlan writes the plan of the migrations in dir that are pending in the database to stdout.
+func runPlan(args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	db := databaseFlags(fs)
+	fs.Parse(args)
+	m, err := db.openDir()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	p, err := m.Plan()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// runApply applies a plan written by runPlan unless the database or the migrations in dir have changed since.
+func runApply(args []string) error {
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	db := databaseFlags(fs)
+	file := fs.String("plan", "plan.json", "file of the plan")
+	fs.Parse(args)
+	bs, err := ioutil.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	p := migrate.Plan{}
+	if err := json.Unmarshal(bs, &p); err != nil {
+		return fmt.Errorf("parse plan: %s: %+v", *file, err)
+	}
+	m, err := db.openDir()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	return m.Apply(p)
+}
+
+// runWatch re-applies the migrations in dir whenever they change until it is interrupted.
+func runWatch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	db := databaseFlags(fs)
+	interval := fs.Duration("interval", time.Second, "interval at which the directory is polled")
+	fs.Parse(args)
+	m, err := db.open()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		cancel()
+	}()
+	if err := m.Watch(ctx, *db.dir, *interval); err != context.Canceled {
+		return err
+	}
+	return nil
+}
+
+// runRollback writes the rollback plan from the current version of the database to the target version to stdout.
+func runRollback(args []string) error {
+	fs := flag.NewFlagSet("rollback", flag.ExitOnError)
+	db := databaseFlags(fs)
+	target := fs.String("target", "", "version the database is reverted to, all migrations are reverted if empty")
+	fs.Parse(args)
+	m, err := db.openDir()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	return m.GenerateRollbackPlan(os.Stdout, migrate.Version(*target))
+}
+
+// runUnlock releases the migration lock left behind by a migrator that crashed while holding it.
+func runUnlock(args []string) error {
+	fs := flag.NewFlagSet("unlock", flag.ExitOnError)
+	db := databaseFlags(fs)
+	fs.Parse(args)
+	m, err := db.open()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	return m.ForceUnlock()
+}
+
+// runHistory writes the deploys of the database to stdout or, with -at, the version the database has been on at the
+// given time.
+func runHistory(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	db := databaseFlags(fs)
+	at := fs.String("at", "", "time in RFC 3339 format")
+	fs.Parse(args)
+	m, err := db.openDir()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	info := m.Info()
+	if *at == "" {
+		return info.History().Render(os.Stdout)
+	}
+	t, err := time.Parse(time.RFC3339, *at)
+	if err != nil {
+		return fmt.Errorf("parse time: %s: %+v", *at, err)
+	}
+	v, ok := info.VersionAt(t)
+	if !ok {
+		return fmt.Errorf("no migration applied at %s", *at)
+	}
+	fmt.Println(v)
+	return nil
+}
+
+// runChangelog writes the changelog of the migrations in dir as Markdown to stdout. With a driver, the applied
+// migrations of the database are included with their dates.
+func runChangelog(args []string) error {
+	fs := flag.NewFlagSet("changelog", flag.ExitOnError)
+	db := databaseFlags(fs)
+	releases := fs.String("releases", "", "releases with the last version they ship, e.g. v1.0.0=12,v1.1.0=15")
+	fs.Parse(args)
+	rs := []migrate.Release{}
+	for _, r := range splitList(*releases) {
+		parts := strings.SplitN(r, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid release: %s", r)
+		}
+		rs = append(rs, migrate.Release{Tag: parts[0], Version: migrate.Version(parts[1])})
+	}
+	if *db.driverName == "" {
+		ms, err := migrate.LoadDir(*db.dir)
+		if err != nil {
+			return err
+		}
+		return migrate.Info{Migrations: ms}.Changelog(rs...).Render(os.Stdout)
+	}
+	m, err := db.openDir()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	return m.Changelog(rs...).Render(os.Stdout)
+}
+
+// migrations returns the migrations in the directory, merged with the applied ones if a driver is given, so that
+// their execution times are known.
+func (d database) migrations() (migrate.Migrations, error) {
+	if *d.driverName == "" {
+		return migrate.LoadDir(*d.dir)
+	}
+	m, err := d.openDir()
+	if err != nil {
+		return nil, err
+	}
+	defer m.Close()
+	ms := migrate.Migrations{}
+	for _, mig := range m.Info().Migrations {
+		if mig.State != migrate.StateSuperseded {
+			ms = append(ms, mig)
+		}
+	}
+	return ms, nil
+}
+
+// splitList splits a comma separated flag value, ignoring empty elements.
+func splitList(value string) []string {
+	list := []string{}
+	for _, e := range strings.Split(value, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			list = append(list, e)
+		}
+	}
+	return list
+}
@@ -0,0 +1,423 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	_ Support             = CockroachSupport{}
+	_ ContextSupport      = CockroachSupport{}
+	_ RetryClassifier     = CockroachSupport{}
+	_ Cleaner             = CockroachSupport{}
+	_ VersionedMetadata   = CockroachSupport{}
+	_ SessionUser         = CockroachSupport{}
+	_ ScriptRecorder      = CockroachSupport{}
+	_ Maintainer          = CockroachSupport{}
+	_ LockWaitSupport     = CockroachSupport{}
+	_ MigrationFinder     = CockroachSupport{}
+	_ ScriptDeleter       = CockroachSupport{}
+	_ MigrationDeleter    = CockroachSupport{}
+	_ ScriptStore         = CockroachSupport{}
+	_ ConfigurableSupport = CockroachSupport{}
+	_ Summarizer          = CockroachSupport{}
+)
+
+// NewCockroachSupport creates a CockroachSupport. The zero value uses the table "migrations" in the current schema.
+// Migrations executed in a transaction (see Batch.Transaction) and the writes to the metadata table are retried
+// when CockroachDB asks for a transaction retry.
+func NewCockroachSupport(opts ...SupportOption) CockroachSupport {
+	return CockroachSupport{
+		config: newSupportConfig(opts),
+	}
+}
+
+// Configure returns a copy of the CockroachSupport with opts applied to its configuration.
+func (s CockroachSupport) Configure(opts ...SupportOption) Support {
+	for _, opt := range opts {
+		opt(&s.config)
+	}
+	return s
+}
+
+type CockroachSupport struct {
+	config SupportConfig
+}
+
+// IsRetryable reports whether err is a transaction retry error (SQLSTATE 40001).
+func (s CockroachSupport) IsRetryable(err error) bool {
+	var state interface{ SQLState() string }
+	if errors.As(err, &state) {
+		return state.SQLState() == "40001"
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "40001") || strings.Contains(msg, "restart transaction")
+}
+
+// SetStatementTimeout sets statement_timeout for the session of q.
+func (s CockroachSupport) SetStatementTimeout(ctx context.Context, q Querier, timeout time.Duration) error {
+	_, err := q.ExecContext(ctx, fmt.Sprintf("SET statement_timeout = '%dms';", timeout/time.Millisecond))
+	return err
+}
+
+// SetLockWaitTimeout sets lock_timeout, which aborts statements that wait longer for a lock.
+func (s CockroachSupport) SetLockWaitTimeout(ctx context.Context, q Querier, timeout time.Duration) error {
+	_, err := q.ExecContext(ctx, fmt.Sprintf("SET lock_timeout = '%dms';", timeout/time.Millisecond))
+	return err
+}
+
+// Dialects returns cockroach and postgres.
+func (s CockroachSupport) Dialects() []string {
+	return []string{"cockroach", "postgres"}
+}
+
+// DetectServer returns the version of the CockroachDB node, e.g. v23.1.2.
+func (s CockroachSupport) DetectServer(db *sql.DB) (Server, error) {
+	var version string
+	if err := db.QueryRow(`SELECT version();`).Scan(&version); err != nil {
+		return Server{}, err
+	}
+	return Server{Product: "CockroachDB", Version: serverVersion(version)}, nil
+}
+
+// showTables returns the name and type of the tables, views and sequences of the configured schema.
+func (s CockroachSupport) showTables(ctx context.Context, q Querier) ([]Object, error) {
+	query := `SHOW TABLES;`
+	if s.config.Schema != "" {
+		query = `SHOW TABLES FROM ` + quoteIdent(s.config.Schema) + `;`
+	}
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	objects := []Object{}
+	for rows.Next() {
+		values := make([]sql.NullString, len(cols))
+		dest := make([]interface{}, len(cols))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		o := Object{Type: ObjectTable}
+		for i, col := range cols {
+			switch col {
+			case "table_name":
+				o.Name = values[i].String
+			case "type":
+				o.Type = strings.ToLower(values[i].String)
+			}
+		}
+		objects = append(objects, o)
+	}
+	return objects, rows.Err()
+}
+
+func (s CockroachSupport) ExistsMigrationsTable(db *sql.DB) (bool, error) {
+	return s.ExistsMigrationsTableContext(context.Background(), db)
+}
+
+func (s CockroachSupport) ExistsMigrationsTableContext(ctx context.Context, q Querier) (bool, error) {
+	objects, err := s.showTables(ctx, q)
+	if err != nil {
+		return false, err
+	}
+	for _, o := range objects {
+		if o.Name == s.config.TableName("") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s CockroachSupport) CreateMigrationsTable(db *sql.DB) error {
+	return s.CreateMigrationsTableContext(context.Background(), db)
+}
+
+func (s CockroachSupport) CreateMigrationsTableContext(ctx context.Context, q Querier) error {
+	_, err := q.ExecContext(ctx, fmt.Sprintf(cockroachMigrations, s.config.QualifiedName("")))
+	return err
+}
+
+func (s CockroachSupport) CreateMigrationsTableScript() string {
+	return fmt.Sprintf(cockroachMigrations, s.config.QualifiedName(""))
+}
+
+// StoreScript stores script with the record with the rank of m and retries transaction retry errors.
+func (s CockroachSupport) StoreScript(db *sql.DB, m Migration, script string) error {
+	data, err := s.config.Compression.Encode(script)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	return retryRetryable(ctx, s, func() error {
+		_, err := db.ExecContext(ctx, `UPDATE `+s.config.QualifiedName("")+` SET script = $1 WHERE rank = $2;`, data, m.Rank)
+		return err
+	})
+}
+
+func (s CockroachSupport) LoadScript(db *sql.DB, m Migration) (string, bool, error) {
+	var data []byte
+	err := db.QueryRow(`SELECT script FROM `+s.config.QualifiedName("")+` WHERE rank = $1;`, m.Rank).Scan(&data)
+	if err == sql.ErrNoRows || (err == nil && data == nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	script, err := decodeStored(data)
+	return script, err == nil, err
+}
+
+// DeleteMigration deletes the record with the rank of m.
+func (s CockroachSupport) DeleteMigration(db *sql.DB, m Migration) error {
+	_, err := db.Exec(s.DeleteMigrationScript(m))
+	return err
+}
+
+// DeleteMigrationScript renders the statement that deletes the record of m.
+func (s CockroachSupport) DeleteMigrationScript(m Migration) string {
+	return `DELETE FROM ` + s.config.QualifiedName("") + ` WHERE rank = ` + sqlInt(m.Rank) + `;`
+}
+
+func (s CockroachSupport) RecordMigrationScript(m Migration) string {
+	return `INSERT INTO ` + s.config.QualifiedName("") + ` (rank, version, description, type, checksum, date, execution_time, status, installed_by, author, ticket) VALUES (` +
+		strings.Join([]string{
+			sqlInt(m.Rank),
+			sqlLiteral(string(m.Version)),
+			sqlLiteral(m.Description),
+			sqlLiteral(string(m.Type)),
+			sqlLiteral(m.Checksum),
+			sqlLiteral(m.Date.Format(time.RFC3339)),
+			sqlInt(int(milliseconds(m.ExecutionTime))),
+			sqlLiteral(string(m.Status)),
+			sqlLiteral(m.InstalledBy),
+			sqlLiteral(m.Author),
+			sqlLiteral(m.Ticket),
+		}, ", ") + `);`
+}
+
+// RecordMigration records m. Transaction retry errors are retried by the Migrator according to its RetryPolicy.
+func (s CockroachSupport) RecordMigration(db *sql.DB, m Migration) error {
+	return s.RecordMigrationContext(context.Background(), db, m)
+}
+
+func (s CockroachSupport) RecordMigrationContext(ctx context.Context, q Querier, m Migration) error {
+	_, err := q.ExecContext(ctx, `INSERT INTO `+s.config.QualifiedName("")+` (rank, version, description, type, checksum, date, execution_time, status, failure, failed_statement, installed_by, author, ticket, output) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14);`,
+		m.Rank,
+		string(m.Version),
+		m.Description,
+		string(m.Type),
+		m.Checksum,
+		m.Date,
+		milliseconds(m.ExecutionTime),
+		string(m.Status),
+		nullString(m.Failure),
+		nullInt(m.FailedStatement),
+		nullString(m.InstalledBy),
+		nullString(m.Author),
+		nullString(m.Ticket),
+		nullString(m.Output),
+	)
+	return err
+}
+
+func (s CockroachSupport) ListMigrations(db *sql.DB) (Migrations, error) {
+	return s.ListMigrationsContext(context.Background(), db)
+}
+
+func (s CockroachSupport) ListMigrationsContext(ctx context.Context, q Querier) (Migrations, error) {
+	return s.findMigrations(ctx, q, MigrationFilter{})
+}
+
+// FindMigrations lists the recorded migrations selected by f ordered by rank.
+func (s CockroachSupport) FindMigrations(db *sql.DB, f MigrationFilter) (Migrations, error) {
+	return s.findMigrations(context.Background(), db, f)
+}
+
+// CountMigrations returns the number of recorded migrations selected by f.
+func (s CockroachSupport) CountMigrations(db *sql.DB, f MigrationFilter) (int, error) {
+	where, args := f.where(lowerCaseColumns(s.config.QualifiedName("")), dollar)
+	var n int
+	err := db.QueryRow(`SELECT count(*) FROM `+s.config.QualifiedName("")+where+`;`, args...).Scan(&n)
+	return n, err
+}
+
+// SummarizeMigrations summarizes the recorded versioned migrations with a single query.
+func (s CockroachSupport) SummarizeMigrations(db *sql.DB) (Summary, error) {
+	table := s.config.QualifiedName("")
+	sum := Summary{}
+	var latest sql.NullString
+	row := db.QueryRow(`SELECT count(*), count(CASE WHEN status = $1 THEN 1 END), (SELECT version FROM `+table+` WHERE version <> $2 ORDER BY rank DESC LIMIT 1) FROM `+table+` WHERE version <> $2;`,
+		string(StatusFailed), string(VersionRepeatable))
+	if err := row.Scan(&sum.Versioned, &sum.Failed, &latest); err != nil {
+		return Summary{}, err
+	}
+	sum.Latest = Version(latest.String)
+	return sum, nil
+}
+
+func (s CockroachSupport) findMigrations(ctx context.Context, q Querier, f MigrationFilter) (Migrations, error) {
+	where, args := f.where(lowerCaseColumns(s.config.QualifiedName("")), dollar)
+	rows, err := q.QueryContext(ctx, `SELECT rank, version, description, type, checksum, date, execution_time, status, failure, failed_statement, installed_by, author, ticket, output FROM `+s.config.QualifiedName("")+where+` ORDER BY rank;`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ms := []Migration{}
+	for rows.Next() {
+		var m Migration
+		var version, typ, status string
+		var checksum, failure, installedBy, author, ticket, output sql.NullString
+		var failedStatement sql.NullInt64
+		var executionTime int64
+		var date time.Time
+		if err := rows.Scan(&m.Rank, &version, &m.Description, &typ, &checksum, &date, &executionTime, &status, &failure, &failedStatement, &installedBy, &author, &ticket, &output); err != nil {
+			return nil, err
+		}
+		m.Version = Version(version)
+		m.Type = Type(typ)
+		m.Checksum = checksum.String
+		m.Date = date.UTC()
+		m.ExecutionTime = fromMilliseconds(executionTime)
+		m.Status = Status(status)
+		m.Failure = failure.String
+		m.FailedStatement = int(failedStatement.Int64)
+		m.InstalledBy = installedBy.String
+		m.Author = author.String
+		m.Ticket = ticket.String
+		m.Output = output.String
+		ms = append(ms, m)
+	}
+	return ms, rows.Err()
+}
+
+func (s CockroachSupport) MetadataUpgrades() []MetadataUpgrade {
+	addColumns := func(columns ...string) func(db *sql.DB) error {
+		return func(db *sql.DB) error {
+			for _, column := range columns {
+				if _, err := db.Exec(`ALTER TABLE ` + s.config.QualifiedName("") + ` ADD COLUMN IF NOT EXISTS ` + column + `;`); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	return []MetadataUpgrade{
+		{Version: 1, Description: "failure diagnostics", Apply: addColumns("failure STRING", "failed_statement INT8")},
+		{Version: 2, Description: "installed by", Apply: addColumns("installed_by STRING")},
+		{Version: 3, Description: "script headers", Apply: addColumns("author STRING", "ticket STRING")},
+		{Version: 4, Description: "recorded scripts", Apply: addColumns("script BYTES")},
+		{Version: 5, Description: "shell output", Apply: addColumns("output STRING")},
+	}
+}
+
+func (s CockroachSupport) MetadataVersion(db *sql.DB) (int, error) {
+	if _, err := db.Exec(fmt.Sprintf(cockroachSchemaVersion, s.config.QualifiedName("_schema"))); err != nil {
+		return 0, err
+	}
+	var version int
+	err := db.QueryRow(`SELECT version FROM ` + s.config.QualifiedName("_schema") + ` WHERE id = 1;`).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return version, err
+}
+
+func (s CockroachSupport) SetMetadataVersion(db *sql.DB, version int) error {
+	if _, err := db.Exec(fmt.Sprintf(cockroachSchemaVersion, s.config.QualifiedName("_schema"))); err != nil {
+		return err
+	}
+	_, err := db.Exec(`UPSERT INTO `+s.config.QualifiedName("_schema")+` (id, version) VALUES (1, $1);`, version)
+	return err
+}
+
+func (s CockroachSupport) CurrentUser(db *sql.DB) (string, error) {
+	var user string
+	err := db.QueryRow(`SELECT current_user;`).Scan(&user)
+	return user, err
+}
+
+func (s CockroachSupport) ListObjects(db *sql.DB) ([]Object, error) {
+	return s.showTables(context.Background(), db)
+}
+
+// Maintain refreshes the statistics of the tables of the configured schema.
+func (s CockroachSupport) Maintain(db *sql.DB) error {
+	objects, err := s.ListObjects(db)
+	if err != nil {
+		return err
+	}
+	for _, o := range objects {
+		if o.Type != ObjectTable {
+			continue
+		}
+		name := quoteIdent(o.Name)
+		if s.config.Schema != "" {
+			name = quoteIdent(s.config.Schema) + "." + name
+		}
+		if _, err := db.Exec(`ANALYZE ` + name + `;`); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s CockroachSupport) DropStatement(o Object) string {
+	name := quoteIdent(o.Name)
+	if s.config.Schema != "" {
+		name = quoteIdent(s.config.Schema) + "." + name
+	}
+	return `DROP ` + strings.ToUpper(o.Type) + ` IF EXISTS ` + name + ` CASCADE;`
+}
+
+// Clean drops the views, tables and sequences of the configured schema.
+func (s CockroachSupport) Clean(db *sql.DB) error {
+	objects, err := s.ListObjects(db)
+	if err != nil {
+		return err
+	}
+	for _, o := range (CleanOptions{}).filter(objects) {
+		if _, err := db.Exec(s.DropStatement(o)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const cockroachMigrations = `
+CREATE TABLE IF NOT EXISTS %s (
+  rank INT8 NOT NULL,
+  version STRING NOT NULL,
+  description STRING NOT NULL,
+  type STRING NOT NULL,
+  checksum STRING,
+  date TIMESTAMPTZ NOT NULL,
+  execution_time INT8 NOT NULL,
+  status STRING NOT NULL,
+  failure STRING,
+  failed_statement INT8,
+  installed_by STRING,
+  author STRING,
+  ticket STRING,
+  script BYTES,
+  output STRING,
+  PRIMARY KEY (rank)
+);`
+
+const cockroachSchemaVersion = `
+CREATE TABLE IF NOT EXISTS %s (
+  id INT8 NOT NULL,
+  version INT8 NOT NULL,
+  PRIMARY KEY (id)
+);`
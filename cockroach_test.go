@@ -0,0 +1,92 @@
+package migrate
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string {
+	return "sqlstate " + string(e)
+}
+
+func (e sqlStateError) SQLState() string {
+	return string(e)
+}
+
+func TestCockroachIsRetryable(t *testing.T) {
+	s := NewCockroachSupport()
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{sqlStateError("40001"), true},
+		{fmt.Errorf("wrapped: %w", sqlStateError("40001")), true},
+		{sqlStateError("42P01"), false},
+		{fmt.Errorf("pq: restart transaction: TransactionRetryWithProtoRefreshError"), true},
+		{fmt.Errorf("syntax error"), false},
+	}
+	for _, test := range tests {
+		if got := s.IsRetryable(test.err); got != test.want {
+			t.Errorf("%v: want %v, got %v", test.err, test.want, got)
+		}
+	}
+}
+
+func TestCockroachDropStatement(t *testing.T) {
+	s := NewCockroachSupport(WithSchema("app"))
+	if got, want := s.DropStatement(Object{Type: "sequence", Name: "ids"}), `DROP SEQUENCE IF EXISTS "app"."ids" CASCADE;`; got != want {
+		t.Errorf("want: %s, got: %s", want, got)
+	}
+}
+
+type retrySupport struct {
+	memSupport
+}
+
+func (s *retrySupport) IsRetryable(err error) bool {
+	return CockroachSupport{}.IsRetryable(err)
+}
+
+func TestMigrateRetry(t *testing.T) {
+	defer func(d time.Duration) { transactionRetryDelay = d }(transactionRetryDelay)
+	transactionRetryDelay = time.Millisecond
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &retrySupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.SetBatch(Batch{Transaction: true})
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	log.fail, log.failTimes, log.failMessage = "CREATE TABLE a", 2, "fake: 40001"
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"BEGIN", "CREATE TABLE a (id INT);", "ROLLBACK",
+		"BEGIN", "CREATE TABLE a (id INT);", "ROLLBACK",
+		"BEGIN", "CREATE TABLE a (id INT);", "COMMIT",
+	}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
+
+func TestMigrateNoRetry(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &retrySupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.SetBatch(Batch{Transaction: true})
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	log.fail, log.failMessage = "CREATE TABLE a", "fake: 42P07"
+	if err := m.Migrate(); err == nil {
+		t.Fatal("want error")
+	}
+	want := []string{"BEGIN", "CREATE TABLE a (id INT);", "ROLLBACK"}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want a single attempt: %q, got: %q", want, got)
+	}
+}
@@ -0,0 +1,70 @@
+package migrate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+)
+
+// Compression is the codec applied to large texts, like scripts or logs, before they are stored in a metadata table.
+type Compression string
+
+const (
+	CompressionNone Compression = ""
+	CompressionGzip Compression = "gzip"
+)
+
+// WithCompression sets the codec used for texts stored in the metadata tables.
+func WithCompression(c Compression) SupportOption {
+	return func(cfg *SupportConfig) {
+		cfg.Compression = c
+	}
+}
+
+// Encode compresses text.
+func (c Compression) Encode(text string) ([]byte, error) {
+	switch c {
+	case CompressionNone:
+		return []byte(text), nil
+	case CompressionGzip:
+		buf := &bytes.Buffer{}
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write([]byte(text)); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown compression: %s", c)
+	}
+}
+
+// Decode decompresses data that has been encoded by Encode.
+func (c Compression) Decode(data []byte) (string, error) {
+	switch c {
+	case CompressionNone:
+		return string(data), nil
+	case CompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", err
+		}
+		defer r.Close()
+		text, err := ioutil.ReadAll(r)
+		return string(text), err
+	default:
+		return "", fmt.Errorf("unknown compression: %s", c)
+	}
+}
+
+// decodeStored decompresses data that has been encoded by any Compression, detected by the gzip header, so that
+// texts stay readable after the Compression of a Support has been changed.
+func decodeStored(data []byte) (string, error) {
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		return CompressionGzip.Decode(data)
+	}
+	return CompressionNone.Decode(data)
+}
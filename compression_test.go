@@ -0,0 +1,28 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompression(t *testing.T) {
+	text := strings.Repeat("INSERT INTO foo VALUES (1);\n", 1000)
+	for _, c := range []Compression{CompressionNone, CompressionGzip} {
+		t.Run(string(c), func(t *testing.T) {
+			data, err := c.Encode(text)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if c == CompressionGzip && len(data) >= len(text) {
+				t.Errorf("want compressed size below %d, got %d", len(text), len(data))
+			}
+			got, err := c.Decode(data)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got != text {
+				t.Errorf("round trip changed the text")
+			}
+		})
+	}
+}
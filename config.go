@@ -0,0 +1,78 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Option configures a Migrator created by New. Every option has a setter of the same effect, e.g. SetLockTimeout for
+// WithLockTimeout, which configures an existing Migrator.
+type Option func(m *Migrator) error
+
+// New creates a Migrator for db with support, configured by opts in their order. Unlike NewMigrator, it logs nothing
+// unless a logger is set with WithLogger.
+func New(db *sql.DB, support Support, opts ...Option) (*Migrator, error) {
+	m := &Migrator{
+		db:      db,
+		support: support,
+	}
+	for _, opt := range opts {
+		if err := opt(m); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// WithLogger sets the logger of the Migrator, see SetLogger. A LogFunc is a Logger.
+func WithLogger(logger Logger) Option {
+	return func(m *Migrator) error {
+		m.SetLogger(logger)
+		return nil
+	}
+}
+
+// WithTableName sets the name of the metadata table. It requires a Support that is a ConfigurableSupport.
+func WithTableName(name string) Option {
+	return func(m *Migrator) error {
+		cs, ok := m.support.(ConfigurableSupport)
+		if !ok {
+			return fmt.Errorf("table name requires a configurable support: %T", m.support)
+		}
+		m.support = cs.Configure(WithTable(name))
+		return nil
+	}
+}
+
+// WithLockTimeout sets how long Migrate and Baseline wait for the lock of a concurrent migrator, see SetLockTimeout.
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(m *Migrator) error {
+		m.SetLockTimeout(timeout)
+		return nil
+	}
+}
+
+// WithOutOfOrder makes Migrate apply pending migrations below the last applied version, see SetOutOfOrder.
+func WithOutOfOrder(outOfOrder bool) Option {
+	return func(m *Migrator) error {
+		m.SetOutOfOrder(outOfOrder)
+		return nil
+	}
+}
+
+// WithValidateOnMigrate makes Migrate validate the applied migrations first, see SetValidateOnMigrate.
+func WithValidateOnMigrate(validate bool) Option {
+	return func(m *Migrator) error {
+		m.SetValidateOnMigrate(validate)
+		return nil
+	}
+}
+
+// WithDryRun makes Migrate only log the migrations it would apply, see SetDryRun.
+func WithDryRun(dryRun bool) Option {
+	return func(m *Migrator) error {
+		m.SetDryRun(dryRun)
+		return nil
+	}
+}
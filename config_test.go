@@ -0,0 +1,79 @@
+package migrate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	db, _ := openFake(t.Name())
+	defer db.Close()
+	m, err := New(db, NewSQLiteSupport(WithSchema("aux")),
+		WithLogger(LogFunc(t.Logf)),
+		WithTableName("schema_history"),
+		WithLockTimeout(5*time.Second),
+		WithOutOfOrder(true),
+		WithValidateOnMigrate(true),
+		WithDryRun(true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := m.support.(SQLiteSupport).config; c.Table != "schema_history" || c.Schema != "aux" {
+		t.Errorf("want the table schema_history in aux, got: %+v", c)
+	}
+	if m.lockTimeout != 5*time.Second || !m.outOfOrder || !m.validateOnMigrate || !m.dryRun || m.logger == nil {
+		t.Errorf("options not applied")
+	}
+
+	if _, err := New(db, &memSupport{}, WithTableName("schema_history")); err == nil {
+		t.Errorf("want error for a support that is not configurable")
+	}
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &memSupport{}
+	m, err := New(db, s, WithLogger(LogFunc(t.Logf)), WithDryRun(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	m.AddSQLMigration("2", "two", "CREATE TABLE b (id INT);")
+	res, err := m.MigrateWithResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Pending != 2 || len(res.Applied) != 0 {
+		t.Errorf("want 2 pending and none applied, got: %+v", res)
+	}
+	if got := log.Statements(); len(got) != 0 {
+		t.Errorf("want no statements, got: %q", got)
+	}
+	if s.exists || len(s.migrations) != 0 {
+		t.Errorf("want the database unchanged, got: %+v", s)
+	}
+}
+
+func TestMigrateValidateOnMigrate(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(db, s, WithLogger(LogFunc(t.Logf)), WithValidateOnMigrate(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.AddSQLMigration("2", "two", "CREATE TABLE b (id INT);")
+	if err := m.Migrate(); err == nil {
+		t.Fatal("want error for an applied migration that is missing locally")
+	}
+	if got := log.Statements(); len(got) != 1 {
+		t.Errorf("want no migration applied, got: %q", got)
+	}
+}
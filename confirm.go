@@ -0,0 +1,35 @@
+package migrate
+
+import "errors"
+
+// Destructive operations that require a confirmation if a ConfirmFunc is set.
+const (
+	OpClean       = "clean"
+	OpRepair      = "repair"
+	OpUndo        = "undo"
+	OpForceUnlock = "force-unlock"
+)
+
+// ErrNotConfirmed is returned if a destructive operation has been declined by the ConfirmFunc.
+var ErrNotConfirmed = errors.New("operation not confirmed")
+
+// ConfirmFunc approves the destructive operation op, e.g. by asking for a typed confirmation or checking an approval
+// token. detail describes what is going to happen.
+type ConfirmFunc func(op string, detail string) bool
+
+// SetConfirm sets the function that has to approve Clean, Repair, Undo and ForceUnlock before they run.
+func (m *Migrator) SetConfirm(confirm ConfirmFunc) {
+	m.confirm = confirm
+}
+
+// confirmed asks the ConfirmFunc to approve op and returns ErrNotConfirmed if it has been declined.
+func (m *Migrator) confirmed(op string, detail string) error {
+	if m.confirm == nil {
+		return nil
+	}
+	if !m.confirm(op, detail) {
+		m.log(LevelWarn, "operation not confirmed", Fields{"operation": op, "detail": detail})
+		return ErrNotConfirmed
+	}
+	return nil
+}
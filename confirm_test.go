@@ -0,0 +1,38 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	m.AddGoMigration("1", "one", func(con *sql.DB) error { return nil })
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	ops := []string{}
+	approve := false
+	m.SetConfirm(func(op string, detail string) bool {
+		ops = append(ops, op)
+		return approve
+	})
+	if err := m.Clean(); !errors.Is(err, ErrNotConfirmed) {
+		t.Fatalf("want not confirmed, got: %v", err)
+	}
+	if len(s.migrations) != 1 {
+		t.Fatalf("want migrations to be kept, got: %d", len(s.migrations))
+	}
+	approve = true
+	if err := m.Clean(); err != nil {
+		t.Fatalf("clean: %v", err)
+	}
+	if len(s.migrations) != 0 {
+		t.Fatalf("want clean database, got: %d migrations", len(s.migrations))
+	}
+	if want := []string{OpClean, OpClean}; len(ops) != len(want) || ops[0] != want[0] || ops[1] != want[1] {
+		t.Errorf("want: %q, got: %q", want, ops)
+	}
+}
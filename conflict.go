@@ -0,0 +1,67 @@
+package migrate
+
+import (
+	"fmt"
+	"sort"
+)
+
+// conflicts returns an error for the first versioned migration without version, the first two versioned migrations
+// with equal versions and the first two repeatable migrations with the same description and type. Versions are equal if they
+// compare as equal under o, e.g. "1" and "01".
+func conflicts(migrations Migrations, repeatable Migrations, o versionOrder) error {
+	sorted := append(Migrations{}, migrations...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return o.Less(sorted[i].Version, sorted[j].Version)
+	})
+	for i, mig := range sorted {
+		if mig.Version == VersionNone {
+			return &MigrationError{Err: ErrConflict, Migration: mig, Detail: "empty version"}
+		}
+		if i > 0 && o.compare(sorted[i-1].Version, mig.Version) == 0 {
+			return &MigrationError{Err: ErrConflict, Migration: mig, Detail: fmt.Sprintf("same version as %s", sorted[i-1])}
+		}
+	}
+	keys := map[repeatableKey]Migration{}
+	for _, mig := range repeatable {
+		if other, ok := keys[keyOf(mig)]; ok {
+			return &MigrationError{Err: ErrConflict, Migration: mig, Detail: fmt.Sprintf("same description as %s", other)}
+		}
+		keys[keyOf(mig)] = mig
+	}
+	return nil
+}
+
+// conflicts reports conflicting registered migrations, see conflicts.
+func (m *Migrator) conflicts() error {
+	migrations, repeatable := m.registered()
+	return conflicts(migrations, repeatable, m.versionOrdering)
+}
+
+// fileConflicts reports migration files with versions that are equal under o or repeatable migration files with the
+// same description.
+func fileConflicts(fs []MigrationFile, o versionOrder) error {
+	versioned := []MigrationFile{}
+	descriptions := map[string]MigrationFile{}
+	for _, f := range fs {
+		if f.Prefix == PrefixUndo {
+			continue
+		}
+		if f.Version == VersionRepeatable {
+			if other, ok := descriptions[f.Description]; ok {
+				return fmt.Errorf("%v: %s and %s have the same description", ErrConflict, other.Name(), f.Name())
+			}
+			descriptions[f.Description] = f
+			continue
+		}
+		versioned = append(versioned, f)
+	}
+	sort.SliceStable(versioned, func(i, j int) bool {
+		return o.Less(versioned[i].Version, versioned[j].Version)
+	})
+	for i := 1; i < len(versioned); i++ {
+		if o.compare(versioned[i-1].Version, versioned[i].Version) == 0 {
+			return fmt.Errorf("%v: %s and %s have the same version", ErrConflict, versioned[i-1].Name(), versioned[i].Name())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package migrate
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMigrateConflicts(t *testing.T) {
+	tests := []struct {
+		name string
+		add  func(m *Migrator)
+		want string
+	}{
+		{
+			name: "duplicate version",
+			add: func(m *Migrator) {
+				m.AddSQLMigration("1", "create users", "CREATE TABLE users (id INT);")
+				m.AddSQLMigration("2", "create orders", "CREATE TABLE orders (id INT);")
+				m.AddSQLMigration("01", "create accounts", "CREATE TABLE accounts (id INT);")
+			},
+			want: "description=create users|type=SQL: same version as @Migration|version=01|description=create accounts",
+		},
+		{
+			name: "duplicate description",
+			add: func(m *Migrator) {
+				m.AddRepeatableSQLMigration("users view", "CREATE VIEW users_view AS SELECT 1;")
+				m.AddRepeatableSQLMigration("users view", "CREATE VIEW users_view AS SELECT 2;")
+			},
+			want: "same description as @Migration|version=R|description=users view",
+		},
+		{
+			name: "empty version",
+			add: func(m *Migrator) {
+				m.AddSQLMigration("", "create users", "CREATE TABLE users (id INT);")
+			},
+			want: "empty version",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &memSupport{}
+			m := newTestMigrator(t, s)
+			test.add(m)
+			err := m.Migrate()
+			if !errors.Is(err, ErrConflict) {
+				t.Fatalf("want conflict, got: %v", err)
+			}
+			if !strings.Contains(err.Error(), test.want) {
+				t.Errorf("want %q in: %v", test.want, err)
+			}
+			if len(s.migrations) != 0 {
+				t.Errorf("unexpected migrations: %v", s.migrations)
+			}
+		})
+	}
+}
+
+func TestLoadDirConflicts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"V1__create_users.sql", "V01__create_accounts.sql"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = LoadDir(dir)
+	if err == nil || !strings.Contains(err.Error(), "V01__create_accounts.sql and V1__create_users.sql") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLoadDirDottedVersions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"V1.1__create_users.sql", "V1_2__create_accounts.sql", "V1.10__create_orders.sql"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ms, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := versions(ms), []Version{"1.1", "1_2", "1.10"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
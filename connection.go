@@ -0,0 +1,98 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// NewConn creates a Migrator whose work all runs on con, e.g. a connection whose session has been prepared by the
+// caller. Session state like advisory locks, search_path or the role persists for the whole run. con is not closed by
+// the Migrator.
+func NewConn(con *sql.Conn, support Support, opts ...Option) (*Migrator, error) {
+	pin := &pinnedConnector{con: con}
+	m, err := New(pinnedDB(pin), support, opts...)
+	if err != nil {
+		return nil, err
+	}
+	m.pin = pin
+	return m, nil
+}
+
+// SetSingleConnection pins all work of the Migrator to a single connection taken from its *sql.DB on first use. Session
+// state like advisory locks, search_path or the role then persists for the whole run. The pool itself is not
+// reconfigured, so it may be shared. The connection is returned to the pool by SetSingleConnection(false) or Close. DB
+// returns a *sql.DB that runs on the pinned connection.
+func (m *Migrator) SetSingleConnection(single bool) {
+	switch {
+	case single && m.pin == nil && m.db != nil:
+		m.pin = &pinnedConnector{pool: m.db}
+		m.db = pinnedDB(m.pin)
+	case !single && m.pin != nil && m.pin.pool != nil:
+		m.unpin()
+	}
+}
+
+// unpin closes the *sql.DB of the pinned connection and returns the connection to the pool it has been taken from.
+func (m *Migrator) unpin() error {
+	err := m.db.Close()
+	if rErr := m.pin.release(); rErr != nil && err == nil {
+		err = rErr
+	}
+	m.db, m.pin = m.pin.pool, nil
+	return err
+}
+
+// SetSessionSetup sets statements that prepare the session before the migrations are listed and before each migration
+// is installed, e.g. "SET search_path TO app;", "SET sql_mode = 'STRICT_ALL_TABLES';" or "SET ROLE migrator;".
+// The statements have to be idempotent. As they apply to a single connection of the pool, they are usually combined with
+// SetSingleConnection.
+func (m *Migrator) SetSessionSetup(stmts ...string) {
+	m.sessionSetup = stmts
+}
+
+// setupSession executes the session setup statements.
+func (m *Migrator) setupSession() error {
+	if m.db == nil {
+		return nil
+	}
+	return m.setupSessionContext(context.Background(), m.db)
+}
+
+// setupSessionContext executes the session setup statements on q.
+func (m *Migrator) setupSessionContext(ctx context.Context, q Querier) error {
+	for _, stmt := range m.sessionSetup {
+		if _, err := q.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("session setup: %s: %+v", stmt, err)
+		}
+	}
+	return nil
+}
+
+// SetConnectRetry makes Migrate ping the database before anything else and retry failed pings according to p, e.g.
+// for a service that starts before its database is reachable. Unlike the policy of SetRetryPolicy, every failure is
+// retried unless p.Retryable is set. Waiting counts towards the timeout of SetTimeout. Without a connect retry the
+// first statement fails if the database is not reachable.
+func (m *Migrator) SetConnectRetry(p RetryPolicy) {
+	m.connectRetry = &p
+}
+
+// awaitConnection pings the database until it is reachable or the connect retry policy is exhausted.
+func (m *Migrator) awaitConnection(ctx context.Context) error {
+	if m.connectRetry == nil || m.db == nil {
+		return nil
+	}
+	retryable := m.connectRetry.Retryable
+	if retryable == nil {
+		retryable = func(err error) bool { return true }
+	}
+	err := m.connectRetry.do(ctx, retryable, func(retry int, err error) {
+		m.log(LevelWarn, "waiting for database", Fields{"retry": retry, "error": err})
+	}, func() error {
+		return m.db.PingContext(ctx)
+	})
+	if err != nil {
+		return fmt.Errorf("connect: %+v", err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSessionSetup(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	m := NewMigrator(t.Logf, db, &memSupport{})
+	m.SetSingleConnection(true)
+	m.SetSessionSetup("SET search_path TO app;")
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	m.AddSQLMigration("2", "two", "CREATE TABLE b (id INT);")
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if got := db.Stats().MaxOpenConnections; got != 0 {
+		t.Errorf("want the pool not to be reconfigured, got: %d", got)
+	}
+	if got := db.Stats().InUse; got != 1 {
+		t.Errorf("want a pinned connection, got: %d", got)
+	}
+	want := []string{
+		"SET search_path TO app;",
+		"SET search_path TO app;",
+		"CREATE TABLE a (id INT);",
+		"SET search_path TO app;",
+		"CREATE TABLE b (id INT);",
+	}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := db.Stats().InUse; got != 0 {
+		t.Errorf("want the pinned connection to be released, got: %d", got)
+	}
+}
+
+func TestNewConn(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	con, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer con.Close()
+	m, err := NewConn(con, &memSupport{}, WithLogger(LogFunc(t.Logf)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetBatch(Batch{Transaction: true})
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := con.ExecContext(context.Background(), "SELECT 1;"); err != nil {
+		t.Errorf("want the connection to stay open: %v", err)
+	}
+	want := []string{"BEGIN", "CREATE TABLE a (id INT);", "COMMIT", "SELECT 1;"}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	if got := db.Stats().OpenConnections; got != 1 {
+		t.Errorf("want a single connection, got: %d", got)
+	}
+}
+
+func TestConnectRetry(t *testing.T) {
+	name := t.Name()
+	db, err := sql.Open("migrate-fake", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	m := NewMigrator(t.Logf, db, &memSupport{})
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	m.SetConnectRetry(RetryPolicy{MaxAttempts: 2, Backoff: func(retry int) time.Duration { return 0 }})
+	if err := m.Migrate(); err == nil {
+		t.Fatalf("want error for an unreachable database")
+	}
+
+	var log *fakeLog
+	m.SetConnectRetry(RetryPolicy{MaxAttempts: 5, Backoff: func(retry int) time.Duration {
+		if retry == 3 {
+			var reachable *sql.DB
+			reachable, log = openFake(name)
+			reachable.Close()
+		}
+		return 0
+	}})
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if log == nil || len(log.Statements()) != 1 {
+		t.Errorf("want the migration to be applied once the database is reachable")
+	}
+}
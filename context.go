@@ -0,0 +1,79 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ContextFunc is a Go migration that takes a MigrationContext.
+type ContextFunc func(c *MigrationContext) error
+
+// MigrationContext is passed to Go migrations added with AddGoContextMigration.
+// It is canceled when the Timeout of the migration expires.
+type MigrationContext struct {
+	context.Context
+	// Tx is the transaction the migration runs in if Batch.Transaction is set. It is nil otherwise.
+	Tx *sql.Tx
+	// DB is the database of the Migrator.
+	DB *sql.DB
+	// Placeholders are the values set with SetPlaceholders.
+	Placeholders map[string]string
+	Version      Version
+	Description  string
+	// Clock is the Clock of the Migrator, see SetClock.
+	Clock Clock
+
+	logger Logger
+}
+
+// Querier returns the transaction of the migration or the database if the migration runs outside of a transaction.
+func (c *MigrationContext) Querier() Querier {
+	if c.Tx != nil {
+		return c.Tx
+	}
+	return c.DB
+}
+
+// Log logs through the logger of the Migrator and adds the version and description of the migration.
+func (c *MigrationContext) Log(level Level, msg string, fields Fields) {
+	fs := Fields{"version": c.Version, "description": c.Description}
+	for k, v := range fields {
+		fs[k] = v
+	}
+	c.logger.Log(level, msg, fs)
+}
+
+// SetPlaceholders sets values that are passed to Go migrations through their MigrationContext.
+func (m *Migrator) SetPlaceholders(placeholders map[string]string) {
+	m.placeholders = placeholders
+}
+
+func (m *Migrator) migrationContext(ctx context.Context, tx *sql.Tx, mig Migration) *MigrationContext {
+	return &MigrationContext{
+		Context:      ctx,
+		Tx:           tx,
+		DB:           m.db,
+		Placeholders: m.placeholders,
+		Version:      mig.Version,
+		Description:  mig.Description,
+		Clock:        m.getClock(),
+		logger:       migratorLogger{m},
+	}
+}
+
+// NewGoContextMigration creates a Go migration that takes a MigrationContext.
+func NewGoContextMigration(version Version, description string, execute ContextFunc, opts ...MigrationOption) Migration {
+	mig := NewGoMigration(version, description, nil, opts...)
+	mig.ExecuteContext = execute
+	return mig
+}
+
+// AddGoContextMigration adds a Go migration that takes a MigrationContext. It runs in the transaction of the migration
+// if Batch.Transaction is set.
+func (m *Migrator) AddGoContextMigration(version Version, description string, execute ContextFunc, opts ...MigrationOption) {
+	m.Add(NewGoContextMigration(version, description, execute, opts...))
+}
+
+func (m *Migrator) AddRepeatableGoContextMigration(description string, execute ContextFunc, opts ...MigrationOption) {
+	m.AddGoContextMigration(VersionRepeatable, description, execute, opts...)
+}
@@ -0,0 +1,30 @@
+package migrate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGoContextMigration(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &txSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.SetBatch(Batch{Transaction: true})
+	m.SetPlaceholders(map[string]string{"table": "users"})
+	m.AddGoContextMigration("1", "backfill", func(c *MigrationContext) error {
+		if c.Tx == nil || c.Version != "1" {
+			t.Errorf("unexpected context: %+v", c)
+		}
+		c.Log(LevelInfo, "backfilling", Fields{"table": c.Placeholders["table"]})
+		_, err := c.Querier().ExecContext(c, "UPDATE "+c.Placeholders["table"]+" SET active = 1;")
+		return err
+	})
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"BEGIN", "UPDATE users SET active = 1;", "RECORD 1", "COMMIT"}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
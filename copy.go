@@ -0,0 +1,201 @@
+package migrate
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+)
+
+// DefaultCopyBatchSize is the number of rows a Copy inserts with a single statement unless configured otherwise.
+const DefaultCopyBatchSize = 500
+
+// Copy copies rows from another database into a table of the database of the Migrator, e.g. to consolidate the SQLite
+// files of devices into a central database. It is run by a Go migration, see AddCopyMigration:
+//
+//	m.AddCopyMigration("12", "import devices", migrate.Copy{
+//		Source: deviceDB,
+//		Query:  "SELECT id, name FROM devices",
+//		Table:  "devices",
+//	})
+//
+// The rows are inserted within the transaction of the migration if Batch.Transaction is set. Otherwise a failed copy
+// leaves the batches inserted before behind, so the next attempt has to tolerate them, e.g. with a Transform that
+// skips them or a Query that selects the remaining rows only.
+type Copy struct {
+	// Source is the database the rows are read from.
+	Source *sql.DB
+	// Query selects the rows to copy from Source with Args.
+	Query string
+	Args  []interface{}
+	// Table is the table the rows are inserted into.
+	Table string
+	// Columns are the columns of Table the values of a row are inserted into. They default to the columns of Query.
+	Columns []string
+	// Transform returns the values to insert for the values of a row of Query, or nil to skip the row.
+	Transform func(row []interface{}) ([]interface{}, error)
+	// BatchSize is the number of rows inserted by a single INSERT. It defaults to DefaultCopyBatchSize. Databases
+	// without multi-row VALUES, e.g. Oracle, require a BatchSize of 1, which AddCopyMigration sets for them.
+	BatchSize int
+	// Placeholder returns the placeholder of the n-th argument of an INSERT, starting at 1. AddCopyMigration sets it
+	// for the dialect of the Support, it defaults to "?" otherwise.
+	Placeholder func(n int) string
+	// Progress is called after every inserted batch.
+	Progress func(p CopyProgress)
+}
+
+// CopyProgress reports the state of a running Copy.
+type CopyProgress struct {
+	Table string
+	// Read is the number of rows read from the source so far, Written the number of rows inserted.
+	Read    int64
+	Written int64
+}
+
+// AddCopyMigration adds a Go migration that runs c. The migration has no checksum unless the option Fingerprint is
+// given.
+func (m *Migrator) AddCopyMigration(version Version, description string, c Copy, opts ...MigrationOption) {
+	if c.Placeholder == nil {
+		c.Placeholder = m.placeholder()
+	}
+	if c.BatchSize == 0 && m.hasDialect("oracle") {
+		c.BatchSize = 1
+	}
+	m.AddGoContextMigration(version, description, c.Run, opts...)
+}
+
+// placeholder returns the placeholder of the dialect of the Support.
+func (m *Migrator) placeholder() func(n int) string {
+	switch {
+	case m.hasDialect(DialectPostgres):
+		return dollar
+	case m.hasDialect("oracle"):
+		return colon
+	}
+	return questionMark
+}
+
+// hasDialect reports whether dialect is one of the dialects of the Support.
+func (m *Migrator) hasDialect(dialect string) bool {
+	ds, ok := m.support.(DialectSupport)
+	if !ok {
+		return false
+	}
+	for _, d := range ds.Dialects() {
+		if d == dialect {
+			return true
+		}
+	}
+	return false
+}
+
+// Run copies the rows of the source into the table within the migration of ctx. The copy stops between batches when
+// ctx is done.
+func (c Copy) Run(ctx *MigrationContext) error {
+	if c.Source == nil || c.Query == "" || c.Table == "" {
+		return fmt.Errorf("copy: source, query and table are required")
+	}
+	rows, err := c.Source.QueryContext(ctx, c.Query, c.Args...)
+	if err != nil {
+		return fmt.Errorf("copy: %s: query source: %+v", c.Table, err)
+	}
+	defer rows.Close()
+	source, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("copy: %s: columns: %+v", c.Table, err)
+	}
+	columns := c.Columns
+	if len(columns) == 0 {
+		columns = source
+	}
+	size := c.BatchSize
+	if size < 1 {
+		size = DefaultCopyBatchSize
+	}
+	p := CopyProgress{Table: c.Table}
+	batch := [][]interface{}{}
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		query, args := c.insert(columns, batch)
+		if _, err := ctx.Querier().ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("copy: %s: insert rows %d to %d: %+v", c.Table, p.Written+1, p.Written+int64(len(batch)), err)
+		}
+		p.Written += int64(len(batch))
+		batch = batch[:0]
+		ctx.Log(LevelInfo, "copied", Fields{"table": c.Table, "read": p.Read, "written": p.Written})
+		if c.Progress != nil {
+			c.Progress(p)
+		}
+		return nil
+	}
+	for rows.Next() {
+		values := make([]interface{}, len(source))
+		dest := make([]interface{}, len(source))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("copy: %s: read row %d: %+v", c.Table, p.Read+1, err)
+		}
+		p.Read++
+		row := values
+		if c.Transform != nil {
+			if row, err = c.Transform(values); err != nil {
+				return fmt.Errorf("copy: %s: transform row %d: %+v", c.Table, p.Read, err)
+			}
+			if row == nil {
+				continue
+			}
+		}
+		if len(row) != len(columns) {
+			return fmt.Errorf("copy: %s: row %d has %d values for %d columns", c.Table, p.Read, len(row), len(columns))
+		}
+		batch = append(batch, row)
+		if len(batch) >= size {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("copy: %s: read rows: %+v", c.Table, err)
+	}
+	return flush()
+}
+
+// insert returns the INSERT of the rows of batch into the columns of the table and its arguments.
+func (c Copy) insert(columns []string, batch [][]interface{}) (string, []interface{}) {
+	placeholder := c.Placeholder
+	if placeholder == nil {
+		placeholder = questionMark
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "INSERT INTO %s (", c.Table)
+	for i, col := range columns {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(col)
+	}
+	buf.WriteString(") VALUES ")
+	args := make([]interface{}, 0, len(batch)*len(columns))
+	for i, row := range batch {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString("(")
+		for j, v := range row {
+			if j > 0 {
+				buf.WriteString(", ")
+			}
+			args = append(args, v)
+			buf.WriteString(placeholder(len(args)))
+		}
+		buf.WriteString(")")
+	}
+	return buf.String(), args
+}
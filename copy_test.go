@@ -0,0 +1,80 @@
+package migrate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCopyMigration(t *testing.T) {
+	source, sourceLog := openFake(t.Name() + "/source")
+	defer source.Close()
+	sourceLog.rows = map[string][][]string{
+		"SELECT id, name FROM devices": {{"1", "a"}, {"2", "skip"}, {"3", "c"}, {"4", "d"}},
+	}
+	db, log := openFake(t.Name())
+	defer db.Close()
+	m := NewMigrator(t.Logf, db, &dialectSupport{dialects: []string{"cockroach", "postgres"}})
+	progress := []CopyProgress{}
+	m.AddCopyMigration("1", "import devices", Copy{
+		Source:  source,
+		Query:   "SELECT id, name FROM devices",
+		Table:   "devices",
+		Columns: []string{"id", "name"},
+		Transform: func(row []interface{}) ([]interface{}, error) {
+			if row[1] == "skip" {
+				return nil, nil
+			}
+			return row, nil
+		},
+		BatchSize: 2,
+		Progress: func(p CopyProgress) {
+			progress = append(progress, p)
+		},
+	})
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"INSERT INTO devices (id, name) VALUES ($1, $2), ($3, $4)",
+		"INSERT INTO devices (id, name) VALUES ($1, $2)",
+	}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	if want := []CopyProgress{{Table: "devices", Read: 3, Written: 2}, {Table: "devices", Read: 4, Written: 3}}; !reflect.DeepEqual(want, progress) {
+		t.Errorf("want: %+v, got: %+v", want, progress)
+	}
+}
+
+func TestCopyOracleBatchSize(t *testing.T) {
+	source, sourceLog := openFake(t.Name() + "/source")
+	defer source.Close()
+	sourceLog.rows = map[string][][]string{"SELECT id FROM devices": {{"1"}, {"2"}}}
+	db, log := openFake(t.Name())
+	defer db.Close()
+	m := NewMigrator(t.Logf, db, &dialectSupport{dialects: []string{"oracle"}})
+	m.AddCopyMigration("1", "import devices", Copy{Source: source, Query: "SELECT id FROM devices", Table: "devices"})
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"INSERT INTO devices (value0) VALUES (:1)",
+		"INSERT INTO devices (value0) VALUES (:1)",
+	}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
+
+func TestCopyMismatchingColumns(t *testing.T) {
+	source, sourceLog := openFake(t.Name() + "/source")
+	defer source.Close()
+	sourceLog.rows = map[string][][]string{"SELECT id, name FROM devices": {{"1", "a"}}}
+	db, _ := openFake(t.Name())
+	defer db.Close()
+	m := NewMigrator(t.Logf, db, &memSupport{})
+	m.AddCopyMigration("1", "import devices", Copy{Source: source, Query: "SELECT id, name FROM devices", Table: "devices", Columns: []string{"id"}})
+	if err := m.Migrate(); err == nil {
+		t.Errorf("want error for a row that does not match the columns")
+	}
+}
@@ -0,0 +1,190 @@
+package migrate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Requirement is a migration of another component that has to be applied before the migration that declares it.
+type Requirement struct {
+	Component string
+	Version   Version
+}
+
+func (r Requirement) String() string {
+	return fmt.Sprintf("%s@%s", r.Component, r.Version)
+}
+
+// Requires declares that a migration requires the migration with version of component to be applied before, e.g.
+// Requires("auth", "5") for a migration of "billing" that references a table created by "auth". It is honored by the
+// MultiMigrator.
+func Requires(component string, version Version) MigrationOption {
+	return func(o *MigrationOptions) {
+		o.Requires = append(o.Requires, Requirement{Component: component, Version: version})
+	}
+}
+
+// step migrates a component up to and including a version.
+type step struct {
+	component string
+	target    Version
+}
+
+// componentState is the state of a component before its migrations are scheduled.
+type componentState struct {
+	pending Migrations
+	// installed is the highest version applied successfully.
+	installed Version
+}
+
+// hasRequirements reports whether any migration of the components declares a requirement.
+func (mm *MultiMigrator) hasRequirements() bool {
+	for _, m := range mm.migrators {
+		migrations, _ := m.registered()
+		for _, mig := range migrations {
+			if len(mig.Options.Requires) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// schedule orders the pending migrations of all components so that every migration is preceded by the migrations it
+// requires and by the lower versions of its component. It prefers the components in the order they have been added and
+// fails before anything is applied if a requirement cannot be satisfied.
+func (mm *MultiMigrator) schedule() ([]step, error) {
+	states := map[string]*componentState{}
+	for _, name := range mm.schemas {
+		s, err := mm.migrators[name].componentState()
+		if err != nil {
+			return nil, fmt.Errorf("schedule: %s: %+v", name, err)
+		}
+		states[name] = s
+	}
+	scheduled := map[Requirement]bool{}
+	satisfied := func(r Requirement) (bool, error) {
+		s, ok := states[r.Component]
+		if !ok {
+			return false, fmt.Errorf("unknown component: %s", r.Component)
+		}
+		m := mm.migrators[r.Component]
+		if s.installed != VersionNone && m.versionOrdering.compare(r.Version, s.installed) <= 0 {
+			return true, nil
+		}
+		if scheduled[r] {
+			return true, nil
+		}
+		for _, mig := range s.pending {
+			if mig.Version == r.Version {
+				return false, nil
+			}
+		}
+		return false, fmt.Errorf("required migration not found: %s", r)
+	}
+	steps := []step{}
+	for {
+		progressed, remaining := false, false
+		for _, name := range mm.schemas {
+			s := states[name]
+			if len(s.pending) == 0 {
+				continue
+			}
+			remaining = true
+			mig := s.pending[0]
+			ready := true
+			for _, r := range mig.Options.Requires {
+				ok, err := satisfied(r)
+				if err != nil {
+					return nil, fmt.Errorf("schedule: %s: %s: %+v", name, mig, err)
+				}
+				ready = ready && ok
+			}
+			if !ready {
+				continue
+			}
+			if n := len(steps); n > 0 && steps[n-1].component == name {
+				steps[n-1].target = mig.Version
+			} else {
+				steps = append(steps, step{component: name, target: mig.Version})
+			}
+			scheduled[Requirement{Component: name, Version: mig.Version}] = true
+			s.pending = s.pending[1:]
+			progressed = true
+			break
+		}
+		if !remaining {
+			return steps, nil
+		}
+		if !progressed {
+			blocked := []string{}
+			for _, name := range mm.schemas {
+				if s := states[name]; len(s.pending) > 0 {
+					blocked = append(blocked, Requirement{Component: name, Version: s.pending[0].Version}.String())
+				}
+			}
+			return nil, fmt.Errorf("schedule: cyclic requirements: %s", strings.Join(blocked, ", "))
+		}
+	}
+}
+
+// migrateScheduled migrates the components along their schedule and then completes every component, which installs
+// their repeatable migrations.
+func (mm *MultiMigrator) migrateScheduled() error {
+	steps, err := mm.schedule()
+	if err != nil {
+		return err
+	}
+	for _, s := range steps {
+		if err := mm.migrators[s.component].migrateTo(s.target); err != nil {
+			return fmt.Errorf("migrate %s: %s: %+v", mm.kinds[s.component], s.component, err)
+		}
+	}
+	for _, name := range mm.schemas {
+		if err := mm.migrators[name].Migrate(); err != nil {
+			return fmt.Errorf("migrate %s: %s: %+v", mm.kinds[name], name, err)
+		}
+	}
+	return nil
+}
+
+// componentState returns the pending versioned migrations and the highest applied version.
+func (m *Migrator) componentState() (*componentState, error) {
+	migrations, _ := m.registered()
+	installed := Migrations{}
+	exists, err := m.support.ExistsMigrationsTable(m.db)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if installed, err = m.findMigrations(MigrationFilter{Versioned: true}); err != nil {
+			return nil, err
+		}
+	}
+	s := &componentState{installed: VersionNone}
+	for _, mig := range installed {
+		if mig.IsRepeatable() || (mig.Status != StatusSuccess && mig.Status != StatusSkipped) {
+			continue
+		}
+		if s.installed == VersionNone || m.versionOrdering.compare(mig.Version, s.installed) > 0 {
+			s.installed = mig.Version
+		}
+	}
+	for _, mig := range newInfo(migrations, nil, installed, m.versionOrdering).filter(StatePending, StateFailed) {
+		if !mig.IsRepeatable() {
+			s.pending = append(s.pending, mig)
+		}
+	}
+	sort.SliceStable(s.pending, func(i, j int) bool {
+		return m.versionOrdering.Less(s.pending[i].Version, s.pending[j].Version)
+	})
+	return s, nil
+}
+
+// migrateTo migrates up to and including the versioned migration target. Repeatable migrations are not installed.
+func (m *Migrator) migrateTo(target Version) error {
+	m.target = target
+	defer func() { m.target = VersionNone }()
+	return m.Migrate()
+}
@@ -0,0 +1,46 @@
+package migrate
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMultiMigratorRequirements(t *testing.T) {
+	mm := NewMultiMigrator(LogFunc(t.Logf), nil)
+	order := []string{}
+	step := func(name string) CommandFunc {
+		return func(con *sql.DB) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+	billing := mm.AddComponent("billing", &memSupport{})
+	auth := mm.AddComponent("auth", &memSupport{})
+	billing.AddGoMigration("1", "invoices", step("billing 1"))
+	billing.AddGoMigration("2", "invoice owners", step("billing 2"), Requires("auth", "2"))
+	billing.AddRepeatableGoMigration("totals", step("billing totals"))
+	auth.AddGoMigration("1", "users", step("auth 1"))
+	auth.AddGoMigration("2", "roles", step("auth 2"))
+	auth.AddGoMigration("3", "sessions", step("auth 3"))
+	if err := mm.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"billing 1", "auth 1", "auth 2", "billing 2", "auth 3", "billing totals"}
+	if !reflect.DeepEqual(want, order) {
+		t.Errorf("want: %v, got: %v", want, order)
+	}
+
+	mm = NewMultiMigrator(LogFunc(t.Logf), nil)
+	a, b := mm.AddComponent("a", &memSupport{}), mm.AddComponent("b", &memSupport{})
+	a.AddGoMigration("1", "one", step("a 1"), Requires("b", "1"))
+	b.AddGoMigration("1", "one", step("b 1"), Requires("a", "1"))
+	if err := mm.Migrate(); err == nil || !strings.Contains(err.Error(), "cyclic") {
+		t.Errorf("want cyclic requirements, got: %v", err)
+	}
+	b.AddGoMigration("2", "two", step("b 2"), Requires("c", "1"))
+	if err := mm.Migrate(); err == nil {
+		t.Errorf("want unsatisfiable requirement")
+	}
+}
@@ -0,0 +1,95 @@
+package migrate
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// DestructivePolicy decides what Migrate does with pending SQL migrations that contain destructive statements, see
+// RuleDestructive.
+type DestructivePolicy int
+
+const (
+	// DestructiveWarn logs a warning for every destructive statement.
+	DestructiveWarn DestructivePolicy = iota
+	// DestructiveRequireAllow rejects migrations with destructive statements unless they are marked with
+	// AllowDestructive.
+	DestructiveRequireAllow
+	// DestructiveBlockProduction rejects migrations with destructive statements if the environment "production" is
+	// active, see SetEnvironment, even if they are marked with AllowDestructive. Other environments log a warning.
+	DestructiveBlockProduction
+)
+
+// EnvironmentProduction is the environment in which DestructiveBlockProduction rejects destructive migrations.
+const EnvironmentProduction = "production"
+
+var (
+	dropObject    = regexp.MustCompile(`(?is)^DROP\s+`)
+	truncateTable = regexp.MustCompile(`(?is)^TRUNCATE\b`)
+	deleteRows    = regexp.MustCompile(`(?is)^DELETE\b`)
+	alterDrop     = regexp.MustCompile(`(?is)^ALTER\s+TABLE\b.*\bDROP\b`)
+	// dropRecreated drops an object that a repeatable migration recreates without losing data.
+	dropRecreated = regexp.MustCompile(`(?is)^DROP\s+(VIEW|TRIGGER|FUNCTION|PROCEDURE)\s+IF\s+EXISTS\b`)
+)
+
+// RuleDestructive reports statements that lose data or schema objects: DROP, TRUNCATE, DELETE without WHERE and
+// ALTER TABLE with DROP, e.g. of a column. It is used by Migrate according to the DestructivePolicy of the Migrator
+// and can be added as a Linter to reject them unconditionally.
+var RuleDestructive = StatementRule{
+	Name: "destructive",
+	Check: func(stmt string) string {
+		switch {
+		case dropObject.MatchString(stmt):
+			return "DROP"
+		case truncateTable.MatchString(stmt):
+			return "TRUNCATE"
+		case deleteRows.MatchString(stmt) && !hasWhere.MatchString(stmt):
+			return "DELETE without WHERE"
+		case alterDrop.MatchString(stmt):
+			return "ALTER TABLE with DROP"
+		}
+		return ""
+	},
+}
+
+// AllowDestructive marks a migration whose destructive statements are intended, see DestructiveRequireAllow.
+func AllowDestructive() MigrationOption {
+	return func(o *MigrationOptions) {
+		o.AllowDestructive = true
+	}
+}
+
+// SetDestructivePolicy sets how Migrate treats pending migrations with destructive statements. It defaults to
+// DestructiveWarn.
+func (m *Migrator) SetDestructivePolicy(p DestructivePolicy) {
+	m.destructivePolicy = p
+}
+
+// checkDestructive enforces the DestructivePolicy on the pending SQL migrations before any of them is applied.
+// Repeatable migrations may drop the views, triggers, functions and procedures they recreate with DROP ... IF EXISTS.
+func (m *Migrator) checkDestructive(pending Migrations) error {
+	for _, mig := range pending {
+		if mig.Type != TypeSQL || mig.ExecuteContext != nil || !m.inEnvironment(mig) {
+			continue
+		}
+		stmts, err := m.statements(mig)
+		if err != nil {
+			return fmt.Errorf("destructive: %s: %+v", mig, err)
+		}
+		for _, v := range RuleDestructive.Lint(mig, stmts) {
+			if mig.IsRepeatable() && dropRecreated.MatchString(stripComments(v.Statement)) {
+				continue
+			}
+			switch {
+			case m.destructivePolicy == DestructiveRequireAllow && !mig.Options.AllowDestructive,
+				m.destructivePolicy == DestructiveBlockProduction && m.environment[EnvironmentProduction]:
+				return &MigrationError{Err: ErrDestructive, Migration: mig, Detail: fmt.Sprintf("%s: %s", v.Message, v.Statement)}
+			case !mig.Options.AllowDestructive:
+				fields := migrationFields(mig)
+				fields["statement"] = v.Statement
+				m.log(LevelWarn, "destructive statement", fields)
+			}
+		}
+	}
+	return nil
+}
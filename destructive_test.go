@@ -0,0 +1,80 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRuleDestructive(t *testing.T) {
+	tests := map[string]string{
+		"DROP TABLE users;":                         "DROP",
+		"drop index users_email;":                   "DROP",
+		"TRUNCATE users;":                           "TRUNCATE",
+		"DELETE FROM users;":                        "DELETE without WHERE",
+		"DELETE FROM users WHERE id = 1;":           "",
+		"ALTER TABLE users DROP COLUMN email;":      "ALTER TABLE with DROP",
+		"ALTER TABLE users ADD COLUMN email TEXT;":  "",
+		"CREATE TABLE drops (id INT);":              "",
+		"-- cleanup\nTRUNCATE sessions;":            "TRUNCATE",
+		"UPDATE users SET email = NULL;":            "",
+		"INSERT INTO log (msg) VALUES ('DROP it');": "",
+	}
+	for stmt, want := range tests {
+		if got := RuleDestructive.Check(stripComments(stmt)); got != want {
+			t.Errorf("%q: want: %q, got: %q", stmt, want, got)
+		}
+	}
+}
+
+func TestMigrateDestructivePolicy(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.AddSQLMigration("1", "create", "CREATE TABLE users (id INT, email TEXT);")
+	m.AddSQLMigration("2", "drop email", "ALTER TABLE users DROP COLUMN email;")
+
+	m.SetDestructivePolicy(DestructiveRequireAllow)
+	if err := m.Migrate(); !errors.Is(err, ErrDestructive) {
+		t.Fatalf("want destructive error, got: %v", err)
+	}
+	if len(log.Statements()) != 0 {
+		t.Errorf("no migration must be applied before the policy is checked: %q", log.Statements())
+	}
+
+	m = NewMigrator(t.Logf, db, s)
+	m.AddSQLMigration("1", "create", "CREATE TABLE users (id INT, email TEXT);")
+	m.AddSQLMigration("2", "drop email", "-- allow-destructive: true\nALTER TABLE users DROP COLUMN email;")
+	m.SetDestructivePolicy(DestructiveBlockProduction)
+	m.SetEnvironment(EnvironmentProduction)
+	if err := m.Migrate(); !errors.Is(err, ErrDestructive) {
+		t.Fatalf("want destructive error in production, got: %v", err)
+	}
+
+	m.SetDestructivePolicy(DestructiveRequireAllow)
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.migrations) != 2 {
+		t.Errorf("want 2 applied migrations, got: %v", s.migrations)
+	}
+}
+
+func TestMigrateDestructiveRepeatable(t *testing.T) {
+	db, _ := openFake(t.Name())
+	defer db.Close()
+	m := NewMigrator(t.Logf, db, &memSupport{})
+	m.SetDestructivePolicy(DestructiveRequireAllow)
+	m.AddRepeatableSQLMigration("user names", "DROP VIEW IF EXISTS user_names;\nCREATE VIEW user_names AS SELECT name FROM users;")
+	m.AddRepeatableSQLMigration("audit trigger", "drop trigger if exists audit;\nCREATE TRIGGER audit AFTER INSERT ON users BEGIN SELECT 1; END;")
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("want recreated objects to be allowed, got: %v", err)
+	}
+
+	m = NewMigrator(t.Logf, db, &memSupport{})
+	m.SetDestructivePolicy(DestructiveRequireAllow)
+	m.AddRepeatableSQLMigration("scratch", "DROP TABLE IF EXISTS scratch;\nCREATE TABLE scratch (id INT);")
+	if err := m.Migrate(); !errors.Is(err, ErrDestructive) {
+		t.Fatalf("want destructive error for a dropped table, got: %v", err)
+	}
+}
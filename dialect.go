@@ -0,0 +1,150 @@
+package migrate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DialectSupport is implemented by Support implementations that select the dialect-conditional blocks of SQL
+// scripts. A block is kept if any of its names is one of the dialects of the Support:
+//
+//	--#if sqlite
+//	CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT);
+//	--#else
+//	CREATE TABLE users (id SERIAL PRIMARY KEY);
+//	--#endif
+//
+// The names of a block are separated by spaces or commas, blocks may be nested. Scripts with conditional blocks require
+// a DialectSupport.
+type DialectSupport interface {
+	// Dialects returns the names of the dialect, e.g. "cockroach" and "postgres" for CockroachDB.
+	Dialects() []string
+}
+
+// selectDialect returns mig with the conditional blocks of its script selected for the dialect of the Support.
+// A streamed script is filtered while it is read.
+func (m *Migrator) selectDialect(mig Migration) (Migration, error) {
+	var dialects []string
+	if ds, ok := m.support.(DialectSupport); ok {
+		dialects = ds.Dialects()
+	}
+	if mig.Source == nil {
+		if !strings.Contains(mig.Script, "--#") {
+			return mig, nil
+		}
+		b := &strings.Builder{}
+		if err := filterDialect(strings.NewReader(mig.Script), b, dialects); err != nil {
+			return mig, err
+		}
+		mig.Script = b.String()
+		return mig, nil
+	}
+	open := mig.Source
+	mig.Source = func() (io.ReadCloser, error) {
+		rc, err := open()
+		if err != nil {
+			return nil, err
+		}
+		pr, pw := io.Pipe()
+		go func() {
+			defer rc.Close()
+			pw.CloseWithError(filterDialect(rc, pw, dialects))
+		}()
+		return pr, nil
+	}
+	return mig, nil
+}
+
+// conditionalBlock is an --#if block of a script that is being filtered.
+type conditionalBlock struct {
+	// active reports whether the lines of the current branch are kept.
+	active bool
+	// taken reports whether a branch of the block has been kept.
+	taken bool
+	// parent reports whether the enclosing block keeps its lines.
+	parent bool
+}
+
+// filterDialect copies the script read from r to w, keeping the lines of the conditional blocks selected by dialects.
+// The directives themselves are removed.
+func filterDialect(r io.Reader, w io.Writer, dialects []string) error {
+	selected := map[string]bool{}
+	for _, d := range dialects {
+		selected[strings.ToLower(d)] = true
+	}
+	br := bufio.NewReader(r)
+	var blocks []conditionalBlock
+	active := true
+	for n := 1; ; n++ {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		fields := directive(line)
+		switch {
+		case len(fields) == 0:
+			if active {
+				if _, wErr := io.WriteString(w, line); wErr != nil {
+					return wErr
+				}
+			}
+		case fields[0] == "if":
+			if len(dialects) == 0 {
+				return fmt.Errorf("line %d: conditional blocks require a support with a dialect", n)
+			}
+			if len(fields) < 2 {
+				return fmt.Errorf("line %d: --#if without dialect", n)
+			}
+			match := false
+			for _, name := range fields[1:] {
+				match = match || selected[strings.ToLower(name)]
+			}
+			blocks = append(blocks, conditionalBlock{active: active && match, taken: match, parent: active})
+		case fields[0] == "else":
+			if len(blocks) == 0 {
+				return fmt.Errorf("line %d: --#else without --#if", n)
+			}
+			b := &blocks[len(blocks)-1]
+			b.active = b.parent && !b.taken
+			b.taken = true
+		case fields[0] == "endif":
+			if len(blocks) == 0 {
+				return fmt.Errorf("line %d: --#endif without --#if", n)
+			}
+			blocks = blocks[:len(blocks)-1]
+		}
+		if len(fields) > 0 {
+			active = len(blocks) == 0 || blocks[len(blocks)-1].active
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if len(blocks) > 0 {
+		return fmt.Errorf("--#if without --#endif")
+	}
+	return nil
+}
+
+// directive returns the lower cased keyword and the arguments of a line holding an --#if, --#else or --#endif
+// directive. It returns nil for any other line.
+func directive(line string) []string {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "--#") {
+		return nil
+	}
+	fields := strings.FieldsFunc(strings.TrimPrefix(line, "--#"), func(r rune) bool {
+		return r == ' ' || r == '\t' || r == ','
+	})
+	if len(fields) == 0 {
+		return nil
+	}
+	switch fields[0] = strings.ToLower(fields[0]); fields[0] {
+	case "if", "else", "endif":
+		return fields
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,64 @@
+package migrate
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type dialectSupport struct {
+	memSupport
+	dialects []string
+}
+
+func (s *dialectSupport) Dialects() []string {
+	return s.dialects
+}
+
+func TestMigrateDialect(t *testing.T) {
+	script := `--#if sqlite
+CREATE TABLE a (id INTEGER PRIMARY KEY AUTOINCREMENT);
+--#else
+CREATE TABLE a (id SERIAL PRIMARY KEY);
+--#endif
+--#if postgres, cockroach
+--#if cockroach
+ALTER TABLE a CONFIGURE ZONE USING gc.ttlseconds = 600;
+--#endif
+CREATE INDEX a_id ON a (id);
+--#endif
+`
+	db, log := openFake(t.Name())
+	defer db.Close()
+	m := NewMigrator(t.Logf, db, &dialectSupport{dialects: []string{"postgres"}})
+	m.AddSQLMigration("1", "one", script)
+	mig, err := NewSQLSourceMigration("2", "two", func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("--#IF SQLite\nSELECT 1;\n--#endif\nSELECT 2;\n")), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Add(mig)
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	want := []string{"CREATE TABLE a (id SERIAL PRIMARY KEY);", "CREATE INDEX a_id ON a (id);", "SELECT 2;"}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+
+	m = NewMigrator(t.Logf, db, &memSupport{})
+	m.AddSQLMigration("1", "one", script)
+	if err := m.Migrate(); err == nil {
+		t.Errorf("want error for a support without a dialect")
+	}
+}
+
+func TestFilterDialect(t *testing.T) {
+	for _, script := range []string{"--#if sqlite\nSELECT 1;\n", "--#endif\n", "--#else\n", "--#if\n--#endif\n"} {
+		if err := filterDialect(strings.NewReader(script), io.Discard, []string{"sqlite"}); err == nil {
+			t.Errorf("want error for: %q", script)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package migrate
+
+import "fmt"
+
+// Finding is an anomaly in the metadata table detected by Doctor.
+type Finding struct {
+	Rank       int
+	Version    Version
+	Problem    string
+	Suggestion string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("@Finding|rank=%d|version=%s|problem=%s|suggestion=%s",
+		f.Rank,
+		f.Version,
+		f.Problem,
+		f.Suggestion,
+	)
+}
+
+// Doctor checks the integrity of the metadata table.
+// It reports duplicate ranks and versions, invalid statuses, unparsable dates and malformed checksums together with a
+// suggested repair action. Gaps in the ranks are expected, e.g. after SetPruneRepeatable deleted superseded records,
+// and are not reported.
+func (m *Migrator) Doctor() ([]Finding, error) {
+	installed, err := m.support.ListMigrations(m.db)
+	if err != nil {
+		return nil, err
+	}
+	return diagnose(installed), nil
+}
+
+func diagnose(installed Migrations) []Finding {
+	fs := []Finding{}
+	add := func(mig Migration, problem string, suggestion string) {
+		fs = append(fs, Finding{
+			Rank:       mig.Rank,
+			Version:    mig.Version,
+			Problem:    problem,
+			Suggestion: suggestion,
+		})
+	}
+	ranks := map[int]bool{}
+	versions := map[Version]bool{}
+	for _, mig := range installed {
+		if ranks[mig.Rank] {
+			add(mig, "duplicate rank", "assign an unused rank to one of the rows")
+		}
+		ranks[mig.Rank] = true
+		if !mig.IsRepeatable() {
+			if versions[mig.Version] {
+				add(mig, "duplicate version", "delete the row that does not reflect the state of the database")
+			}
+			versions[mig.Version] = true
+		}
+		switch mig.Status {
+		case StatusSuccess, StatusFailed, StatusSkipped, StatusCherryPicked:
+		default:
+			add(mig, fmt.Sprintf("invalid status %q", mig.Status), "set the status to success or failed")
+		}
+		if mig.Date.IsZero() {
+			add(mig, "unparsable date", "set the date to an RFC3339 timestamp")
+		}
+		switch {
+		case mig.Type == TypeSQL && mig.Checksum == "":
+			add(mig, "missing checksum", "recompute the checksum from the local script")
+		case mig.Checksum != "" && !validChecksum(mig.Checksum):
+			add(mig, fmt.Sprintf("malformed checksum %q", mig.Checksum), "recompute the checksum from the local script")
+		}
+	}
+	return fs
+}
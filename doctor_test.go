@@ -0,0 +1,28 @@
+package migrate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiagnose(t *testing.T) {
+	now := time.Now()
+	cs := SQLChecksum("")
+	installed := Migrations{
+		{Rank: 1, Version: "1", Type: TypeSQL, Checksum: cs, Date: now, Status: StatusSuccess},
+		{Rank: 1, Version: "2", Type: TypeSQL, Checksum: cs, Date: now, Status: StatusSuccess},
+		{Rank: 3, Version: "2", Type: TypeSQL, Checksum: cs, Date: now, Status: StatusSuccess},
+		{Rank: 4, Version: "3", Type: TypeSQL, Checksum: "crc", Date: now, Status: "unknown"},
+		{Rank: 5, Version: "4", Type: TypeGo, Status: StatusSuccess},
+	}
+	want := []string{"duplicate rank", "duplicate version", `invalid status "unknown"`, `malformed checksum "crc"`, "unparsable date"}
+	got := diagnose(installed)
+	if len(got) != len(want) {
+		t.Fatalf("want %d findings, got %d: %v", len(want), len(got), got)
+	}
+	for i, f := range got {
+		if f.Problem != want[i] {
+			t.Errorf("want: %s, got: %s", want[i], f.Problem)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// fakeDriver is a database/sql driver that records the executed statements. Statements containing the
+// fail text of the log fail with failMessage, only the first failTimes ones if failTimes is set. Queries found in
+// results return a single row with the given value, queries found in rows return the given rows.
+type fakeDriver struct{}
+
+type fakeLog struct {
+	mu         sync.Mutex
+	statements []string
+	fail       string
+	failTimes  int
+	// failMessage prefixes the errors of failing statements, e.g. "fake: 40001" for a retryable failure.
+	failMessage string
+	results     map[string]string
+	rows        map[string][][]string
+}
+
+func (l *fakeLog) exec(query string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.statements = append(l.statements, query)
+	if l.fail != "" && strings.Contains(query, l.fail) {
+		if l.failTimes > 0 {
+			l.failTimes--
+			if l.failTimes == 0 {
+				l.fail = ""
+			}
+		}
+		msg := l.failMessage
+		if msg == "" {
+			msg = "fake"
+		}
+		return fmt.Errorf("%s: %s", msg, query)
+	}
+	return nil
+}
+
+func (l *fakeLog) Statements() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string{}, l.statements...)
+}
+
+var (
+	fakeMu   sync.Mutex
+	fakeLogs = map[string]*fakeLog{}
+)
+
+func init() {
+	sql.Register("migrate-fake", fakeDriver{})
+}
+
+// openFake opens a database of the fake driver named after the test.
+func openFake(name string) (*sql.DB, *fakeLog) {
+	fakeMu.Lock()
+	l := &fakeLog{}
+	fakeLogs[name] = l
+	fakeMu.Unlock()
+	db, _ := sql.Open("migrate-fake", name)
+	return db, l
+}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	l, ok := fakeLogs[name]
+	if !ok {
+		return nil, fmt.Errorf("fake: unknown database: %s", name)
+	}
+	return &fakeConn{log: l}, nil
+}
+
+type fakeConn struct {
+	log *fakeLog
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("fake: prepare not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c, c.log.exec("BEGIN")
+}
+
+func (c *fakeConn) Commit() error {
+	return c.log.exec("COMMIT")
+}
+
+func (c *fakeConn) Rollback() error {
+	return c.log.exec("ROLLBACK")
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.log.exec(query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.log.exec(query); err != nil {
+		return nil, err
+	}
+	c.log.mu.Lock()
+	defer c.log.mu.Unlock()
+	if v, ok := c.log.results[query]; ok {
+		return &fakeRows{values: [][]string{{v}}}, nil
+	}
+	if rows, ok := c.log.rows[query]; ok {
+		return &fakeRows{values: rows}, nil
+	}
+	return &fakeRows{}, nil
+}
+
+type fakeRows struct {
+	values [][]string
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.values) == 0 {
+		return []string{"value"}
+	}
+	columns := make([]string, len(r.values[0]))
+	for i := range columns {
+		columns[i] = fmt.Sprintf("value%d", i)
+	}
+	return columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	for i, v := range r.values[0] {
+		dest[i] = v
+	}
+	r.values = r.values[1:]
+	return nil
+}
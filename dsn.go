@@ -0,0 +1,155 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DSNOption configures NewMigratorFromDSN.
+type DSNOption func(*dsnConfig)
+
+type dsnConfig struct {
+	support   Support
+	connect   RetryPolicy
+	connector ConnectorFunc
+}
+
+// ConnectorFunc creates the connector for dsn, e.g. one whose dialer tunnels through SSH or that authenticates every
+// new connection with a short lived IAM token.
+type ConnectorFunc func(dsn string) (driver.Connector, error)
+
+var (
+	connectorsMu sync.RWMutex
+	connectors   = map[string]ConnectorFunc{}
+)
+
+// RegisterConnector makes NewMigratorFromDSN establish the connections of the database/sql driver driverName with the
+// connectors created by f instead of sql.Open. Packages imported for their side effects use it to plug connection
+// establishment into a build of the command line tool. If RegisterConnector is called twice with the same name or if f
+// is nil, it panics.
+func RegisterConnector(driverName string, f ConnectorFunc) {
+	connectorsMu.Lock()
+	defer connectorsMu.Unlock()
+	if f == nil {
+		panic("migrate: connector is nil")
+	}
+	if _, dup := connectors[driverName]; dup {
+		panic("migrate: RegisterConnector called twice for driver " + driverName)
+	}
+	connectors[driverName] = f
+}
+
+// connectorFor returns the ConnectorFunc registered under driverName.
+func connectorFor(driverName string) (ConnectorFunc, bool) {
+	connectorsMu.RLock()
+	defer connectorsMu.RUnlock()
+	f, ok := connectors[driverName]
+	return f, ok
+}
+
+// WithSupport sets the Support instead of selecting it from the registry.
+func WithSupport(s Support) DSNOption {
+	return func(c *dsnConfig) {
+		c.support = s
+	}
+}
+
+// WithConnectRetry sets how often connecting to the database is attempted. Every failure is retried.
+// The default are 5 attempts with an exponential backoff from 200ms up to 2s.
+func WithConnectRetry(p RetryPolicy) DSNOption {
+	return func(c *dsnConfig) {
+		c.connect = p
+	}
+}
+
+// WithConnector establishes the connections with the connector created by f instead of the one registered for the
+// driver or sql.Open.
+func WithConnector(f ConnectorFunc) DSNOption {
+	return func(c *dsnConfig) {
+		c.connector = f
+	}
+}
+
+// open opens the database with the connector of c, the connector registered for driverName or sql.Open.
+func (c *dsnConfig) open(driverName string, dsn string) (*sql.DB, error) {
+	f := c.connector
+	if f == nil {
+		f, _ = connectorFor(driverName)
+	}
+	if f == nil {
+		return sql.Open(driverName, dsn)
+	}
+	con, err := f(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(con), nil
+}
+
+// NewMigratorFromDSN opens the database with the database/sql driver driverName and returns a Migrator that owns it,
+// so Close closes the pool. The Support is the one registered under driverName or, if there is none, under the scheme
+// of dsn. Connections are established by the connector of WithConnector or RegisterConnector if there is one.
+// The connection is verified with retries before NewMigratorFromDSN returns.
+func NewMigratorFromDSN(log LogFunc, driverName string, dsn string, opts ...DSNOption) (*Migrator, error) {
+	c := &dsnConfig{
+		connect: RetryPolicy{MaxAttempts: 5, Backoff: ExponentialBackoff(200*time.Millisecond, 2*time.Second)},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.support == nil {
+		s, ok := SupportFor(driverName)
+		if !ok {
+			var err error
+			if s, err = SupportForDSN(dsn); err != nil {
+				return nil, fmt.Errorf("select support: %s: %+v", driverName, err)
+			}
+		}
+		c.support = s
+	}
+	db, err := c.open(driverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open: %s: %+v", driverName, err)
+	}
+	m := NewMigrator(log, db, c.support)
+	ping := func() error {
+		return db.Ping()
+	}
+	retryable := func(err error) bool {
+		return true
+	}
+	logRetry := func(retry int, err error) {
+		m.log(LevelWarn, "retrying to connect", Fields{"retry": retry, "error": err})
+	}
+	if err := c.connect.do(context.Background(), retryable, logRetry, ping); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connect: %s: %+v", driverName, err)
+	}
+	m.ownsDB = true
+	return m, nil
+}
+
+// DB returns the database of the Migrator.
+func (m *Migrator) DB() *sql.DB {
+	return m.db
+}
+
+// Close closes the database if the Migrator has opened it with NewMigratorFromDSN and releases a connection pinned by
+// SetSingleConnection.
+func (m *Migrator) Close() error {
+	var err error
+	if m.pin != nil {
+		err = m.unpin()
+	}
+	if !m.ownsDB || m.db == nil {
+		return err
+	}
+	if cErr := m.db.Close(); cErr != nil && err == nil {
+		err = cErr
+	}
+	return err
+}
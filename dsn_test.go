@@ -0,0 +1,83 @@
+package migrate
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+func TestNewMigratorFromDSN(t *testing.T) {
+	if _, err := NewMigratorFromDSN(t.Logf, "migrate-fake", "unknown"); err == nil {
+		t.Fatalf("want error for unknown support")
+	}
+	noWait := RetryPolicy{MaxAttempts: 2, Backoff: func(retry int) time.Duration { return 0 }}
+	if _, err := NewMigratorFromDSN(t.Logf, "migrate-fake", "unknown", WithSupport(&memSupport{}), WithConnectRetry(noWait)); err == nil {
+		t.Fatalf("want connect error")
+	}
+	_, log := openFake(t.Name())
+	m, err := NewMigratorFromDSN(t.Logf, "migrate-fake", t.Name(), WithSupport(&memSupport{}), WithConnectRetry(noWait))
+	if err != nil {
+		t.Fatalf("new migrator: %v", err)
+	}
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if got := log.Statements(); len(got) != 1 {
+		t.Errorf("unexpected statements: %q", got)
+	}
+	if err := m.db.Ping(); err == nil {
+		t.Errorf("want closed database")
+	}
+}
+
+func TestNewMigratorFromDSNConnector(t *testing.T) {
+	_, log := openFake(t.Name())
+	dialed := ""
+	connector := func(dsn string) (driver.Connector, error) {
+		dialed = dsn
+		return fakeConnector{name: t.Name()}, nil
+	}
+	m, err := NewMigratorFromDSN(t.Logf, "migrate-fake", "tunnel://"+t.Name(), WithSupport(&memSupport{}), WithConnector(connector))
+	if err != nil {
+		t.Fatalf("new migrator: %v", err)
+	}
+	defer m.Close()
+	if dialed != "tunnel://"+t.Name() {
+		t.Errorf("want connector for dsn, got: %q", dialed)
+	}
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if got := log.Statements(); len(got) != 1 {
+		t.Errorf("unexpected statements: %q", got)
+	}
+
+	RegisterConnector("migrate-fake-connector", connector)
+	m, err = NewMigratorFromDSN(t.Logf, "migrate-fake-connector", "registered", WithSupport(&memSupport{}))
+	if err != nil {
+		t.Fatalf("new migrator with registered connector: %v", err)
+	}
+	defer m.Close()
+	if dialed != "registered" {
+		t.Errorf("want registered connector, got: %q", dialed)
+	}
+}
+
+// fakeConnector connects to the fake database name.
+type fakeConnector struct {
+	name string
+}
+
+func (c fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return fakeDriver{}.Open(c.name)
+}
+
+func (c fakeConnector) Driver() driver.Driver {
+	return fakeDriver{}
+}
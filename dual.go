@@ -0,0 +1,184 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// DualMigrator applies the migrations of a primary Migrator to a secondary database in lockstep.
+// Every migration is applied to the secondary database right after it succeeded on the primary one.
+// It is meant for cutovers where an old and a new database are operated in parallel.
+type DualMigrator struct {
+	Primary   *Migrator
+	Secondary *Migrator
+}
+
+// NewDualMigrator creates a DualMigrator that mirrors the migrations registered with primary to the secondary database.
+func NewDualMigrator(primary *Migrator, secondary *sql.DB, support Support) *DualMigrator {
+	return &DualMigrator{
+		Primary: primary,
+		Secondary: &Migrator{
+			logger:          primary.logger,
+			logLevel:        primary.logLevel,
+			db:              secondary,
+			support:         support,
+			versionOrdering: primary.versionOrdering,
+		},
+	}
+}
+
+// DualResult is the combined outcome of a DualMigrator run.
+type DualResult struct {
+	Primary     Info
+	Secondary   Info
+	Divergences []Divergence
+}
+
+// Divergence is a difference between the applied migrations of the primary and the secondary database.
+type Divergence struct {
+	Version     Version
+	Description string
+	Problem     string
+}
+
+func (d Divergence) String() string {
+	return fmt.Sprintf("@Divergence|version=%s|description=%s|problem=%s", d.Version, d.Description, d.Problem)
+}
+
+// Migrate applies pending migrations to both databases.
+// It refuses to run if the databases have diverged before and reports divergences detected afterwards as an error.
+func (d *DualMigrator) Migrate() (DualResult, error) {
+	p, s := d.Primary, d.Secondary
+	migrations, repeatable := p.registered()
+	s.mu.Lock()
+	s.migrations, s.repeatable = migrations, repeatable
+	s.mu.Unlock()
+	err := p.withLock(func() error {
+		return s.withLock(func() error {
+			if err := d.checkDivergence(); err != nil {
+				return err
+			}
+			p.lockstep = s.installNext
+			defer func() { p.lockstep = nil }()
+			if err := p.migrateRun(); err != nil {
+				return err
+			}
+			return d.checkDivergence()
+		})
+	})
+	return d.result(), err
+}
+
+func (d *DualMigrator) result() DualResult {
+	r := DualResult{
+		Primary:   d.Primary.Info(),
+		Secondary: d.Secondary.Info(),
+	}
+	p, pErr := d.Primary.support.ListMigrations(d.Primary.db)
+	s, sErr := d.Secondary.support.ListMigrations(d.Secondary.db)
+	if pErr == nil && sErr == nil {
+		r.Divergences = diverge(p, s)
+	}
+	return r
+}
+
+func (d *DualMigrator) checkDivergence() error {
+	for _, m := range []*Migrator{d.Primary, d.Secondary} {
+		if err := m.ensureMigrationsTable(); err != nil {
+			return err
+		}
+	}
+	p, err := d.Primary.support.ListMigrations(d.Primary.db)
+	if err != nil {
+		return err
+	}
+	s, err := d.Secondary.support.ListMigrations(d.Secondary.db)
+	if err != nil {
+		return err
+	}
+	if ds := diverge(p, s); len(ds) > 0 {
+		return fmt.Errorf("databases have diverged: %s", ds[0])
+	}
+	return nil
+}
+
+// installNext installs mig with the next free rank unless it has already been applied.
+func (m *Migrator) installNext(mig Migration) error {
+	installed, err := m.support.ListMigrations(m.db)
+	if err != nil {
+		return err
+	}
+	rank := 0
+	for _, a := range installed {
+		if a.Rank > rank {
+			rank = a.Rank
+		}
+	}
+	if latest, ok := latestApplied(installed)[appliedKey(mig)]; ok && latest.Status == StatusSuccess && checksumMatches(latest.Checksum, mig) {
+		return nil
+	}
+	mig.Rank = rank + 1
+	return m.install(mig)
+}
+
+func (m *Migrator) ensureMigrationsTable() error {
+	exists, err := m.support.ExistsMigrationsTable(m.db)
+	if err != nil || exists {
+		return err
+	}
+	return m.newMigrationsTable()
+}
+
+// diverge compares the latest state of every applied migration.
+func diverge(primary Migrations, secondary Migrations) []Divergence {
+	ds := []Divergence{}
+	p, s := latestApplied(primary), latestApplied(secondary)
+	add := func(mig Migration, problem string) {
+		ds = append(ds, Divergence{
+			Version:     mig.Version,
+			Description: mig.Description,
+			Problem:     problem,
+		})
+	}
+	for _, pm := range primary {
+		k := appliedKey(pm)
+		if p[k].Rank != pm.Rank {
+			continue
+		}
+		sm, ok := s[k]
+		switch {
+		case !ok:
+			add(pm, "not applied to secondary")
+		case sm.Status != pm.Status:
+			add(pm, fmt.Sprintf("status %s on primary, %s on secondary", pm.Status, sm.Status))
+		case sm.Checksum != pm.Checksum:
+			add(pm, fmt.Sprintf("checksum %s on primary, %s on secondary", pm.Checksum, sm.Checksum))
+		}
+	}
+	for _, sm := range secondary {
+		k := appliedKey(sm)
+		if s[k].Rank != sm.Rank {
+			continue
+		}
+		if _, ok := p[k]; !ok {
+			add(sm, "not applied to primary")
+		}
+	}
+	return ds
+}
+
+// appliedKey identifies versioned migrations by version and repeatable ones by description and type.
+func appliedKey(mig Migration) string {
+	if mig.IsRepeatable() {
+		return string(VersionRepeatable) + ":" + string(mig.Type) + ":" + mig.Description
+	}
+	return string(mig.Version)
+}
+
+func latestApplied(installed Migrations) map[string]Migration {
+	latest := map[string]Migration{}
+	for _, mig := range installed {
+		latest[appliedKey(mig)] = mig
+	}
+	return latest
+}
@@ -0,0 +1,28 @@
+package migrate
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestDualMigrator(t *testing.T) {
+	ps, ss := &memSupport{}, &memSupport{}
+	p := newTestMigrator(t, ps)
+	d := NewDualMigrator(p, nil, ss)
+	p.AddGoMigration("1", "one", func(con *sql.DB) error { return nil })
+	p.AddRepeatableSQLMigration("view", "")
+	res, err := d.Migrate()
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if len(ps.migrations) != 2 || len(ss.migrations) != 2 {
+		t.Errorf("want 2 migrations on both, got %d and %d", len(ps.migrations), len(ss.migrations))
+	}
+	if len(res.Divergences) != 0 {
+		t.Errorf("want no divergences, got %v", res.Divergences)
+	}
+	ss.migrations = ss.migrations[:1]
+	if _, err := d.Migrate(); err == nil {
+		t.Fatalf("want divergence error")
+	}
+}
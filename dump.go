@@ -0,0 +1,53 @@
+package migrate
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// SchemaDumper is implemented by Support implementations that are able to write a canonical dump of the schema DDL,
+// which is stable for equal schemas and can be diffed to detect schema drift.
+type SchemaDumper interface {
+	DumpSchema(con *sql.DB, w io.Writer) error
+}
+
+// SetSchemaDump makes Migrate write a dump of the schema to w after all migrations have been applied successfully.
+func (m *Migrator) SetSchemaDump(w io.Writer) {
+	m.schemaDump = w
+	m.schemaDumpFile = ""
+}
+
+// SetSchemaDumpFile makes Migrate write a dump of the schema to the file path after all migrations have been applied successfully.
+func (m *Migrator) SetSchemaDumpFile(path string) {
+	m.schemaDump = nil
+	m.schemaDumpFile = path
+}
+
+// DumpSchema writes a dump of the schema to w.
+func (m *Migrator) DumpSchema(w io.Writer) error {
+	sd, ok := m.support.(SchemaDumper)
+	if !ok {
+		return fmt.Errorf("support does not dump schemas: %T", m.support)
+	}
+	return sd.DumpSchema(m.db, w)
+}
+
+// dumpSchema writes the dump configured by SetSchemaDump or SetSchemaDumpFile.
+func (m *Migrator) dumpSchema() error {
+	switch {
+	case m.schemaDump != nil:
+		return m.DumpSchema(m.schemaDump)
+	case m.schemaDumpFile != "":
+		buf := &bytes.Buffer{}
+		if err := m.DumpSchema(buf); err != nil {
+			return err
+		}
+		m.log(LevelDebug, "dumped schema", Fields{"file": m.schemaDumpFile})
+		return ioutil.WriteFile(m.schemaDumpFile, buf.Bytes(), 0644)
+	default:
+		return nil
+	}
+}
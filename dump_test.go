@@ -0,0 +1,36 @@
+package migrate
+
+import (
+	"bytes"
+	"database/sql"
+	"io"
+	"testing"
+)
+
+type dumpSupport struct {
+	memSupport
+}
+
+func (s *dumpSupport) DumpSchema(con *sql.DB, w io.Writer) error {
+	_, err := io.WriteString(w, "CREATE TABLE users (id INT);\n")
+	return err
+}
+
+func TestMigrateSchemaDump(t *testing.T) {
+	s := &dumpSupport{}
+	m := newTestMigrator(t, s)
+	buf := &bytes.Buffer{}
+	m.SetSchemaDump(buf)
+	m.AddGoMigration("1", "users", func(con *sql.DB) error { return nil })
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "CREATE TABLE users (id INT);\n"; got != want {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	m = newTestMigrator(t, &memSupport{})
+	m.SetSchemaDump(buf)
+	if err := m.Migrate(); err == nil {
+		t.Errorf("want error for a support that does not dump schemas")
+	}
+}
@@ -0,0 +1,72 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Election configures the coordination of replicas that call Migrate concurrently, e.g. during a rolling deployment.
+// The replica that acquires the migration lock within the grace period becomes the leader and migrates, the others
+// observe it: they wait until all migrations have been applied and validate them. An observer fails as soon as it
+// observes that the leader failed to apply a migration.
+type Election struct {
+	// Grace is how long Migrate waits for the migration lock before it observes the leader instead.
+	Grace time.Duration
+	// Wait bounds how long an observer waits for the leader to finish. Zero waits forever.
+	Wait time.Duration
+	// PollInterval is the interval in which an observer reads the metadata table. It defaults to one second.
+	PollInterval time.Duration
+}
+
+// SetLeaderElection makes Migrate elect a leader among concurrent migrators. It requires a Support that is a Locker.
+func (m *Migrator) SetLeaderElection(e Election) {
+	m.election = &e
+}
+
+// withLeaderLock calls f if the Migrator is the leader and observes the leader otherwise.
+// Without leader election it is withLock.
+func (m *Migrator) withLeaderLock(f func() error) error {
+	if m.election == nil {
+		return m.withLock(f)
+	}
+	l, ok := m.support.(Locker)
+	if !ok {
+		return fmt.Errorf("leader election requires a support that locks: %T", m.support)
+	}
+	m.running.Lock()
+	if err := l.Lock(m.db, m.election.Grace); err != nil {
+		m.running.Unlock()
+		if !errors.Is(err, ErrLockTimeout) {
+			return fmt.Errorf("lock: %+v", err)
+		}
+		m.log(LevelInfo, "observing migration leader", Fields{"reason": err})
+		return m.observeLeader()
+	}
+	defer m.running.Unlock()
+	err := f()
+	if uErr := l.Unlock(m.db); uErr != nil && err == nil {
+		err = uErr
+	}
+	return err
+}
+
+// observeLeader waits until the leader applied all migrations and validates them.
+func (m *Migrator) observeLeader() error {
+	ctx := context.Background()
+	if m.election.Wait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.election.Wait)
+		defer cancel()
+	}
+	interval := m.election.PollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	if err := m.WaitUntilCurrent(ctx, interval); err != nil {
+		return err
+	}
+	m.log(LevelInfo, "leader finished migrating", nil)
+	return m.Validate()
+}
@@ -0,0 +1,71 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLeaderElection(t *testing.T) {
+	s := &memSupport{}
+	executed := 0
+	add := func(m *Migrator) {
+		m.AddGoMigration("1", "one", func(con *sql.DB) error {
+			executed++
+			return nil
+		})
+	}
+	election := Election{Grace: time.Millisecond, Wait: 10 * time.Millisecond, PollInterval: time.Millisecond}
+
+	observer := newTestMigrator(t, s)
+	observer.SetLeaderElection(election)
+	add(observer)
+	s.locked = true
+	if err := observer.Migrate(); !errors.Is(err, ErrPendingMigrations) {
+		t.Fatalf("want observer to time out waiting for the leader, got: %v", err)
+	}
+	s.locked = false
+
+	leader := newTestMigrator(t, s)
+	leader.SetLeaderElection(election)
+	add(leader)
+	if err := leader.Migrate(); err != nil {
+		t.Fatalf("leader: %v", err)
+	}
+	s.locked = true
+	if err := observer.Migrate(); err != nil {
+		t.Fatalf("observer: %v", err)
+	}
+	if executed != 1 {
+		t.Errorf("want migration to be executed once, got: %d", executed)
+	}
+}
+
+type brokenLockSupport struct {
+	memSupport
+}
+
+func (s *brokenLockSupport) Lock(con *sql.DB, timeout time.Duration) error {
+	return errors.New("connection refused")
+}
+
+func TestLeaderElectionFailures(t *testing.T) {
+	s := &memSupport{}
+	observer := newTestMigrator(t, s)
+	observer.SetLeaderElection(Election{Grace: time.Millisecond, PollInterval: time.Millisecond})
+	observer.AddGoMigration("1", "one", func(con *sql.DB) error { return nil })
+	s.migrations = Migrations{{Rank: 1, Version: "1", Description: "one", Type: TypeGo, Status: StatusFailed}}
+	s.exists, s.locked = true, true
+	if err := observer.Migrate(); !errors.Is(err, ErrFailedMigrationDetected) {
+		t.Errorf("want observer to fail with the leader, got: %v", err)
+	}
+
+	b := &brokenLockSupport{}
+	m := newTestMigrator(t, b)
+	m.SetLeaderElection(Election{Grace: time.Millisecond, Wait: time.Millisecond, PollInterval: time.Millisecond})
+	m.AddGoMigration("1", "one", func(con *sql.DB) error { return nil })
+	if err := m.Migrate(); err == nil || errors.Is(err, ErrPendingMigrations) {
+		t.Errorf("want lock error instead of observing, got: %v", err)
+	}
+}
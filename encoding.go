@@ -0,0 +1,107 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MarshalJSON encodes the Migration with the stable, lower case field names used by Info. The date is formatted as RFC 3339.
+func (m Migration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newMigrationJSON(m))
+}
+
+// UnmarshalJSON decodes a Migration encoded by MarshalJSON. Scripts and executors are not part of the encoding.
+func (m *Migration) UnmarshalJSON(data []byte) error {
+	v := migrationJSON{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	mig, err := v.migration()
+	if err != nil {
+		return err
+	}
+	*m = mig
+	return nil
+}
+
+// UnmarshalJSON decodes an Info encoded by MarshalJSON.
+func (i *Info) UnmarshalJSON(data []byte) error {
+	v := infoJSON{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	info := Info{Migrations: Migrations{}, Server: v.Server}
+	for _, mj := range v.Migrations {
+		mig, err := mj.migration()
+		if err != nil {
+			return err
+		}
+		info.Migrations = append(info.Migrations, mig)
+	}
+	for _, rj := range v.Runs {
+		r := Run{Token: rj.Token, Status: Status(rj.Status), Labels: rj.Labels}
+		var err error
+		if r.Started, err = parseTime(rj.Started); err != nil {
+			return err
+		}
+		if r.Finished, err = parseTime(rj.Finished); err != nil {
+			return err
+		}
+		info.Runs = append(info.Runs, r)
+	}
+	*i = info
+	return nil
+}
+
+func (v Version) MarshalText() ([]byte, error) {
+	return []byte(v), nil
+}
+
+func (v *Version) UnmarshalText(text []byte) error {
+	*v = Version(text)
+	return nil
+}
+
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s), nil
+}
+
+// UnmarshalText accepts the known statuses regardless of case.
+func (s *Status) UnmarshalText(text []byte) error {
+	for _, known := range []Status{StatusSuccess, StatusFailed, StatusSkipped, StatusCherryPicked} {
+		if strings.EqualFold(string(known), string(text)) {
+			*s = known
+			return nil
+		}
+	}
+	if len(text) == 0 {
+		*s = ""
+		return nil
+	}
+	return fmt.Errorf("unknown status: %s", text)
+}
+
+func (t Type) MarshalText() ([]byte, error) {
+	return []byte(t), nil
+}
+
+// UnmarshalText accepts the known types regardless of case, e.g. "sql" for TypeSQL.
+func (t *Type) UnmarshalText(text []byte) error {
+	for _, known := range []Type{TypeGo, TypeSQL, TypeBaseline, TypeShell} {
+		if strings.EqualFold(string(known), string(text)) {
+			*t = known
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown type: %s", text)
+}
+
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s), nil
+}
+
+func (s *State) UnmarshalText(text []byte) error {
+	*s = State(text)
+	return nil
+}
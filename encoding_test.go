@@ -0,0 +1,69 @@
+package migrate
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrationJSON(t *testing.T) {
+	mig := Migration{
+		Rank:          1,
+		Version:       "1",
+		Description:   "one",
+		Type:          TypeSQL,
+		Checksum:      SQLChecksum("SELECT 1;"),
+		Date:          time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+		ExecutionTime: 42 * time.Millisecond,
+		Status:        StatusSuccess,
+		InstalledBy:   "ci",
+	}
+	bs, err := json.Marshal(mig)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(bs), `"date":"2024-01-31T12:00:00Z"`) || !strings.Contains(string(bs), `"installed_by":"ci"`) {
+		t.Errorf("unexpected encoding: %s", bs)
+	}
+	got := Migration{}
+	if err := json.Unmarshal(bs, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(mig, got) {
+		t.Errorf("want: %+v, got: %+v", mig, got)
+	}
+
+	info := Info{Migrations: Migrations{mig}, Runs: []Run{{Token: "t", Status: StatusSuccess, Started: mig.Date, Finished: mig.Date}}}
+	bs, err = json.Marshal(info)
+	if err != nil {
+		t.Fatalf("marshal info: %v", err)
+	}
+	gotInfo := Info{}
+	if err := json.Unmarshal(bs, &gotInfo); err != nil {
+		t.Fatalf("unmarshal info: %v", err)
+	}
+	if !reflect.DeepEqual(info, gotInfo) {
+		t.Errorf("want: %+v, got: %+v", info, gotInfo)
+	}
+}
+
+func TestTextUnmarshal(t *testing.T) {
+	v := struct {
+		Type   Type
+		Status Status
+	}{}
+	if err := json.Unmarshal([]byte(`{"Type":"sql","Status":"SUCCESS"}`), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if v.Type != TypeSQL || v.Status != StatusSuccess {
+		t.Errorf("unexpected values: %+v", v)
+	}
+	if err := json.Unmarshal([]byte(`{"Type":"shell"}`), &v); err != nil || v.Type != TypeShell {
+		t.Errorf("want shell type, got: %s, %v", v.Type, err)
+	}
+	if err := json.Unmarshal([]byte(`{"Type":"python"}`), &v); err == nil {
+		t.Errorf("want error for unknown type")
+	}
+}
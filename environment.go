@@ -0,0 +1,51 @@
+package migrate
+
+import "fmt"
+
+// OnlyIn restricts a migration to environments or feature flags, e.g. OnlyIn("test", "dev") for seed data.
+// The migration is installed if any of them is active and recorded as skipped otherwise.
+func OnlyIn(environments ...string) MigrationOption {
+	return func(o *MigrationOptions) {
+		o.Environments = append(o.Environments, environments...)
+	}
+}
+
+// SetEnvironment sets the active environment and enabled feature flags. Migrations restricted by OnlyIn to
+// none of them are skipped by subsequent calls to Migrate.
+func (m *Migrator) SetEnvironment(active ...string) {
+	m.environment = map[string]bool{}
+	for _, env := range active {
+		m.environment[env] = true
+	}
+}
+
+// inEnvironment reports whether mig is unrestricted or restricted to an active environment.
+func (m *Migrator) inEnvironment(mig Migration) bool {
+	if len(mig.Options.Environments) == 0 {
+		return true
+	}
+	for _, env := range mig.Options.Environments {
+		if m.environment[env] {
+			return true
+		}
+	}
+	return false
+}
+
+// skipInactive records mig as intentionally skipped because none of its environments is active.
+func (m *Migrator) skipInactive(mig Migration) error {
+	fields := migrationFields(mig)
+	fields["environments"] = mig.Options.Environments
+	m.log(LevelInfo, "skipping migration for inactive environment", fields)
+	mig.Date = m.now().UTC()
+	mig.InstalledBy = m.installedBy()
+	mig.Status = StatusSkipped
+	if err := m.record(mig, false); err != nil {
+		return fmt.Errorf("record migration: %s: %+v", mig, err)
+	}
+	m.collect(func(r *MigrationResult) {
+		r.Skipped = append(r.Skipped, mig)
+		r.advance(mig, m.versionOrdering)
+	})
+	return nil
+}
@@ -0,0 +1,58 @@
+package migrate
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestMigrateEnvironment(t *testing.T) {
+	tests := []struct {
+		name   string
+		active []string
+		seeded bool
+	}{
+		{"none", nil, false},
+		{"prod", []string{"prod"}, false},
+		{"dev", []string{"dev"}, true},
+		{"flag", []string{"prod", "demo-data"}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &memSupport{}
+			seeded := false
+			m := newTestMigrator(t, s)
+			m.SetEnvironment(test.active...)
+			m.AddGoMigration("1", "schema", func(con *sql.DB) error { return nil })
+			m.AddGoMigration("2", "seed", func(con *sql.DB) error {
+				seeded = true
+				return nil
+			}, OnlyIn("test", "dev", "demo-data"))
+			m.AddGoMigration("3", "index", func(con *sql.DB) error { return nil })
+			if err := m.Migrate(); err != nil {
+				t.Fatalf("migrate: %v", err)
+			}
+			if seeded != test.seeded {
+				t.Fatalf("want seeded %t, got: %t", test.seeded, seeded)
+			}
+			if len(s.migrations) != 3 {
+				t.Fatalf("want 3 records, got: %d", len(s.migrations))
+			}
+			want := StatusSkipped
+			if test.seeded {
+				want = StatusSuccess
+			}
+			if got := s.migrations[1].Status; got != want {
+				t.Errorf("want status %s, got: %s", want, got)
+			}
+			if got := len(m.Info().Skipped()); got != 0 && test.seeded || got != 1 && !test.seeded {
+				t.Errorf("unexpected skipped migrations: %d", got)
+			}
+			if err := m.Migrate(); err != nil {
+				t.Fatalf("migrate again: %v", err)
+			}
+			if len(s.migrations) != 3 {
+				t.Fatalf("want no new records, got: %d", len(s.migrations))
+			}
+		})
+	}
+}
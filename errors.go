@@ -0,0 +1,49 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors reported for common failure modes. Use errors.Is to detect them, errors caused by a specific
+// migration are wrapped in a *MigrationError.
+var (
+	ErrFailedMigrationDetected = errors.New("detected a failed migration")
+	ErrChecksumMismatch        = errors.New("checksum mismatch")
+	ErrMigrationsTableMissing  = errors.New("migrations table missing")
+	ErrOutOfOrder              = errors.New("migration out of order")
+	ErrTimeout                 = errors.New("migration run timed out")
+	ErrPendingMigrations       = errors.New("pending migrations")
+	ErrConflict                = errors.New("conflicting migrations")
+	ErrSchemaMismatch          = errors.New("schema mismatch")
+	ErrManifestMismatch        = errors.New("migrations do not match manifest")
+	ErrPlanOutdated            = errors.New("plan outdated")
+	ErrDestructive             = errors.New("destructive migration")
+	ErrAborted                 = errors.New("migration run aborted")
+	ErrUnknownMigration        = errors.New("unknown applied migration")
+	ErrForeignKeyViolation     = errors.New("foreign key violation")
+	ErrLockTimeout             = errors.New("timed out acquiring the migration lock")
+)
+
+// MigrationError is an error caused by a specific migration.
+type MigrationError struct {
+	Err       error
+	Migration Migration
+	// Detail is additional information, e.g. the mismatching checksums.
+	Detail string
+}
+
+func (e *MigrationError) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("%v: %s", e.Err, e.Migration)
+	}
+	return fmt.Sprintf("%v: %s: %s", e.Err, e.Migration, e.Detail)
+}
+
+func (e *MigrationError) Unwrap() error {
+	return e.Err
+}
+
+func migrationError(err error, mig Migration) error {
+	return &MigrationError{Err: err, Migration: mig}
+}
@@ -0,0 +1,37 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	if err := m.Validate(); !errors.Is(err, ErrMigrationsTableMissing) {
+		t.Errorf("want ErrMigrationsTableMissing, got: %v", err)
+	}
+	noop := func(con *sql.DB) error { return nil }
+	m.AddGoMigration("1", "one", noop)
+	m.AddGoMigration("3", "three", noop)
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	m.AddGoMigration("2", "two", noop)
+	err := m.Validate()
+	var merr *MigrationError
+	if !errors.Is(err, ErrOutOfOrder) || !errors.As(err, &merr) || merr.Migration.Version != "2" {
+		t.Errorf("want ErrOutOfOrder for 2, got: %v", err)
+	}
+	s.migrations[0].Checksum = "changed"
+	m = newTestMigrator(t, s)
+	m.AddGoMigration("1", "one", noop)
+	if err := m.Migrate(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("want ErrChecksumMismatch, got: %v", err)
+	}
+	s.migrations[1].Status = StatusFailed
+	if err := m.Migrate(); !errors.Is(err, ErrFailedMigrationDetected) {
+		t.Errorf("want ErrFailedMigrationDetected, got: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package migrate
+
+import "time"
+
+// Event is emitted by Migrate to report its progress. It is one of RunStarted, MigrationStarted, StatementExecuted,
+// MigrationFinished and RunFinished.
+type Event interface {
+	event()
+}
+
+// RunStarted is emitted after the applied migrations have been listed.
+type RunStarted struct {
+	Time time.Time
+	// Pending is the number of migrations that are going to be installed.
+	Pending int
+}
+
+// MigrationStarted is emitted before a migration is installed.
+type MigrationStarted struct {
+	Migration Migration
+	Time      time.Time
+}
+
+// StatementExecuted is emitted after a statement, or a batch of statements, of a SQL migration has been executed.
+type StatementExecuted struct {
+	Migration Migration
+	// Statements is the number of statements executed so far.
+	Statements int
+	// Total is the number of statements of the migration or -1 if it is streamed.
+	Total int
+}
+
+// MigrationFinished is emitted after a migration has been installed. Its outcome is reported by Migration.Status.
+type MigrationFinished struct {
+	Migration Migration
+	Duration  time.Duration
+	Err       error
+}
+
+// RunFinished is emitted at the end of every call to Migrate.
+type RunFinished struct {
+	Duration time.Duration
+	Err      error
+}
+
+func (RunStarted) event()        {}
+func (MigrationStarted) event()  {}
+func (StatementExecuted) event() {}
+func (MigrationFinished) event() {}
+func (RunFinished) event()       {}
+
+// Subscriber receives the events of a Migrator. Notify is called synchronously, so it should return quickly.
+type Subscriber interface {
+	Notify(e Event)
+}
+
+// SubscriberFunc is a function that receives events.
+type SubscriberFunc func(e Event)
+
+func (f SubscriberFunc) Notify(e Event) {
+	f(e)
+}
+
+// ChanSubscriber sends the events to ch. The run blocks while ch is full.
+func ChanSubscriber(ch chan<- Event) Subscriber {
+	return SubscriberFunc(func(e Event) {
+		ch <- e
+	})
+}
+
+// Subscribe adds a subscriber for the events of subsequent calls to Migrate.
+func (m *Migrator) Subscribe(s Subscriber) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscribers = append(m.subscribers, s)
+}
+
+func (m *Migrator) emit(e Event) {
+	m.mu.Lock()
+	subscribers := m.subscribers
+	m.mu.Unlock()
+	m.collect(func(r *MigrationResult) { r.observe(e, m.versionOrdering) })
+	m.reported(func(r *Report) { r.observe(e) })
+	for _, s := range subscribers {
+		s.Notify(e)
+	}
+}
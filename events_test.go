@@ -0,0 +1,46 @@
+package migrate
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMigrateEvents(t *testing.T) {
+	db, _ := openFake(t.Name())
+	defer db.Close()
+	m := NewMigrator(t.Logf, db, &memSupport{})
+	ch := make(chan Event, 16)
+	m.Subscribe(ChanSubscriber(ch))
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);")
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	close(ch)
+	got := []string{}
+	for e := range ch {
+		switch e := e.(type) {
+		case RunStarted:
+			got = append(got, fmt.Sprintf("run started %d", e.Pending))
+		case MigrationStarted:
+			got = append(got, "migration started "+string(e.Migration.Version))
+		case StatementExecuted:
+			got = append(got, fmt.Sprintf("statement %d/%d", e.Statements, e.Total))
+		case MigrationFinished:
+			got = append(got, fmt.Sprintf("migration finished %s %s", e.Migration.Version, e.Migration.Status))
+		case RunFinished:
+			got = append(got, fmt.Sprintf("run finished %v", e.Err))
+		}
+	}
+	want := []string{
+		"run started 1",
+		"migration started 1",
+		"statement 1/2",
+		"statement 2/2",
+		"migration finished 1 success",
+		"run finished <nil>",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
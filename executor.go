@@ -0,0 +1,118 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ExecutorFactory creates the CommandFunc that executes a script of a specific Type.
+type ExecutorFactory func(script string) (CommandFunc, error)
+
+var (
+	executorsMu sync.RWMutex
+	executors   = map[Type]ExecutorFactory{}
+)
+
+func init() {
+	RegisterExecutor(TypeSQL, func(script string) (CommandFunc, error) {
+		return sqlExecutor(script), nil
+	})
+}
+
+// RegisterExecutor makes migrations of Type typ available through NewMigration.
+// If RegisterExecutor is called twice with the same type or if factory is nil, it panics.
+func RegisterExecutor(typ Type, factory ExecutorFactory) {
+	executorsMu.Lock()
+	defer executorsMu.Unlock()
+	if factory == nil {
+		panic("migrate: executor factory is nil")
+	}
+	if _, dup := executors[typ]; dup {
+		panic("migrate: RegisterExecutor called twice for type " + string(typ))
+	}
+	executors[typ] = factory
+}
+
+// Types returns a sorted list of the types that have a registered executor.
+func Types() []Type {
+	executorsMu.RLock()
+	defer executorsMu.RUnlock()
+	ts := []Type{}
+	for t := range executors {
+		ts = append(ts, t)
+	}
+	sort.Slice(ts, func(i, j int) bool { return ts[i] < ts[j] })
+	return ts
+}
+
+// NewMigration creates a migration of Type typ whose script is executed by the registered executor.
+func NewMigration(version Version, description string, typ Type, script string) (Migration, error) {
+	executorsMu.RLock()
+	factory, ok := executors[typ]
+	executorsMu.RUnlock()
+	if !ok {
+		return Migration{}, fmt.Errorf("unknown migration type: %s", typ)
+	}
+	execute, err := factory(script)
+	if err != nil {
+		return Migration{}, fmt.Errorf("%s migration: %s: %+v", typ, description, err)
+	}
+	return Migration{
+		Version:     version,
+		Description: description,
+		Type:        typ,
+		Checksum:    SQLChecksum(script),
+		Script:      script,
+		Execute:     execute,
+	}, nil
+}
+
+func (m *Migrator) AddScriptMigration(version Version, description string, typ Type, script string) error {
+	mig, err := NewMigration(version, description, typ, script)
+	if err != nil {
+		return err
+	}
+	m.Add(mig)
+	return nil
+}
+
+func (m *Migrator) AddRepeatableScriptMigration(description string, typ Type, script string) error {
+	return m.AddScriptMigration(VersionRepeatable, description, typ, script)
+}
+
+// statementRetryDelay is the pause before a statement marked with the retry directive is executed again.
+var statementRetryDelay = time.Second
+
+func sqlExecutor(script string) CommandFunc {
+	return func(db *sql.DB) error {
+		for _, stmt := range ParseStatements(script) {
+			if err := execStatement(context.Background(), db, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// execer is satisfied by *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+var (
+	_ execer = &sql.DB{}
+	_ execer = &sql.Tx{}
+)
+
+func execStatement(ctx context.Context, con execer, stmt Statement) error {
+	_, err := con.ExecContext(ctx, stmt.SQL)
+	for i := 0; err != nil && i < stmt.Retries && ctx.Err() == nil; i++ {
+		time.Sleep(statementRetryDelay)
+		_, err = con.ExecContext(ctx, stmt.SQL)
+	}
+	return err
+}
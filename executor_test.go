@@ -0,0 +1,34 @@
+package migrate
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestNewMigration(t *testing.T) {
+	const typ Type = "Test"
+	scripts := []string{}
+	RegisterExecutor(typ, func(script string) (CommandFunc, error) {
+		return func(con *sql.DB) error {
+			scripts = append(scripts, script)
+			return nil
+		}, nil
+	})
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	if err := m.AddScriptMigration("1", "test", typ, "echo"); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := m.AddScriptMigration("2", "unknown", "Unknown", "echo"); err == nil {
+		t.Errorf("want error for unknown type")
+	}
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if len(scripts) != 1 || scripts[0] != "echo" {
+		t.Errorf("unexpected executed scripts: %v", scripts)
+	}
+	if got := s.migrations[0]; got.Type != typ || got.Checksum != SQLChecksum("echo") {
+		t.Errorf("unexpected recorded migration: %s %s", got, got.Checksum)
+	}
+}
@@ -0,0 +1,148 @@
+package migrate
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ExportFormat is the format of an exported history.
+type ExportFormat string
+
+const (
+	FormatJSON ExportFormat = "json"
+	FormatCSV  ExportFormat = "csv"
+)
+
+// BulkRecorder is implemented by Support implementations that are able to record many migrations at once.
+type BulkRecorder interface {
+	RecordMigrations(con *sql.DB, ms Migrations) error
+}
+
+var csvHeader = []string{"rank", "version", "description", "type", "checksum", "date", "execution_time_ms", "status"}
+
+// ExportHistory writes the contents of the metadata table to w.
+func (m *Migrator) ExportHistory(w io.Writer, format ExportFormat) error {
+	ms, err := m.support.ListMigrations(m.db)
+	if err != nil {
+		return err
+	}
+	return encodeHistory(w, ms, format)
+}
+
+// ImportHistory records the migrations read from r in the empty metadata table. The migrations are not executed.
+// This brings a database to a known state, e.g. to seed test databases whose schema has been restored otherwise.
+func (m *Migrator) ImportHistory(r io.Reader, format ExportFormat) error {
+	ms, err := decodeHistory(r, format)
+	if err != nil {
+		return err
+	}
+	return m.withLock(func() error {
+		if err := m.importMigrations(ms); err != nil {
+			return err
+		}
+		m.log(LevelInfo, "imported history", Fields{"count": len(ms)})
+		return nil
+	})
+}
+
+// importMigrations records ms in the metadata table, which is created if necessary and has to be empty.
+func (m *Migrator) importMigrations(ms Migrations) error {
+	exists, err := m.support.ExistsMigrationsTable(m.db)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := m.newMigrationsTable(); err != nil {
+			return err
+		}
+	}
+	n, err := m.countMigrations(MigrationFilter{})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("unable to import: found existing migrations")
+	}
+	if br, ok := m.support.(BulkRecorder); ok {
+		return br.RecordMigrations(m.db, ms)
+	}
+	for _, mig := range ms {
+		if err := m.support.RecordMigration(m.db, mig); err != nil {
+			return fmt.Errorf("record migration: %s: %+v", mig, err)
+		}
+	}
+	return nil
+}
+
+func encodeHistory(w io.Writer, ms Migrations, format ExportFormat) error {
+	switch format {
+	case FormatJSON:
+		v := infoJSON{Migrations: make([]migrationJSON, 0, len(ms))}
+		for _, mig := range ms {
+			v.Migrations = append(v.Migrations, newMigrationJSON(mig))
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(csvHeader)
+		for _, mig := range ms {
+			v := newMigrationJSON(mig)
+			cw.Write([]string{strconv.Itoa(v.Rank), v.Version, v.Description, v.Type, v.Checksum, v.Date, strconv.FormatInt(v.ExecutionTime, 10), v.Status})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+func decodeHistory(r io.Reader, format ExportFormat) (Migrations, error) {
+	vs := []migrationJSON{}
+	switch format {
+	case FormatJSON:
+		v := infoJSON{}
+		if err := json.NewDecoder(r).Decode(&v); err != nil {
+			return nil, fmt.Errorf("decode history: %+v", err)
+		}
+		vs = v.Migrations
+	case FormatCSV:
+		records, err := csv.NewReader(r).ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("decode history: %+v", err)
+		}
+		for i, rec := range records {
+			if i == 0 {
+				continue
+			}
+			if len(rec) != len(csvHeader) {
+				return nil, fmt.Errorf("decode history: line %d: want %d fields, got %d", i+1, len(csvHeader), len(rec))
+			}
+			rank, err := strconv.Atoi(rec[0])
+			if err != nil {
+				return nil, fmt.Errorf("decode history: line %d: rank: %+v", i+1, err)
+			}
+			executionTime, err := strconv.ParseInt(rec[6], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("decode history: line %d: execution time: %+v", i+1, err)
+			}
+			vs = append(vs, migrationJSON{Rank: rank, Version: rec[1], Description: rec[2], Type: rec[3], Checksum: rec[4], Date: rec[5], ExecutionTime: executionTime, Status: rec[7]})
+		}
+	default:
+		return nil, fmt.Errorf("unknown format: %s", format)
+	}
+	ms := Migrations{}
+	for _, v := range vs {
+		mig, err := v.migration()
+		if err != nil {
+			return nil, fmt.Errorf("decode history: %+v", err)
+		}
+		ms = append(ms, mig)
+	}
+	return ms, nil
+}
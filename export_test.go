@@ -0,0 +1,34 @@
+package migrate
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExportImportHistory(t *testing.T) {
+	history := Migrations{
+		{Rank: 1, Version: "1", Description: "create users", Type: TypeSQL, Checksum: "a", Date: time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC), ExecutionTime: 12 * time.Millisecond, Status: StatusSuccess},
+		{Rank: 2, Version: VersionRepeatable, Description: "users, view", Type: TypeSQL, Checksum: "b", Date: time.Date(2024, 1, 31, 12, 0, 1, 0, time.UTC), ExecutionTime: 3 * time.Millisecond, Status: StatusSuccess},
+	}
+	for _, format := range []ExportFormat{FormatJSON, FormatCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			src := newTestMigrator(t, &memSupport{exists: true, migrations: history})
+			buf := &bytes.Buffer{}
+			if err := src.ExportHistory(buf, format); err != nil {
+				t.Fatalf("export: %v", err)
+			}
+			dst := &memSupport{}
+			if err := newTestMigrator(t, dst).ImportHistory(bytes.NewReader(buf.Bytes()), format); err != nil {
+				t.Fatalf("import: %v", err)
+			}
+			if !reflect.DeepEqual(history, dst.migrations) {
+				t.Errorf("want:\n%+v\ngot:\n%+v", history, dst.migrations)
+			}
+			if err := newTestMigrator(t, dst).ImportHistory(bytes.NewReader(buf.Bytes()), format); err == nil {
+				t.Errorf("want error for non-empty history")
+			}
+		})
+	}
+}
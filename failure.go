@@ -0,0 +1,39 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// withFailure records the message of err and the failing statement in mig. The message of a failed statement is
+// recorded without its position, which is kept in FailedStatement.
+func withFailure(mig Migration, err error) Migration {
+	mig.Failure = err.Error()
+	var se *StatementError
+	if errors.As(err, &se) {
+		mig.Failure = se.Err.Error()
+		mig.FailedStatement = se.Index + 1
+	}
+	return mig
+}
+
+// failure describes why a recorded migration failed or returns an empty string if unknown.
+func (m Migration) failure() string {
+	switch {
+	case m.Failure == "":
+		return ""
+	case m.FailedStatement > 0:
+		return fmt.Sprintf("statement %d: %s", m.FailedStatement, m.Failure)
+	default:
+		return m.Failure
+	}
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullInt(i int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(i), Valid: i != 0}
+}
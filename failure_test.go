@@ -0,0 +1,35 @@
+package migrate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMigrateRecordsFailure(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);")
+	log.fail = "CREATE TABLE b"
+	err := m.Migrate()
+	var se *StatementError
+	if !errors.As(err, &se) || se.Error() != "statement 2: fake: CREATE TABLE b (id INT);" {
+		t.Fatalf("want error of statement 2, got: %v", err)
+	}
+	rec := s.migrations[0]
+	if rec.Status != StatusFailed || rec.FailedStatement != 2 || rec.Failure != "fake: CREATE TABLE b (id INT);" {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	if want, got := "statement 2: fake: CREATE TABLE b (id INT);", rec.failure(); want != got {
+		t.Errorf("want failure: %q, got: %q", want, got)
+	}
+	err = m.Migrate()
+	if !errors.Is(err, ErrFailedMigrationDetected) || !strings.Contains(err.Error(), "statement 2: ") {
+		t.Fatalf("want failure detail, got: %v", err)
+	}
+	if info := m.Info().String(); !strings.Contains(info, "1 failed: statement 2: ") {
+		t.Errorf("want failure in info, got:\n%s", info)
+	}
+}
@@ -0,0 +1,105 @@
+package migrate
+
+import "database/sql"
+
+// Summary summarizes the versioned migrations recorded in the metadata table.
+type Summary struct {
+	// Versioned is the number of recorded versioned migrations, including baselines.
+	Versioned int
+	// Failed is the number of them that have failed.
+	Failed int
+	// Latest is the version of the one with the highest rank, VersionNone if there is none.
+	Latest Version
+}
+
+// Summarizer is implemented by Support implementations that summarize the recorded versioned migrations with a single
+// query, so that SetFastCheck does not load and scan every recorded migration.
+type Summarizer interface {
+	SummarizeMigrations(con *sql.DB) (Summary, error)
+}
+
+// SetFastCheck makes Migrate check whether the database is current before it locks the metadata table and lists the
+// recorded migrations, and return right away if it is, e.g. for services that call Migrate on every start. The check
+// is fast if the Support is a Summarizer: it reads the number of recorded versioned migrations and the latest of them
+// with a single query and only lists the records of repeatable migrations. If the database is current, nothing is
+// validated, no callbacks are called and the checksums of applied versioned migrations are not verified. The number and
+// latest version do not tell which versions have been applied, so the check is skipped by a Migrator that is set to
+// SetStrict or SetOutOfOrder.
+func (m *Migrator) SetFastCheck(fast bool) {
+	m.fastCheck = fast
+}
+
+// IsCurrent reports whether the database is current by the fast check of SetFastCheck. Unlike IsUpToDate, it may
+// report false for a current database whose recorded migrations cannot be matched by their number and latest
+// version, e.g. after a baseline or an out of order migration, or whose Migrator is set to SetStrict or SetOutOfOrder,
+// and Migrate does the full check.
+func (m *Migrator) IsCurrent() (bool, error) {
+	_, current, err := m.current()
+	return current, err
+}
+
+// current reports whether the database is current by the fast check and returns the summary of the recorded
+// migrations.
+func (m *Migrator) current() (Summary, bool, error) {
+	if m.strict || m.outOfOrder {
+		return Summary{}, false, nil
+	}
+	exists, err := m.support.ExistsMigrationsTable(m.db)
+	if err != nil || !exists {
+		return Summary{}, false, err
+	}
+	sum, err := m.summarize()
+	if err != nil {
+		return sum, false, err
+	}
+	migrations, repeatable := m.registered()
+	latest := VersionNone
+	for _, mig := range migrations {
+		latest = m.versionOrdering.later(latest, mig.Version)
+	}
+	if sum.Failed > 0 || sum.Versioned != len(migrations) || (latest != VersionNone && m.versionOrdering.compare(sum.Latest, latest) != 0) {
+		return sum, false, nil
+	}
+	if len(repeatable) == 0 {
+		return sum, true, nil
+	}
+	recorded, err := m.findMigrations(MigrationFilter{Repeatable: true})
+	if err != nil {
+		return sum, false, err
+	}
+	return sum, len(newInfo(nil, repeatable, recorded, m.versionOrdering).filter(StatePending, StateOutdated, StateFailed)) == 0, nil
+}
+
+// summarize summarizes the recorded versioned migrations. They are summarized by the Support if it is a Summarizer.
+func (m *Migrator) summarize() (Summary, error) {
+	if s, ok := m.support.(Summarizer); ok {
+		return s.SummarizeMigrations(m.db)
+	}
+	ms, err := m.findMigrations(MigrationFilter{Versioned: true})
+	if err != nil {
+		return Summary{}, err
+	}
+	sum := Summary{Versioned: len(ms)}
+	for _, mig := range ms {
+		if mig.Status == StatusFailed {
+			sum.Failed++
+		}
+		sum.Latest = mig.Version
+	}
+	return sum, nil
+}
+
+// checkFast returns whether the database is current if SetFastCheck is set and summarizes it in res.
+func (m *Migrator) checkFast(res *MigrationResult) (bool, error) {
+	if !m.fastCheck {
+		return false, nil
+	}
+	sum, current, err := m.current()
+	if err != nil || !current {
+		return false, err
+	}
+	res.UpToDate = sum.Versioned
+	res.Version = sum.Latest
+	m.log(LevelInfo, "database is current", Fields{"version": sum.Latest, "migrations": sum.Versioned})
+	return true, nil
+}
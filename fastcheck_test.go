@@ -0,0 +1,151 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+)
+
+// summarizingSupport summarizes the recorded migrations without listing them, like a Summarizer with a single query.
+type summarizingSupport struct {
+	memSupport
+	summaries int
+}
+
+func (s *summarizingSupport) SummarizeMigrations(con *sql.DB) (Summary, error) {
+	s.summaries++
+	sum := Summary{}
+	for _, mig := range s.migrations {
+		if mig.IsRepeatable() {
+			continue
+		}
+		sum.Versioned++
+		if mig.Status == StatusFailed {
+			sum.Failed++
+		}
+		sum.Latest = mig.Version
+	}
+	return sum, nil
+}
+
+func TestMigrateFastCheck(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &summarizingSupport{}
+	add := func(m *Migrator) {
+		m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+		m.AddSQLMigration("2", "two", "CREATE TABLE b (id INT);")
+		m.AddRepeatableSQLMigration("views", "CREATE VIEW v AS SELECT 1;")
+	}
+	m := NewMigrator(t.Logf, db, s)
+	add(m)
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	before := len(log.Statements())
+
+	m = NewMigrator(t.Logf, db, s)
+	m.SetFastCheck(true)
+	add(m)
+	res, err := m.MigrateWithResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.UpToDate != 2 || res.Version != "2" || s.summaries != 1 {
+		t.Errorf("want the fast check to report version 2, got: %+v, %d summaries", res, s.summaries)
+	}
+	if got := log.Statements()[before:]; len(got) != 0 {
+		t.Errorf("want no statements, got: %q", got)
+	}
+	if current, err := m.IsCurrent(); err != nil || !current {
+		t.Errorf("want current, got: %v, %v", current, err)
+	}
+
+	m.AddRepeatableSQLMigration("grants", "GRANT SELECT ON v TO reader;")
+	if current, err := m.IsCurrent(); err != nil || current {
+		t.Errorf("want a pending repeatable migration, got: %v, %v", current, err)
+	}
+	m.AddSQLMigration("3", "three", "CREATE TABLE c (id INT);")
+	if current, err := m.IsCurrent(); err != nil || current {
+		t.Errorf("want a pending versioned migration, got: %v, %v", current, err)
+	}
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := log.Statements()[before:]; len(got) != 2 {
+		t.Errorf("want the pending migrations applied, got: %q", got)
+	}
+}
+
+func TestFastCheckStrictOutOfOrder(t *testing.T) {
+	s := &summarizingSupport{}
+	s.exists = true
+	s.migrations = Migrations{
+		{Rank: 1, Version: "1", Description: "one", Type: TypeGo, Status: StatusSuccess},
+		{Rank: 2, Version: "3", Description: "three", Type: TypeGo, Status: StatusSuccess},
+		{Rank: 3, Version: "4", Description: "four", Type: TypeGo, Status: StatusSuccess},
+	}
+	for _, set := range []func(m *Migrator){
+		func(m *Migrator) {},
+		func(m *Migrator) { m.SetStrict(true) },
+		func(m *Migrator) { m.SetOutOfOrder(true) },
+	} {
+		m := newTestMigrator(t, s)
+		for _, v := range []Version{"1", "2", "4"} {
+			m.AddGoMigration(v, "step", func(con *sql.DB) error { return nil })
+		}
+		set(m)
+		current, err := m.IsCurrent()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := !m.strict && !m.outOfOrder; want != current {
+			t.Errorf("strict %v, out of order %v: want current: %v, got: %v", m.strict, m.outOfOrder, want, current)
+		}
+	}
+}
+
+func TestSQLiteSummarizeMigrations(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	log.rows = map[string][][]string{
+		`SELECT count(*), count(CASE WHEN status = ? THEN 1 END), (SELECT version FROM "migrations" WHERE version <> ? ORDER BY rank DESC LIMIT 1) FROM "migrations" WHERE version <> ?;`: {{"5000", "1", "5000"}},
+	}
+	sum, err := SQLiteSupport{}.SummarizeMigrations(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Summary{Versioned: 5000, Failed: 1, Latest: "5000"}); sum != want {
+		t.Errorf("want: %+v, got: %+v", want, sum)
+	}
+}
+
+// benchmarkStartup measures Migrate on a database with n applied migrations, as called on every start of a service.
+func benchmarkStartup(b *testing.B, n int, fast bool) {
+	db, _ := openFake(b.Name())
+	defer db.Close()
+	s := &summarizingSupport{}
+	m := NewMigrator(nil, db, s)
+	m.SetFastCheck(fast)
+	for i := 1; i <= n; i++ {
+		m.AddSQLMigration(Version(fmt.Sprint(i)), fmt.Sprintf("migration %d", i), fmt.Sprintf("CREATE TABLE t%d (id INT);", i))
+	}
+	if err := m.Migrate(); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := m.Migrate(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMigrateCurrent5k(b *testing.B) {
+	benchmarkStartup(b, 5000, false)
+}
+
+func BenchmarkMigrateCurrent5kFastCheck(b *testing.B) {
+	benchmarkStartup(b, 5000, true)
+}
@@ -0,0 +1,150 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// MigrationFinder is implemented by Support implementations that filter, order and count the recorded migrations in
+// the database, so that the Migrator does not load and scan every recorded migration where it needs only some of them,
+// e.g. the successfully applied versions, the latest records of the repeatable migrations or whether there are any.
+type MigrationFinder interface {
+	// FindMigrations lists the recorded migrations selected by f ordered by rank.
+	FindMigrations(con *sql.DB, f MigrationFilter) (Migrations, error)
+	// CountMigrations returns the number of recorded migrations selected by f.
+	CountMigrations(con *sql.DB, f MigrationFilter) (int, error)
+}
+
+// MigrationFilter selects recorded migrations. The zero value selects all of them.
+type MigrationFilter struct {
+	// AfterRank selects the migrations with a rank above AfterRank.
+	AfterRank int
+	// Versioned selects versioned migrations only.
+	Versioned bool
+	// Repeatable selects repeatable migrations only.
+	Repeatable bool
+	// Statuses selects the migrations with one of the statuses, any status if empty.
+	Statuses []Status
+	// Latest selects only the latest record of each repeatable migration, the one with the highest rank, and skips the
+	// records superseded by it. Migrate reads no others.
+	Latest bool
+}
+
+// filterColumns names the metadata table and its columns that a MigrationFilter selects by in the statements of a
+// Support.
+type filterColumns struct {
+	table       string
+	rank        string
+	version     string
+	description string
+	typ         string
+	status      string
+}
+
+// lowerCaseColumns returns the filterColumns of the metadata table named table with the column names of this package.
+func lowerCaseColumns(table string) filterColumns {
+	return filterColumns{table: table, rank: "rank", version: "version", description: "description", typ: "type", status: "status"}
+}
+
+// matches reports whether f selects mig.
+func (f MigrationFilter) matches(mig Migration) bool {
+	if mig.Rank <= f.AfterRank || (f.Versioned && mig.IsRepeatable()) || (f.Repeatable && !mig.IsRepeatable()) {
+		return false
+	}
+	if len(f.Statuses) == 0 {
+		return true
+	}
+	for _, s := range f.Statuses {
+		if mig.Status == s {
+			return true
+		}
+	}
+	return false
+}
+
+// where returns the WHERE clause, including a leading space, selecting the migrations of f by the columns c and its
+// arguments. placeholder returns the placeholder of the n-th argument, starting at 1.
+func (f MigrationFilter) where(c filterColumns, placeholder func(n int) string) (string, []interface{}) {
+	conditions := []string{}
+	args := []interface{}{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return placeholder(len(args))
+	}
+	if f.AfterRank > 0 {
+		conditions = append(conditions, c.rank+" > "+arg(f.AfterRank))
+	}
+	if f.Versioned {
+		conditions = append(conditions, c.version+" <> "+arg(string(VersionRepeatable)))
+	}
+	if f.Repeatable {
+		conditions = append(conditions, c.version+" = "+arg(string(VersionRepeatable)))
+	}
+	if f.Latest {
+		conditions = append(conditions, "("+c.version+" <> "+arg(string(VersionRepeatable))+" OR "+c.rank+" IN (SELECT MAX("+c.rank+") FROM "+c.table+
+			" WHERE "+c.version+" = "+arg(string(VersionRepeatable))+" GROUP BY "+c.description+", "+c.typ+"))")
+	}
+	if len(f.Statuses) > 0 {
+		ps := []string{}
+		for _, s := range f.Statuses {
+			ps = append(ps, arg(string(s)))
+		}
+		conditions = append(conditions, c.status+" IN ("+strings.Join(ps, ", ")+")")
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func questionMark(n int) string {
+	return "?"
+}
+
+func dollar(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func colon(n int) string {
+	return fmt.Sprintf(":%d", n)
+}
+
+// findMigrations lists the recorded migrations selected by f ordered by rank. They are filtered by the Support if it
+// is a MigrationFinder.
+func (m *Migrator) findMigrations(f MigrationFilter) (Migrations, error) {
+	if mf, ok := m.support.(MigrationFinder); ok {
+		return mf.FindMigrations(m.db, f)
+	}
+	installed, err := m.support.ListMigrations(m.db)
+	if err != nil {
+		return nil, err
+	}
+	sortByRank(installed)
+	latest := map[repeatableKey]int{}
+	for _, mig := range installed {
+		if mig.IsRepeatable() {
+			latest[keyOf(mig)] = mig.Rank
+		}
+	}
+	ms := Migrations{}
+	for _, mig := range installed {
+		if f.Latest && mig.IsRepeatable() && latest[keyOf(mig)] != mig.Rank {
+			continue
+		}
+		if f.matches(mig) {
+			ms = append(ms, mig)
+		}
+	}
+	return ms, nil
+}
+
+// countMigrations returns the number of recorded migrations selected by f. They are counted by the Support if it is
+// a MigrationFinder.
+func (m *Migrator) countMigrations(f MigrationFilter) (int, error) {
+	if mf, ok := m.support.(MigrationFinder); ok {
+		return mf.CountMigrations(m.db, f)
+	}
+	ms, err := m.findMigrations(f)
+	return len(ms), err
+}
@@ -0,0 +1,83 @@
+package migrate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMigrationFilter(t *testing.T) {
+	f := MigrationFilter{AfterRank: 2, Versioned: true, Statuses: []Status{StatusSuccess, StatusSkipped}}
+	where, args := f.where(lowerCaseColumns("migrations"), dollar)
+	if want := " WHERE rank > $1 AND version <> $2 AND status IN ($3, $4)"; where != want {
+		t.Errorf("want: %q, got: %q", want, where)
+	}
+	if want := []interface{}{2, "R", "success", "skipped"}; !reflect.DeepEqual(want, args) {
+		t.Errorf("want: %v, got: %v", want, args)
+	}
+	if where, args := (MigrationFilter{}).where(lowerCaseColumns("migrations"), dollar); where != "" || args != nil {
+		t.Errorf("want no clause, got: %q %v", where, args)
+	}
+
+	s := &reversedSupport{}
+	s.migrations = Migrations{
+		{Rank: 1, Version: "1", Status: StatusSuccess},
+		{Rank: 2, Version: VersionRepeatable, Status: StatusSuccess},
+		{Rank: 3, Version: "2", Status: StatusFailed},
+		{Rank: 4, Version: "3", Status: StatusSuccess},
+	}
+	m := newTestMigrator(t, s)
+	ms, err := m.findMigrations(MigrationFilter{AfterRank: 1, Versioned: true, Statuses: []Status{StatusSuccess}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 1 || ms[0].Rank != 4 {
+		t.Errorf("unexpected migrations: %v", ms)
+	}
+	if n, err := m.countMigrations(MigrationFilter{Versioned: true}); err != nil || n != 3 {
+		t.Errorf("want 3 versioned migrations, got: %d, %v", n, err)
+	}
+}
+
+func TestMigrationFilterLatest(t *testing.T) {
+	where, args := MigrationFilter{Latest: true}.where(lowerCaseColumns(`"migrations"`), questionMark)
+	if want := ` WHERE (version <> ? OR rank IN (SELECT MAX(rank) FROM "migrations" WHERE version = ? GROUP BY description, type))`; where != want {
+		t.Errorf("want: %q, got: %q", want, where)
+	}
+	if want := []interface{}{"R", "R"}; !reflect.DeepEqual(want, args) {
+		t.Errorf("want: %v, got: %v", want, args)
+	}
+
+	s := &reversedSupport{}
+	s.migrations = Migrations{
+		{Rank: 1, Version: "1", Status: StatusSuccess},
+		{Rank: 2, Version: VersionRepeatable, Description: "views", Type: TypeSQL, Status: StatusSuccess},
+		{Rank: 3, Version: VersionRepeatable, Description: "grants", Type: TypeSQL, Status: StatusSuccess},
+		{Rank: 4, Version: VersionRepeatable, Description: "views", Type: TypeSQL, Status: StatusFailed},
+		{Rank: 5, Version: "2", Status: StatusSuccess},
+	}
+	m := newTestMigrator(t, s)
+	ms, err := m.findMigrations(MigrationFilter{Latest: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ranks := []int{}
+	for _, mig := range ms {
+		ranks = append(ranks, mig.Rank)
+	}
+	if want := []int{1, 3, 4, 5}; !reflect.DeepEqual(want, ranks) {
+		t.Errorf("want ranks: %v, got: %v", want, ranks)
+	}
+}
+
+func TestSQLiteCountMigrations(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	log.results = map[string]string{`SELECT count(*) FROM "migrations" WHERE version <> ?;`: "7"}
+	n, err := SQLiteSupport{}.CountMigrations(db, MigrationFilter{Versioned: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 7 {
+		t.Errorf("want 7, got: %d", n)
+	}
+}
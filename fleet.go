@@ -0,0 +1,126 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// Tenant is one of the databases (or schemas) of a Fleet.
+type Tenant struct {
+	ID      string
+	DB      *sql.DB
+	Support Support
+}
+
+// Provisioner creates the database or schema of a new tenant.
+type Provisioner interface {
+	Provision(tenantID string) (Tenant, error)
+}
+
+// ProvisionerFunc is a function that implements Provisioner.
+type ProvisionerFunc func(tenantID string) (Tenant, error)
+
+func (f ProvisionerFunc) Provision(tenantID string) (Tenant, error) {
+	return f(tenantID)
+}
+
+// Inventory keeps track of the tenants of a Fleet.
+type Inventory interface {
+	Register(t Tenant) error
+	Tenants() ([]Tenant, error)
+}
+
+// MemoryInventory is an Inventory that is held in memory.
+type MemoryInventory struct {
+	mu      sync.Mutex
+	tenants []Tenant
+}
+
+func (i *MemoryInventory) Register(t Tenant) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, e := range i.tenants {
+		if e.ID == t.ID {
+			return fmt.Errorf("tenant already registered: %s", t.ID)
+		}
+	}
+	i.tenants = append(i.tenants, t)
+	return nil
+}
+
+func (i *MemoryInventory) Tenants() ([]Tenant, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return append([]Tenant{}, i.tenants...), nil
+}
+
+// Fleet applies one migration set to many tenant databases.
+type Fleet struct {
+	logger      Logger
+	provisioner Provisioner
+	inventory   Inventory
+	register    func(m *Migrator)
+}
+
+// NewFleet creates a Fleet. register adds the migration set to the Migrator of a tenant.
+func NewFleet(logger Logger, provisioner Provisioner, inventory Inventory, register func(m *Migrator)) *Fleet {
+	return &Fleet{
+		logger:      logger,
+		provisioner: provisioner,
+		inventory:   inventory,
+		register:    register,
+	}
+}
+
+// Migrator returns a Migrator for t with the migration set of the fleet.
+func (f *Fleet) Migrator(t Tenant) *Migrator {
+	m := NewMigrator(nil, t.DB, t.Support)
+	m.SetLogger(fieldLogger{key: "tenant", value: t.ID, logger: f.logger})
+	f.register(m)
+	return m
+}
+
+// Onboard provisions the database of a new tenant, applies the full migration set and registers the tenant in the inventory.
+// A tenant that is already registered is neither provisioned nor migrated again.
+func (f *Fleet) Onboard(tenantID string) (Tenant, error) {
+	ts, err := f.inventory.Tenants()
+	if err != nil {
+		return Tenant{}, err
+	}
+	for _, t := range ts {
+		if t.ID == tenantID {
+			return Tenant{}, fmt.Errorf("tenant already registered: %s", tenantID)
+		}
+	}
+	t, err := f.provisioner.Provision(tenantID)
+	if err != nil {
+		return Tenant{}, fmt.Errorf("provision tenant: %s: %+v", tenantID, err)
+	}
+	t.ID = tenantID
+	if err := f.Migrator(t).Migrate(); err != nil {
+		return t, fmt.Errorf("migrate tenant: %s: %+v", tenantID, err)
+	}
+	if err := f.inventory.Register(t); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+// fieldLogger adds a field, e.g. the tenant, to every log entry.
+type fieldLogger struct {
+	key    string
+	value  string
+	logger Logger
+}
+
+func (l fieldLogger) Log(level Level, msg string, fields Fields) {
+	if l.logger == nil {
+		return
+	}
+	fs := Fields{l.key: l.value}
+	for k, v := range fields {
+		fs[k] = v
+	}
+	l.logger.Log(level, msg, fs)
+}
@@ -0,0 +1,77 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+)
+
+func TestFleetOnboard(t *testing.T) {
+	supports := map[string]*memSupport{}
+	inventory := &MemoryInventory{}
+	provisioned := 0
+	f := NewFleet(LogFunc(t.Logf), ProvisionerFunc(func(id string) (Tenant, error) {
+		provisioned++
+		s := &memSupport{}
+		supports[id] = s
+		return Tenant{Support: s}, nil
+	}), inventory, func(m *Migrator) {
+		m.AddGoMigration("1", "init", func(con *sql.DB) error { return nil })
+	})
+	if _, err := f.Onboard("acme"); err != nil {
+		t.Fatalf("onboard: %v", err)
+	}
+	if _, err := f.Onboard("acme"); err == nil {
+		t.Errorf("want error for duplicate tenant")
+	}
+	if provisioned != 1 {
+		t.Errorf("want the duplicate tenant not to be provisioned, got %d provisions", provisioned)
+	}
+	if got := len(supports["acme"].migrations); got != 1 {
+		t.Errorf("want 1 migration, got %d", got)
+	}
+	if ts, _ := inventory.Tenants(); len(ts) != 1 || ts[0].ID != "acme" {
+		t.Errorf("unexpected tenants: %v", ts)
+	}
+}
+
+func TestFleetMigrateAll(t *testing.T) {
+	inventory := &MemoryInventory{}
+	for _, id := range []string{"a", "b", "c", "d"} {
+		inventory.Register(Tenant{ID: id, Support: &memSupport{}})
+	}
+	f := NewFleet(LogFunc(t.Logf), nil, inventory, func(m *Migrator) {
+		m.AddGoMigration("1", "init", func(con *sql.DB) error { return nil })
+	})
+	results, err := f.MigrateAll(ParallelOptions{Workers: 2})
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	for _, r := range results {
+		if r.Err != nil || r.Skipped || len(r.Tenant.Support.(*memSupport).migrations) != 1 {
+			t.Errorf("unexpected result: %+v", r)
+		}
+	}
+
+	f = NewFleet(LogFunc(t.Logf), nil, inventory, func(m *Migrator) {
+		m.AddGoMigration("2", "fail", func(con *sql.DB) error { return fmt.Errorf("fail") })
+	})
+	results, err = f.MigrateAll(ParallelOptions{Workers: 1, Policy: StopOnFailure})
+	if err == nil {
+		t.Fatalf("want error")
+	}
+	if results[0].Err == nil {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	for _, r := range results[1:] {
+		if !r.Skipped || len(r.Tenant.Support.(*memSupport).migrations) != 1 {
+			t.Errorf("want tenant %s skipped, got: %+v", r.Tenant.ID, r)
+		}
+	}
+	results, _ = f.MigrateAll(ParallelOptions{Workers: 4, Policy: ContinueOnFailure})
+	for _, r := range results {
+		if r.Err == nil || r.Skipped {
+			t.Errorf("unexpected result: %+v", r)
+		}
+	}
+}
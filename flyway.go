@@ -0,0 +1,136 @@
+package migrate
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// FlywayTable is the default name of Flyway's schema history table.
+const FlywayTable = "flyway_schema_history"
+
+// ImportFlyway copies the rows of a Flyway schema history table into the empty metadata table,
+// so that Migrate continues from where Flyway left off.
+// Flyway's CRC32 checksums are not comparable to the checksums of this package and are not imported.
+// Imported versioned migrations are therefore not verified and imported repeatable migrations are applied once more.
+// Flyway writes dotted versions like 1.1 for scripts named V1_1__..., so an imported version is spelled like the
+// registered migration it compares as equal to, see VersionOrdering.Compare.
+func (m *Migrator) ImportFlyway(table string) error {
+	return m.withLock(func() error {
+		return m.importFlyway(table)
+	})
+}
+
+func (m *Migrator) importFlyway(table string) error {
+	ms, err := listFlywayMigrations(m.db, table)
+	if err != nil {
+		return err
+	}
+	migrations, _ := m.registered()
+	for i, mig := range ms {
+		ms[i].Version = m.normalizeVersion(migrations, mig.Version)
+	}
+	if err := m.importMigrations(ms); err != nil {
+		return err
+	}
+	m.log(LevelInfo, "imported flyway history", Fields{"table": table, "count": len(ms)})
+	return nil
+}
+
+// normalizeVersion returns the version of the migration among migrations that compares as equal to v, v otherwise.
+func (m *Migrator) normalizeVersion(migrations Migrations, v Version) Version {
+	if v == VersionRepeatable {
+		return v
+	}
+	for _, mig := range migrations {
+		if m.versionOrdering.compare(mig.Version, v) == 0 {
+			return mig.Version
+		}
+	}
+	return v
+}
+
+func listFlywayMigrations(con *sql.DB, table string) (Migrations, error) {
+	rows, err := con.Query(`SELECT installed_rank, version, description, type, installed_on, execution_time, success FROM ` + table + ` ORDER BY installed_rank;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ms := Migrations{}
+	for rows.Next() {
+		var rank int
+		var version sql.NullString
+		var description string
+		var typ string
+		var installedOn interface{}
+		var executionTime int64
+		var success bool
+		if err := rows.Scan(&rank, &version, &description, &typ, &installedOn, &executionTime, &success); err != nil {
+			return nil, err
+		}
+		t, ok := flywayType(typ)
+		if !ok {
+			continue
+		}
+		mig := Migration{
+			Rank:          rank,
+			Version:       VersionRepeatable,
+			Description:   description,
+			Type:          t,
+			Date:          flywayTime(installedOn),
+			ExecutionTime: fromMilliseconds(executionTime),
+			Status:        StatusFailed,
+		}
+		if version.Valid {
+			mig.Version = Version(strings.TrimSpace(version.String))
+		}
+		if success {
+			mig.Status = StatusSuccess
+		}
+		ms = append(ms, mig)
+	}
+	return ms, rows.Err()
+}
+
+// flywayType maps a Flyway migration type. Rows of types that have no equivalent (undo and delete markers) are skipped.
+func flywayType(typ string) (Type, bool) {
+	switch typ {
+	case "SQL", "SCRIPT":
+		return TypeSQL, true
+	case "JDBC", "SPRING_JDBC", "JAVA":
+		return TypeGo, true
+	case "BASELINE", "SQL_BASELINE", "JDBC_BASELINE", "SCHEMA":
+		return TypeBaseline, true
+	case "UNDO_SQL", "UNDO_JDBC", "UNDO_SCRIPT", "DELETE":
+		return "", false
+	default:
+		return Type(typ), true
+	}
+}
+
+var flywayTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+}
+
+// flywayTime converts installed_on, which drivers report either as time.Time or as text.
+func flywayTime(v interface{}) time.Time {
+	var s string
+	switch t := v.(type) {
+	case time.Time:
+		return t.UTC()
+	case string:
+		s = t
+	case []byte:
+		s = string(t)
+	default:
+		return time.Time{}
+	}
+	for _, layout := range flywayTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,58 @@
+package migrate
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFlywayTime(t *testing.T) {
+	want := time.Date(2019, 3, 4, 10, 11, 12, 0, time.UTC)
+	tests := []struct {
+		name string
+		in   interface{}
+	}{
+		{"time", want},
+		{"rfc3339", "2019-03-04T10:11:12Z"},
+		{"timestamp", "2019-03-04 10:11:12"},
+		{"bytes", []byte("2019-03-04 10:11:12.000")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := flywayTime(test.in); !got.Equal(want) {
+				t.Errorf("want: %s, got: %s", want, got)
+			}
+		})
+	}
+}
+
+func TestImportFlywayDottedVersions(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	log.rows = map[string][][]string{
+		`SELECT installed_rank, version, description, type, installed_on, execution_time, success FROM flyway_schema_history ORDER BY installed_rank;`: {
+			{"1", "1", "create users", "SQL", "2024-01-31 12:00:00", "10", "true"},
+			{"2", "1.1", "add email", "SQL", "2024-01-31 12:00:01", "20", "true"},
+			{"3", "1.2", "add phone", "SQL", "2024-01-31 12:00:02", "30", "true"},
+			{"4", "1.10", "add index", "SQL", "2024-01-31 12:00:03", "40", "true"},
+		},
+	}
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	noop := func(con *sql.DB) error { return nil }
+	m.AddGoMigration("1", "create users", noop)
+	m.AddGoMigration("1_1", "add email", noop)
+	m.AddGoMigration("1_2", "add phone", noop)
+	m.AddGoMigration("1_10", "add index", noop)
+	m.AddGoMigration("1_11", "add view", noop)
+	if err := m.ImportFlyway(FlywayTable); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := versions(s.migrations), []Version{"1", "1_1", "1_2", "1_10"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if got, want := versions(m.Info().Pending()), []Version{"1_11"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("want pending: %v, got: %v", want, got)
+	}
+}
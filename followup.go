@@ -0,0 +1,57 @@
+package migrate
+
+import (
+	"time"
+)
+
+// FollowUp is an obligation of a migration that has to be resolved by a later one, e.g. dropping a column
+// once it is no longer read after the expand half of an expand/contract change.
+type FollowUp struct {
+	Note string
+	// Within is the time after the migration has been applied in which the follow-up is due.
+	Within time.Duration
+}
+
+// WithFollowUp declares a follow-up that is due within the given time after the migration has been applied.
+func WithFollowUp(note string, within time.Duration) MigrationOption {
+	return func(o *MigrationOptions) {
+		o.FollowUp = &FollowUp{Note: note, Within: within}
+	}
+}
+
+// Resolves marks the migration as the follow-up of the given versions.
+func Resolves(versions ...Version) MigrationOption {
+	return func(o *MigrationOptions) {
+		o.Resolves = append(o.Resolves, versions...)
+	}
+}
+
+// Overdue returns the applied migrations whose follow-up is due at now without a known migration resolving it.
+func (i Info) Overdue(now time.Time) Migrations {
+	resolved := map[Version]bool{}
+	for _, mig := range i.Migrations {
+		for _, v := range mig.Options.Resolves {
+			resolved[v] = true
+		}
+	}
+	ms := Migrations{}
+	for _, mig := range i.Migrations {
+		fu := mig.Options.FollowUp
+		if fu == nil || mig.State != StateApplied || resolved[mig.Version] {
+			continue
+		}
+		if now.After(mig.Date.Add(fu.Within)) {
+			ms = append(ms, mig)
+		}
+	}
+	return ms
+}
+
+func (m *Migrator) warnOverdue(overdue Migrations) {
+	for _, mig := range overdue {
+		fields := migrationFields(mig)
+		fields["follow_up"] = mig.Options.FollowUp.Note
+		fields["due"] = mig.Date.Add(mig.Options.FollowUp.Within).Format(time.RFC3339)
+		m.log(LevelWarn, "follow-up overdue", fields)
+	}
+}
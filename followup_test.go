@@ -0,0 +1,27 @@
+package migrate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInfoOverdue(t *testing.T) {
+	applied := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	local := Migrations{
+		NewSQLMigration("1", "add email", "", WithFollowUp("drop mail column", 30*24*time.Hour)),
+		NewSQLMigration("2", "add name", "", WithFollowUp("drop first_name column", 30*24*time.Hour)),
+		NewSQLMigration("3", "drop first_name", "", Resolves("2")),
+	}
+	installed := Migrations{
+		{Rank: 1, Version: "1", Description: "add email", Checksum: local[0].Checksum, Date: applied, Status: StatusSuccess},
+		{Rank: 2, Version: "2", Description: "add name", Checksum: local[1].Checksum, Date: applied, Status: StatusSuccess},
+	}
+	info := newInfo(local, nil, installed, versionOrder{})
+	if overdue := info.Overdue(applied.Add(24 * time.Hour)); len(overdue) != 0 {
+		t.Errorf("want no overdue follow-ups, got:\n%s", overdue)
+	}
+	overdue := info.Overdue(applied.Add(31 * 24 * time.Hour))
+	if len(overdue) != 1 || overdue[0].Version != "1" {
+		t.Errorf("want overdue follow-up of 1, got:\n%s", overdue)
+	}
+}
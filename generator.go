@@ -0,0 +1,70 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// GenerateOptions control the scripts created by GenerateMigration.
+type GenerateOptions struct {
+	// Repeatable creates a repeatable instead of a versioned migration.
+	Repeatable bool
+	// Undo additionally creates an undo script for a versioned migration.
+	Undo bool
+}
+
+// GenerateMigration creates empty scripts for a new migration in dir and returns their paths.
+// Versioned migrations get a timestamp version higher than any existing one.
+func GenerateMigration(dir string, description string, opts GenerateOptions) ([]string, error) {
+	return generateMigration(dir, description, opts, time.Now())
+}
+
+func generateMigration(dir string, description string, opts GenerateOptions, now time.Time) ([]string, error) {
+	if fileDescription(description) == "" {
+		return nil, fmt.Errorf("missing description")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	existing, err := ListMigrationFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	fs := []MigrationFile{}
+	if opts.Repeatable {
+		fs = append(fs, MigrationFile{Prefix: PrefixRepeatable, Version: VersionRepeatable, Description: description})
+	} else {
+		ms := Migrations{}
+		for _, f := range existing {
+			ms = append(ms, Migration{Version: f.Version})
+		}
+		version := NextTimestampVersion(ms, now)
+		fs = append(fs, MigrationFile{Prefix: PrefixVersioned, Version: version, Description: description})
+		if opts.Undo {
+			fs = append(fs, MigrationFile{Prefix: PrefixUndo, Version: version, Description: description})
+		}
+	}
+	paths := []string{}
+	for _, f := range fs {
+		path := filepath.Join(dir, f.Name())
+		content := fmt.Sprintf("-- %s\n", description)
+		if f.Prefix == PrefixUndo {
+			content = fmt.Sprintf("-- undo: %s\n", description)
+		}
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return paths, err
+		}
+		_, err = file.WriteString(content)
+		if cErr := file.Close(); err == nil {
+			err = cErr
+		}
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
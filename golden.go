@@ -0,0 +1,119 @@
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// SchemaMismatchError reports a schema that differs from its golden file.
+type SchemaMismatchError struct {
+	File string
+	// Diff lists the lines of the golden file missing from the schema prefixed with "-" and the additional lines of
+	// the schema prefixed with "+", surrounded by unchanged lines.
+	Diff string
+}
+
+func (e *SchemaMismatchError) Error() string {
+	return fmt.Sprintf("%v: %s:\n%s", ErrSchemaMismatch, e.File, e.Diff)
+}
+
+func (e *SchemaMismatchError) Unwrap() error {
+	return ErrSchemaMismatch
+}
+
+// VerifySchema compares the dump of the schema with the golden file, typically after Migrate in a test. It reports a
+// *SchemaMismatchError if they differ, e.g. because the code of a Go migration has been changed instead of adding a
+// new migration. The Support has to implement SchemaDumper.
+func (m *Migrator) VerifySchema(golden string) error {
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		return fmt.Errorf("read golden schema: %+v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := m.DumpSchema(buf); err != nil {
+		return fmt.Errorf("dump schema: %+v", err)
+	}
+	if bytes.Equal(want, buf.Bytes()) {
+		return nil
+	}
+	return &SchemaMismatchError{File: golden, Diff: diffLines(string(want), buf.String())}
+}
+
+// WriteGoldenSchema writes the dump of the schema to the golden file, e.g. after an intended change of the schema.
+func (m *Migrator) WriteGoldenSchema(golden string) error {
+	buf := &bytes.Buffer{}
+	if err := m.DumpSchema(buf); err != nil {
+		return fmt.Errorf("dump schema: %+v", err)
+	}
+	return ioutil.WriteFile(golden, buf.Bytes(), 0644)
+}
+
+// diffContext is the number of unchanged lines shown around changed lines.
+const diffContext = 2
+
+// diffLines returns a line diff of a and b, see SchemaMismatchError.Diff.
+func diffLines(a string, b string) string {
+	as := strings.Split(strings.TrimSuffix(a, "\n"), "\n")
+	bs := strings.Split(strings.TrimSuffix(b, "\n"), "\n")
+	// lcs[i][j] is the length of the longest common subsequence of as[i:] and bs[j:]
+	lcs := make([][]int, len(as)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bs)+1)
+	}
+	for i := len(as) - 1; i >= 0; i-- {
+		for j := len(bs) - 1; j >= 0; j-- {
+			switch {
+			case as[i] == bs[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	type line struct {
+		op   byte
+		text string
+	}
+	lines := []line{}
+	i, j := 0, 0
+	for i < len(as) || j < len(bs) {
+		switch {
+		case i < len(as) && j < len(bs) && as[i] == bs[j]:
+			lines = append(lines, line{' ', as[i]})
+			i++
+			j++
+		case j == len(bs) || (i < len(as) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, line{'-', as[i]})
+			i++
+		default:
+			lines = append(lines, line{'+', bs[j]})
+			j++
+		}
+	}
+	show := make([]bool, len(lines))
+	for k, l := range lines {
+		if l.op == ' ' {
+			continue
+		}
+		for c := k - diffContext; c <= k+diffContext; c++ {
+			if c >= 0 && c < len(lines) {
+				show[c] = true
+			}
+		}
+	}
+	buf := &bytes.Buffer{}
+	for k, l := range lines {
+		if !show[k] {
+			if k > 0 && show[k-1] {
+				buf.WriteString("...\n")
+			}
+			continue
+		}
+		fmt.Fprintf(buf, "%c %s\n", l.op, l.text)
+	}
+	return buf.String()
+}
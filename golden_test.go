@@ -0,0 +1,47 @@
+package migrate
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifySchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	golden := filepath.Join(dir, "schema.sql")
+	m := newTestMigrator(t, &dumpSupport{})
+	if err := m.VerifySchema(golden); err == nil {
+		t.Errorf("want error for missing golden file")
+	}
+	if err := m.WriteGoldenSchema(golden); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.VerifySchema(golden); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	ioutil.WriteFile(golden, []byte("CREATE TABLE accounts (id INT);\nCREATE TABLE users (id BIGINT);\n"), 0644)
+	err = m.VerifySchema(golden)
+	var mismatch *SchemaMismatchError
+	if !errors.Is(err, ErrSchemaMismatch) || !errors.As(err, &mismatch) {
+		t.Fatalf("want schema mismatch, got: %v", err)
+	}
+	want := "- CREATE TABLE accounts (id INT);\n- CREATE TABLE users (id BIGINT);\n+ CREATE TABLE users (id INT);\n"
+	if mismatch.Diff != want {
+		t.Errorf("want: %q, got: %q", want, mismatch.Diff)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+	b := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n"
+	want := "  3\n  4\n- 5\n+ five\n  6\n  7\n...\n"
+	if got := diffLines(a, b); got != want {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
@@ -0,0 +1,156 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Dialects of the scripts rendered by GrantScript.
+const (
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+)
+
+// GrantSpec declares the privileges of a role on tables, e.g. read access of a reporting role:
+//
+//	GrantSpec{Role: "reporting", Privileges: []string{"SELECT"}, Schema: "app"}
+//
+// Grants on a schema cover its existing and future tables, so that new tables do not need the same boilerplate GRANT.
+type GrantSpec struct {
+	// Role is the grantee. For MySQL it is quoted as 'role' unless it contains an @, e.g. 'app'@'%'.
+	Role string `json:"role"`
+	// Privileges are the granted privileges, e.g. SELECT, INSERT, UPDATE, DELETE or ALL PRIVILEGES.
+	Privileges []string `json:"privileges"`
+	// Schema is the schema whose tables the privileges are granted on, in MySQL the database.
+	Schema string `json:"schema,omitempty"`
+	// Tables are the tables the privileges are granted on, qualified by Schema if it is set.
+	Tables []string `json:"tables,omitempty"`
+	// Owner makes the role the owner of Tables. It is only supported by Postgres.
+	Owner bool `json:"owner,omitempty"`
+}
+
+var privilege = regexp.MustCompile(`^[A-Za-z][A-Za-z ]*$`)
+
+// LoadGrants decodes grant specs from a JSON array, e.g. a configuration file kept next to the migrations.
+func LoadGrants(r io.Reader) ([]GrantSpec, error) {
+	specs := []GrantSpec{}
+	if err := json.NewDecoder(r).Decode(&specs); err != nil {
+		return nil, fmt.Errorf("decode grants: %+v", err)
+	}
+	return specs, nil
+}
+
+// GrantScript renders the statements that apply specs in dialect, DialectPostgres or DialectMySQL. The statements
+// are idempotent, so the script can be applied again whenever the specs change.
+func GrantScript(dialect string, specs ...GrantSpec) (string, error) {
+	b := &strings.Builder{}
+	for _, spec := range specs {
+		if spec.Role == "" {
+			return "", fmt.Errorf("grant: missing role")
+		}
+		if spec.Schema == "" && len(spec.Tables) == 0 {
+			return "", fmt.Errorf("grant: %s: missing schema or tables", spec.Role)
+		}
+		for _, p := range spec.Privileges {
+			if !privilege.MatchString(p) {
+				return "", fmt.Errorf("grant: %s: invalid privilege: %q", spec.Role, p)
+			}
+		}
+		var err error
+		switch dialect {
+		case DialectPostgres:
+			postgresGrants(b, spec)
+		case DialectMySQL:
+			err = mysqlGrants(b, spec)
+		default:
+			err = fmt.Errorf("grant: unsupported dialect: %s", dialect)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+func postgresGrants(b *strings.Builder, spec GrantSpec) {
+	role := quoteIdent(spec.Role)
+	privileges := strings.ToUpper(strings.Join(spec.Privileges, ", "))
+	tables := make([]string, len(spec.Tables))
+	for i, t := range spec.Tables {
+		tables[i] = quoteIdent(t)
+		if spec.Schema != "" {
+			tables[i] = quoteIdent(spec.Schema) + "." + tables[i]
+		}
+	}
+	if spec.Schema != "" {
+		fmt.Fprintf(b, "GRANT USAGE ON SCHEMA %s TO %s;\n", quoteIdent(spec.Schema), role)
+	}
+	if len(spec.Privileges) > 0 {
+		if len(tables) > 0 {
+			fmt.Fprintf(b, "GRANT %s ON TABLE %s TO %s;\n", privileges, strings.Join(tables, ", "), role)
+		} else {
+			fmt.Fprintf(b, "GRANT %s ON ALL TABLES IN SCHEMA %s TO %s;\n", privileges, quoteIdent(spec.Schema), role)
+			fmt.Fprintf(b, "ALTER DEFAULT PRIVILEGES IN SCHEMA %s GRANT %s ON TABLES TO %s;\n", quoteIdent(spec.Schema), privileges, role)
+		}
+	}
+	if spec.Owner {
+		for _, t := range tables {
+			fmt.Fprintf(b, "ALTER TABLE %s OWNER TO %s;\n", t, role)
+		}
+	}
+}
+
+func mysqlGrants(b *strings.Builder, spec GrantSpec) error {
+	if spec.Owner {
+		return fmt.Errorf("grant: %s: ownership is not supported by %s", spec.Role, DialectMySQL)
+	}
+	if len(spec.Privileges) == 0 {
+		return nil
+	}
+	role := spec.Role
+	if !strings.Contains(role, "@") {
+		role = sqlLiteral(role)
+	}
+	privileges := strings.ToUpper(strings.Join(spec.Privileges, ", "))
+	if len(spec.Tables) == 0 {
+		fmt.Fprintf(b, "GRANT %s ON %s.* TO %s;\n", privileges, quoteMySQL(spec.Schema), role)
+		return nil
+	}
+	for _, t := range spec.Tables {
+		table := quoteMySQL(t)
+		if spec.Schema != "" {
+			table = quoteMySQL(spec.Schema) + "." + table
+		}
+		fmt.Fprintf(b, "GRANT %s ON %s TO %s;\n", privileges, table, role)
+	}
+	return nil
+}
+
+func quoteMySQL(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+// AddGrants adds a repeatable SQL migration that applies specs in the dialect of the Support after the versioned
+// migrations. It is applied again whenever the specs change. The Support has to be a DialectSupport of Postgres or
+// MySQL, e.g. CockroachSupport.
+func (m *Migrator) AddGrants(description string, specs ...GrantSpec) error {
+	ds, ok := m.support.(DialectSupport)
+	if !ok {
+		return fmt.Errorf("grants require a support with a dialect: %T", m.support)
+	}
+	dialect := strings.Join(ds.Dialects(), ", ")
+	for _, d := range ds.Dialects() {
+		if d == DialectPostgres || d == DialectMySQL {
+			dialect = d
+		}
+	}
+	script, err := GrantScript(dialect, specs...)
+	if err != nil {
+		return err
+	}
+	m.AddRepeatableSQLMigration(description, script)
+	return nil
+}
@@ -0,0 +1,66 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGrantScript(t *testing.T) {
+	specs, err := LoadGrants(strings.NewReader(`[
+		{"role": "reporting", "privileges": ["select"], "schema": "app"},
+		{"role": "app", "privileges": ["SELECT", "INSERT"], "schema": "app", "tables": ["users", "orders"], "owner": true}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := GrantScript(DialectPostgres, specs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `GRANT USAGE ON SCHEMA "app" TO "reporting";
+GRANT SELECT ON ALL TABLES IN SCHEMA "app" TO "reporting";
+ALTER DEFAULT PRIVILEGES IN SCHEMA "app" GRANT SELECT ON TABLES TO "reporting";
+GRANT USAGE ON SCHEMA "app" TO "app";
+GRANT SELECT, INSERT ON TABLE "app"."users", "app"."orders" TO "app";
+ALTER TABLE "app"."users" OWNER TO "app";
+ALTER TABLE "app"."orders" OWNER TO "app";
+`
+	if got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+
+	if _, err := GrantScript(DialectMySQL, specs...); err == nil {
+		t.Errorf("want error for ownership in MySQL")
+	}
+	got, err = GrantScript(DialectMySQL, specs[0], GrantSpec{Role: "'app'@'%'", Privileges: []string{"UPDATE"}, Tables: []string{"users"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "GRANT SELECT ON `app`.* TO 'reporting';\nGRANT UPDATE ON `users` TO 'app'@'%';\n"
+	if got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+
+	if _, err := GrantScript(DialectPostgres, GrantSpec{Role: "r", Privileges: []string{"SELECT; DROP TABLE users"}, Schema: "app"}); err == nil {
+		t.Errorf("want error for an invalid privilege")
+	}
+}
+
+func TestAddGrants(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &dialectSupport{dialects: []string{"cockroach", "postgres"}}
+	m := NewMigrator(t.Logf, db, s)
+	if err := m.AddGrants("grants", GrantSpec{Role: "reporting", Privileges: []string{"SELECT"}, Tables: []string{"users"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := log.Statements(); len(got) != 1 || got[0] != `GRANT SELECT ON TABLE "users" TO "reporting";` {
+		t.Errorf("unexpected statements: %q", got)
+	}
+	if err := newTestMigrator(t, &memSupport{}).AddGrants("grants"); err == nil {
+		t.Errorf("want error for a support without dialect")
+	}
+}
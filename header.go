@@ -0,0 +1,112 @@
+package migrate
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// ScriptHeader is the metadata declared by the leading comments of a SQL script:
+//
+//	-- author: jane
+//	-- ticket: OPS-123
+//	-- requires: auth@5, billing@2
+//	-- tags: data, long-running
+//	-- allow-destructive: true
+//	-- requires-version: 12
+//	-- rebuilds-tables: true
+//	CREATE TABLE invoices (...);
+//
+// Keys are case insensitive, unknown keys are ignored. Requires lists migrations of other components of a
+// MultiMigrator as component@version, see Requires. Tags label the migration, see Tags. AllowDestructive marks its
+// destructive statements as intended, see AllowDestructive. RequiresVersion is the version of the schema a
+// repeatable migration waits for, see RequiresVersion. RebuildsTables disables the enforcement of foreign keys while
+// the migration runs, see RebuildsTables. The header ends at the first line that is neither blank nor a comment.
+type ScriptHeader struct {
+	Author           string
+	Ticket           string
+	Requires         []Requirement
+	Tags             []string
+	AllowDestructive bool
+	RequiresVersion  Version
+	RebuildsTables   bool
+}
+
+// ParseScriptHeader parses the header of script.
+func ParseScriptHeader(script string) ScriptHeader {
+	return readScriptHeader(strings.NewReader(script))
+}
+
+// readScriptHeader parses the header of the script read from r. Only the header is read.
+func readScriptHeader(r io.Reader) ScriptHeader {
+	h := ScriptHeader{}
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "--") {
+			break
+		}
+		parts := strings.SplitN(strings.TrimPrefix(line, "--"), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+		switch strings.ToLower(strings.TrimSpace(parts[0])) {
+		case "author":
+			h.Author = value
+		case "ticket":
+			h.Ticket = value
+		case "requires":
+			for _, req := range strings.Split(value, ",") {
+				if req = strings.TrimSpace(req); req == "" {
+					continue
+				}
+				r := Requirement{Version: Version(req)}
+				if i := strings.LastIndex(req, "@"); i >= 0 {
+					r = Requirement{Component: req[:i], Version: Version(req[i+1:])}
+				}
+				h.Requires = append(h.Requires, r)
+			}
+		case "allow-destructive":
+			h.AllowDestructive = strings.EqualFold(value, "true")
+		case "requires-version":
+			h.RequiresVersion = Version(value)
+		case "rebuilds-tables":
+			h.RebuildsTables = strings.EqualFold(value, "true")
+		case "tags":
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					h.Tags = append(h.Tags, tag)
+				}
+			}
+		}
+	}
+	return h
+}
+
+// withHeader returns mig with the metadata of h.
+func (m Migration) withHeader(h ScriptHeader) Migration {
+	m.Author = h.Author
+	m.Ticket = h.Ticket
+	m.Options.Requires = append(m.Options.Requires, h.Requires...)
+	m.Options.Tags = append(m.Options.Tags, h.Tags...)
+	m.Options.AllowDestructive = m.Options.AllowDestructive || h.AllowDestructive
+	m.Options.RebuildsTables = m.Options.RebuildsTables || h.RebuildsTables
+	if h.RequiresVersion != VersionNone {
+		m.Options.RequiresVersion = h.RequiresVersion
+	}
+	return m
+}
+
+// sourceHeader parses the header of the script opened by open.
+func sourceHeader(open func() (io.ReadCloser, error)) (ScriptHeader, error) {
+	rc, err := open()
+	if err != nil {
+		return ScriptHeader{}, err
+	}
+	defer rc.Close()
+	return readScriptHeader(rc), nil
+}
@@ -0,0 +1,62 @@
+package migrate
+
+import (
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseScriptHeader(t *testing.T) {
+	script := `
+-- Author: jane
+-- ticket: OPS-123
+-- requires: auth@5, billing@2
+-- tags: data, long-running
+-- requires-version: 12
+-- rebuilds-tables: TRUE
+-- plain comment
+CREATE TABLE invoices (id INT);
+-- author: not part of the header
+`
+	want := ScriptHeader{
+		Author:          "jane",
+		Ticket:          "OPS-123",
+		Requires:        []Requirement{{Component: "auth", Version: "5"}, {Component: "billing", Version: "2"}},
+		Tags:            []string{"data", "long-running"},
+		RequiresVersion: "12",
+		RebuildsTables:  true,
+	}
+	if got := ParseScriptHeader(script); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %+v, got: %+v", want, got)
+	}
+	if got := ParseScriptHeader("CREATE TABLE t (id INT);"); !reflect.DeepEqual(ScriptHeader{}, got) {
+		t.Errorf("want empty header, got: %+v", got)
+	}
+}
+
+func TestMigrationHeader(t *testing.T) {
+	script := "-- author: jane\n-- ticket: OPS-123\n-- requires: auth@5\nCREATE TABLE invoices (id INT);\n"
+	mig := NewSQLMigration("1", "invoices", script, Requires("billing", "1"))
+	if mig.Author != "jane" || mig.Ticket != "OPS-123" {
+		t.Errorf("unexpected metadata: author=%q ticket=%q", mig.Author, mig.Ticket)
+	}
+	want := []Requirement{{Component: "auth", Version: "5"}, {Component: "billing", Version: "1"}}
+	if !reflect.DeepEqual(want, mig.Options.Requires) {
+		t.Errorf("want requires: %v, got: %v", want, mig.Options.Requires)
+	}
+	src, err := NewSQLSourceMigration("1", "invoices", func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader(script)), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Author != "jane" || src.Ticket != "OPS-123" {
+		t.Errorf("unexpected metadata of streamed script: author=%q ticket=%q", src.Author, src.Ticket)
+	}
+	info := newInfo(Migrations{mig}, nil, nil, versionOrder{})
+	if got := info.Migrations[0]; got.Author != "jane" || got.Ticket != "OPS-123" {
+		t.Errorf("info must surface metadata: %+v", got)
+	}
+}
@@ -0,0 +1,108 @@
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+)
+
+// DeployGap is the longest pause between two applied migrations that History groups into the same deploy if no
+// recorded run covers them.
+var DeployGap = 5 * time.Minute
+
+// Deploy is a window in which migrations have been applied, usually a single run of Migrate.
+type Deploy struct {
+	// Run is the recorded run that applied the migrations, nil if the Support does not record runs or the
+	// migrations have been applied outside of a recorded run.
+	Run        *Run
+	Start      time.Time
+	End        time.Time
+	Migrations Migrations
+}
+
+// History lists the deploys of a database, oldest first.
+type History []Deploy
+
+// finished returns the time mig has finished.
+func finished(mig Migration) time.Time {
+	return mig.Date.Add(mig.ExecutionTime)
+}
+
+// VersionAt returns the version the database has been on at t, i.e. the version of the last versioned migration
+// that had been applied successfully, skipped or baselined by then, and false if there has been none. Failed and
+// cherry-picked migrations do not change the version, repeatable migrations are ignored.
+func (i Info) VersionAt(t time.Time) (Version, bool) {
+	version := VersionNone
+	found := false
+	for _, mig := range i.Migrations {
+		if mig.IsRepeatable() || mig.Date.IsZero() || finished(mig).After(t) {
+			continue
+		}
+		if mig.Status == StatusSuccess || mig.Status == StatusSkipped {
+			version = mig.Version
+			found = true
+		}
+	}
+	return version, found
+}
+
+// History groups the applied migrations into deploys. A migration belongs to the recorded run it has been applied
+// in, see Info.Runs. Migrations outside of a recorded run are grouped as long as each starts at most DeployGap after
+// the previous one has finished.
+func (i Info) History() History {
+	h := History{}
+	var current *Deploy
+	for _, mig := range i.Migrations {
+		if mig.Date.IsZero() {
+			continue
+		}
+		run := i.runAt(mig.Date)
+		switch {
+		case current == nil,
+			run != nil && (current.Run == nil || current.Run.Token != run.Token),
+			run == nil && (current.Run != nil || mig.Date.Sub(current.End) > DeployGap):
+			h = append(h, Deploy{Run: run, Start: mig.Date})
+			current = &h[len(h)-1]
+		}
+		current.Migrations = append(current.Migrations, mig)
+		if end := finished(mig); end.After(current.End) {
+			current.End = end
+		}
+	}
+	return h
+}
+
+// runAt returns the recorded run that has been in progress at t.
+func (i Info) runAt(t time.Time) *Run {
+	for n, r := range i.Runs {
+		if !t.Before(r.Started) && !t.After(r.Finished) {
+			return &i.Runs[n]
+		}
+	}
+	return nil
+}
+
+// String returns the text written by Render.
+func (h History) String() string {
+	buf := &bytes.Buffer{}
+	h.Render(buf)
+	return buf.String()
+}
+
+// Render writes the deploys with their migrations to w.
+func (h History) Render(w io.Writer) error {
+	buf := &bytes.Buffer{}
+	for _, d := range h {
+		fmt.Fprintf(buf, "%s - %s", d.Start.Format("2006-01-02 15:04:05"), d.End.Format("2006-01-02 15:04:05"))
+		if d.Run != nil {
+			fmt.Fprintf(buf, " run %s (%s)", d.Run.Token, d.Run.Status)
+		}
+		buf.WriteString("\n")
+		for _, mig := range d.Migrations {
+			fmt.Fprintf(buf, "  %s\t%s\t%s\n", mig.Version, mig.Description, mig.Status)
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
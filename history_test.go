@@ -0,0 +1,65 @@
+package migrate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInfoVersionAt(t *testing.T) {
+	day := time.Date(2024, 3, 5, 14, 0, 0, 0, time.UTC)
+	info := Info{
+		Migrations: Migrations{
+			{Rank: 1, Version: "1", Description: "one", Date: day, ExecutionTime: 1000 * time.Millisecond, Status: StatusSuccess},
+			{Rank: 2, Version: "R", Description: "views", Date: day.Add(time.Minute), Status: StatusSuccess},
+			{Rank: 3, Version: "2", Description: "two", Date: day.Add(30 * time.Minute), ExecutionTime: 120000 * time.Millisecond, Status: StatusSuccess},
+			{Rank: 4, Version: "3", Description: "three", Date: day.Add(time.Hour), Status: StatusFailed},
+			{Version: "4", Description: "four", State: StatePending},
+		},
+	}
+	tests := []struct {
+		at   time.Time
+		want Version
+		ok   bool
+	}{
+		{day.Add(-time.Minute), VersionNone, false},
+		{day.Add(time.Second), "1", true},
+		{day.Add(31 * time.Minute), "1", true},
+		{day.Add(32 * time.Minute), "2", true},
+		{day.Add(2 * time.Hour), "2", true},
+	}
+	for _, test := range tests {
+		if got, ok := info.VersionAt(test.at); got != test.want || ok != test.ok {
+			t.Errorf("%s: want: %q %v, got: %q %v", test.at, test.want, test.ok, got, ok)
+		}
+	}
+}
+
+func TestInfoHistory(t *testing.T) {
+	day := time.Date(2024, 3, 5, 14, 0, 0, 0, time.UTC)
+	info := Info{
+		Migrations: Migrations{
+			{Rank: 1, Version: "1", Description: "one", Date: day, Status: StatusSuccess},
+			{Rank: 2, Version: "2", Description: "two", Date: day.Add(time.Minute), Status: StatusSuccess},
+			{Rank: 3, Version: "3", Description: "three", Date: day.Add(time.Hour), Status: StatusSuccess},
+			{Rank: 4, Version: "4", Description: "four", Date: day.Add(24 * time.Hour), Status: StatusSuccess},
+			{Rank: 5, Version: "5", Description: "five", Date: day.Add(24*time.Hour + time.Minute), Status: StatusFailed},
+			{Version: "6", Description: "six", State: StatePending},
+		},
+		Runs: []Run{
+			{Token: "deploy-42", Started: day.Add(24 * time.Hour), Finished: day.Add(24*time.Hour + 2*time.Minute), Status: StatusFailed},
+		},
+	}
+	h := info.History()
+	if len(h) != 3 {
+		t.Fatalf("want 3 deploys, got:\n%s", h)
+	}
+	if len(h[0].Migrations) != 2 || h[0].Run != nil || !h[0].End.Equal(day.Add(time.Minute)) {
+		t.Errorf("unexpected first deploy: %+v", h[0])
+	}
+	if len(h[1].Migrations) != 1 || h[1].Migrations[0].Version != "3" {
+		t.Errorf("unexpected second deploy: %+v", h[1])
+	}
+	if h[2].Run == nil || h[2].Run.Token != "deploy-42" || len(h[2].Migrations) != 2 {
+		t.Errorf("want the last deploy to be the recorded run, got: %+v", h[2])
+	}
+}
@@ -0,0 +1,66 @@
+package migrate
+
+import "database/sql"
+
+// Hooks are invoked by Migrate around the whole run and around every migration that gets installed.
+// An error returned by a hook aborts the run. All hooks are optional.
+type Hooks struct {
+	BeforeMigrate       func(con *sql.DB) error
+	AfterMigrate        func(con *sql.DB) error
+	BeforeEachMigration func(con *sql.DB, mig Migration) error
+	AfterEachMigration  func(con *sql.DB, mig Migration) error
+	OnError             func(con *sql.DB, mig Migration, err error)
+}
+
+// SetHooks sets the hooks invoked by Migrate.
+func (m *Migrator) SetHooks(hooks Hooks) {
+	m.hooks = hooks
+}
+
+func (m *Migrator) beforeMigrate() error {
+	if err := m.callback(CallbackBeforeMigrate); err != nil {
+		return err
+	}
+	if m.hooks.BeforeMigrate == nil {
+		return nil
+	}
+	return m.hooks.BeforeMigrate(m.db)
+}
+
+func (m *Migrator) afterMigrate() error {
+	if err := m.callback(CallbackAfterMigrate); err != nil {
+		return err
+	}
+	if m.hooks.AfterMigrate == nil {
+		return nil
+	}
+	return m.hooks.AfterMigrate(m.db)
+}
+
+func (m *Migrator) beforeEachMigration(mig Migration) error {
+	if err := m.callback(CallbackBeforeEachMigrate); err != nil {
+		return err
+	}
+	if m.hooks.BeforeEachMigration == nil {
+		return nil
+	}
+	return m.hooks.BeforeEachMigration(m.db, mig)
+}
+
+func (m *Migrator) afterEachMigration(mig Migration) error {
+	if err := m.callback(CallbackAfterEachMigrate); err != nil {
+		return err
+	}
+	if m.hooks.AfterEachMigration == nil {
+		return nil
+	}
+	return m.hooks.AfterEachMigration(m.db, mig)
+}
+
+// onError passes err to the OnError hook and returns it.
+func (m *Migrator) onError(mig Migration, err error) error {
+	if m.hooks.OnError != nil {
+		m.hooks.OnError(m.db, mig, err)
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+package migrate
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// HTTPOption configures the handler returned by NewHTTPHandler.
+type HTTPOption func(*httpHandler)
+
+// WithAuthorization sets the function that authorizes requests to trigger a run with POST /migrate.
+func WithAuthorization(authorize func(r *http.Request) bool) HTTPOption {
+	return func(h *httpHandler) {
+		h.authorize = authorize
+	}
+}
+
+// WithBearerToken authorizes requests to trigger a run that carry the header "Authorization: Bearer <token>".
+func WithBearerToken(token string) HTTPOption {
+	return WithAuthorization(func(r *http.Request) bool {
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		return token != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+	})
+}
+
+type httpHandler struct {
+	m         *Migrator
+	authorize func(r *http.Request) bool
+	mux       *http.ServeMux
+}
+
+// NewHTTPHandler returns a handler for admin ports that exposes the state of the migrations of m:
+//
+//	GET  /migrations  Info as JSON
+//	GET  /healthz     200 if no migration is pending, outdated or failed, 503 otherwise
+//	POST /migrate     runs Migrate and responds with the resulting Info
+//
+// POST /migrate is forbidden unless an authorization is configured with WithAuthorization or WithBearerToken.
+func NewHTTPHandler(m *Migrator, opts ...HTTPOption) http.Handler {
+	h := &httpHandler{
+		m:   m,
+		mux: http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.mux.HandleFunc("/migrations", h.migrations)
+	h.mux.HandleFunc("/healthz", h.healthz)
+	h.mux.HandleFunc("/migrate", h.migrate)
+	return h
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *httpHandler) migrations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSON(w, http.StatusMethodNotAllowed, errorJSON{Error: "method not allowed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, h.m.Info())
+}
+
+func (h *httpHandler) healthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSON(w, http.StatusMethodNotAllowed, errorJSON{Error: "method not allowed"})
+		return
+	}
+	info := h.m.Info()
+	v := healthJSON{
+		Pending:  len(info.Pending()),
+		Outdated: len(info.filter(StateOutdated)),
+		Failed:   len(info.filter(StateFailed)),
+	}
+	status := http.StatusOK
+	if v.Pending > 0 || v.Outdated > 0 || v.Failed > 0 {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, v)
+}
+
+func (h *httpHandler) migrate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, errorJSON{Error: "method not allowed"})
+		return
+	}
+	if h.authorize == nil || !h.authorize(r) {
+		writeJSON(w, http.StatusForbidden, errorJSON{Error: "forbidden"})
+		return
+	}
+	if err := h.m.Migrate(); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorJSON{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, h.m.Info())
+}
+
+type healthJSON struct {
+	Pending  int `json:"pending"`
+	Outdated int `json:"outdated"`
+	Failed   int `json:"failed"`
+}
+
+type errorJSON struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,71 @@
+package migrate
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	m.AddGoMigration("1", "one", func(con *sql.DB) error { return nil })
+	h := NewHTTPHandler(m, WithBearerToken("secret"))
+
+	do := func(method string, path string, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := do(http.MethodGet, "/healthz", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("want unhealthy with pending migration, got: %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/migrate", "wrong"); w.Code != http.StatusForbidden {
+		t.Errorf("want forbidden, got: %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/migrate", "secret"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("want method not allowed, got: %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/migrate", "secret"); w.Code != http.StatusOK {
+		t.Fatalf("want migrate to succeed, got: %d %s", w.Code, w.Body)
+	}
+	if w := do(http.MethodGet, "/healthz", ""); w.Code != http.StatusOK {
+		t.Errorf("want healthy, got: %d %s", w.Code, w.Body)
+	}
+	w := do(http.MethodGet, "/migrations", "")
+	info := Info{}
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatalf("decode info: %v", err)
+	}
+	if len(info.Migrations) != 1 || info.Migrations[0].State != StateApplied {
+		t.Errorf("unexpected info: %s", info.Migrations)
+	}
+}
+
+func TestHTTPHealthzOutdated(t *testing.T) {
+	s := &memSupport{}
+	noop := func(con *sql.DB) error { return nil }
+	m := newTestMigrator(t, s)
+	m.AddRepeatableGoMigration("views", noop, Fingerprint("1"))
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	m = newTestMigrator(t, s)
+	m.AddRepeatableGoMigration("views", noop, Fingerprint("2"))
+	w := httptest.NewRecorder()
+	NewHTTPHandler(m).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	v := healthJSON{}
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusServiceUnavailable || v.Outdated != 1 {
+		t.Errorf("want unhealthy with an outdated migration, got: %d %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,335 @@
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Info is the merged view of the locally registered migrations and the ones recorded in the database.
+// Applied migrations are listed in the order of their rank followed by local ones that have not been applied.
+// Every migration is classified by its State.
+type Info struct {
+	Migrations Migrations
+	// Runs are the recorded runs, if the Support records runs.
+	Runs []Run
+	// Server is the database server, if the Support is a ServerDetector.
+	Server *Server
+}
+
+// Current returns the latest successfully applied versioned migration.
+func (i Info) Current() (Migration, bool) {
+	var current Migration
+	found := false
+	for _, mig := range i.Migrations {
+		if !mig.IsRepeatable() && mig.Status == StatusSuccess {
+			current = mig
+			found = true
+		}
+	}
+	return current, found
+}
+
+// Pending returns the migrations that would be applied by the next call to Migrate.
+func (i Info) Pending() Migrations {
+	return i.filter(StatePending)
+}
+
+// Ignored returns the local migrations that are not applied because they are at or below the baseline or the
+// last installed version.
+func (i Info) Ignored() Migrations {
+	return i.filter(StateIgnored)
+}
+
+// Skipped returns the failed migrations that have been skipped by the recovery policy SkipFailed
+// and the migrations that have been skipped for an inactive environment.
+func (i Info) Skipped() Migrations {
+	return i.filter(StateSkipped)
+}
+
+// Orphaned returns the applied migrations that are not registered locally, either missing or from the future.
+func (i Info) Orphaned() Migrations {
+	return i.filter(StateMissing, StateFuture)
+}
+
+func (i Info) filter(states ...State) Migrations {
+	ms := Migrations{}
+	for _, mig := range i.Migrations {
+		for _, state := range states {
+			if mig.State == state {
+				ms = append(ms, mig)
+			}
+		}
+	}
+	return ms
+}
+
+// newInfo merges the registered migrations with the installed ones and sets their states, ordering versions with o.
+func newInfo(migrations Migrations, repeatable Migrations, installed Migrations, o versionOrder) Info {
+	local := map[Version]Migration{}
+	latestLocal := VersionNone
+	for _, mig := range migrations {
+		local[mig.Version] = mig
+		latestLocal = o.later(latestLocal, mig.Version)
+	}
+	localRepeatable := map[repeatableKey]Migration{}
+	for _, mig := range repeatable {
+		localRepeatable[keyOf(mig)] = mig
+	}
+	latestRepeatable := map[repeatableKey]int{}
+	for i, mig := range installed {
+		if mig.IsRepeatable() {
+			latestRepeatable[keyOf(mig)] = i
+		}
+	}
+
+	ms := Migrations{}
+	applied := map[Version]bool{}
+	lastInstalled := VersionNone
+	checksumsRepeatable := map[repeatableKey]string{}
+	for i, mig := range installed {
+		if mig.IsRepeatable() {
+			l, known := localRepeatable[keyOf(mig)]
+			switch {
+			case latestRepeatable[keyOf(mig)] != i:
+				mig.State = StateSuperseded
+			case mig.Status == StatusSkipped:
+				mig.State = StateSkipped
+			case mig.Status != StatusSuccess:
+				mig.State = StateFailed
+			case !known:
+				mig.State = StateMissing
+			case !checksumMatches(mig.Checksum, l):
+				mig.State = StateOutdated
+			default:
+				mig.State = StateApplied
+				mig.Script = l.Script
+				mig.Options = l.Options
+			}
+			if mig.Status == StatusSuccess || mig.Status == StatusSkipped {
+				checksumsRepeatable[keyOf(mig)] = mig.Checksum
+			}
+			ms = append(ms, mig)
+			continue
+		}
+		applied[mig.Version] = true
+		if mig.Status == StatusSuccess || mig.Status == StatusSkipped {
+			lastInstalled = o.later(lastInstalled, mig.Version)
+		}
+		l, known := local[mig.Version]
+		switch {
+		case mig.Status == StatusSkipped:
+			mig.State = StateSkipped
+		case mig.Status != StatusSuccess && mig.Status != StatusCherryPicked:
+			mig.State = StateFailed
+		case mig.Type == TypeBaseline:
+			mig.State = StateBaseline
+		case known:
+			mig.State = StateApplied
+			mig.Script = l.Script
+			mig.Options = l.Options
+		case o.compare(mig.Version, latestLocal) <= 0:
+			mig.State = StateMissing
+		default:
+			mig.State = StateFuture
+		}
+		ms = append(ms, mig)
+	}
+	for _, mig := range migrations {
+		if applied[mig.Version] {
+			continue
+		}
+		if o.compare(mig.Version, lastInstalled) <= 0 {
+			mig.State = StateIgnored
+		} else {
+			mig.State = StatePending
+		}
+		ms = append(ms, mig)
+	}
+	for _, mig := range repeatable {
+		if cs, ok := checksumsRepeatable[keyOf(mig)]; ok && checksumMatches(cs, mig) {
+			continue
+		}
+		mig.State = StatePending
+		ms = append(ms, mig)
+	}
+	return Info{
+		Migrations: ms,
+	}
+}
+
+type infoJSON struct {
+	Migrations []migrationJSON `json:"migrations"`
+	Runs       []runJSON       `json:"runs,omitempty"`
+	Server     *Server         `json:"server,omitempty"`
+}
+
+type migrationJSON struct {
+	Rank            int    `json:"rank,omitempty"`
+	Version         string `json:"version"`
+	Description     string `json:"description"`
+	Type            string `json:"type"`
+	Checksum        string `json:"checksum,omitempty"`
+	Date            string `json:"date,omitempty"`
+	ExecutionTime   int64  `json:"execution_time_ms"`
+	Status          string `json:"status,omitempty"`
+	State           string `json:"state,omitempty"`
+	Failure         string `json:"failure,omitempty"`
+	FailedStatement int    `json:"failed_statement,omitempty"`
+	InstalledBy     string `json:"installed_by,omitempty"`
+	Author          string `json:"author,omitempty"`
+	Ticket          string `json:"ticket,omitempty"`
+	Output          string `json:"output,omitempty"`
+}
+
+func newMigrationJSON(mig Migration) migrationJSON {
+	return migrationJSON{
+		Rank:            mig.Rank,
+		Version:         string(mig.Version),
+		Description:     mig.Description,
+		Type:            string(mig.Type),
+		Checksum:        mig.Checksum,
+		Date:            formatTime(mig.Date),
+		ExecutionTime:   milliseconds(mig.ExecutionTime),
+		Status:          string(mig.Status),
+		State:           string(mig.State),
+		Failure:         mig.Failure,
+		FailedStatement: mig.FailedStatement,
+		InstalledBy:     mig.InstalledBy,
+		Author:          mig.Author,
+		Ticket:          mig.Ticket,
+		Output:          mig.Output,
+	}
+}
+
+func (v migrationJSON) migration() (Migration, error) {
+	mig := Migration{
+		Rank:            v.Rank,
+		Version:         Version(v.Version),
+		Description:     v.Description,
+		Type:            Type(v.Type),
+		Checksum:        v.Checksum,
+		ExecutionTime:   fromMilliseconds(v.ExecutionTime),
+		Status:          Status(v.Status),
+		State:           State(v.State),
+		Failure:         v.Failure,
+		FailedStatement: v.FailedStatement,
+		InstalledBy:     v.InstalledBy,
+		Author:          v.Author,
+		Ticket:          v.Ticket,
+		Output:          v.Output,
+	}
+	d, err := parseTime(v.Date)
+	if err != nil {
+		return Migration{}, err
+	}
+	mig.Date = d
+	return mig, nil
+}
+
+type runJSON struct {
+	Token    string            `json:"token"`
+	Started  string            `json:"started"`
+	Finished string            `json:"finished"`
+	Status   string            `json:"status"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+// MarshalJSON encodes the Info with stable, lower case field names. Dates are formatted as RFC 3339.
+func (i Info) MarshalJSON() ([]byte, error) {
+	v := infoJSON{
+		Migrations: make([]migrationJSON, 0, len(i.Migrations)),
+		Server:     i.Server,
+	}
+	for _, mig := range i.Migrations {
+		v.Migrations = append(v.Migrations, newMigrationJSON(mig))
+	}
+	for _, r := range i.Runs {
+		v.Runs = append(v.Runs, runJSON{
+			Token:    r.Token,
+			Started:  formatTime(r.Started),
+			Finished: formatTime(r.Finished),
+			Status:   string(r.Status),
+			Labels:   r.Labels,
+		})
+	}
+	return json.Marshal(v)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse date: %s: %+v", s, err)
+	}
+	return t, nil
+}
+
+// String returns the table written by Render.
+func (i Info) String() string {
+	buf := &bytes.Buffer{}
+	i.Render(buf)
+	return buf.String()
+}
+
+// Render writes the migrations as an aligned ASCII table to w.
+// The status column shows the State of a migration, or its Status if no State has been computed.
+func (i Info) Render(w io.Writer) error {
+	rows := [][]string{{"Version", "Description", "Type", "Status", "Date", "Duration"}}
+	for _, mig := range i.Migrations {
+		status := string(mig.State)
+		if status == "" {
+			status = string(mig.Status)
+		}
+		date, duration := "", ""
+		if !mig.Date.IsZero() {
+			date = mig.Date.Format("2006-01-02 15:04:05")
+			duration = mig.ExecutionTime.String()
+		}
+		rows = append(rows, []string{string(mig.Version), mig.Description, string(mig.Type), status, date, duration})
+	}
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for c, cell := range row {
+			if len(cell) > widths[c] {
+				widths[c] = len(cell)
+			}
+		}
+	}
+	sep := "+"
+	for _, width := range widths {
+		sep += strings.Repeat("-", width+2) + "+"
+	}
+	buf := &bytes.Buffer{}
+	buf.WriteString(sep + "\n")
+	for r, row := range rows {
+		buf.WriteString("|")
+		for c, cell := range row {
+			fmt.Fprintf(buf, " %-*s |", widths[c], cell)
+		}
+		buf.WriteString("\n")
+		if r == 0 {
+			buf.WriteString(sep + "\n")
+		}
+	}
+	buf.WriteString(sep + "\n")
+	for _, mig := range i.Migrations {
+		if f := mig.failure(); f != "" {
+			fmt.Fprintf(buf, "%s failed: %s\n", mig.Version, f)
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,88 @@
+package migrate
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestInfo(t *testing.T) {
+	local := Migrations{
+		{Version: "1", Description: "one", Checksum: "a"},
+		{Version: "2", Description: "two", Checksum: "b"},
+		{Version: "4", Description: "four", Checksum: "d"},
+		{Version: "5", Description: "five", Checksum: "e"},
+	}
+	repeatable := Migrations{
+		{Version: VersionRepeatable, Description: "view", Checksum: "v2"},
+	}
+	installed := Migrations{
+		{Rank: 1, Version: "1", Description: "baseline", Type: TypeBaseline, Status: StatusSuccess},
+		{Rank: 2, Version: "2", Description: "two", Checksum: "b", Status: StatusSuccess},
+		{Rank: 3, Version: VersionRepeatable, Description: "view", Checksum: "v0", Status: StatusSuccess},
+		{Rank: 4, Version: "3", Description: "three", Checksum: "c", Status: StatusSuccess},
+		{Rank: 5, Version: VersionRepeatable, Description: "view", Checksum: "v1", Status: StatusSuccess},
+		{Rank: 6, Version: "9", Description: "nine", Checksum: "i", Status: StatusSuccess},
+		{Rank: 7, Version: "10", Description: "ten", Checksum: "j", Status: StatusFailed},
+	}
+	info := newInfo(local, repeatable, installed, versionOrder{})
+	want := []State{StateBaseline, StateApplied, StateSuperseded, StateMissing, StateOutdated, StateFuture, StateFailed, StateIgnored, StateIgnored, StatePending}
+	if len(info.Migrations) != len(want) {
+		t.Fatalf("want %d migrations, got %d:\n%s", len(want), len(info.Migrations), info.Migrations)
+	}
+	for i, mig := range info.Migrations {
+		if mig.State != want[i] {
+			t.Errorf("%s: want state %s, got %s", mig, want[i], mig.State)
+		}
+	}
+	if current, _ := info.Current(); current.Version != "9" {
+		t.Errorf("want current version 9, got %s", current.Version)
+	}
+	if pending := info.Pending(); len(pending) != 1 || pending[0].Description != "view" {
+		t.Errorf("unexpected pending migrations:\n%s", pending)
+	}
+	if ignored := info.Ignored(); len(ignored) != 2 || ignored[0].Version != "4" {
+		t.Errorf("unexpected ignored migrations:\n%s", ignored)
+	}
+	if orphaned := info.Orphaned(); len(orphaned) != 2 || orphaned[0].Version != "3" || orphaned[1].Version != "9" {
+		t.Errorf("unexpected orphaned migrations:\n%s", orphaned)
+	}
+}
+
+func TestInfoMarshalJSON(t *testing.T) {
+	info := Info{
+		Migrations: Migrations{
+			{Rank: 1, Version: "1", Description: "one", Type: TypeSQL, Checksum: "a", Date: time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC), ExecutionTime: 12 * time.Millisecond, Status: StatusSuccess, State: StateApplied},
+			{Version: "2", Description: "two", Type: TypeGo, State: StatePending},
+		},
+	}
+	bs, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"migrations":[` +
+		`{"rank":1,"version":"1","description":"one","type":"SQL","checksum":"a","date":"2024-01-31T12:00:00Z","execution_time_ms":12,"status":"success","state":"applied"},` +
+		`{"version":"2","description":"two","type":"Go","execution_time_ms":0,"state":"pending"}]}`
+	if string(bs) != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, bs)
+	}
+}
+
+func TestInfoRender(t *testing.T) {
+	info := Info{
+		Migrations: Migrations{
+			{Rank: 1, Version: "1", Description: "create users", Type: TypeSQL, Date: time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC), ExecutionTime: 1500 * time.Millisecond, Status: StatusSuccess, State: StateApplied},
+			{Version: "2", Description: "index", Type: TypeSQL, State: StatePending},
+		},
+	}
+	want := `+---------+--------------+------+---------+---------------------+----------+
+| Version | Description  | Type | Status  | Date                | Duration |
++---------+--------------+------+---------+---------------------+----------+
+| 1       | create users | SQL  | applied | 2024-01-31 12:00:00 | 1.5s     |
+| 2       | index        | SQL  | pending |                     |          |
++---------+--------------+------+---------+---------------------+----------+
+`
+	if got := info.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
@@ -0,0 +1,32 @@
+package migrate
+
+import "database/sql"
+
+// SessionUser is implemented by Support implementations that are able to tell the database user of the session.
+// It is recorded as InstalledBy unless an identity is set with SetInstalledBy.
+type SessionUser interface {
+	CurrentUser(con *sql.DB) (string, error)
+}
+
+// SetInstalledBy sets the identity recorded with every migration, e.g. a service name or CI job ID.
+// It takes precedence over the database user of the session.
+func (m *Migrator) SetInstalledBy(identity string) {
+	m.identity = identity
+}
+
+// installedBy returns the identity recorded with a migration.
+func (m *Migrator) installedBy() string {
+	if m.identity != "" {
+		return m.identity
+	}
+	su, ok := m.support.(SessionUser)
+	if !ok {
+		return ""
+	}
+	user, err := su.CurrentUser(m.db)
+	if err != nil {
+		m.log(LevelDebug, "current user", Fields{"error": err})
+		return ""
+	}
+	return user
+}
@@ -0,0 +1,35 @@
+package migrate
+
+import (
+	"database/sql"
+	"testing"
+)
+
+type userSupport struct {
+	memSupport
+}
+
+func (s *userSupport) CurrentUser(con *sql.DB) (string, error) {
+	return "app_owner", nil
+}
+
+func TestInstalledBy(t *testing.T) {
+	noop := func(con *sql.DB) error { return nil }
+	s := &userSupport{}
+	m := newTestMigrator(t, s)
+	m.AddGoMigration("1", "one", noop)
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	m.SetInstalledBy("ci-job-42")
+	m.AddGoMigration("2", "two", noop)
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	want := []string{"app_owner", "ci-job-42"}
+	for i, mig := range m.Info().Migrations {
+		if mig.InstalledBy != want[i] {
+			t.Errorf("%s: want installed by %s, got: %s", mig, want[i], mig.InstalledBy)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrLintFailed is reported by Migrate if a Linter rejected a pending migration.
+var ErrLintFailed = errors.New("lint failed")
+
+// Violation is a problem found by a Linter in a pending migration.
+type Violation struct {
+	Rule      string
+	Migration Migration
+	Statement string
+	Message   string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s: %s: %s", v.Rule, v.Migration, v.Message, v.Statement)
+}
+
+// Linter inspects the statements of a pending SQL migration before it is applied.
+type Linter interface {
+	Lint(mig Migration, stmts []Statement) []Violation
+}
+
+// LintError rejects a run because of the violations found by the linters of the Migrator.
+type LintError struct {
+	Violations []Violation
+}
+
+func (e *LintError) Error() string {
+	ss := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		ss[i] = v.String()
+	}
+	return fmt.Sprintf("%v: %s", ErrLintFailed, strings.Join(ss, "; "))
+}
+
+func (e *LintError) Unwrap() error {
+	return ErrLintFailed
+}
+
+// StatementRule is a Linter that checks every statement of a migration on its own.
+type StatementRule struct {
+	Name string
+	// Check returns a message describing the violation of the rule by stmt or an empty string.
+	// Comments are removed from stmt.
+	Check func(stmt string) string
+}
+
+func (r StatementRule) Lint(mig Migration, stmts []Statement) []Violation {
+	var vs []Violation
+	for _, stmt := range stmts {
+		if msg := r.Check(stripComments(stmt.SQL)); msg != "" {
+			vs = append(vs, Violation{Rule: r.Name, Migration: mig, Statement: stmt.SQL, Message: msg})
+		}
+	}
+	return vs
+}
+
+var (
+	dropTable        = regexp.MustCompile(`(?is)^DROP\s+TABLE\b`)
+	dropTableIfExist = regexp.MustCompile(`(?is)^DROP\s+TABLE\s+IF\s+EXISTS\b`)
+	addNotNull       = regexp.MustCompile(`(?is)^ALTER\s+TABLE\b.*\bADD\b.*\bNOT\s+NULL\b`)
+	hasDefault       = regexp.MustCompile(`(?is)\bDEFAULT\b`)
+	updateOrDelete   = regexp.MustCompile(`(?is)^(UPDATE|DELETE)\b`)
+	hasWhere         = regexp.MustCompile(`(?is)\bWHERE\b`)
+	lineComment      = regexp.MustCompile(`--[^\n]*`)
+	blockComment     = regexp.MustCompile(`(?s)/\*.*?\*/`)
+)
+
+// Built-in lint rules.
+var (
+	// RuleDropTableIfExists rejects DROP TABLE without IF EXISTS.
+	RuleDropTableIfExists = StatementRule{
+		Name: "drop-table-if-exists",
+		Check: func(stmt string) string {
+			if dropTable.MatchString(stmt) && !dropTableIfExist.MatchString(stmt) {
+				return "DROP TABLE without IF EXISTS"
+			}
+			return ""
+		},
+	}
+	// RuleNotNullDefault rejects NOT NULL columns that are added without a default.
+	RuleNotNullDefault = StatementRule{
+		Name: "not-null-default",
+		Check: func(stmt string) string {
+			if addNotNull.MatchString(stmt) && !hasDefault.MatchString(stmt) {
+				return "NOT NULL column added without default"
+			}
+			return ""
+		},
+	}
+	// RuleBoundedWrite rejects UPDATE and DELETE without WHERE.
+	RuleBoundedWrite = StatementRule{
+		Name: "bounded-write",
+		Check: func(stmt string) string {
+			if updateOrDelete.MatchString(stmt) && !hasWhere.MatchString(stmt) {
+				return "UPDATE or DELETE without WHERE"
+			}
+			return ""
+		},
+	}
+)
+
+// DefaultLinters are the built-in lint rules.
+var DefaultLinters = []Linter{RuleDropTableIfExists, RuleNotNullDefault, RuleBoundedWrite}
+
+// AddLinter adds linters that have to accept every pending SQL migration before Migrate applies any of them.
+func (m *Migrator) AddLinter(linters ...Linter) {
+	m.linters = append(m.linters, linters...)
+}
+
+// Lint returns the violations found by the linters of the Migrator in the pending migrations.
+func (m *Migrator) Lint() ([]Violation, error) {
+	installed, err := m.listMigrations()
+	if err != nil {
+		return nil, err
+	}
+	migrations, repeatable := m.registered()
+	return m.lint(newInfo(migrations, repeatable, installed, m.versionOrdering).Pending())
+}
+
+func (m *Migrator) lint(pending Migrations) ([]Violation, error) {
+	var vs []Violation
+	if len(m.linters) == 0 {
+		return vs, nil
+	}
+	for _, mig := range pending {
+		if mig.Type != TypeSQL || mig.ExecuteContext != nil {
+			continue
+		}
+		stmts, err := m.statements(mig)
+		if err != nil {
+			return nil, fmt.Errorf("lint: %s: %+v", mig, err)
+		}
+		for _, l := range m.linters {
+			vs = append(vs, l.Lint(mig, stmts)...)
+		}
+	}
+	return vs, nil
+}
+
+// statements returns the statements of a SQL migration, which are read from its Source if set.
+func (m *Migrator) statements(mig Migration) ([]Statement, error) {
+	mig, err := m.render(mig)
+	if err != nil {
+		return nil, err
+	}
+	if mig.Source == nil {
+		return m.splitter().Parse(mig.Script), nil
+	}
+	rc, err := mig.Source()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	var stmts []Statement
+	sr := m.splitter().NewReader(rc)
+	for sr.Next() {
+		stmts = append(stmts, sr.Statement())
+	}
+	return stmts, sr.Err()
+}
+
+func stripComments(stmt string) string {
+	stmt = blockComment.ReplaceAllString(stmt, "")
+	stmt = lineComment.ReplaceAllString(stmt, "")
+	return strings.TrimSpace(stmt)
+}
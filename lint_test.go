@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDefaultLinters(t *testing.T) {
+	tests := []struct {
+		stmt string
+		rule string
+	}{
+		{"DROP TABLE a;", RuleDropTableIfExists.Name},
+		{"drop table if exists a;", ""},
+		{"ALTER TABLE a ADD COLUMN b INT NOT NULL;", RuleNotNullDefault.Name},
+		{"ALTER TABLE a ADD COLUMN b INT NOT NULL DEFAULT 0;", ""},
+		{"UPDATE a SET b = 1;", RuleBoundedWrite.Name},
+		{"-- all of them\nDELETE FROM a;", RuleBoundedWrite.Name},
+		{"DELETE FROM a WHERE b = 1;", ""},
+		{"CREATE TABLE a (b INT NOT NULL);", ""},
+	}
+	for _, test := range tests {
+		var vs []Violation
+		for _, l := range DefaultLinters {
+			vs = append(vs, l.Lint(Migration{}, []Statement{{SQL: test.stmt}})...)
+		}
+		switch {
+		case test.rule == "" && len(vs) > 0:
+			t.Errorf("%s: want no violation, got: %v", test.stmt, vs)
+		case test.rule != "" && (len(vs) != 1 || vs[0].Rule != test.rule):
+			t.Errorf("%s: want violation of %s, got: %v", test.stmt, test.rule, vs)
+		}
+	}
+}
+
+func TestMigrateLint(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.AddLinter(DefaultLinters...)
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	m.AddSQLMigration("2", "two", "DELETE FROM a;")
+	err := m.Migrate()
+	if !errors.Is(err, ErrLintFailed) {
+		t.Fatalf("want lint error, got: %v", err)
+	}
+	if vs := err.(*LintError).Violations; len(vs) != 1 || vs[0].Migration.Version != "2" {
+		t.Errorf("unexpected violations: %v", vs)
+	}
+	if got := log.Statements(); len(got) != 0 {
+		t.Errorf("want no statements, got: %q", got)
+	}
+}
@@ -0,0 +1,202 @@
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// File name prefixes of migration scripts.
+const (
+	PrefixVersioned  = "V"
+	PrefixUndo       = "U"
+	PrefixRepeatable = "R"
+)
+
+const (
+	fileSeparator = "__"
+	fileSuffix    = ".sql"
+	gzipSuffix    = ".gz"
+)
+
+// MigrationFile is a migration script named <prefix><version>__<description>.sql, e.g. V1__create_users.sql or R__users_view.sql.
+// Scripts compressed with gzip are named like V2__seed.sql.gz.
+type MigrationFile struct {
+	Prefix      string
+	Version     Version
+	Description string
+	// Compressed is set for scripts compressed with gzip. Their checksums cover the decompressed scripts.
+	Compressed bool
+}
+
+// ParseMigrationFile parses the base name of a migration script.
+func ParseMigrationFile(name string) (MigrationFile, bool) {
+	compressed := strings.HasSuffix(name, fileSuffix+gzipSuffix)
+	name = strings.TrimSuffix(name, gzipSuffix)
+	if !strings.HasSuffix(name, fileSuffix) {
+		return MigrationFile{}, false
+	}
+	parts := strings.SplitN(strings.TrimSuffix(name, fileSuffix), fileSeparator, 2)
+	if len(parts) != 2 || len(parts[0]) < 1 || parts[1] == "" {
+		return MigrationFile{}, false
+	}
+	f := MigrationFile{
+		Prefix:      parts[0][:1],
+		Version:     Version(parts[0][1:]),
+		Description: strings.Replace(parts[1], "_", " ", -1),
+		Compressed:  compressed,
+	}
+	switch f.Prefix {
+	case PrefixVersioned, PrefixUndo:
+		if f.Version == VersionNone {
+			return MigrationFile{}, false
+		}
+	case PrefixRepeatable:
+		if f.Version != VersionNone {
+			return MigrationFile{}, false
+		}
+		f.Version = VersionRepeatable
+	default:
+		return MigrationFile{}, false
+	}
+	return f, true
+}
+
+// Name returns the file name of f.
+func (f MigrationFile) Name() string {
+	version := string(f.Version)
+	if f.Prefix == PrefixRepeatable {
+		version = ""
+	}
+	name := f.Prefix + version + fileSeparator + fileDescription(f.Description) + fileSuffix
+	if f.Compressed {
+		name += gzipSuffix
+	}
+	return name
+}
+
+// fileDescription converts a description into the form used in file names.
+func fileDescription(description string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '_'
+		}
+	}, strings.TrimSpace(description))
+}
+
+// ListMigrationFiles returns the migration scripts in dir sorted by version and name. Other files are ignored.
+func ListMigrationFiles(dir string) ([]MigrationFile, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	fs := []MigrationFile{}
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		if f, ok := ParseMigrationFile(info.Name()); ok {
+			fs = append(fs, f)
+		}
+	}
+	sort.Slice(fs, func(i, j int) bool {
+		return lessMigrationFile(fs[i], fs[j], versionOrder{})
+	})
+	return fs, nil
+}
+
+// lessMigrationFile orders migration files by version under o and name.
+func lessMigrationFile(a MigrationFile, b MigrationFile, o versionOrder) bool {
+	if c := o.compare(a.Version, b.Version); c != 0 {
+		return c < 0
+	}
+	return a.Name() < b.Name()
+}
+
+// LoadDir loads the versioned and repeatable SQL migrations found in dir.
+// Undo scripts are not applied by Migrate; they are set as UndoSQL of the versioned migration with the same version. Compressed scripts are streamed instead of read into memory. Files with equal versions, e.g. V1__a.sql and V01__b.sql,
+// or repeatable files with the same description are rejected with ErrConflict.
+func LoadDir(dir string) (Migrations, error) {
+	return loadDir(dir, versionOrder{})
+}
+
+// loadDir runs LoadDir, ordering versions with o.
+func loadDir(dir string, o versionOrder) (Migrations, error) {
+	fs, err := ListMigrationFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(fs, func(i, j int) bool {
+		return lessMigrationFile(fs[i], fs[j], o)
+	})
+	if err := fileConflicts(fs, o); err != nil {
+		return nil, err
+	}
+	undo := map[Version]string{}
+	for _, f := range fs {
+		if f.Prefix != PrefixUndo || f.Compressed {
+			continue
+		}
+		script, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read undo script: %s: %+v", f.Name(), err)
+		}
+		undo[f.Version] = string(script)
+	}
+	ms := Migrations{}
+	for _, f := range fs {
+		if f.Prefix == PrefixUndo {
+			continue
+		}
+		var opts []MigrationOption
+		if script, ok := undo[f.Version]; ok && f.Version != VersionRepeatable {
+			opts = append(opts, UndoSQL(script))
+		}
+		path := filepath.Join(dir, f.Name())
+		if f.Compressed {
+			mig, err := NewSQLSourceMigration(f.Version, f.Description, gunzip(func() (io.ReadCloser, error) {
+				return os.Open(path)
+			}), opts...)
+			if err != nil {
+				return nil, fmt.Errorf("read migration: %s: %+v", f.Name(), err)
+			}
+			ms = append(ms, mig)
+			continue
+		}
+		script, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read migration: %s: %+v", f.Name(), err)
+		}
+		ms = append(ms, NewSQLMigration(f.Version, f.Description, string(script), opts...))
+	}
+	return ms, nil
+}
+
+// AddDir adds the migrations loaded from dir by LoadDir and the callbacks loaded by LoadCallbacks. Their versions are
+// ordered like those of the Migrator, see SetVersionComparator.
+func (m *Migrator) AddDir(dir string) error {
+	ms, err := loadDir(dir, m.versionOrdering)
+	if err != nil {
+		return err
+	}
+	cs, err := LoadCallbacks(dir)
+	if err != nil {
+		return err
+	}
+	for _, mig := range ms {
+		m.Add(mig)
+	}
+	for _, c := range cs {
+		m.AddCallback(c)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package migrate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseMigrationFile(t *testing.T) {
+	tests := []struct {
+		name string
+		want MigrationFile
+		ok   bool
+	}{
+		{"V1__create_users.sql", MigrationFile{PrefixVersioned, "1", "create users", false}, true},
+		{"U20240131120000__create_users.sql", MigrationFile{PrefixUndo, "20240131120000", "create users", false}, true},
+		{"R__users_view.sql", MigrationFile{PrefixRepeatable, VersionRepeatable, "users view", false}, true},
+		{"V2__seed.sql.gz", MigrationFile{PrefixVersioned, "2", "seed", true}, true},
+		{"R1__users_view.sql", MigrationFile{}, false},
+		{"V__missing_version.sql", MigrationFile{}, false},
+		{"V1_create_users.sql", MigrationFile{}, false},
+		{"V1__create_users.txt", MigrationFile{}, false},
+		{"V1__create_users.gz", MigrationFile{}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := ParseMigrationFile(test.name)
+			if ok != test.ok || !reflect.DeepEqual(test.want, got) {
+				t.Errorf("want: %v %v, got: %v %v", test.want, test.ok, got, ok)
+			}
+			if ok && got.Name() != test.name {
+				t.Errorf("want name: %s, got: %s", test.name, got.Name())
+			}
+		})
+	}
+}
+
+func TestGenerateMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	now := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	paths, err := generateMigration(dir, "Add users table", GenerateOptions{Undo: true}, now)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	want := []string{filepath.Join(dir, "V20240131120000__add_users_table.sql"), filepath.Join(dir, "U20240131120000__add_users_table.sql")}
+	if !reflect.DeepEqual(want, paths) {
+		t.Errorf("want: %v, got: %v", want, paths)
+	}
+	paths, err = generateMigration(dir, "add index", GenerateOptions{}, now)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if want := filepath.Join(dir, "V20240131120001__add_index.sql"); paths[0] != want {
+		t.Errorf("want: %s, got: %s", want, paths[0])
+	}
+	m := newTestMigrator(t, &memSupport{})
+	if err := m.AddDir(dir); err != nil {
+		t.Fatalf("add dir: %v", err)
+	}
+	if len(m.migrations) != 2 || m.migrations[0].Description != "add users table" {
+		t.Errorf("unexpected migrations:\n%s", m.migrations)
+	}
+}
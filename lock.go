@@ -0,0 +1,64 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Locker is implemented by Support implementations that are able to guard
+// the migrations table against concurrent migrators.
+// Lock waits up to timeout for the lock to become available; a timeout of zero fails fast. If the lock is still held
+// by another migrator then, the error wraps ErrLockTimeout.
+type Locker interface {
+	Lock(con *sql.DB, timeout time.Duration) error
+	Unlock(con *sql.DB) error
+}
+
+// ForceUnlocker is implemented by Locker implementations whose lock outlives a migrator that crashed while holding it,
+// e.g. the lock row of SQLite, as opposed to a lock that is released with the session of its holder.
+type ForceUnlocker interface {
+	ForceUnlock(con *sql.DB) error
+}
+
+const lockPollInterval = 100 * time.Millisecond
+
+// SetLockTimeout sets how long Migrate and Baseline wait for a concurrent migrator to release its lock.
+func (m *Migrator) SetLockTimeout(timeout time.Duration) {
+	m.lockTimeout = timeout
+}
+
+func (m *Migrator) withLock(f func() error) error {
+	m.running.Lock()
+	defer m.running.Unlock()
+	l, ok := m.support.(Locker)
+	if !ok {
+		return f()
+	}
+	if err := l.Lock(m.db, m.lockTimeout); err != nil {
+		return err
+	}
+	err := f()
+	if uErr := l.Unlock(m.db); uErr != nil && err == nil {
+		err = uErr
+	}
+	return err
+}
+
+// ForceUnlock releases the lock of the Support whoever holds it, e.g. after a migrator crashed while holding it. It
+// must not be called while another migrator is running, as that migrator would no longer be guarded against
+// concurrent ones. ForceUnlock requires a Support that is a ForceUnlocker and a confirmation if a ConfirmFunc is set.
+func (m *Migrator) ForceUnlock() error {
+	fu, ok := m.support.(ForceUnlocker)
+	if !ok {
+		return fmt.Errorf("force unlock requires a support that is a force unlocker: %T", m.support)
+	}
+	if err := m.confirmed(OpForceUnlock, "release the migration lock whoever holds it"); err != nil {
+		return err
+	}
+	if err := fu.ForceUnlock(m.db); err != nil {
+		return fmt.Errorf("force unlock: %+v", err)
+	}
+	m.log(LevelWarn, "forced unlock", Fields{})
+	return nil
+}
@@ -0,0 +1,100 @@
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// Level is the severity of a log entry.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+}
+
+// Fields are the structured values attached to a log entry.
+type Fields map[string]interface{}
+
+// Logger receives structured log entries from the Migrator.
+type Logger interface {
+	Log(level Level, msg string, fields Fields)
+}
+
+// Log implements Logger by formatting the entry as a single line of the form "msg key=value ...".
+// A nil LogFunc discards the entry.
+func (f LogFunc) Log(level Level, msg string, fields Fields) {
+	if f == nil {
+		return
+	}
+	buf := &bytes.Buffer{}
+	if level != LevelInfo {
+		fmt.Fprintf(buf, "%s: ", level)
+	}
+	buf.WriteString(msg)
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(buf, " %s=%v", k, fields[k])
+	}
+	f("%s", buf.String())
+}
+
+// SetLogger replaces the logger the Migrator has been created with. A nil logger discards all entries.
+func (m *Migrator) SetLogger(logger Logger) {
+	m.logger = logger
+}
+
+// SetLogLevel sets the lowest level that is logged. The default LevelDebug logs everything, LevelInfo suppresses the
+// entries about migrations that have already been applied, and LevelWarn logs only warnings and failures.
+func (m *Migrator) SetLogLevel(level Level) {
+	m.logLevel = level
+}
+
+func (m *Migrator) log(level Level, msg string, fields Fields) {
+	if level >= LevelWarn {
+		m.reported(func(r *Report) { r.warn(m.now(), level, msg, fields) })
+	}
+	if m.logger == nil || level < m.logLevel {
+		return
+	}
+	m.logger.Log(level, msg, fields)
+}
+
+// migratorLogger logs through a Migrator, e.g. for Go migrations, so that its log level applies.
+type migratorLogger struct {
+	m *Migrator
+}
+
+func (l migratorLogger) Log(level Level, msg string, fields Fields) {
+	l.m.log(level, msg, fields)
+}
+
+func migrationFields(mig Migration) Fields {
+	return Fields{
+		"version":     mig.Version,
+		"description": mig.Description,
+		"type":        mig.Type,
+	}
+}
@@ -0,0 +1,73 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLogFunc(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  Level
+		msg    string
+		fields Fields
+		want   string
+	}{
+		{"info", LevelInfo, "installing", Fields{"version": Version("1"), "description": "init"}, "installing description=init version=1"},
+		{"error", LevelError, "installed", Fields{"status": StatusFailed}, "error: installed status=failed"},
+		{"no fields", LevelDebug, "skipping", nil, "debug: skipping"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got string
+			log := LogFunc(func(format string, args ...interface{}) {
+				got = fmt.Sprintf(format, args...)
+			})
+			log.Log(test.level, test.msg, test.fields)
+			if got != test.want {
+				t.Errorf("want: %q, got: %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	s := &memSupport{}
+	m := NewMigrator(nil, nil, s)
+	m.AddGoMigration("1", "one", func(con *sql.DB) error { return nil })
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate with nil log: %v", err)
+	}
+	var got []string
+	m.SetLogger(LogFunc(func(format string, args ...interface{}) {
+		got = append(got, fmt.Sprintf(format, args...))
+	}))
+	m.SetLogLevel(LevelInfo)
+	m.AddGoMigration("2", "two", func(con *sql.DB) error { return nil })
+	m.Migrate()
+	if len(got) == 0 {
+		t.Errorf("want installations to be logged")
+	}
+	for _, line := range got {
+		if strings.HasPrefix(line, "debug: ") {
+			t.Errorf("unexpected debug entry: %s", line)
+		}
+	}
+	got = nil
+	m.SetLogLevel(LevelWarn)
+	m.AddGoMigration("3", "three", func(con *sql.DB) error { return errors.New("boom") })
+	m.Migrate()
+	if len(got) == 0 {
+		t.Errorf("want failures to be logged")
+	}
+	for _, line := range got {
+		if !strings.HasPrefix(line, "warn: ") && !strings.HasPrefix(line, "error: ") {
+			t.Errorf("unexpected entry: %s", line)
+		}
+	}
+	m.SetLogger(nil)
+	m.Migrate()
+}
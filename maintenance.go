@@ -0,0 +1,31 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Maintainer is implemented by Support implementations that refresh the statistics of the query planner, e.g. with
+// ANALYZE, so that queries are planned well right after structural changes.
+type Maintainer interface {
+	Maintain(con *sql.DB) error
+}
+
+// SetMaintenance makes Migrate refresh the statistics of the database after a run that applied migrations.
+// The Support has to implement Maintainer.
+func (m *Migrator) SetMaintenance(maintain bool) {
+	m.maintenance = maintain
+}
+
+// maintain refreshes the statistics of the database if enabled by SetMaintenance.
+func (m *Migrator) maintain() error {
+	if !m.maintenance {
+		return nil
+	}
+	mt, ok := m.support.(Maintainer)
+	if !ok {
+		return fmt.Errorf("maintenance requires a support that maintains statistics: %T", m.support)
+	}
+	m.log(LevelInfo, "refreshing statistics", nil)
+	return mt.Maintain(m.db)
+}
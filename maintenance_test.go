@@ -0,0 +1,58 @@
+package migrate
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+type maintainSupport struct {
+	memSupport
+	maintained int
+}
+
+func (s *maintainSupport) Maintain(con *sql.DB) error {
+	s.maintained++
+	return nil
+}
+
+func TestMigrateMaintenance(t *testing.T) {
+	s := &maintainSupport{}
+	m := newTestMigrator(t, s)
+	m.AddGoMigration("1", "one", func(con *sql.DB) error { return nil })
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if s.maintained != 0 {
+		t.Errorf("maintenance is disabled by default")
+	}
+	m.SetMaintenance(true)
+	m.AddGoMigration("2", "two", func(con *sql.DB) error { return nil })
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if s.maintained != 1 {
+		t.Errorf("want maintenance after applying migrations only, got: %d", s.maintained)
+	}
+	m = newTestMigrator(t, &memSupport{})
+	m.SetMaintenance(true)
+	m.AddGoMigration("1", "one", func(con *sql.DB) error { return nil })
+	if err := m.Migrate(); err == nil {
+		t.Errorf("want error for a support that does not maintain statistics")
+	}
+}
+
+func TestSQLiteMaintain(t *testing.T) {
+	db, log := openFake(t.Name())
+	s := NewSQLiteSupport(WithAttachment("aux", "aux.db"))
+	if err := s.Maintain(db); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`ANALYZE;`, `ANALYZE "aux";`}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
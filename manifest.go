@@ -0,0 +1,181 @@
+package migrate
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// Manifest freezes the set of migrations of a release, e.g. generated at build time with WriteManifest and embedded
+// into the release artifact. A Migrator with a manifest set by SetManifest refuses to migrate if the registered
+// migrations differ from it, so unreviewed migrations cannot sneak into a release.
+type Manifest struct {
+	Migrations []ManifestEntry `json:"migrations"`
+	// Hash is the hex encoded SHA-256 hash of the entries.
+	Hash string `json:"hash"`
+	// Signature is the hex encoded HMAC-SHA256 of Hash if the manifest has been signed.
+	Signature string `json:"signature,omitempty"`
+}
+
+// ManifestEntry identifies a migration in a Manifest.
+type ManifestEntry struct {
+	Version     Version `json:"version"`
+	Description string  `json:"description"`
+	Type        Type    `json:"type"`
+	Checksum    string  `json:"checksum,omitempty"`
+}
+
+func (e ManifestEntry) String() string {
+	return fmt.Sprintf("@ManifestEntry|version=%s|description=%s|type=%s|checksum=%s", e.Version, e.Description, e.Type, e.Checksum)
+}
+
+// NewManifest returns the manifest of ms. The manifest is signed with key unless key is empty.
+func NewManifest(ms Migrations, key []byte) Manifest {
+	mf := Manifest{Migrations: []ManifestEntry{}}
+	for _, mig := range ms.Sorted() {
+		mf.Migrations = append(mf.Migrations, ManifestEntry{
+			Version:     mig.Version,
+			Description: mig.Description,
+			Type:        mig.Type,
+			Checksum:    mig.Checksum,
+		})
+	}
+	mf.Hash = manifestHash(mf.Migrations)
+	if len(key) > 0 {
+		mf.Signature = manifestSignature(mf.Hash, key)
+	}
+	return mf
+}
+
+// ReadManifest reads a manifest written by WriteManifest.
+func ReadManifest(file string) (Manifest, error) {
+	mf := Manifest{}
+	bs, err := ioutil.ReadFile(file)
+	if err != nil {
+		return mf, err
+	}
+	if err := json.Unmarshal(bs, &mf); err != nil {
+		return mf, fmt.Errorf("parse manifest: %s: %+v", file, err)
+	}
+	return mf, nil
+}
+
+// Manifest returns the manifest of the registered migrations signed with key unless key is empty.
+func (m *Migrator) Manifest(key []byte) Manifest {
+	return NewManifest(m.local(), key)
+}
+
+// WriteManifest writes the manifest of the registered migrations signed with key to file.
+func (m *Migrator) WriteManifest(file string, key []byte) error {
+	bs, err := json.MarshalIndent(m.Manifest(key), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(bs, '\n'), 0644)
+}
+
+// SetManifest makes Migrate and Validate fail with ErrManifestMismatch if the registered migrations differ from mf. If
+// key is not empty, mf has to be signed with it.
+func (m *Migrator) SetManifest(mf Manifest, key []byte) {
+	m.manifest = &mf
+	m.manifestKey = key
+}
+
+// checkManifest verifies the registered migrations against the manifest set by SetManifest.
+func (m *Migrator) checkManifest(migrations Migrations, repeatable Migrations) error {
+	if m.manifest == nil {
+		return nil
+	}
+	mf := m.manifest
+	if hash := manifestHash(mf.Migrations); hash != mf.Hash {
+		return &ManifestError{Detail: fmt.Sprintf("hash of entries is %s, manifest has %s", hash, mf.Hash)}
+	}
+	if len(m.manifestKey) > 0 && !hmac.Equal([]byte(manifestSignature(mf.Hash, m.manifestKey)), []byte(mf.Signature)) {
+		return &ManifestError{Detail: "invalid signature"}
+	}
+	frozen := map[string]ManifestEntry{}
+	for _, e := range mf.Migrations {
+		frozen[manifestID(e.Version, e.Description)] = e
+	}
+	err := &ManifestError{}
+	registered := map[string]bool{}
+	for _, mig := range append(append(Migrations{}, migrations...), repeatable...) {
+		key := manifestID(mig.Version, mig.Description)
+		registered[key] = true
+		e, ok := frozen[key]
+		switch {
+		case !ok:
+			err.Unlisted = append(err.Unlisted, mig)
+		case e.Description != mig.Description || e.Type != mig.Type || e.Checksum != mig.Checksum:
+			err.Changed = append(err.Changed, mig)
+		}
+	}
+	for _, e := range mf.Migrations {
+		if !registered[manifestID(e.Version, e.Description)] {
+			err.Missing = append(err.Missing, e)
+		}
+	}
+	if len(err.Unlisted) > 0 || len(err.Changed) > 0 || len(err.Missing) > 0 {
+		return err
+	}
+	return nil
+}
+
+// ManifestError reports registered migrations that differ from the manifest set by SetManifest.
+type ManifestError struct {
+	// Unlisted are registered migrations that are not in the manifest.
+	Unlisted Migrations
+	// Changed are registered migrations whose description, type or checksum differs from the manifest.
+	Changed Migrations
+	// Missing are the entries of the manifest that are not registered.
+	Missing []ManifestEntry
+	// Detail describes a manifest that has been tampered with.
+	Detail string
+}
+
+func (e *ManifestError) Error() string {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "%v", ErrManifestMismatch)
+	if e.Detail != "" {
+		fmt.Fprintf(buf, ": %s", e.Detail)
+	}
+	for _, mig := range e.Unlisted {
+		fmt.Fprintf(buf, "\nunlisted: %s", mig)
+	}
+	for _, mig := range e.Changed {
+		fmt.Fprintf(buf, "\nchanged: %s", mig)
+	}
+	for _, entry := range e.Missing {
+		fmt.Fprintf(buf, "\nmissing: %s", entry)
+	}
+	return buf.String()
+}
+
+func (e *ManifestError) Unwrap() error {
+	return ErrManifestMismatch
+}
+
+func manifestID(v Version, description string) string {
+	if v == VersionRepeatable {
+		return string(v) + "\x00" + description
+	}
+	return string(v)
+}
+
+func manifestHash(entries []ManifestEntry) string {
+	h := sha256.New()
+	for _, e := range entries {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\n", e.Version, e.Description, e.Type, e.Checksum)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func manifestSignature(hash string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(hash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,67 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "manifest.json")
+	noop := func(con *sql.DB) error { return nil }
+	key := []byte("secret")
+
+	build := newTestMigrator(t, &memSupport{})
+	build.AddGoMigration("1", "one", noop, Fingerprint("v1"))
+	build.AddRepeatableGoMigration("view", noop)
+	if err := build.WriteManifest(file, key); err != nil {
+		t.Fatal(err)
+	}
+	mf, err := ReadManifest(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestMigrator(t, &memSupport{})
+	m.SetManifest(mf, key)
+	m.AddGoMigration("1", "one", noop, Fingerprint("v1"))
+	m.AddRepeatableGoMigration("view", noop)
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	m.AddGoMigration("2", "unreviewed", noop)
+	err = m.Migrate()
+	var me *ManifestError
+	if !errors.As(err, &me) || len(me.Unlisted) != 1 || me.Unlisted[0].Version != "2" {
+		t.Fatalf("want unlisted migration, got: %v", err)
+	}
+	if err := m.Validate(); !errors.Is(err, ErrManifestMismatch) {
+		t.Errorf("want validate to fail, got: %v", err)
+	}
+
+	changed := newTestMigrator(t, &memSupport{})
+	changed.SetManifest(mf, key)
+	changed.AddGoMigration("1", "one", noop, Fingerprint("v2"))
+	if err := changed.Migrate(); !errors.As(err, &me) || len(me.Changed) != 1 || len(me.Missing) != 1 {
+		t.Errorf("want changed and missing migrations, got: %v", err)
+	}
+
+	forged := mf
+	forged.Migrations = append([]ManifestEntry{}, mf.Migrations...)
+	forged.Migrations = append(forged.Migrations, ManifestEntry{Version: "2", Description: "unreviewed", Type: TypeGo})
+	forged.Hash = manifestHash(forged.Migrations)
+	m.SetManifest(forged, key)
+	if err := m.Migrate(); err == nil || !strings.Contains(err.Error(), "invalid signature") {
+		t.Errorf("want invalid signature, got: %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+// MetadataUpgrade is a change of the metadata table introduced by a version of this package, e.g. a new column.
+type MetadataUpgrade struct {
+	Version     int
+	Description string
+	// Apply changes a metadata table of the previous version. It has to tolerate tables that already contain the change.
+	Apply func(con *sql.DB) error
+}
+
+// VersionedMetadata is implemented by Support implementations that version the schema of their metadata table,
+// so that tables created by earlier versions of this package are upgraded on first use instead of breaking on
+// INSERT. CreateMigrationsTable always creates the latest schema.
+type VersionedMetadata interface {
+	// MetadataUpgrades returns all upgrades ordered by version.
+	MetadataUpgrades() []MetadataUpgrade
+	// MetadataVersion returns the version of the metadata table, 0 if it has never been upgraded.
+	MetadataVersion(con *sql.DB) (int, error)
+	SetMetadataVersion(con *sql.DB, version int) error
+}
+
+// upgradeMetadata applies the pending upgrades of an existing metadata table. It is a no-op after it succeeded once.
+func (m *Migrator) upgradeMetadata() error {
+	vm, ok := m.support.(VersionedMetadata)
+	m.mu.Lock()
+	upgraded := m.metadataUpgraded
+	m.mu.Unlock()
+	if !ok || upgraded {
+		return nil
+	}
+	exists, err := m.support.ExistsMigrationsTable(m.db)
+	if err != nil || !exists {
+		return err
+	}
+	current, err := vm.MetadataVersion(m.db)
+	if err != nil {
+		return fmt.Errorf("metadata version: %+v", err)
+	}
+	upgrades := vm.MetadataUpgrades()
+	if latest := latestMetadataVersion(upgrades); current > latest {
+		return fmt.Errorf("metadata table version %d is newer than the supported version %d", current, latest)
+	}
+	for _, u := range upgrades {
+		if u.Version <= current {
+			continue
+		}
+		m.log(LevelInfo, "upgrading metadata table", Fields{"version": u.Version, "description": u.Description})
+		if err := u.Apply(m.db); err != nil {
+			return fmt.Errorf("upgrade metadata table: %d: %+v", u.Version, err)
+		}
+		if err := vm.SetMetadataVersion(m.db, u.Version); err != nil {
+			return fmt.Errorf("set metadata version: %d: %+v", u.Version, err)
+		}
+	}
+	m.mu.Lock()
+	m.metadataUpgraded = true
+	m.mu.Unlock()
+	return nil
+}
+
+// newMigrationsTable creates the metadata table and records it as up to date.
+func (m *Migrator) newMigrationsTable() error {
+	if err := m.support.CreateMigrationsTable(m.db); err != nil {
+		return err
+	}
+	vm, ok := m.support.(VersionedMetadata)
+	if !ok {
+		return nil
+	}
+	if err := vm.SetMetadataVersion(m.db, latestMetadataVersion(vm.MetadataUpgrades())); err != nil {
+		return fmt.Errorf("set metadata version: %+v", err)
+	}
+	return nil
+}
+
+func latestMetadataVersion(upgrades []MetadataUpgrade) int {
+	latest := 0
+	for _, u := range upgrades {
+		if u.Version > latest {
+			latest = u.Version
+		}
+	}
+	return latest
+}
+
+// listMigrations upgrades the metadata table if needed and lists the applied migrations ordered by rank, whatever
+// order the Support lists them in.
+func (m *Migrator) listMigrations() (Migrations, error) {
+	if err := m.upgradeMetadata(); err != nil {
+		return nil, err
+	}
+	return m.findMigrations(MigrationFilter{})
+}
+
+// sortByRank sorts the applied migrations ms by rank, as the state of repeatable migrations depends on their order.
+func sortByRank(ms Migrations) {
+	sort.SliceStable(ms, func(i, j int) bool {
+		return ms[i].Rank < ms[j].Rank
+	})
+}
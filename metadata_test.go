@@ -0,0 +1,57 @@
+package migrate
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+type versionedSupport struct {
+	memSupport
+	version int
+	applied []int
+}
+
+func (s *versionedSupport) MetadataUpgrades() []MetadataUpgrade {
+	upgrade := func(v int) MetadataUpgrade {
+		return MetadataUpgrade{Version: v, Apply: func(con *sql.DB) error {
+			s.applied = append(s.applied, v)
+			return nil
+		}}
+	}
+	return []MetadataUpgrade{upgrade(1), upgrade(2), upgrade(3)}
+}
+
+func (s *versionedSupport) MetadataVersion(con *sql.DB) (int, error) {
+	return s.version, nil
+}
+
+func (s *versionedSupport) SetMetadataVersion(con *sql.DB, version int) error {
+	s.version = version
+	return nil
+}
+
+func TestUpgradeMetadata(t *testing.T) {
+	s := &versionedSupport{}
+	m := newTestMigrator(t, s)
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if s.version != 3 || len(s.applied) != 0 {
+		t.Fatalf("want new table at version 3 without upgrades, got: %d %v", s.version, s.applied)
+	}
+
+	s.version = 1
+	m = newTestMigrator(t, s)
+	m.Info()
+	m.Info()
+	if s.version != 3 || len(s.applied) != 2 || s.applied[0] != 2 || s.applied[1] != 3 {
+		t.Fatalf("want upgrades 2 and 3 applied once, got: %d %v", s.version, s.applied)
+	}
+
+	s.version = 4
+	m = newTestMigrator(t, s)
+	if err := m.Migrate(); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Fatalf("want error for newer metadata table, got: %v", err)
+	}
+}
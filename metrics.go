@@ -0,0 +1,36 @@
+package migrate
+
+import "time"
+
+// Metrics receives measurements from the Migrator, e.g. to update Prometheus collectors.
+type Metrics interface {
+	// ObserveMigration is called for every installed migration. Its outcome is reported by mig.Status.
+	ObserveMigration(mig Migration, duration time.Duration)
+	// ObserveRun is called at the end of every call to Migrate.
+	ObserveRun(duration time.Duration, err error)
+	// SetCurrentVersion is called with the latest successfully applied version after every call to Migrate.
+	SetCurrentVersion(version Version)
+}
+
+// SetMetrics sets the receiver of migration measurements.
+func (m *Migrator) SetMetrics(metrics Metrics) {
+	m.metrics = metrics
+}
+
+func (m *Migrator) observeMigration(mig Migration, duration time.Duration) {
+	if m.metrics != nil {
+		m.metrics.ObserveMigration(mig, duration)
+	}
+}
+
+func (m *Migrator) observeRun(start time.Time, err error) {
+	if m.metrics == nil {
+		return
+	}
+	m.metrics.ObserveRun(m.since(start), err)
+	applied, lErr := m.findMigrations(MigrationFilter{Versioned: true, Statuses: []Status{StatusSuccess}})
+	if lErr != nil {
+		return
+	}
+	m.metrics.SetCurrentVersion(m.versionOrdering.latest(applied))
+}
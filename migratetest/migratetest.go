@@ -0,0 +1,146 @@
+// Package migratetest provides migrated databases to Go tests. By default every test gets its own in-memory SQLite
+// database, the test binary has to import a SQLite driver registered as "sqlite3":
+//
+//	func TestUsers(t *testing.T) {
+//		db := migratetest.New(t, migratetest.Options{}, func(m *migrate.Migrator) {
+//			m.AddDir("../migrations")
+//		})
+//		db.Exec("INSERT INTO users (id) VALUES (1)")
+//		db.Reset()
+//		db.AssertSchema("testdata/schema.sql")
+//	}
+//
+// Setting the environment variables MIGRATETEST_DRIVER and MIGRATETEST_DSN runs the tests against another database,
+// e.g. one started in a container. Such a database is shared by the tests and cleaned before it is migrated.
+package migratetest
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cognicraft/migrate"
+)
+
+// Environment variables selecting the database of the tests.
+const (
+	EnvDriver = "MIGRATETEST_DRIVER"
+	EnvDSN    = "MIGRATETEST_DSN"
+	// EnvUpdate makes AssertSchema write the golden files instead of comparing them if it is set to a non-empty value.
+	EnvUpdate = "MIGRATETEST_UPDATE"
+)
+
+// DefaultDriver is the database/sql driver of in-memory SQLite databases.
+const DefaultDriver = "sqlite3"
+
+// Options configure the database of a test.
+type Options struct {
+	// Driver is the database/sql driver. It defaults to the environment variable MIGRATETEST_DRIVER or DefaultDriver.
+	Driver string
+	// DSN is the data source name. It defaults to the environment variable MIGRATETEST_DSN or an in-memory SQLite
+	// database of its own.
+	DSN string
+	// Support is the Support of the database. It defaults to the one registered for Driver.
+	Support migrate.Support
+}
+
+// DB is a migrated database of a test.
+type DB struct {
+	*sql.DB
+	// Migrator is the Migrator that has migrated the database.
+	Migrator *migrate.Migrator
+	t        testing.TB
+}
+
+var databases int64
+
+// New opens the database selected by opts, registers the migrations with register and applies them. A database that
+// is not of its own is cleaned first. The test fails if the database cannot be migrated. The database is closed when
+// the test ends.
+func New(t testing.TB, opts Options, register func(m *migrate.Migrator)) *DB {
+	t.Helper()
+	shared := true
+	if opts.Driver == "" {
+		opts.Driver = os.Getenv(EnvDriver)
+	}
+	if opts.DSN == "" {
+		opts.DSN = os.Getenv(EnvDSN)
+	}
+	if opts.Driver == "" {
+		opts.Driver = DefaultDriver
+	}
+	if opts.DSN == "" {
+		shared = false
+		opts.DSN = memoryDSN(t.Name())
+	}
+	dsnOpts := []migrate.DSNOption{
+		migrate.WithConnectRetry(migrate.RetryPolicy{MaxAttempts: 10, Backoff: migrate.ExponentialBackoff(100*time.Millisecond, 2*time.Second)}),
+	}
+	if opts.Support != nil {
+		dsnOpts = append(dsnOpts, migrate.WithSupport(opts.Support))
+	}
+	m, err := migrate.NewMigratorFromDSN(t.Logf, opts.Driver, opts.DSN, dsnOpts...)
+	if err != nil {
+		t.Fatalf("migratetest: open: %v", err)
+	}
+	t.Cleanup(func() {
+		m.Close()
+	})
+	if register != nil {
+		register(m)
+	}
+	db := &DB{DB: m.DB(), Migrator: m, t: t}
+	if shared {
+		db.Reset()
+		return db
+	}
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migratetest: migrate: %v", err)
+	}
+	return db
+}
+
+// Reset cleans the database and migrates it again, e.g. between the subtests of a test. The test fails if the
+// database cannot be reset.
+func (db *DB) Reset() {
+	db.t.Helper()
+	if err := db.Migrator.Clean(); err != nil {
+		db.t.Fatalf("migratetest: clean: %v", err)
+	}
+	if err := db.Migrator.Migrate(); err != nil {
+		db.t.Fatalf("migratetest: migrate: %v", err)
+	}
+}
+
+// AssertSchema fails the test with a diff if the schema differs from the golden file, see migrate.Migrator.VerifySchema.
+// The golden file is written instead if the environment variable MIGRATETEST_UPDATE is set.
+func (db *DB) AssertSchema(golden string) {
+	db.t.Helper()
+	if os.Getenv(EnvUpdate) != "" {
+		if err := db.Migrator.WriteGoldenSchema(golden); err != nil {
+			db.t.Fatalf("migratetest: %v", err)
+		}
+		return
+	}
+	if err := db.Migrator.VerifySchema(golden); err != nil {
+		db.t.Errorf("migratetest: %v", err)
+	}
+}
+
+// memoryDSN returns the DSN of an in-memory SQLite database that is shared by the connections of a pool but not by
+// other tests.
+func memoryDSN(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	return fmt.Sprintf("file:%s_%d?mode=memory&cache=shared", name, atomic.AddInt64(&databases, 1))
+}
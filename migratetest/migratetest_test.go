@@ -0,0 +1,169 @@
+package migratetest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cognicraft/migrate"
+)
+
+// fakeDriver accepts every statement and counts the rows inserted into the table of its DSN.
+type fakeDriver struct{}
+
+var (
+	fakeMu   sync.Mutex
+	fakeRows = map[string]int{}
+	fakeDSNs []string
+)
+
+func init() {
+	sql.Register("migratetest-fake", fakeDriver{})
+}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	fakeDSNs = append(fakeDSNs, name)
+	return fakeConn{name: name}, nil
+}
+
+type fakeConn struct {
+	name string
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("fake: prepare not supported")
+}
+
+func (c fakeConn) Close() error {
+	return nil
+}
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("fake: transactions not supported")
+}
+
+func (c fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if strings.HasPrefix(query, "INSERT") {
+		fakeMu.Lock()
+		fakeRows[c.name]++
+		fakeMu.Unlock()
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string              { return []string{"value"} }
+func (emptyRows) Close() error                   { return nil }
+func (emptyRows) Next(dest []driver.Value) error { return io.EOF }
+
+// memSupport keeps the metadata table in memory and clears the rows of the fake database on Clean.
+type memSupport struct {
+	mu         sync.Mutex
+	migrations migrate.Migrations
+	cleaned    int
+}
+
+func (s *memSupport) ExistsMigrationsTable(con *sql.DB) (bool, error) {
+	return true, nil
+}
+
+func (s *memSupport) CreateMigrationsTable(con *sql.DB) error {
+	return nil
+}
+
+func (s *memSupport) RecordMigration(con *sql.DB, m migrate.Migration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.migrations = append(s.migrations, m)
+	return nil
+}
+
+func (s *memSupport) ListMigrations(con *sql.DB) (migrate.Migrations, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append(migrate.Migrations{}, s.migrations...), nil
+}
+
+func (s *memSupport) Clean(con *sql.DB) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.migrations = nil
+	s.cleaned++
+	return nil
+}
+
+func register(m *migrate.Migrator) {
+	m.AddSQLMigration("1", "create users", "CREATE TABLE users (id INT);")
+	m.AddSQLMigration("2", "seed users", "INSERT INTO users (id) VALUES (1);")
+}
+
+func TestNew(t *testing.T) {
+	s := &memSupport{}
+	db := New(t, Options{Driver: "migratetest-fake", Support: s}, register)
+	if got := len(s.migrations); got != 2 {
+		t.Fatalf("want 2 migrations, got: %d", got)
+	}
+	if s.cleaned != 0 {
+		t.Errorf("own database must not be cleaned")
+	}
+	if _, err := db.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	dsn := fakeDSNs[len(fakeDSNs)-1]
+	if !strings.HasPrefix(dsn, "file:TestNew_") || !strings.Contains(dsn, "mode=memory") {
+		t.Errorf("unexpected dsn: %s", dsn)
+	}
+	db.Reset()
+	if s.cleaned != 1 || len(s.migrations) != 2 || fakeRows[dsn] != 2 {
+		t.Errorf("unexpected reset: cleaned=%d migrations=%d rows=%d", s.cleaned, len(s.migrations), fakeRows[dsn])
+	}
+}
+
+func TestNewShared(t *testing.T) {
+	s := &memSupport{}
+	New(t, Options{Driver: "migratetest-fake", DSN: "shared", Support: s}, register)
+	if s.cleaned != 1 || len(s.migrations) != 2 {
+		t.Errorf("shared database must be cleaned first: cleaned=%d migrations=%d", s.cleaned, len(s.migrations))
+	}
+}
+
+type dumpSupport struct {
+	memSupport
+}
+
+func (s *dumpSupport) DumpSchema(con *sql.DB, w io.Writer) error {
+	_, err := io.WriteString(w, "CREATE TABLE users (id INT);\n")
+	return err
+}
+
+func TestAssertSchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migratetest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	golden := filepath.Join(dir, "schema.sql")
+	db := New(t, Options{Driver: "migratetest-fake", Support: &dumpSupport{}}, register)
+	os.Setenv(EnvUpdate, "1")
+	db.AssertSchema(golden)
+	os.Unsetenv(EnvUpdate)
+	if got, _ := ioutil.ReadFile(golden); string(got) != "CREATE TABLE users (id INT);\n" {
+		t.Errorf("unexpected golden file: %q", got)
+	}
+	db.AssertSchema(golden)
+}
@@ -2,11 +2,13 @@ package migrate
 
 import (
 	"bytes"
-	"crypto/md5"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"io"
-	"strconv"
+	"sort"
+	"sync"
 	"time"
 )
 
@@ -17,23 +19,122 @@ const (
 
 type LogFunc func(format string, args ...interface{})
 
+// NewMigrator creates a Migrator for db with support that logs to log. It is New with WithLogger, which does not fail.
 func NewMigrator(log LogFunc, db *sql.DB, support Support) *Migrator {
-	return &Migrator{
-		log:     log,
-		db:      db,
-		support: support,
-	}
+	m, _ := New(db, support, WithLogger(log))
+	return m
 }
 
 type Migrator struct {
-	log        LogFunc
-	db         *sql.DB
-	support    Support
-	migrations Migrations
-	repeatable Migrations
+	logger      Logger
+	logLevel    Level
+	db          *sql.DB
+	support     Support
+	migrations  Migrations
+	repeatable  Migrations
+	runToken    string
+	runLabels   map[string]string
+	lockTimeout time.Duration
+	hooks       Hooks
+
+	warnOnChecksumMismatch bool
+	allowShell             bool
+	waiter                 Waiter
+	batch                  Batch
+	lockstep               func(mig Migration) error
+	customSplitter         *Splitter
+	baselineOnMigrate      *Migration
+	metrics                Metrics
+	recovery               Recovery
+	schemaDump             io.Writer
+	schemaDumpFile         string
+	placeholders           map[string]string
+	checksumAlgorithm      ChecksumAlgorithm
+	environment            map[string]bool
+	timeout                time.Duration
+	statementTimeout       time.Duration
+	deadline               time.Time
+	abort                  context.Context
+	confirm                ConfirmFunc
+	linters                []Linter
+	subscribers            []Subscriber
+	normalize              bool
+	normalization          Normalization
+	metadataUpgraded       bool
+	identity               string
+	callbacks              []Callback
+	savepoints             bool
+	template               *Template
+	repeatableWorkers      int
+	retryPolicy            RetryPolicy
+	election               *Election
+	sessionSetup           []string
+	ownsDB                 bool
+	pin                    *pinnedConnector
+	versionOrdering        versionOrder
+	target                 Version
+	result                 *MigrationResult
+	maintenance            bool
+	lockWaitTimeout        time.Duration
+	statementLog           *StatementLog
+	cherryPick             map[Version]bool
+	skipVersions           map[Version]bool
+	outOfBand              bool
+	manifest               *Manifest
+	manifestKey            []byte
+	minAppVersion          string
+	secrets                SecretResolver
+	plan                   *Plan
+	backup                 *Backup
+	pruneRepeatable        bool
+	onlyTags               map[string]bool
+	excludeTags            map[string]bool
+	destructivePolicy      DestructivePolicy
+	connectRetry           *RetryPolicy
+	recordScripts          bool
+	notifiers              []Notifier
+	reportWriter           io.Writer
+	reportFormat           ReportFormat
+	reportFile             string
+	outOfOrder             bool
+	validateOnMigrate      bool
+	dryRun                 bool
+	fastCheck              bool
+	strict                 bool
+	allowNewerSchema       bool
+	clock                  Clock
+
+	// mu guards migrations, repeatable, subscribers, notifiers, callbacks and result, running serializes the operations
+	// that change the database and recording serializes the records of repeatable migrations that are installed in
+	// parallel. reporting guards report, the report of the current run.
+	report    *Report
+	reporting sync.Mutex
+	mu        sync.Mutex
+	running   sync.Mutex
+	recording sync.Mutex
+}
+
+// SetRunToken sets an idempotency token for subsequent calls to Migrate.
+// A run that has already completed successfully with the same token is not repeated,
+// which makes it safe to retry a whole migration job.
+func (m *Migrator) SetRunToken(token string) {
+	m.runToken = token
 }
 
+// SetWarnOnChecksumMismatch makes Migrate log a warning instead of failing
+// when the checksum of an applied migration differs from the local one.
+func (m *Migrator) SetWarnOnChecksumMismatch(warn bool) {
+	m.warnOnChecksumMismatch = warn
+}
+
+// Add adds a migration. It is safe to add migrations concurrently and after Migrate, which applies them on its next call.
+// Migrations are applied in the order of their versions and descriptions, not in the order they have been added. A
+// versioned migration added below the last applied version makes the next call to Migrate fail with ErrOutOfOrder
+// unless out of order migrations are allowed, see SetOutOfOrder.
 func (m *Migrator) Add(mig Migration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mig = m.withChecksum(mig)
 	if mig.IsRepeatable() {
 		m.repeatable = append(m.repeatable, mig)
 	} else {
@@ -41,98 +142,278 @@ func (m *Migrator) Add(mig Migration) {
 	}
 }
 
-func (m *Migrator) AddSQLMigration(version Version, description string, script string) {
-	m.Add(Migration{
-		Version:     version,
-		Description: description,
-		Type:        TypeSQL,
-		Checksum:    SQLChecksum(script),
-		Execute: func(db *sql.DB) error {
-			for _, stmt := range Statements(script) {
-				if _, err := db.Exec(stmt); err != nil {
-					return err
-				}
-			}
-			return nil
-		},
+// registered returns copies of the registered migrations. Versioned migrations are sorted by version and repeatable
+// migrations by description, so the order in which they are applied does not depend on the order of the calls to Add,
+// e.g. from the init functions of several packages.
+func (m *Migrator) registered() (Migrations, Migrations) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	migrations := append(Migrations{}, m.migrations...)
+	sort.SliceStable(migrations, func(i, j int) bool {
+		return m.versionOrdering.Less(migrations[i].Version, migrations[j].Version)
 	})
+	repeatable := append(Migrations{}, m.repeatable...)
+	sort.SliceStable(repeatable, func(i, j int) bool {
+		if repeatable[i].Description != repeatable[j].Description {
+			return repeatable[i].Description < repeatable[j].Description
+		}
+		return repeatable[i].Type < repeatable[j].Type
+	})
+	return migrations, repeatable
 }
 
-func (m *Migrator) AddRepeatableSQLMigration(description string, script string) {
-	m.AddSQLMigration(VersionRepeatable, description, script)
+func (m *Migrator) AddSQLMigration(version Version, description string, script string, opts ...MigrationOption) {
+	m.Add(NewSQLMigration(version, description, script, opts...))
 }
 
-func (m *Migrator) AddGoMigration(version Version, description string, execute CommandFunc) {
-	m.Add(Migration{
-		Version:     version,
-		Description: description,
-		Type:        TypeGo,
-		Execute:     execute,
-	})
+func (m *Migrator) AddRepeatableSQLMigration(description string, script string, opts ...MigrationOption) {
+	m.AddSQLMigration(VersionRepeatable, description, script, opts...)
+}
+
+func (m *Migrator) AddGoMigration(version Version, description string, execute CommandFunc, opts ...MigrationOption) {
+	m.Add(NewGoMigration(version, description, execute, opts...))
 }
 
-func (m *Migrator) AddRepeatableGoMigration(description string, execute CommandFunc) {
-	m.AddGoMigration(VersionRepeatable, description, execute)
+func (m *Migrator) AddRepeatableGoMigration(description string, execute CommandFunc, opts ...MigrationOption) {
+	m.AddGoMigration(VersionRepeatable, description, execute, opts...)
 }
 
 // create metadata table if not exists
 // apply missing migrations
 func (m *Migrator) Migrate() error {
+	_, err := m.MigrateWithResult()
+	return err
+}
+
+func (m *Migrator) migrateRun() error {
+	if m.runToken == "" && len(m.runLabels) == 0 {
+		return m.migrate()
+	}
+	rr, ok := m.support.(RunRecorder)
+	if !ok {
+		return fmt.Errorf("run token and labels require a support that records runs: %T", m.support)
+	}
+	if err := rr.CreateRunsTable(m.db); err != nil {
+		return err
+	}
+	token := m.runToken
+	if token == "" {
+		token = fmt.Sprintf("run-%d", m.now().UnixNano())
+	} else {
+		prev, found, err := rr.FindRun(m.db, token)
+		if err != nil {
+			return err
+		}
+		if found && prev.Status == StatusSuccess {
+			m.log(LevelInfo, "skipping completed run", Fields{"token": token})
+			return nil
+		}
+	}
+	run := Run{
+		Token:   token,
+		Started: m.now().UTC(),
+		Labels:  m.runLabels,
+	}
+	m.log(LevelInfo, "starting run", Fields{"token": token, "labels": m.runLabels})
+	err := m.migrate()
+	run.Finished = m.now().UTC()
+	if err == nil {
+		run.Status = StatusSuccess
+	} else {
+		run.Status = StatusFailed
+	}
+	if rErr := rr.RecordRun(m.db, run); rErr != nil {
+		return fmt.Errorf("record run: %s: %+v", run.Token, rErr)
+	}
+	return err
+}
+
+func (m *Migrator) migrate() (err error) {
+	if err := m.initSession(); err != nil {
+		return err
+	}
 	exists, err := m.support.ExistsMigrationsTable(m.db)
 	if err != nil {
 		return err
 	}
 	if !exists {
-		if err := m.support.CreateMigrationsTable(m.db); err != nil {
+		if err := m.createMigrationsTable(); err != nil {
 			return err
 		}
 	}
-	installed, err := m.support.ListMigrations(m.db)
+	if err := m.upgradeMetadata(); err != nil {
+		return err
+	}
+	// superseded records of repeatable migrations do not change what is applied
+	installed, err := m.findMigrations(MigrationFilter{Latest: true})
 	if err != nil {
 		return err
 	}
-	rank := 0
-	lastInstalled := VersionNone
-	checksumsRepeatable := map[string]string{}
-	for _, mig := range installed {
-		if mig.IsRepeatable() {
-			checksumsRepeatable[mig.Description] = mig.Checksum
-		} else {
-			switch mig.Status {
-			case StatusFailed:
-				return fmt.Errorf("detected a failed migration: %s", mig)
-			case StatusSuccess:
-				lastInstalled = mig.Version
-			default:
-				return fmt.Errorf("unknown status in migration: %s", mig)
-			}
+	migrations, repeatable := m.registered()
+	if err := conflicts(migrations, repeatable, m.versionOrdering); err != nil {
+		return err
+	}
+	if err := m.checkManifest(migrations, repeatable); err != nil {
+		return err
+	}
+	if err := m.checkPlan(migrations, repeatable, installed); err != nil {
+		return err
+	}
+	if err := m.checkUnknown(migrations, installed); err != nil {
+		return err
+	}
+	if err := m.validateBeforeMigrate(); err != nil {
+		return err
+	}
+	s, err := m.schedule(migrations, repeatable, installed)
+	if err != nil {
+		return err
+	}
+	for _, failed := range s.failed {
+		if _, err := m.recover(failed); err != nil {
+			return err
 		}
-		rank = mig.Rank
 	}
-	// install pending
-	for _, mig := range m.migrations {
-		if LEQ(mig.Version, lastInstalled) {
-			m.log("skipping installed migration: %s - %s", mig.Version, mig.Description)
-			continue
+	pending := s.pending
+	defer func() { m.outOfBand = false }()
+	vs, err := m.lint(pending)
+	if err != nil {
+		return err
+	}
+	if len(vs) > 0 {
+		return &LintError{Violations: vs}
+	}
+	if err := m.checkDestructive(pending); err != nil {
+		return err
+	}
+	if err := m.checkServer(pending); err != nil {
+		return err
+	}
+	if len(s.info.filter(StatePending, StateOutdated))+len(m.outOfOrderPending(s.info)) > 0 {
+		restore, bErr := m.backupBeforeMigrate()
+		if bErr != nil {
+			return fmt.Errorf("backup: %+v", bErr)
 		}
-		rank++
-		mig.Rank = rank
-		if err := m.install(mig); err != nil {
-			return err
+		if restore != "" {
+			defer func() {
+				if err != nil {
+					err = m.restoreAfterFailure(restore, err)
+				}
+			}()
+		}
+	}
+	m.collect(func(r *MigrationResult) { r.Version = s.lastInstalled })
+	m.emit(RunStarted{Time: m.now().UTC(), Pending: len(pending)})
+	if err := m.beforeMigrate(); err != nil {
+		return m.onError(Migration{}, err)
+	}
+	// install pending
+	for _, st := range s.versioned {
+		mig := st.mig
+		switch st.kind {
+		case stepIgnore:
+			if m.versionOrdering.compare(mig.Version, s.baseline) > 0 {
+				return m.onError(mig, &MigrationError{
+					Err:       ErrOutOfOrder,
+					Migration: mig,
+					Detail:    fmt.Sprintf("below last installed version %s", s.lastInstalled),
+				})
+			}
+			m.log(LevelInfo, "ignoring migration below baseline", migrationFields(mig))
+		case stepApplied:
+			if err := m.verifyChecksum(st.record, mig); err != nil {
+				return m.onError(mig, err)
+			}
+			if st.record.Status == StatusCherryPicked {
+				m.log(LevelDebug, "skipping cherry-picked migration", migrationFields(mig))
+			} else {
+				m.log(LevelDebug, "skipping installed migration", migrationFields(mig))
+			}
+			m.collect(func(r *MigrationResult) { r.UpToDate++ })
+		case stepExclude:
+			m.log(LevelInfo, "skipping excluded migration", migrationFields(mig))
+		case stepDefer:
+			fields := migrationFields(mig)
+			fields["app_version"] = mig.Options.ContractAfter
+			fields["min_app_version"] = m.minAppVersion
+			m.log(LevelWarn, "deferring contract migration", fields)
+		case stepRetry:
+			if err := m.expired(mig); err != nil {
+				return m.onError(mig, err)
+			}
+			m.outOfBand = st.outOfBand
+			mig = m.resume(mig, st.record)
+			if err := m.installRecording(mig, true); err != nil {
+				return m.onError(mig, err)
+			}
+		case stepSkipInactive:
+			if err := m.expired(mig); err != nil {
+				return m.onError(mig, err)
+			}
+			if err := m.skipInactive(mig); err != nil {
+				return m.onError(mig, err)
+			}
+		case stepInstall:
+			if err := m.expired(mig); err != nil {
+				return m.onError(mig, err)
+			}
+			if st.outOfOrder {
+				fields := migrationFields(mig)
+				fields["installed"] = s.lastInstalled
+				m.log(LevelWarn, "installing migration out of order", fields)
+			}
+			m.outOfBand = st.outOfBand
+			if err := m.install(mig); err != nil {
+				return m.onError(mig, err)
+			}
 		}
 	}
 	// install repeatable
-	for _, mig := range m.repeatable {
-		if cs, exists := checksumsRepeatable[mig.Description]; exists && cs == mig.Checksum {
-			m.log("skipping repeatable migration: %s", mig.Description)
-			continue
+	m.outOfBand = false
+	schema, err := m.schemaVersion(s.repeatable)
+	if err != nil {
+		return err
+	}
+	outdated := Migrations{}
+	for _, st := range m.repeatableSteps(s, schema) {
+		mig := st.mig
+		switch st.kind {
+		case stepApplied:
+			m.log(LevelDebug, "skipping repeatable migration", migrationFields(mig))
+		case stepRename:
+			if err := m.rename(st.record, mig); err != nil {
+				return m.onError(mig, err)
+			}
+		case stepAwait:
+			fields := migrationFields(mig)
+			fields["requires_version"] = mig.Options.RequiresVersion
+			fields["schema_version"] = schema
+			m.log(LevelInfo, "deferring repeatable migration", fields)
+		case stepSkipInactive:
+			if err := m.skipInactive(mig); err != nil {
+				return m.onError(mig, err)
+			}
+		case stepInstall:
+			outdated = append(outdated, mig)
 		}
-		rank++
-		mig.Rank = rank
-		if err := m.install(mig); err != nil {
-			return err
+	}
+	if err := m.installRepeatable(outdated); err != nil {
+		return err
+	}
+	if err := m.pruneSuperseded(); err != nil {
+		return err
+	}
+	if err := m.afterMigrate(); err != nil {
+		return m.onError(Migration{}, err)
+	}
+	if len(pending) > 0 {
+		if err := m.maintain(); err != nil {
+			return fmt.Errorf("maintain: %+v", err)
 		}
 	}
+	if err := m.dumpSchema(); err != nil {
+		return fmt.Errorf("dump schema: %+v", err)
+	}
 	return nil
 }
 
@@ -140,95 +421,254 @@ func (m *Migrator) Migrate() error {
 // Clean is a great help in development and test. It will effectively give you a fresh start, by wiping your configured schemas completely clean. All objects (tables, views, procedures, ...) will be dropped.
 // Needless to say: do not use against your production DB!
 func (m *Migrator) Clean() error {
-	return m.support.Clean(m.db)
+	if err := m.confirmed(OpClean, "drop all objects"); err != nil {
+		return err
+	}
+	if err := m.initSession(); err != nil {
+		return err
+	}
+	if err := m.clean(); err != nil {
+		return err
+	}
+	return m.callback(CallbackAfterClean)
 }
 
 // The details and status information about all the migrations.
 // List lets you know where you stand. At a glance you will see which migrations have already been applied, which other ones are still pending, when they were executed and whether they were successful or not.
 func (m *Migrator) Info() Info {
-	ms, err := m.support.ListMigrations(m.db)
+	ms, err := m.listMigrations()
 	if err != nil {
-		m.log("error: %v", err)
+		m.log(LevelError, "list migrations", Fields{"error": err})
 	}
-	return Info{
-		Migrations: ms,
+	migrations, repeatable := m.registered()
+	info := newInfo(migrations, repeatable, ms, m.versionOrdering)
+	if rr, ok := m.support.(RunRecorder); ok {
+		runs, err := rr.ListRuns(m.db)
+		if err != nil {
+			m.log(LevelDebug, "list runs", Fields{"error": err})
+		}
+		info.Runs = runs
 	}
+	if sd, ok := m.support.(ServerDetector); ok {
+		s, err := sd.DetectServer(m.db)
+		if err != nil {
+			m.log(LevelDebug, "detect server", Fields{"error": err})
+		} else {
+			info.Server = &s
+		}
+	}
+	m.warnOverdue(info.Overdue(m.now()))
+	return info
 }
 
 // Baselines an existing database, excluding all migrations upto and including baselineVersion.
 // Baseline is for introducing Migrator to existing databases by baselining them at a specific version. The will cause Migrate to ignore all migrations upto and including the baseline version. Newer migrations will then be applied as usual.
 func (m *Migrator) Baseline(version Version, description string) error {
+	return m.withLock(func() error {
+		return m.baseline(version, description)
+	})
+}
+
+func (m *Migrator) baseline(version Version, description string) error {
 	exists, err := m.support.ExistsMigrationsTable(m.db)
 	if err != nil {
 		return err
 	}
 	if !exists {
-		if err := m.support.CreateMigrationsTable(m.db); err != nil {
+		if err := m.newMigrationsTable(); err != nil {
 			return err
 		}
 	}
-	installed, err := m.support.ListMigrations(m.db)
+	n, err := m.countMigrations(MigrationFilter{})
 	if err != nil {
 		return err
 	}
-	if len(installed) > 0 {
+	if n > 0 {
 		return fmt.Errorf("unable to baseline: found existing migrations")
 	}
-	mig := Migration{
+	mig := newBaseline(version, description, m.now())
+	mig.InstalledBy = m.installedBy()
+	m.support.RecordMigration(m.db, mig)
+	return m.migrateRun()
+}
+
+func newBaseline(version Version, description string, date time.Time) Migration {
+	return Migration{
 		Rank:        1,
 		Version:     version,
 		Description: description,
 		Type:        TypeBaseline,
-		Date:        time.Now().UTC(),
+		Date:        date.UTC(),
 		Status:      StatusSuccess,
 	}
-	m.support.RecordMigration(m.db, mig)
-	return m.Migrate()
 }
 
 // Validates the applied migrations against the available ones.
 // Validate helps you verify that the migrations applied to the database match the ones available locally.
 // This is very useful to detect accidental changes that may prevent you from reliably recreating the schema.
-func (m *Migrator) Validate() {
+func (m *Migrator) Validate() error {
+	if err := m.conflicts(); err != nil {
+		return err
+	}
+	if err := m.checkManifest(m.registered()); err != nil {
+		return err
+	}
+	exists, err := m.support.ExistsMigrationsTable(m.db)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrMigrationsTableMissing
+	}
+	installed, err := m.listMigrations()
+	if err != nil {
+		return err
+	}
+	r := Reconcile(m.local(), installed, ReconcileOptions{OutOfOrder: m.outOfOrder, Now: m.now(), Comparator: m.versionOrdering.VersionComparator})
+	m.warnOverdue(r.Overdue)
+	if len(r.Problems) > 0 {
+		return r.Problems[0]
+	}
+	return nil
+}
 
+// Repair realigns the checksums recorded in the metadata table with the checksum algorithm set by
+// SetChecksumAlgorithm, e.g. after switching to SHA-256. Only records whose checksum matches the local migration under
+// its own algorithm are rewritten, so changed scripts are still reported by Validate. It requires a Support that is a
+// MigrationUpdater.
+func (m *Migrator) Repair() error {
+	return m.withLock(m.repairChecksums)
 }
 
-// Repairs the metadata table
-// Repair is your tool to fix issues with the metadata table. It has two main uses:
-// - Remove failed migration entries (only for databases that do NOT support DDL transactions)
-func (m *Migrator) Repair() {
+// verifyChecksum compares the checksum of an applied migration with the local one.
+func (m *Migrator) verifyChecksum(applied Migration, local Migration) error {
+	err := checksumMismatch(applied, local)
+	if err == nil || !m.warnOnChecksumMismatch {
+		return err
+	}
+	fields := migrationFields(local)
+	fields["applied"] = applied.Checksum
+	fields["local"] = local.Checksum
+	m.log(LevelWarn, "checksum mismatch", fields)
+	return nil
+}
 
+// checksumMismatch reports an error if a recorded checksum is known and differs from the local one.
+func checksumMismatch(applied Migration, local Migration) error {
+	if applied.Checksum == "" || checksumMatches(applied.Checksum, local) {
+		return nil
+	}
+	return &MigrationError{
+		Err:       ErrChecksumMismatch,
+		Migration: local,
+		Detail:    fmt.Sprintf("applied %s, local %s", applied.Checksum, local.Checksum),
+	}
 }
 
 func (m *Migrator) install(mig Migration) error {
-	if mig.Execute == nil {
+	return m.installRecording(mig, false)
+}
+
+// installRecording installs mig and records it. If update is set, the existing record with the rank of mig is updated.
+func (m *Migrator) installRecording(mig Migration, update bool) error {
+	if mig.Execute == nil && mig.ExecuteContext == nil {
 		return fmt.Errorf("cannot execute migration: %s", mig)
 	}
-	m.log("installing: %s", mig)
-	mig.Date = time.Now().UTC()
-	err := mig.Execute(m.db)
-	mig.ExecutionTime = int(time.Since(mig.Date) / time.Millisecond)
+	if err := m.initSession(); err != nil {
+		return err
+	}
+	if err := m.beforeEachMigration(mig); err != nil {
+		return err
+	}
+	m.log(LevelInfo, "installing", migrationFields(mig))
+	mig.Date = m.now().UTC()
+	mig.InstalledBy = m.installedBy()
+	m.emit(MigrationStarted{Migration: mig, Time: mig.Date})
+	var record func(ctx context.Context, q Querier) error
+	if !update {
+		record = m.recordInTx(mig)
+	}
+	recorded, err := m.execute(mig, record)
+	mig = mig.withOutput()
+	if err == nil && m.waiter != nil {
+		err = m.waiter.Wait(m.db, mig)
+	}
+	mig.ExecutionTime = m.since(mig.Date)
+	duration := mig.ExecutionTime
+	fields := migrationFields(mig)
+	fields["duration"] = duration
 	if err == nil {
-		mig.Status = StatusSuccess
+		mig.Status = m.successStatus()
+		fields["status"] = mig.Status
+		m.log(LevelInfo, "installed", fields)
 	} else {
 		mig.Status = StatusFailed
+		mig = withFailure(mig, err)
+		fields["status"] = mig.Status
+		fields["error"] = err
+		m.log(LevelError, "installed", fields)
 	}
-	if rErr := m.support.RecordMigration(m.db, mig); rErr != nil {
-		return fmt.Errorf("record migration: %s: %+v", mig, rErr)
+	m.observeMigration(mig, duration)
+	m.emit(MigrationFinished{Migration: mig, Duration: duration, Err: err})
+	if !recorded {
+		if rErr := m.record(mig, update); rErr != nil {
+			return fmt.Errorf("record migration: %s: %+v", mig, rErr)
+		}
 	}
-	return err
+	if sErr := m.storeScript(mig); sErr != nil {
+		return sErr
+	}
+	if err != nil {
+		if mig.Options.IgnoreFailure && !errors.Is(err, ErrAborted) {
+			m.log(LevelWarn, "ignoring failure", fields)
+			return nil
+		}
+		return err
+	}
+	if m.lockstep != nil {
+		if err := m.lockstep(mig); err != nil {
+			return err
+		}
+	}
+	return m.afterEachMigration(mig)
 }
 
 type Migration struct {
-	Rank          int
-	Version       Version
-	Description   string
-	Type          Type
-	Checksum      string
-	Date          time.Time
-	ExecutionTime int
+	Rank        int
+	Version     Version
+	Description string
+	Type        Type
+	Checksum    string
+	Date        time.Time
+	// ExecutionTime is the duration of the installation. It is recorded in whole milliseconds.
+	ExecutionTime time.Duration
 	Status        Status
-	Execute       CommandFunc `json:"-"`
+	State         State `json:",omitempty"`
+	// Failure is the error message of a failed migration.
+	Failure string `json:",omitempty"`
+	// FailedStatement is the position of the statement of a failed SQL migration that caused the failure,
+	// starting at 1. It is 0 if unknown.
+	FailedStatement int `json:",omitempty"`
+	// InstalledBy is the identity that applied the migration, see SetInstalledBy.
+	InstalledBy string `json:",omitempty"`
+	// Author and Ticket are declared by the header of a SQL script, see ScriptHeader.
+	Author string `json:",omitempty"`
+	Ticket string `json:",omitempty"`
+	// Output is the combined output of the command of a shell migration, see AddShellMigration.
+	Output  string           `json:",omitempty"`
+	Script  string           `json:"-"`
+	Options MigrationOptions `json:"-"`
+	Execute CommandFunc      `json:"-"`
+	// Source opens the script of a SQL migration that is streamed instead of held in Script.
+	Source func() (io.ReadCloser, error) `json:"-"`
+	// ExecuteContext is used instead of Execute by Go migrations that take a MigrationContext.
+	ExecuteContext ContextFunc `json:"-"`
+	// resumeAt is the position of the statement, starting at 1, a resumed SQL migration continues with.
+	resumeAt int
+	// shell holds the outcome of the command of a shell migration.
+	shell *shellRun
+	// normalized reports whether the Migrator normalizes the script before it is hashed.
+	normalized bool
 }
 
 func (m Migration) IsRepeatable() bool {
@@ -243,6 +683,30 @@ func (m Migration) String() string {
 	)
 }
 
+func NewSQLMigration(version Version, description string, script string, opts ...MigrationOption) Migration {
+	return Migration{
+		Version:     version,
+		Description: description,
+		Type:        TypeSQL,
+		Checksum:    SQLChecksum(script),
+		Script:      script,
+		Execute:     sqlExecutor(script),
+	}.withHeader(ParseScriptHeader(script)).withOptions(opts)
+}
+
+func NewGoMigration(version Version, description string, execute CommandFunc, opts ...MigrationOption) Migration {
+	mig := Migration{
+		Version:     version,
+		Description: description,
+		Type:        TypeGo,
+		Execute:     execute,
+	}.withOptions(opts)
+	if mig.Options.Fingerprint != "" {
+		mig.Checksum = SQLChecksum(mig.Options.Fingerprint)
+	}
+	return mig
+}
+
 type Migrations []Migration
 
 func (ms Migrations) String() string {
@@ -254,20 +718,10 @@ func (ms Migrations) String() string {
 	return buf.String()
 }
 
-type Support interface {
-	ExistsMigrationsTable(con *sql.DB) (bool, error)
-	CreateMigrationsTable(con *sql.DB) error
-	RecordMigration(con *sql.DB, m Migration) error
-	ListMigrations(con *sql.DB) (Migrations, error)
-	Clean(con *sql.DB) error
-}
-
 type Version string
 
 func LEQ(a Version, b Version) bool {
-	ai, _ := strconv.ParseInt(string(a), 10, 64)
-	bi, _ := strconv.ParseInt(string(b), 10, 64)
-	return ai <= bi
+	return OrderNumeric.Compare(a, b) <= 0
 }
 
 const (
@@ -280,6 +734,12 @@ type Status string
 const (
 	StatusSuccess Status = "success"
 	StatusFailed  Status = "failed"
+	// StatusSkipped is a failed migration that has been skipped by the recovery policy SkipFailed
+	// or a migration that has been skipped because none of its environments was active.
+	StatusSkipped Status = "skipped"
+	// StatusCherryPicked is a migration that has been installed out of band, see SetCherryPick and SetSkipVersions.
+	// It does not advance the installed version, so lower pending versions are still applied by subsequent runs.
+	StatusCherryPicked Status = "cherry-picked"
 )
 
 type Type string
@@ -290,14 +750,9 @@ const (
 	TypeBaseline Type = "Baseline"
 )
 
-type Info struct {
-	Migrations Migrations
-}
-
 type CommandFunc func(con *sql.DB) error
 
+// SQLChecksum returns the MD5 checksum of script.
 func SQLChecksum(script string) string {
-	h := md5.New()
-	io.WriteString(h, script)
-	return fmt.Sprintf("%x", h.Sum(nil))
+	return ChecksumMD5.Sum(script)
 }
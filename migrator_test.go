@@ -0,0 +1,537 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memSupport struct {
+	exists     bool
+	migrations Migrations
+	runs       map[string]Run
+	locked     bool
+	tables     int
+}
+
+func (s *memSupport) ExistsMigrationsTable(con *sql.DB) (bool, error) {
+	return s.exists, nil
+}
+
+func (s *memSupport) CreateMigrationsTable(con *sql.DB) error {
+	s.exists = true
+	return nil
+}
+
+func (s *memSupport) RecordMigration(con *sql.DB, m Migration) error {
+	m.Execute = nil
+	s.migrations = append(s.migrations, m)
+	return nil
+}
+
+func (s *memSupport) UpdateMigration(con *sql.DB, m Migration) error {
+	m.Execute = nil
+	for i, e := range s.migrations {
+		if e.Rank == m.Rank {
+			s.migrations[i] = m
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown rank: %d", m.Rank)
+}
+
+func (s *memSupport) ListMigrations(con *sql.DB) (Migrations, error) {
+	return append(Migrations{}, s.migrations...), nil
+}
+
+func (s *memSupport) Clean(con *sql.DB) error {
+	*s = memSupport{}
+	return nil
+}
+
+func (s *memSupport) CreateRunsTable(con *sql.DB) error {
+	if s.runs == nil {
+		s.runs = map[string]Run{}
+	}
+	return nil
+}
+
+func (s *memSupport) RecordRun(con *sql.DB, r Run) error {
+	s.runs[r.Token] = r
+	return nil
+}
+
+func (s *memSupport) FindRun(con *sql.DB, token string) (Run, bool, error) {
+	r, ok := s.runs[token]
+	return r, ok, nil
+}
+
+func (s *memSupport) ListRuns(con *sql.DB) ([]Run, error) {
+	rs := []Run{}
+	for _, r := range s.runs {
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+func (s *memSupport) Lock(con *sql.DB, timeout time.Duration) error {
+	if s.locked {
+		return ErrLockTimeout
+	}
+	s.locked = true
+	return nil
+}
+
+func (s *memSupport) Unlock(con *sql.DB) error {
+	s.locked = false
+	return nil
+}
+
+func (s *memSupport) IsSchemaEmpty(con *sql.DB) (bool, error) {
+	return s.tables == 0, nil
+}
+
+func newTestMigrator(t *testing.T, s Support) *Migrator {
+	return NewMigrator(t.Logf, nil, s)
+}
+
+func TestMigrateRunToken(t *testing.T) {
+	s := &memSupport{}
+	calls := 0
+	count := func(con *sql.DB) error {
+		calls++
+		return nil
+	}
+	m := newTestMigrator(t, s)
+	m.AddGoMigration("1", "count", count)
+	m.SetRunToken("job-1")
+	for i := 0; i < 2; i++ {
+		if err := m.Migrate(); err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("want 1 execution, got %d", calls)
+	}
+	m.AddGoMigration("2", "count", count)
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("want 1 execution, got %d", calls)
+	}
+	m.SetRunToken("job-2")
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("want 2 executions, got %d", calls)
+	}
+}
+
+func TestMigrateLocked(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	m.AddGoMigration("1", "noop", func(con *sql.DB) error { return nil })
+	s.locked = true
+	if err := m.Migrate(); err == nil {
+		t.Fatalf("want lock error")
+	}
+	if len(s.migrations) != 0 {
+		t.Errorf("want no migrations, got %d", len(s.migrations))
+	}
+	s.locked = false
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if s.locked {
+		t.Errorf("want lock to be released")
+	}
+}
+
+func TestMigrateHooks(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	calls := []string{}
+	m.SetHooks(Hooks{
+		BeforeMigrate: func(con *sql.DB) error {
+			calls = append(calls, "before")
+			return nil
+		},
+		AfterMigrate: func(con *sql.DB) error {
+			calls = append(calls, "after")
+			return nil
+		},
+		BeforeEachMigration: func(con *sql.DB, mig Migration) error {
+			calls = append(calls, "before "+string(mig.Version))
+			return nil
+		},
+		AfterEachMigration: func(con *sql.DB, mig Migration) error {
+			calls = append(calls, "after "+string(mig.Version))
+			return nil
+		},
+		OnError: func(con *sql.DB, mig Migration, err error) {
+			calls = append(calls, "error "+string(mig.Version))
+		},
+	})
+	m.AddGoMigration("1", "ok", func(con *sql.DB) error { return nil })
+	m.AddGoMigration("2", "fail", func(con *sql.DB) error { return fmt.Errorf("fail") })
+	if err := m.Migrate(); err == nil {
+		t.Fatalf("want error")
+	}
+	want := []string{"before", "before 1", "after 1", "before 2", "error 2"}
+	if !reflect.DeepEqual(want, calls) {
+		t.Errorf("want: %v, got: %v", want, calls)
+	}
+}
+
+func TestMigrateChecksumMismatch(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	m.Add(NewSQLMigration("1", "init", "CREATE TABLE foo (bar PRIMARY KEY);"))
+	s.exists = true
+	s.migrations = Migrations{
+		{Rank: 1, Version: "1", Description: "init", Type: TypeSQL, Checksum: SQLChecksum("CREATE TABLE foo (baz PRIMARY KEY);"), Status: StatusSuccess},
+	}
+	if err := m.Migrate(); err == nil {
+		t.Errorf("want checksum mismatch")
+	}
+	m.SetWarnOnChecksumMismatch(true)
+	if err := m.Migrate(); err != nil {
+		t.Errorf("want warning only, got: %v", err)
+	}
+}
+
+func TestMigrateWaiter(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	polls := 0
+	m.SetWaiter(PollWaiter{
+		Interval: time.Millisecond,
+		Done: func(con *sql.DB, mig Migration) (bool, error) {
+			polls++
+			return polls == 3, nil
+		},
+	})
+	m.AddGoMigration("1", "async", func(con *sql.DB) error { return nil })
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if polls != 3 {
+		t.Errorf("want 3 polls, got %d", polls)
+	}
+	m.SetWaiter(PollWaiter{
+		Interval: time.Millisecond,
+		Timeout:  5 * time.Millisecond,
+		Done: func(con *sql.DB, mig Migration) (bool, error) {
+			return false, nil
+		},
+	})
+	m.AddGoMigration("2", "never", func(con *sql.DB) error { return nil })
+	if err := m.Migrate(); err == nil {
+		t.Fatalf("want timeout")
+	}
+	if got := s.migrations[1].Status; got != StatusFailed {
+		t.Errorf("want status %s, got %s", StatusFailed, got)
+	}
+}
+
+func TestMigrateRunLabels(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	m.SetRunLabels(map[string]string{"deploy": "42"})
+	m.AddGoMigration("1", "noop", func(con *sql.DB) error { return nil })
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	runs := m.Info().Runs
+	if len(runs) != 1 {
+		t.Fatalf("want 1 run, got %d", len(runs))
+	}
+	if runs[0].Labels["deploy"] != "42" || runs[0].Status != StatusSuccess {
+		t.Errorf("unexpected run: %+v", runs[0])
+	}
+}
+
+func TestMigrateOptions(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	m.AddGoMigration("1", "best effort", func(con *sql.DB) error { return fmt.Errorf("fail") }, IgnoreFailure())
+	m.AddGoContextMigration("2", "slow", func(ctx *MigrationContext) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, Timeout(5*time.Millisecond))
+	if err := m.Migrate(); err == nil {
+		t.Fatalf("want timeout")
+	}
+	want := []Status{StatusFailed, StatusFailed}
+	for i, mig := range s.migrations {
+		if mig.Status != want[i] {
+			t.Errorf("%s: want status %s, got %s", mig, want[i], mig.Status)
+		}
+	}
+	m = newTestMigrator(t, s)
+	m.AddGoMigration("1", "best effort", func(con *sql.DB) error { return fmt.Errorf("fail") }, IgnoreFailure())
+	if err := m.Validate(); err == nil {
+		t.Errorf("want failed migration 2 to be reported")
+	}
+	s.migrations = s.migrations[:1]
+	if err := m.Migrate(); err != nil {
+		t.Errorf("want ignored failure to be skipped, got: %v", err)
+	}
+}
+
+func TestMigrateBaselineOnMigrate(t *testing.T) {
+	s := &memSupport{tables: 3}
+	m := newTestMigrator(t, s)
+	m.SetBaselineOnMigrate("2", "legacy")
+	calls := 0
+	count := func(con *sql.DB) error {
+		calls++
+		return nil
+	}
+	m.AddGoMigration("1", "one", count)
+	m.AddGoMigration("2", "two", count)
+	m.AddGoMigration("3", "three", count)
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("want 1 execution, got %d", calls)
+	}
+	if len(s.migrations) != 2 || s.migrations[0].Type != TypeBaseline || s.migrations[1].Rank != 2 {
+		t.Errorf("unexpected migrations:\n%s", s.migrations)
+	}
+}
+
+type testMetrics struct {
+	migrations []Status
+	runs       int
+	version    Version
+}
+
+func (tm *testMetrics) ObserveMigration(mig Migration, duration time.Duration) {
+	tm.migrations = append(tm.migrations, mig.Status)
+}
+
+func (tm *testMetrics) ObserveRun(duration time.Duration, err error) {
+	tm.runs++
+}
+
+func (tm *testMetrics) SetCurrentVersion(version Version) {
+	tm.version = version
+}
+
+func TestMigrateMetrics(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	tm := &testMetrics{}
+	m.SetMetrics(tm)
+	m.AddGoMigration("1", "ok", func(con *sql.DB) error { return nil })
+	m.AddGoMigration("2", "fail", func(con *sql.DB) error { return fmt.Errorf("fail") })
+	m.Migrate()
+	if !reflect.DeepEqual([]Status{StatusSuccess, StatusFailed}, tm.migrations) {
+		t.Errorf("unexpected observed migrations: %v", tm.migrations)
+	}
+	if tm.runs != 1 || tm.version != "1" {
+		t.Errorf("want 1 run at version 1, got %d at %s", tm.runs, tm.version)
+	}
+}
+
+func TestMigrateIncremental(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	order := []Version{}
+	var mu sync.Mutex
+	add := func(v Version) {
+		m.AddGoMigration(v, "v"+string(v), func(con *sql.DB) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, v)
+			return nil
+		})
+	}
+	add("1")
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	wg := sync.WaitGroup{}
+	for _, v := range []Version{"4", "2", "3"} {
+		wg.Add(1)
+		go func(v Version) {
+			defer wg.Done()
+			add(v)
+		}(v)
+	}
+	wg.Wait()
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate again: %v", err)
+	}
+	if want := []Version{"1", "2", "3", "4"}; !reflect.DeepEqual(want, order) {
+		t.Errorf("want: %v, got: %v", want, order)
+	}
+	for i, mig := range s.migrations {
+		if mig.Rank != i+1 || mig.Version != order[i] {
+			t.Errorf("unexpected record: %d %s", mig.Rank, mig.Version)
+		}
+	}
+}
+
+func TestMigrateParallelRepeatable(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	m.SetParallelRepeatable(3)
+	started := sync.WaitGroup{}
+	started.Add(3)
+	for _, name := range []string{"a", "b", "c"} {
+		m.AddRepeatableGoMigration(name, func(con *sql.DB) error {
+			started.Done()
+			done := make(chan struct{})
+			go func() {
+				started.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+				return nil
+			case <-time.After(5 * time.Second):
+				return fmt.Errorf("repeatable migrations not run concurrently")
+			}
+		}, Fingerprint(name))
+	}
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if len(s.migrations) != 3 {
+		t.Fatalf("want 3 records, got: %d", len(s.migrations))
+	}
+	ranks := map[int]bool{}
+	for _, mig := range s.migrations {
+		if mig.Status != StatusSuccess {
+			t.Errorf("want success, got: %s", mig)
+		}
+		ranks[mig.Rank] = true
+	}
+	if len(ranks) != 3 {
+		t.Errorf("want distinct ranks, got: %v", ranks)
+	}
+}
+
+func TestMigrateParallelRepeatableFailure(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	m.SetParallelRepeatable(2)
+	started := sync.WaitGroup{}
+	started.Add(2)
+	var mu sync.Mutex
+	ran := []string{}
+	for _, name := range []string{"a", "b", "c", "d"} {
+		name := name
+		m.AddRepeatableGoMigration(name, func(con *sql.DB) error {
+			mu.Lock()
+			ran = append(ran, name)
+			mu.Unlock()
+			if name != "a" && name != "b" {
+				return nil
+			}
+			// a and b fail once both have been started, while c is waiting for a worker.
+			started.Done()
+			started.Wait()
+			return fmt.Errorf("fail")
+		}, Fingerprint(name))
+	}
+	if err := m.Migrate(); err == nil {
+		t.Fatal("want error")
+	}
+	sort.Strings(ran)
+	if want := []string{"a", "b"}; !reflect.DeepEqual(want, ran) {
+		t.Errorf("want only %v started, got: %v", want, ran)
+	}
+}
+
+func TestMigrateOrderIndependentOfAdd(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	noop := func(con *sql.DB) error { return nil }
+	m.AddRepeatableGoMigration("users view", noop)
+	m.AddGoMigration("3", "three", noop)
+	m.AddRepeatableGoMigration("orders view", noop)
+	m.AddGoMigration("1", "one", noop)
+	m.AddGoMigration("2", "two", noop)
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	got := []string{}
+	for _, mig := range s.migrations {
+		got = append(got, mig.Description)
+	}
+	want := []string{"one", "two", "three", "orders view", "users view"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
+
+// reversedSupport lists the recorded migrations in reverse order of their ranks.
+type reversedSupport struct {
+	memSupport
+}
+
+func (s *reversedSupport) ListMigrations(con *sql.DB) (Migrations, error) {
+	ms := Migrations{}
+	for i := len(s.migrations) - 1; i >= 0; i-- {
+		ms = append(ms, s.migrations[i])
+	}
+	return ms, nil
+}
+
+func TestMigrateRankUnordered(t *testing.T) {
+	noop := func(con *sql.DB) error { return nil }
+	s := &reversedSupport{}
+	m := newTestMigrator(t, s)
+	m.AddGoMigration("1", "one", noop)
+	m.AddGoMigration("2", "two", noop)
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	m.AddGoMigration("3", "three", noop)
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	ranks := map[int]bool{}
+	for _, mig := range s.migrations {
+		if ranks[mig.Rank] {
+			t.Fatalf("duplicate rank: %d", mig.Rank)
+		}
+		ranks[mig.Rank] = true
+	}
+	if got := s.migrations[2].Rank; got != 3 {
+		t.Errorf("want rank 3, got: %d", got)
+	}
+}
+
+func TestMigrateLateLowerVersion(t *testing.T) {
+	noop := func(con *sql.DB) error { return nil }
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	m.AddGoMigration("1", "one", noop)
+	m.AddGoMigration("3", "three", noop)
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	m.AddGoMigration("2", "two", noop)
+	if err := m.Migrate(); !errors.Is(err, ErrOutOfOrder) {
+		t.Fatalf("want out of order error, got: %v", err)
+	}
+	m.SetOutOfOrder(true)
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate out of order: %v", err)
+	}
+	if want, got := []Version{"1", "3", "2"}, versions(s.migrations); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
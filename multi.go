@@ -0,0 +1,106 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// MultiMigrator migrates several schemas or components of one database. Every schema or component has its own metadata
+// table and migration set. Migrate applies them in the order they have been added.
+type MultiMigrator struct {
+	logger    Logger
+	db        *sql.DB
+	schemas   []string
+	kinds     map[string]string
+	migrators map[string]*Migrator
+}
+
+// NewMultiMigrator creates a MultiMigrator for db.
+func NewMultiMigrator(logger Logger, db *sql.DB) *MultiMigrator {
+	return &MultiMigrator{
+		logger:    logger,
+		db:        db,
+		kinds:     map[string]string{},
+		migrators: map[string]*Migrator{},
+	}
+}
+
+// AddSchema adds a schema and returns its Migrator to register the migrations of the schema with.
+// support has to keep the metadata table in schema, e.g. NewSQLiteSupport(WithSchema(schema)).
+func (mm *MultiMigrator) AddSchema(schema string, support Support) *Migrator {
+	return mm.add("schema", schema, support)
+}
+
+// AddComponent adds a component, e.g. a reusable library like "auth" or "billing" that ships its own migrations, and
+// returns its Migrator. Versions are numbered per component, so they never collide with those of the host application.
+// support has to keep the metadata table of the component, e.g. NewSQLiteSupport(WithTable(ComponentTable("auth"))).
+// Components and schemas share their names.
+func (mm *MultiMigrator) AddComponent(name string, support Support) *Migrator {
+	return mm.add("component", name, support)
+}
+
+// ComponentTable returns the name of the metadata table of a component, e.g. "migrations_auth".
+func ComponentTable(name string) string {
+	return DefaultTable + "_" + name
+}
+
+func (mm *MultiMigrator) add(kind string, name string, support Support) *Migrator {
+	if m, ok := mm.migrators[name]; ok {
+		return m
+	}
+	m := NewMigrator(nil, mm.db, support)
+	m.SetLogger(fieldLogger{key: kind, value: name, logger: mm.logger})
+	mm.schemas = append(mm.schemas, name)
+	mm.kinds[name] = kind
+	mm.migrators[name] = m
+	return m
+}
+
+// Schema returns the Migrator of schema.
+func (mm *MultiMigrator) Schema(schema string) (*Migrator, bool) {
+	m, ok := mm.migrators[schema]
+	return m, ok
+}
+
+// Component returns the Migrator of the component name.
+func (mm *MultiMigrator) Component(name string) (*Migrator, bool) {
+	return mm.Schema(name)
+}
+
+// Schemas returns the schemas and components in the order they are migrated.
+func (mm *MultiMigrator) Schemas() []string {
+	return append([]string{}, mm.schemas...)
+}
+
+// Migrate migrates the schemas in order and stops at the first schema that fails. If migrations declare requirements
+// with Requires, the pending migrations of all schemas are ordered so that every requirement is applied first.
+func (mm *MultiMigrator) Migrate() error {
+	if mm.hasRequirements() {
+		return mm.migrateScheduled()
+	}
+	for _, schema := range mm.schemas {
+		if err := mm.migrators[schema].Migrate(); err != nil {
+			return fmt.Errorf("migrate %s: %s: %+v", mm.kinds[schema], schema, err)
+		}
+	}
+	return nil
+}
+
+// Validate validates the schemas in order.
+func (mm *MultiMigrator) Validate() error {
+	for _, schema := range mm.schemas {
+		if err := mm.migrators[schema].Validate(); err != nil {
+			return fmt.Errorf("validate %s: %s: %+v", mm.kinds[schema], schema, err)
+		}
+	}
+	return nil
+}
+
+// Info returns the Info of every schema.
+func (mm *MultiMigrator) Info() map[string]Info {
+	infos := map[string]Info{}
+	for _, schema := range mm.schemas {
+		infos[schema] = mm.migrators[schema].Info()
+	}
+	return infos
+}
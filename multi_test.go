@@ -0,0 +1,57 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMultiMigrator(t *testing.T) {
+	mm := NewMultiMigrator(LogFunc(t.Logf), nil)
+	order := []string{}
+	step := func(name string) CommandFunc {
+		return func(con *sql.DB) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+	shared, tenant := &memSupport{}, &memSupport{}
+	mm.AddSchema("shared", shared).AddGoMigration("1", "shared one", step("shared 1"))
+	mm.AddSchema("tenant", tenant).AddGoMigration("1", "tenant one", step("tenant 1"))
+	mm.AddSchema("shared", shared).AddGoMigration("2", "shared two", step("shared 2"))
+	if err := mm.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"shared 1", "shared 2", "tenant 1"}; !reflect.DeepEqual(want, order) {
+		t.Errorf("want: %v, got: %v", want, order)
+	}
+	if len(shared.migrations) != 2 || len(tenant.migrations) != 1 {
+		t.Errorf("unexpected histories:\n%s\n%s", shared.migrations, tenant.migrations)
+	}
+	m, _ := mm.Schema("tenant")
+	m.AddGoMigration("2", "tenant two", func(con *sql.DB) error { return fmt.Errorf("fail") })
+	if err := mm.Migrate(); err == nil {
+		t.Errorf("want error")
+	}
+}
+
+func TestMultiMigratorComponents(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	mm := NewMultiMigrator(LogFunc(t.Logf), db)
+	mm.AddComponent("auth", &memSupport{}).AddSQLMigration("1", "users", "CREATE TABLE users (id INT);")
+	mm.AddComponent("app", &memSupport{}).AddSQLMigration("1", "orders", "CREATE TABLE orders (id INT);")
+	if err := mm.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"CREATE TABLE users (id INT);", "CREATE TABLE orders (id INT);"}; !reflect.DeepEqual(want, log.Statements()) {
+		t.Errorf("want: %q, got: %q", want, log.Statements())
+	}
+	if m, ok := mm.Component("auth"); !ok || m.Info().Migrations[0].Description != "users" {
+		t.Errorf("want component auth")
+	}
+	if got := ComponentTable("auth"); got != "migrations_auth" {
+		t.Errorf("unexpected table of component: %s", got)
+	}
+}
@@ -0,0 +1,185 @@
+package migrate
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"strings"
+	"unicode"
+)
+
+// bom is the UTF-8 byte order mark.
+const bom = "\xef\xbb\xbf"
+
+// NormalizeScript strips a leading UTF-8 byte order mark and converts CRLF line endings to LF, so that a script
+// checked out on Windows has the same checksum as on Linux.
+func NormalizeScript(script string) string {
+	return strings.Replace(strings.TrimPrefix(script, bom), "\r\n", "\n", -1)
+}
+
+// Normalization configures cosmetic changes of scripts that do not change their checksums. It is applied in addition
+// to NormalizeScript and only to compute checksums; scripts are executed as written.
+type Normalization struct {
+	// TrimTrailingWhitespace removes spaces and tabs at the end of lines.
+	TrimTrailingWhitespace bool
+	// CollapseBlankLines replaces consecutive blank lines with a single one.
+	CollapseBlankLines bool
+	// UppercaseKeywords converts SQL keywords outside of literals, quoted identifiers and comments to upper case.
+	UppercaseKeywords bool
+}
+
+// Apply returns script normalized by NormalizeScript and n.
+func (n Normalization) Apply(script string) string {
+	bs, _ := ioutil.ReadAll(newNormalizingReader(strings.NewReader(script), n))
+	return string(bs)
+}
+
+// SetNormalize makes the Migrator normalize scripts with NormalizeScript before their checksums are computed and
+// before they are split into statements. Recorded checksums of scripts with CRLF line endings or a byte order mark
+// still match and are realigned by Repair.
+func (m *Migrator) SetNormalize(normalize bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.normalize = normalize
+	m.recomputeChecksums()
+}
+
+// SetNormalization makes the Migrator compute checksums of scripts normalized by n, so that reformatting a script does
+// not invalidate its recorded checksum. Recorded checksums of the scripts as written still match and are realigned by
+// Repair.
+func (m *Migrator) SetNormalization(n Normalization) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.normalization = n
+	m.recomputeChecksums()
+}
+
+// checksumNormalization returns the normalization checksums are computed with or nil if scripts are hashed as written.
+func (m *Migrator) checksumNormalization() *Normalization {
+	if !m.normalize && m.normalization == (Normalization{}) {
+		return nil
+	}
+	return &m.normalization
+}
+
+// recomputeChecksums recomputes the checksums of the registered migrations. m.mu has to be held.
+func (m *Migrator) recomputeChecksums() {
+	for i, mig := range m.migrations {
+		m.migrations[i] = m.withChecksum(mig)
+	}
+	for i, mig := range m.repeatable {
+		m.repeatable[i] = m.withChecksum(mig)
+	}
+}
+
+// normalizingReader applies NormalizeScript and a Normalization to the script read from r.
+type normalizingReader struct {
+	r       *bufio.Reader
+	n       Normalization
+	buf     string
+	started bool
+	blank   bool
+	quote   rune
+	comment bool
+	err     error
+}
+
+func newNormalizingReader(r io.Reader, n Normalization) io.Reader {
+	return &normalizingReader{r: bufio.NewReader(r), n: n}
+}
+
+func (n *normalizingReader) Read(p []byte) (int, error) {
+	for len(n.buf) == 0 {
+		if n.err != nil {
+			return 0, n.err
+		}
+		line, err := n.r.ReadString('\n')
+		n.err = err
+		if !n.started {
+			line = strings.TrimPrefix(line, bom)
+			n.started = true
+		}
+		if strings.HasSuffix(line, "\r\n") {
+			line = line[:len(line)-2] + "\n"
+		}
+		if n.n.TrimTrailingWhitespace {
+			eol := ""
+			if strings.HasSuffix(line, "\n") {
+				line, eol = line[:len(line)-1], "\n"
+			}
+			line = strings.TrimRight(line, " \t") + eol
+		}
+		if n.n.CollapseBlankLines {
+			blank := strings.TrimSpace(line) == "" && strings.HasSuffix(line, "\n")
+			if blank && n.blank {
+				continue
+			}
+			n.blank = blank
+		}
+		if n.n.UppercaseKeywords {
+			line = n.uppercaseKeywords(line)
+		}
+		n.buf = line
+	}
+	k := copy(p, n.buf)
+	n.buf = n.buf[k:]
+	return k, nil
+}
+
+// uppercaseKeywords converts the SQL keywords of line to upper case. Literals, quoted identifiers and block comments
+// may span lines, so their state is kept in the reader.
+func (n *normalizingReader) uppercaseKeywords(line string) string {
+	rs := []rune(line)
+	out := make([]rune, 0, len(rs))
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case n.comment:
+			if r == '*' && i+1 < len(rs) && rs[i+1] == '/' {
+				n.comment = false
+				out = append(out, r, rs[i+1])
+				i++
+				continue
+			}
+		case n.quote != 0:
+			if r == n.quote {
+				n.quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			n.quote = r
+		case r == '-' && i+1 < len(rs) && rs[i+1] == '-':
+			return string(append(out, rs[i:]...))
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			n.comment = true
+			out = append(out, r, rs[i+1])
+			i++
+			continue
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_') {
+				j++
+			}
+			word := string(rs[i:j])
+			if sqlKeywords[strings.ToUpper(word)] {
+				word = strings.ToUpper(word)
+			}
+			out = append(out, []rune(word)...)
+			i = j - 1
+			continue
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
+
+// sqlKeywords are the keywords converted by Normalization.UppercaseKeywords.
+var sqlKeywords = map[string]bool{}
+
+func init() {
+	for _, k := range strings.Fields(`ADD ALL ALTER AND ANY AS ASC BEGIN BETWEEN BY CASCADE CASE CHECK COLUMN COMMIT
+		CONSTRAINT CREATE DEFAULT DELETE DESC DISTINCT DROP ELSE END EXISTS FOREIGN FROM FULL GROUP HAVING IF IN INDEX
+		INNER INSERT INTO IS JOIN KEY LEFT LIKE LIMIT NOT NULL ON OR ORDER OUTER PRIMARY REFERENCES RENAME REPLACE RIGHT
+		ROLLBACK SELECT SET TABLE THEN TO TRANSACTION TRIGGER UNION UNIQUE UPDATE USING VALUES VIEW WHEN WHERE WITH`) {
+		sqlKeywords[k] = true
+	}
+}
@@ -0,0 +1,81 @@
+package migrate
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	unix := "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n"
+	windows := "\xef\xbb\xbfCREATE TABLE a (id INT);\r\nCREATE TABLE b (id INT);\r\n"
+	if got := NormalizeScript(windows); got != unix {
+		t.Fatalf("want: %q, got: %q", unix, got)
+	}
+	got, err := ioutil.ReadAll(newNormalizingReader(strings.NewReader(windows), Normalization{}))
+	if err != nil || string(got) != unix {
+		t.Fatalf("want: %q, got: %q, %v", unix, got, err)
+	}
+
+	m := newTestMigrator(t, &memSupport{})
+	m.AddSQLMigration("1", "one", windows)
+	source, _ := NewSQLSourceMigration("2", "two", func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader(windows)), nil
+	})
+	m.Add(source)
+	m.SetNormalize(true)
+	for _, mig := range m.migrations {
+		if mig.Checksum != SQLChecksum(unix) {
+			t.Errorf("%s: want checksum of normalized script, got: %s", mig, mig.Checksum)
+		}
+	}
+	if !checksumMatches(SQLChecksum(unix), m.migrations[0]) {
+		t.Errorf("want checksum to match regardless of line endings")
+	}
+	if checksumMatches(SQLChecksum(unix), NewSQLMigration("1", "one", windows)) {
+		t.Errorf("want line endings to matter without normalization")
+	}
+	want := []Statement{{SQL: "CREATE TABLE a (id INT);"}, {SQL: "CREATE TABLE b (id INT);"}}
+	if got := m.splitter().Parse(windows); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
+
+func TestNormalization(t *testing.T) {
+	n := Normalization{TrimTrailingWhitespace: true, CollapseBlankLines: true, UppercaseKeywords: true}
+	original := "create table a (id INT);\n\ninsert into a values (1); -- insert into\n"
+	reformatted := "CREATE TABLE a (id INT);  \r\n\n\n\nINSERT INTO a VALUES (1); -- insert into\n"
+	if got, want := n.Apply(reformatted), n.Apply(original); got != want {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	if got, want := n.Apply("select 'select' from \"select\";\n/* select\nselect */ select"), "SELECT 'select' FROM \"select\";\n/* select\nselect */ SELECT"; got != want {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+
+	s := &memSupport{exists: true}
+	s.RecordMigration(nil, Migration{Rank: 1, Version: "1", Description: "one", Type: TypeSQL, Checksum: SQLChecksum(original), Status: StatusSuccess})
+	m := newTestMigrator(t, s)
+	m.SetNormalization(n)
+	m.AddSQLMigration("1", "one", reformatted)
+	if err := m.Validate(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("want mismatch before repair, got: %v", err)
+	}
+	m = newTestMigrator(t, s)
+	m.SetNormalization(n)
+	m.AddSQLMigration("1", "one", original)
+	if err := m.Repair(); err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+	if got, want := s.migrations[0].Checksum, SQLChecksum(n.Apply(original)); got != want {
+		t.Fatalf("want normalized checksum after repair: %s, got: %s", want, got)
+	}
+	m = newTestMigrator(t, s)
+	m.SetNormalization(n)
+	m.AddSQLMigration("1", "one", reformatted)
+	if err := m.Validate(); err != nil {
+		t.Fatalf("want reformatted script to validate: %v", err)
+	}
+}
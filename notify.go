@@ -0,0 +1,143 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RunNotification is the summary of a run passed to the Notifiers of a Migrator.
+type RunNotification struct {
+	Result MigrationResult
+	// Err is the error of the run, nil if it succeeded.
+	Err error
+	// Labels are the labels of the run, see SetRunLabels, e.g. the environment or a deploy ID.
+	Labels map[string]string
+}
+
+// Failed reports whether the run failed.
+func (n RunNotification) Failed() bool {
+	return n.Err != nil
+}
+
+// String returns a one-line summary of the run, e.g. for a chat message.
+func (n RunNotification) String() string {
+	labels := ""
+	if len(n.Labels) > 0 {
+		keys := make([]string, 0, len(n.Labels))
+		for k := range n.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, len(keys))
+		for i, k := range keys {
+			pairs[i] = k + "=" + n.Labels[k]
+		}
+		labels = " [" + strings.Join(pairs, ", ") + "]"
+	}
+	if n.Err != nil {
+		return fmt.Sprintf("migration run failed%s after %d applied migrations: %v", labels, len(n.Result.Applied), n.Err)
+	}
+	return fmt.Sprintf("migration run succeeded%s: %d applied, %d skipped, version %s, took %s", labels,
+		len(n.Result.Applied), len(n.Result.Skipped), n.Result.Version, n.Result.Duration.Round(time.Millisecond))
+}
+
+// Notifier is told about the outcome of runs, e.g. to post a chat message when a production migration fails.
+type Notifier interface {
+	NotifyRun(ctx context.Context, n RunNotification) error
+}
+
+// NotifierFunc is a function that is told about runs.
+type NotifierFunc func(ctx context.Context, n RunNotification) error
+
+func (f NotifierFunc) NotifyRun(ctx context.Context, n RunNotification) error {
+	return f(ctx, n)
+}
+
+// NotifyTimeout limits the time a Notifier may take.
+var NotifyTimeout = 10 * time.Second
+
+// AddNotifier adds notifiers that are told about subsequent runs of Migrate that failed or changed the database.
+// Runs without pending migrations are not notified. A failing notifier is logged and does not fail the run.
+func (m *Migrator) AddNotifier(notifiers ...Notifier) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.notifiers = append(m.notifiers, notifiers...)
+}
+
+// notify tells the notifiers about the outcome of a run.
+func (m *Migrator) notify(res MigrationResult, err error) {
+	m.mu.Lock()
+	notifiers := m.notifiers
+	m.mu.Unlock()
+	if len(notifiers) == 0 || (err == nil && len(res.Applied)+len(res.Failed)+len(res.Skipped) == 0) {
+		return
+	}
+	n := RunNotification{Result: res, Err: err, Labels: m.runLabels}
+	for _, notifier := range notifiers {
+		ctx, cancel := context.WithTimeout(context.Background(), NotifyTimeout)
+		if nErr := notifier.NotifyRun(ctx, n); nErr != nil {
+			m.log(LevelWarn, "notify run", Fields{"error": nErr})
+		}
+		cancel()
+	}
+}
+
+// Webhook is a Notifier that posts a JSON message to a URL, by default the text of the notification in the format of
+// Slack incoming webhooks.
+type Webhook struct {
+	URL string
+	// OnlyFailures skips successful runs.
+	OnlyFailures bool
+	// Header is added to the requests, e.g. for an authorization.
+	Header http.Header
+	// Client sends the requests. It defaults to http.DefaultClient.
+	Client *http.Client
+	// Payload returns the body of the request. It defaults to {"text": n.String()}.
+	Payload func(n RunNotification) ([]byte, error)
+}
+
+func (w Webhook) NotifyRun(ctx context.Context, n RunNotification) error {
+	if w.OnlyFailures && !n.Failed() {
+		return nil
+	}
+	payload := w.Payload
+	if payload == nil {
+		payload = slackPayload
+	}
+	body, err := payload(n)
+	if err != nil {
+		return fmt.Errorf("webhook payload: %+v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for k, vs := range w.Header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+func slackPayload(n RunNotification) ([]byte, error) {
+	return json.Marshal(map[string]string{"text": n.String()})
+}
@@ -0,0 +1,70 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMigrateNotifier(t *testing.T) {
+	var got []RunNotification
+	m := newTestMigrator(t, &memSupport{})
+	m.AddNotifier(NotifierFunc(func(ctx context.Context, n RunNotification) error {
+		got = append(got, n)
+		return fmt.Errorf("chat down")
+	}))
+	m.AddGoMigration("1", "one", func(con *sql.DB) error { return nil })
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("a failing notifier must not fail the run: %v", err)
+	}
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	m.AddGoMigration("2", "two", func(con *sql.DB) error { return fmt.Errorf("boom") })
+	if err := m.Migrate(); err == nil {
+		t.Fatal("want error")
+	}
+	if len(got) != 2 {
+		t.Fatalf("want notifications for the changing and the failed run, got: %v", got)
+	}
+	if got[0].Failed() || len(got[0].Result.Applied) != 1 || !got[1].Failed() {
+		t.Errorf("unexpected notifications: %v", got)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var texts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := map[string]string{}
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		texts = append(texts, v["text"])
+	}))
+	defer srv.Close()
+	w := Webhook{URL: srv.URL, OnlyFailures: true}
+	ctx := context.Background()
+	if err := w.NotifyRun(ctx, RunNotification{Result: MigrationResult{Version: "1"}}); err != nil {
+		t.Fatal(err)
+	}
+	n := RunNotification{Err: fmt.Errorf("boom"), Labels: map[string]string{"env": "production", "deploy": "42"}}
+	if err := w.NotifyRun(ctx, n); err != nil {
+		t.Fatal(err)
+	}
+	if len(texts) != 1 || texts[0] != "migration run failed [deploy=42, env=production] after 0 applied migrations: boom" {
+		t.Errorf("unexpected messages: %q", texts)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer failing.Close()
+	if err := (Webhook{URL: failing.URL}).NotifyRun(ctx, n); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("want error for a failing webhook, got: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package migrate
+
+import "time"
+
+// MigrationOptions control the execution of a single migration.
+type MigrationOptions struct {
+	// NoTransaction executes a SQL migration outside of a transaction even if Batch.Transaction is set,
+	// e.g. for CREATE INDEX CONCURRENTLY.
+	NoTransaction bool
+	// IgnoreFailure records a failed migration without failing the run. Later runs do not treat it as failed.
+	IgnoreFailure bool
+	// Timeout limits the execution time of the migration by cancelling its context. A Go migration without a
+	// MigrationContext cannot be cancelled, so it is rejected with a timeout.
+	Timeout time.Duration
+	// FollowUp is an obligation that has to be resolved by a later migration.
+	FollowUp *FollowUp
+	// Resolves lists the versions whose follow-up is resolved by the migration.
+	Resolves []Version
+	// Verifications are run after the migration has been executed.
+	Verifications []Verification
+	// VerifyTimeout limits the time of all verifications. It defaults to DefaultVerifyTimeout.
+	VerifyTimeout time.Duration
+	// Undo reverts the migration if a verification fails outside of a transaction.
+	Undo CommandFunc
+	// UndoScript is the script set by UndoSQL. It is rendered by GenerateRollbackPlan.
+	UndoScript string
+	// Fingerprint identifies the code of a Go migration. Its checksum is recorded and validated like the one of a script.
+	Fingerprint string
+	// AllowDestructive marks destructive statements of the migration as intended, see AllowDestructive.
+	AllowDestructive bool
+	// Tags label the migration for SetOnlyTags and SetExcludeTags.
+	Tags []string
+	// Environments restrict the migration to the active environments or feature flags of the Migrator.
+	Environments []string
+	// Requires lists the migrations of other components that have to be applied before.
+	Requires []Requirement
+	// LockWaitTimeout overrides the lock wait timeout of the Migrator for the migration, see SetLockWaitTimeout.
+	LockWaitTimeout time.Duration
+	// Phase is the phase of the migration in an expand/contract schema change, see Expand and Contract.
+	Phase Phase
+	// ContractAfter is the application version a contract migration waits for, see Contract.
+	ContractAfter string
+	// MinServerVersion is the lowest version of the database server the migration runs on, see MinServerVersion.
+	MinServerVersion string
+	// RequiresVersion is the version of the schema a repeatable migration waits for, see RequiresVersion.
+	RequiresVersion Version
+	// RebuildsTables disables the enforcement of foreign keys while the migration runs, see RebuildsTables.
+	RebuildsTables bool
+}
+
+type MigrationOption func(*MigrationOptions)
+
+func NoTransaction() MigrationOption {
+	return func(o *MigrationOptions) {
+		o.NoTransaction = true
+	}
+}
+
+func IgnoreFailure() MigrationOption {
+	return func(o *MigrationOptions) {
+		o.IgnoreFailure = true
+	}
+}
+
+// Timeout limits the execution time of a SQL migration or a Go migration with a MigrationContext, see
+// MigrationOptions.Timeout.
+func Timeout(timeout time.Duration) MigrationOption {
+	return func(o *MigrationOptions) {
+		o.Timeout = timeout
+	}
+}
+
+// LockWaitTimeout limits how long the statements of the migration wait for locks, see Migrator.SetLockWaitTimeout.
+func LockWaitTimeout(timeout time.Duration) MigrationOption {
+	return func(o *MigrationOptions) {
+		o.LockWaitTimeout = timeout
+	}
+}
+
+// Fingerprint identifies the code of a Go migration, e.g. "backfill-emails/v2". Change it whenever the
+// behaviour of the migration changes, so that Validate detects drift and repeatable Go migrations are re-applied.
+func Fingerprint(id string) MigrationOption {
+	return func(o *MigrationOptions) {
+		o.Fingerprint = id
+	}
+}
+
+func (m Migration) withOptions(opts []MigrationOption) Migration {
+	for _, opt := range opts {
+		opt(&m.Options)
+	}
+	return m
+}
@@ -0,0 +1,53 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGoMigrationFingerprint(t *testing.T) {
+	s := &memSupport{}
+	calls := 0
+	backfill := func(con *sql.DB) error {
+		calls++
+		return nil
+	}
+	m := newTestMigrator(t, s)
+	m.AddGoMigration("1", "backfill", backfill, Fingerprint("backfill/v1"))
+	m.AddRepeatableGoMigration("refresh", backfill, Fingerprint("refresh/v1"))
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	m = newTestMigrator(t, s)
+	m.AddGoMigration("1", "backfill", backfill, Fingerprint("backfill/v1"))
+	m.AddRepeatableGoMigration("refresh", backfill, Fingerprint("refresh/v2"))
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("want 3 calls, got %d", calls)
+	}
+	m = newTestMigrator(t, s)
+	m.AddGoMigration("1", "backfill", backfill, Fingerprint("backfill/v2"))
+	if err := m.Validate(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("want ErrChecksumMismatch, got: %v", err)
+	}
+}
+
+func TestMigrateTimeoutGo(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	ran := false
+	m.AddGoMigration("1", "slow", func(con *sql.DB) error {
+		ran = true
+		return nil
+	}, Timeout(time.Second))
+	if err := m.Migrate(); err == nil {
+		t.Fatalf("want error for a Go migration without a MigrationContext")
+	}
+	if ran {
+		t.Errorf("want the migration not to run")
+	}
+}
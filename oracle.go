@@ -0,0 +1,374 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	_ Support             = OracleSupport{}
+	_ ContextSupport      = OracleSupport{}
+	_ Cleaner             = OracleSupport{}
+	_ Maintainer          = OracleSupport{}
+	_ LockWaitSupport     = OracleSupport{}
+	_ MigrationFinder     = OracleSupport{}
+	_ ConfigurableSupport = OracleSupport{}
+	_ Summarizer          = OracleSupport{}
+	_ VersionedMetadata   = OracleSupport{}
+	_ SplitterSupport     = OracleSupport{}
+)
+
+// NewOracleSupport creates an OracleSupport. The zero value uses the table MIGRATIONS of the current user.
+// Table and schema names are upper cased, so they match unquoted identifiers in scripts.
+func NewOracleSupport(opts ...SupportOption) OracleSupport {
+	return OracleSupport{
+		config: newSupportConfig(opts),
+	}
+}
+
+// Configure returns a copy of the OracleSupport with opts applied to its configuration.
+func (s OracleSupport) Configure(opts ...SupportOption) Support {
+	for _, opt := range opts {
+		opt(&s.config)
+	}
+	return s
+}
+
+type OracleSupport struct {
+	config SupportConfig
+}
+
+// tableName returns the upper cased name of the metadata table with suffix appended.
+func (s OracleSupport) tableName(suffix string) string {
+	return strings.ToUpper(s.config.TableName(suffix))
+}
+
+// qualifiedName returns the quoted and schema qualified name of the metadata table with suffix appended.
+func (s OracleSupport) qualifiedName(suffix string) string {
+	name := quoteIdent(s.tableName(suffix))
+	if s.config.Schema == "" {
+		return name
+	}
+	return quoteIdent(strings.ToUpper(s.config.Schema)) + "." + name
+}
+
+// filterColumns returns the upper cased names of the metadata table and its columns that filters select by.
+func (s OracleSupport) filterColumns() filterColumns {
+	return filterColumns{table: s.qualifiedName(""), rank: "INSTALLED_RANK", version: "VERSION", description: "DESCRIPTION", typ: "TYPE", status: "STATUS"}
+}
+
+func (s OracleSupport) ExistsMigrationsTable(db *sql.DB) (bool, error) {
+	return s.ExistsMigrationsTableContext(context.Background(), db)
+}
+
+func (s OracleSupport) ExistsMigrationsTableContext(ctx context.Context, q Querier) (bool, error) {
+	return s.existsTable(ctx, q, "")
+}
+
+// existsTable reports whether the metadata table with suffix appended exists.
+func (s OracleSupport) existsTable(ctx context.Context, q Querier, suffix string) (bool, error) {
+	var count int
+	var row *sql.Row
+	if s.config.Schema == "" {
+		row = q.QueryRowContext(ctx, `SELECT COUNT(*) FROM USER_TABLES WHERE TABLE_NAME = :1`, s.tableName(suffix))
+	} else {
+		row = q.QueryRowContext(ctx, `SELECT COUNT(*) FROM ALL_TABLES WHERE OWNER = :1 AND TABLE_NAME = :2`, strings.ToUpper(s.config.Schema), s.tableName(suffix))
+	}
+	err := row.Scan(&count)
+	return count > 0, err
+}
+
+// addColumn adds a column to a metadata table created by an earlier version of this package.
+func (s OracleSupport) addColumn(db *sql.DB, column string, definition string) error {
+	var count int
+	var row *sql.Row
+	if s.config.Schema == "" {
+		row = db.QueryRow(`SELECT COUNT(*) FROM USER_TAB_COLUMNS WHERE TABLE_NAME = :1 AND COLUMN_NAME = :2`, s.tableName(""), column)
+	} else {
+		row = db.QueryRow(`SELECT COUNT(*) FROM ALL_TAB_COLUMNS WHERE OWNER = :1 AND TABLE_NAME = :2 AND COLUMN_NAME = :3`, strings.ToUpper(s.config.Schema), s.tableName(""), column)
+	}
+	if err := row.Scan(&count); err != nil || count > 0 {
+		return err
+	}
+	_, err := db.Exec(`ALTER TABLE ` + s.qualifiedName("") + ` ADD (` + column + ` ` + definition + `)`)
+	return err
+}
+
+func (s OracleSupport) MetadataUpgrades() []MetadataUpgrade {
+	return []MetadataUpgrade{
+		{Version: 1, Description: "failure diagnostics", Apply: func(db *sql.DB) error {
+			if err := s.addColumn(db, "FAILURE", "CLOB"); err != nil {
+				return err
+			}
+			return s.addColumn(db, "FAILED_STATEMENT", "NUMBER(10)")
+		}},
+		{Version: 2, Description: "installed by", Apply: func(db *sql.DB) error {
+			return s.addColumn(db, "INSTALLED_BY", "VARCHAR2(100)")
+		}},
+		{Version: 3, Description: "script headers", Apply: func(db *sql.DB) error {
+			if err := s.addColumn(db, "AUTHOR", "VARCHAR2(100)"); err != nil {
+				return err
+			}
+			return s.addColumn(db, "TICKET", "VARCHAR2(100)")
+		}},
+		{Version: 4, Description: "shell output", Apply: func(db *sql.DB) error {
+			return s.addColumn(db, "OUTPUT", "CLOB")
+		}},
+	}
+}
+
+func (s OracleSupport) MetadataVersion(db *sql.DB) (int, error) {
+	exists, err := s.existsTable(context.Background(), db, "_schema")
+	if err != nil || !exists {
+		return 0, err
+	}
+	var version int
+	err = db.QueryRow(`SELECT VERSION FROM ` + s.qualifiedName("_schema") + ` WHERE ID = 1`).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return version, err
+}
+
+func (s OracleSupport) SetMetadataVersion(db *sql.DB, version int) error {
+	exists, err := s.existsTable(context.Background(), db, "_schema")
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if _, err := db.Exec(fmt.Sprintf(oracleSchemaVersion, s.qualifiedName("_schema"))); err != nil {
+			return err
+		}
+	}
+	_, err = db.Exec(`MERGE INTO `+s.qualifiedName("_schema")+` t USING DUAL ON (t.ID = 1) WHEN MATCHED THEN UPDATE SET t.VERSION = :1 WHEN NOT MATCHED THEN INSERT (ID, VERSION) VALUES (1, :2)`, version, version)
+	return err
+}
+
+func (s OracleSupport) CreateMigrationsTable(db *sql.DB) error {
+	return s.CreateMigrationsTableContext(context.Background(), db)
+}
+
+func (s OracleSupport) CreateMigrationsTableContext(ctx context.Context, q Querier) error {
+	_, err := q.ExecContext(ctx, fmt.Sprintf(oracleMigrations, s.qualifiedName("")))
+	return err
+}
+
+func (s OracleSupport) RecordMigration(db *sql.DB, m Migration) error {
+	return s.RecordMigrationContext(context.Background(), db, m)
+}
+
+func (s OracleSupport) RecordMigrationContext(ctx context.Context, q Querier, m Migration) error {
+	_, err := q.ExecContext(ctx, `INSERT INTO `+s.qualifiedName("")+` (INSTALLED_RANK, VERSION, DESCRIPTION, TYPE, CHECKSUM, INSTALLED_ON, EXECUTION_TIME, STATUS, FAILURE, FAILED_STATEMENT, INSTALLED_BY, AUTHOR, TICKET, OUTPUT) VALUES (:1, :2, :3, :4, :5, :6, :7, :8, :9, :10, :11, :12, :13, :14)`,
+		m.Rank,
+		string(m.Version),
+		m.Description,
+		string(m.Type),
+		m.Checksum,
+		m.Date,
+		milliseconds(m.ExecutionTime),
+		string(m.Status),
+		nullString(m.Failure),
+		nullInt(m.FailedStatement),
+		nullString(m.InstalledBy),
+		nullString(m.Author),
+		nullString(m.Ticket),
+		nullString(m.Output),
+	)
+	return err
+}
+
+func (s OracleSupport) ListMigrations(db *sql.DB) (Migrations, error) {
+	return s.ListMigrationsContext(context.Background(), db)
+}
+
+func (s OracleSupport) ListMigrationsContext(ctx context.Context, q Querier) (Migrations, error) {
+	return s.findMigrations(ctx, q, MigrationFilter{})
+}
+
+// FindMigrations lists the recorded migrations selected by f ordered by rank.
+func (s OracleSupport) FindMigrations(db *sql.DB, f MigrationFilter) (Migrations, error) {
+	return s.findMigrations(context.Background(), db, f)
+}
+
+// CountMigrations returns the number of recorded migrations selected by f.
+func (s OracleSupport) CountMigrations(db *sql.DB, f MigrationFilter) (int, error) {
+	where, args := f.where(s.filterColumns(), colon)
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM `+s.qualifiedName("")+where, args...).Scan(&n)
+	return n, err
+}
+
+// SummarizeMigrations summarizes the recorded versioned migrations with a single query.
+func (s OracleSupport) SummarizeMigrations(db *sql.DB) (Summary, error) {
+	table := s.qualifiedName("")
+	sum := Summary{}
+	var latest sql.NullString
+	row := db.QueryRow(`SELECT COUNT(*), COUNT(CASE WHEN STATUS = :1 THEN 1 END), (SELECT VERSION FROM `+table+` WHERE VERSION <> :2 ORDER BY INSTALLED_RANK DESC FETCH FIRST 1 ROWS ONLY) FROM `+table+` WHERE VERSION <> :3`,
+		string(StatusFailed), string(VersionRepeatable), string(VersionRepeatable))
+	if err := row.Scan(&sum.Versioned, &sum.Failed, &latest); err != nil {
+		return Summary{}, err
+	}
+	sum.Latest = Version(latest.String)
+	return sum, nil
+}
+
+func (s OracleSupport) findMigrations(ctx context.Context, q Querier, f MigrationFilter) (Migrations, error) {
+	where, args := f.where(s.filterColumns(), colon)
+	rows, err := q.QueryContext(ctx, `SELECT INSTALLED_RANK, VERSION, DESCRIPTION, TYPE, CHECKSUM, INSTALLED_ON, EXECUTION_TIME, STATUS, FAILURE, FAILED_STATEMENT, INSTALLED_BY, AUTHOR, TICKET, OUTPUT FROM `+s.qualifiedName("")+where+` ORDER BY INSTALLED_RANK`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ms := []Migration{}
+	for rows.Next() {
+		var m Migration
+		var version, typ, status string
+		// Oracle stores empty strings as NULL.
+		var description, checksum, failure, installedBy, author, ticket, output sql.NullString
+		var failedStatement sql.NullInt64
+		var executionTime int64
+		var date time.Time
+		if err := rows.Scan(&m.Rank, &version, &description, &typ, &checksum, &date, &executionTime, &status, &failure, &failedStatement, &installedBy, &author, &ticket, &output); err != nil {
+			return nil, err
+		}
+		m.Version = Version(version)
+		m.Description = description.String
+		m.Type = Type(typ)
+		m.Checksum = checksum.String
+		m.Date = date.UTC()
+		m.ExecutionTime = fromMilliseconds(executionTime)
+		m.Status = Status(status)
+		m.Failure = failure.String
+		m.FailedStatement = int(failedStatement.Int64)
+		m.InstalledBy = installedBy.String
+		m.Author = author.String
+		m.Ticket = ticket.String
+		m.Output = output.String
+		ms = append(ms, m)
+	}
+	return ms, rows.Err()
+}
+
+// ListObjects lists the objects of the configured schema that can be dropped. Indexes, LOBs and package bodies
+// are dropped together with the objects they belong to and are not listed.
+func (s OracleSupport) ListObjects(db *sql.DB) ([]Object, error) {
+	var rows *sql.Rows
+	var err error
+	if s.config.Schema == "" {
+		rows, err = db.Query(`SELECT OBJECT_TYPE, OBJECT_NAME FROM USER_OBJECTS WHERE OBJECT_TYPE IN (` + oracleObjectTypes + `) AND GENERATED = 'N' AND SECONDARY = 'N' ORDER BY OBJECT_NAME`)
+	} else {
+		rows, err = db.Query(`SELECT OBJECT_TYPE, OBJECT_NAME FROM ALL_OBJECTS WHERE OWNER = :1 AND OBJECT_TYPE IN (`+oracleObjectTypes+`) AND GENERATED = 'N' AND SECONDARY = 'N' ORDER BY OBJECT_NAME`, strings.ToUpper(s.config.Schema))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	objects := []Object{}
+	for rows.Next() {
+		var o Object
+		if err := rows.Scan(&o.Type, &o.Name); err != nil {
+			return nil, err
+		}
+		o.Type = strings.ToLower(o.Type)
+		objects = append(objects, o)
+	}
+	return objects, rows.Err()
+}
+
+func (s OracleSupport) DropStatement(o Object) string {
+	name := quoteIdent(o.Name)
+	if s.config.Schema != "" {
+		name = quoteIdent(strings.ToUpper(s.config.Schema)) + "." + name
+	}
+	stmt := `DROP ` + strings.ToUpper(o.Type) + ` ` + name
+	switch o.Type {
+	case ObjectTable:
+		stmt += ` CASCADE CONSTRAINTS`
+	case "type":
+		stmt += ` FORCE`
+	}
+	return stmt
+}
+
+// Clean drops the objects of the configured schema and purges the recycle bin.
+func (s OracleSupport) Clean(db *sql.DB) error {
+	objects, err := s.ListObjects(db)
+	if err != nil {
+		return err
+	}
+	for _, o := range (CleanOptions{}).filter(objects) {
+		if _, err := db.Exec(s.DropStatement(o)); err != nil {
+			return err
+		}
+	}
+	_, err = db.Exec(`PURGE RECYCLEBIN`)
+	return err
+}
+
+// SetLockWaitTimeout sets DDL_LOCK_TIMEOUT, which limits how long DDL waits for a lock, in whole seconds rounded up.
+func (s OracleSupport) SetLockWaitTimeout(ctx context.Context, q Querier, timeout time.Duration) error {
+	seconds := (timeout + time.Second - 1) / time.Second
+	_, err := q.ExecContext(ctx, fmt.Sprintf("ALTER SESSION SET DDL_LOCK_TIMEOUT = %d", seconds))
+	return err
+}
+
+// Dialects returns oracle.
+// Splitter returns the OracleSplitter, as Oracle rejects statements terminated by a semicolon and PL/SQL blocks are
+// terminated by a "/" line as in SQL*Plus.
+func (s OracleSupport) Splitter() Splitter {
+	return OracleSplitter
+}
+
+func (s OracleSupport) Dialects() []string {
+	return []string{"oracle"}
+}
+
+// DetectServer returns the version of the Oracle database.
+func (s OracleSupport) DetectServer(db *sql.DB) (Server, error) {
+	srv := Server{Product: "Oracle"}
+	err := db.QueryRow(`SELECT version FROM product_component_version WHERE product LIKE 'Oracle%' AND ROWNUM = 1`).Scan(&srv.Version)
+	return srv, err
+}
+
+// Maintain gathers the optimizer statistics of the configured schema.
+func (s OracleSupport) Maintain(db *sql.DB) error {
+	owner := `USER`
+	if s.config.Schema != "" {
+		owner = sqlLiteral(s.config.Schema)
+	}
+	_, err := db.Exec(`BEGIN DBMS_STATS.GATHER_SCHEMA_STATS(ownname => ` + owner + `); END;`)
+	return err
+}
+
+const oracleObjectTypes = `'TABLE', 'VIEW', 'SEQUENCE', 'SYNONYM', 'TRIGGER', 'PROCEDURE', 'FUNCTION', 'PACKAGE', 'TYPE', 'MATERIALIZED VIEW'`
+
+// oracleMigrations uses upper case column names, INSTALLED_RANK and INSTALLED_ON avoid the keywords RANK and DATE.
+// Statements must not be terminated with a semicolon.
+const oracleMigrations = `
+CREATE TABLE %s (
+  INSTALLED_RANK NUMBER(10) NOT NULL,
+  VERSION VARCHAR2(50) NOT NULL,
+  DESCRIPTION VARCHAR2(200),
+  TYPE VARCHAR2(20) NOT NULL,
+  CHECKSUM VARCHAR2(128),
+  INSTALLED_ON TIMESTAMP NOT NULL,
+  EXECUTION_TIME NUMBER(10) NOT NULL,
+  STATUS VARCHAR2(20) NOT NULL,
+  FAILURE CLOB,
+  FAILED_STATEMENT NUMBER(10),
+  INSTALLED_BY VARCHAR2(100),
+  AUTHOR VARCHAR2(100),
+  TICKET VARCHAR2(100),
+  OUTPUT CLOB,
+  PRIMARY KEY (INSTALLED_RANK)
+)`
+
+const oracleSchemaVersion = `
+CREATE TABLE %s (
+  ID NUMBER(10) NOT NULL,
+  VERSION NUMBER(10) NOT NULL,
+  PRIMARY KEY (ID)
+)`
@@ -0,0 +1,57 @@
+package migrate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOracleNames(t *testing.T) {
+	s := NewOracleSupport(WithSchema("app"), WithTable("schema_history"))
+	if got, want := s.qualifiedName(""), `"APP"."SCHEMA_HISTORY"`; got != want {
+		t.Errorf("want: %s, got: %s", want, got)
+	}
+	tests := []struct {
+		object Object
+		want   string
+	}{
+		{Object{Type: ObjectTable, Name: "USERS"}, `DROP TABLE "APP"."USERS" CASCADE CONSTRAINTS`},
+		{Object{Type: "type", Name: "ADDRESS"}, `DROP TYPE "APP"."ADDRESS" FORCE`},
+		{Object{Type: "materialized view", Name: "STATS"}, `DROP MATERIALIZED VIEW "APP"."STATS"`},
+	}
+	for _, test := range tests {
+		if got := s.DropStatement(test.object); got != test.want {
+			t.Errorf("want: %s, got: %s", test.want, got)
+		}
+	}
+}
+
+func TestOracleUpgradeMetadata(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	log.results = map[string]string{
+		`SELECT COUNT(*) FROM USER_TABLES WHERE TABLE_NAME = :1`:                           "1",
+		`SELECT COUNT(*) FROM USER_TAB_COLUMNS WHERE TABLE_NAME = :1 AND COLUMN_NAME = :2`: "0",
+	}
+	m := NewMigrator(t.Logf, db, NewOracleSupport())
+	if err := m.upgradeMetadata(); err != nil {
+		t.Fatal(err)
+	}
+	alters := []string{}
+	for _, stmt := range log.Statements() {
+		if strings.HasPrefix(stmt, "ALTER TABLE") {
+			alters = append(alters, stmt)
+		}
+	}
+	want := []string{
+		`ALTER TABLE "MIGRATIONS" ADD (FAILURE CLOB)`,
+		`ALTER TABLE "MIGRATIONS" ADD (FAILED_STATEMENT NUMBER(10))`,
+		`ALTER TABLE "MIGRATIONS" ADD (INSTALLED_BY VARCHAR2(100))`,
+		`ALTER TABLE "MIGRATIONS" ADD (AUTHOR VARCHAR2(100))`,
+		`ALTER TABLE "MIGRATIONS" ADD (TICKET VARCHAR2(100))`,
+		`ALTER TABLE "MIGRATIONS" ADD (OUTPUT CLOB)`,
+	}
+	if !reflect.DeepEqual(want, alters) {
+		t.Errorf("want: %q, got: %q", want, alters)
+	}
+}
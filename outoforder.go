@@ -0,0 +1,36 @@
+package migrate
+
+// SetOutOfOrder makes Migrate apply pending migrations below the last applied version, e.g. those merged from a
+// branch after a later version has been deployed, instead of ignoring them. Migrations at or below the baseline are
+// still ignored, and Validate does not report the pending ones with ErrOutOfOrder.
+func (m *Migrator) SetOutOfOrder(outOfOrder bool) {
+	m.outOfOrder = outOfOrder
+}
+
+// outOfOrderPending returns the ignored migrations of info that Migrate applies out of order.
+func (m *Migrator) outOfOrderPending(info Info) Migrations {
+	if !m.outOfOrder {
+		return nil
+	}
+	baseline := VersionNone
+	for _, mig := range info.Migrations {
+		if mig.Type == TypeBaseline {
+			baseline = mig.Version
+		}
+	}
+	pending := Migrations{}
+	for _, mig := range info.Ignored() {
+		if baseline == VersionNone || m.versionOrdering.compare(mig.Version, baseline) > 0 {
+			pending = append(pending, mig)
+		}
+	}
+	return pending
+}
+
+// later returns the later of the versions a and b, b if a is VersionNone.
+func (o versionOrder) later(a Version, b Version) Version {
+	if a == VersionNone || o.compare(b, a) > 0 {
+		return b
+	}
+	return a
+}
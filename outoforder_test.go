@@ -0,0 +1,45 @@
+package migrate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMigrateOutOfOrder(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	m.AddSQLMigration("3", "three", "CREATE TABLE c (id INT);")
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	m.AddSQLMigration("2", "two", "CREATE TABLE b (id INT);")
+	if err := m.Validate(); err == nil {
+		t.Errorf("want out of order error")
+	}
+	m.SetOutOfOrder(true)
+	if err := m.Validate(); err != nil {
+		t.Errorf("want no out of order error, got: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		res, err := m.MigrateWithResult()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Version != "3" {
+			t.Errorf("want version 3, got: %s", res.Version)
+		}
+	}
+	want := []string{"CREATE TABLE a (id INT);", "CREATE TABLE c (id INT);", "CREATE TABLE b (id INT);"}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	if rec := s.migrations[2]; len(s.migrations) != 3 || rec.Version != "2" || rec.Status != StatusSuccess {
+		t.Errorf("unexpected records: %+v", s.migrations)
+	}
+	if pending := m.Info().Pending(); len(pending) != 0 {
+		t.Errorf("want no pending migrations, got: %v", pending)
+	}
+}
@@ -0,0 +1,174 @@
+package migrate
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FailurePolicy decides how a parallel run continues after a tenant failed.
+type FailurePolicy int
+
+const (
+	// StopOnFailure does not start further tenants after the first failure. Running ones are completed.
+	StopOnFailure FailurePolicy = iota
+	// ContinueOnFailure migrates all tenants regardless of failures.
+	ContinueOnFailure
+)
+
+// ParallelOptions configure Fleet.MigrateAll.
+type ParallelOptions struct {
+	// Workers is the number of tenants migrated concurrently. It defaults to 1.
+	Workers int
+	Policy  FailurePolicy
+}
+
+// TenantResult is the outcome of migrating a single tenant.
+type TenantResult struct {
+	Tenant   Tenant
+	Err      error
+	Duration time.Duration
+	// Skipped is set for tenants that have not been migrated due to the failure policy.
+	Skipped bool
+}
+
+// MigrateAll migrates the tenants of the inventory concurrently. The results are in the order of the inventory.
+func (f *Fleet) MigrateAll(opts ParallelOptions) ([]TenantResult, error) {
+	ts, err := f.inventory.Tenants()
+	if err != nil {
+		return nil, fmt.Errorf("list tenants: %+v", err)
+	}
+	return f.MigrateTenants(ts, opts)
+}
+
+// MigrateTenants migrates the tenants concurrently with a bounded number of workers.
+// The results are in the order of ts. The error summarizes the failed tenants.
+func (f *Fleet) MigrateTenants(ts []Tenant, opts ParallelOptions) ([]TenantResult, error) {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	results := make([]TenantResult, len(ts))
+	jobs := make(chan int)
+	var mu sync.Mutex
+	failed := false
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				mu.Lock()
+				stop := failed && opts.Policy == StopOnFailure
+				mu.Unlock()
+				if stop {
+					results[i] = TenantResult{Tenant: ts[i], Skipped: true}
+					continue
+				}
+				results[i] = f.migrateTenant(ts[i])
+				if results[i].Err != nil {
+					mu.Lock()
+					failed = true
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range ts {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results, tenantsError(results)
+}
+
+func (f *Fleet) migrateTenant(t Tenant) TenantResult {
+	start := time.Now()
+	err := f.Migrator(t).Migrate()
+	return TenantResult{
+		Tenant:   t,
+		Err:      err,
+		Duration: time.Since(start),
+	}
+}
+
+func tenantsError(results []TenantResult) error {
+	var first *TenantResult
+	failed, skipped := 0, 0
+	for i, r := range results {
+		switch {
+		case r.Skipped:
+			skipped++
+		case r.Err != nil:
+			if first == nil {
+				first = &results[i]
+			}
+			failed++
+		}
+	}
+	if first == nil {
+		return nil
+	}
+	return fmt.Errorf("migrate tenants: %d failed, %d skipped: %s: %+v", failed, skipped, first.Tenant.ID, first.Err)
+}
+
+// SetParallelRepeatable makes Migrate install outdated repeatable migrations concurrently with up to workers
+// migrations at a time, each on its own connection of the pool. Repeatable migrations must then be independent of
+// each other, and hooks and subscribers must be safe for concurrent use. The outcome of every migration is recorded
+// on its own. After a failure no further repeatable migrations are started. The default of 1 installs them in order.
+func (m *Migrator) SetParallelRepeatable(workers int) {
+	m.repeatableWorkers = workers
+}
+
+// installRepeatable installs the outdated repeatable migrations, concurrently if configured.
+func (m *Migrator) installRepeatable(outdated Migrations) error {
+	if m.repeatableWorkers <= 1 || len(outdated) <= 1 {
+		for _, mig := range outdated {
+			if err := m.expired(mig); err != nil {
+				return m.onError(mig, err)
+			}
+			if err := m.install(mig); err != nil {
+				return m.onError(mig, err)
+			}
+		}
+		return nil
+	}
+	errs := make([]error, len(outdated))
+	jobs := make(chan int)
+	var mu sync.Mutex
+	failed := false
+	var wg sync.WaitGroup
+	for w := 0; w < m.repeatableWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				mu.Lock()
+				stop := failed
+				mu.Unlock()
+				if stop {
+					continue
+				}
+				if errs[i] = m.expired(outdated[i]); errs[i] == nil {
+					errs[i] = m.install(outdated[i])
+				}
+				if errs[i] != nil {
+					mu.Lock()
+					failed = true
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range outdated {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return m.onError(outdated[i], err)
+		}
+	}
+	return nil
+}
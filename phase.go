@@ -0,0 +1,78 @@
+package migrate
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Phase is the phase of a migration in an expand/contract schema change for zero-downtime deployments. The expand
+// phase adds new structures that old and new application code can use, the contract phase removes the old ones once
+// no running application code uses them anymore.
+type Phase string
+
+const (
+	PhaseExpand   Phase = "expand"
+	PhaseContract Phase = "contract"
+)
+
+// Expand marks a migration as part of the expand phase. Expand migrations are installed like any other migration.
+func Expand() MigrationOption {
+	return func(o *MigrationOptions) {
+		o.Phase = PhaseExpand
+	}
+}
+
+// Contract marks a migration as part of the contract phase. It is installed only once the minimum application version
+// set by SetMinAppVersion is at least appVersion, i.e. the first version of the application that does not use what the
+// migration removes.
+func Contract(appVersion string) MigrationOption {
+	return func(o *MigrationOptions) {
+		o.Phase = PhaseContract
+		o.ContractAfter = appVersion
+	}
+}
+
+// SetMinAppVersion sets the lowest version of the application that is still running, e.g. as reported by the
+// deployment. Contract migrations that require a higher version are deferred: Migrate stops before them without an
+// error and leaves them and all following migrations, including repeatable ones, pending. Without a minimum
+// application version all contract migrations are deferred.
+func (m *Migrator) SetMinAppVersion(version string) {
+	m.minAppVersion = version
+}
+
+// contractable reports whether mig may be installed, which is the case unless it is a contract migration whose
+// application version has not been confirmed by SetMinAppVersion.
+func (m *Migrator) contractable(mig Migration) bool {
+	if mig.Options.Phase != PhaseContract {
+		return true
+	}
+	return m.minAppVersion != "" && compareDottedVersions(m.minAppVersion, mig.Options.ContractAfter) >= 0
+}
+
+// compareDottedVersions compares versions like 2.10.1 by their dot separated parts, numerically if both
+// parts are numbers. A leading "v" is ignored.
+func compareDottedVersions(a string, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		ap, bp := "0", "0"
+		if i < len(as) {
+			ap = as[i]
+		}
+		if i < len(bs) {
+			bp = bs[i]
+		}
+		ai, aErr := strconv.ParseInt(ap, 10, 64)
+		bi, bErr := strconv.ParseInt(bp, 10, 64)
+		switch {
+		case aErr == nil && bErr == nil && ai != bi:
+			if ai < bi {
+				return -1
+			}
+			return 1
+		case (aErr != nil || bErr != nil) && ap != bp:
+			return strings.Compare(ap, bp)
+		}
+	}
+	return 0
+}
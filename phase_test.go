@@ -0,0 +1,59 @@
+package migrate
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestContractMigrations(t *testing.T) {
+	m := newTestMigrator(t, &memSupport{})
+	order := []string{}
+	step := func(name string) CommandFunc {
+		return func(con *sql.DB) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+	m.AddGoMigration("1", "add email", step("expand"), Expand())
+	m.AddGoMigration("2", "drop mail", step("contract"), Contract("2.0"))
+	m.AddGoMigration("3", "add phone", step("next"))
+	m.AddRepeatableGoMigration("view", step("view"))
+
+	m.SetMinAppVersion("1.9.3")
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"expand"}; !reflect.DeepEqual(want, order) {
+		t.Fatalf("want: %v, got: %v", want, order)
+	}
+	if pending := m.Info().Pending(); len(pending) != 3 {
+		t.Errorf("want deferred migrations to be pending, got:\n%s", pending)
+	}
+
+	m.SetMinAppVersion("v2.0.1")
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"expand", "contract", "next", "view"}; !reflect.DeepEqual(want, order) {
+		t.Errorf("want: %v, got: %v", want, order)
+	}
+}
+
+func TestCompareDottedVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2.10", "2.9", 1},
+		{"2.0", "2", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2", "1.2.1", -1},
+		{"1.2-rc1", "1.2-rc2", -1},
+	}
+	for _, test := range tests {
+		if got := compareDottedVersions(test.a, test.b); got != test.want {
+			t.Errorf("%s <=> %s: want %d, got %d", test.a, test.b, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+)
+
+// pinnedConnector connects a *sql.DB to a single *sql.Conn, so that every statement issued through the *sql.DB runs in
+// the session of that connection. The connection is either given or taken from pool on first use.
+type pinnedConnector struct {
+	mu   sync.Mutex
+	pool *sql.DB
+	con  *sql.Conn
+}
+
+// pinnedDB returns a *sql.DB that runs on c.
+func pinnedDB(c *pinnedConnector) *sql.DB {
+	db := sql.OpenDB(c)
+	db.SetMaxOpenConns(1)
+	return db
+}
+
+func (c *pinnedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.con == nil {
+		con, err := c.pool.Conn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.con = con
+	}
+	return &pinnedConn{con: c.con}, nil
+}
+
+func (c *pinnedConnector) Driver() driver.Driver {
+	return pinnedDriver{}
+}
+
+// release returns a connection taken from the pool.
+func (c *pinnedConnector) release() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pool == nil || c.con == nil {
+		return nil
+	}
+	err := c.con.Close()
+	c.con = nil
+	return err
+}
+
+type pinnedDriver struct{}
+
+func (pinnedDriver) Open(name string) (driver.Conn, error) {
+	return nil, errors.New("pinned connections are opened by their connector")
+}
+
+// pinnedConn passes statements on to the pinned connection or to its transaction.
+type pinnedConn struct {
+	con *sql.Conn
+	tx  *sql.Tx
+}
+
+var (
+	_ driver.ExecerContext     = &pinnedConn{}
+	_ driver.QueryerContext    = &pinnedConn{}
+	_ driver.ConnBeginTx       = &pinnedConn{}
+	_ driver.Pinger            = &pinnedConn{}
+	_ driver.NamedValueChecker = &pinnedConn{}
+)
+
+func (c *pinnedConn) querier() Querier {
+	if c.tx != nil {
+		return c.tx
+	}
+	return c.con
+}
+
+func (c *pinnedConn) Prepare(query string) (driver.Stmt, error) {
+	return &pinnedStmt{con: c, query: query}, nil
+}
+
+// Close keeps the pinned connection open, it is closed by its owner.
+func (c *pinnedConn) Close() error {
+	return nil
+}
+
+func (c *pinnedConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *pinnedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	tx, err := c.con.BeginTx(ctx, &sql.TxOptions{Isolation: sql.IsolationLevel(opts.Isolation), ReadOnly: opts.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	c.tx = tx
+	return pinnedTx{con: c}, nil
+}
+
+func (c *pinnedConn) Ping(ctx context.Context) error {
+	return c.con.PingContext(ctx)
+}
+
+// CheckNamedValue passes the arguments on unchanged, they are converted by the driver of the pinned connection.
+func (c *pinnedConn) CheckNamedValue(v *driver.NamedValue) error {
+	return nil
+}
+
+func (c *pinnedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.querier().ExecContext(ctx, query, pinnedArgs(args)...)
+}
+
+func (c *pinnedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.querier().QueryContext(ctx, query, pinnedArgs(args)...)
+	if err != nil {
+		return nil, err
+	}
+	return &pinnedRows{rows: rows}, nil
+}
+
+// pinnedArgs returns the arguments of a statement in the form accepted by *sql.Conn.
+func pinnedArgs(args []driver.NamedValue) []interface{} {
+	vs := make([]interface{}, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			vs[i] = sql.Named(arg.Name, arg.Value)
+		} else {
+			vs[i] = arg.Value
+		}
+	}
+	return vs
+}
+
+type pinnedTx struct {
+	con *pinnedConn
+}
+
+func (t pinnedTx) Commit() error {
+	defer func() { t.con.tx = nil }()
+	return t.con.tx.Commit()
+}
+
+func (t pinnedTx) Rollback() error {
+	defer func() { t.con.tx = nil }()
+	return t.con.tx.Rollback()
+}
+
+type pinnedStmt struct {
+	con   *pinnedConn
+	query string
+}
+
+var (
+	_ driver.StmtExecContext  = &pinnedStmt{}
+	_ driver.StmtQueryContext = &pinnedStmt{}
+)
+
+func (s *pinnedStmt) Close() error {
+	return nil
+}
+
+func (s *pinnedStmt) NumInput() int {
+	return -1
+}
+
+func (s *pinnedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *pinnedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *pinnedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.con.ExecContext(ctx, s.query, args)
+}
+
+func (s *pinnedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.con.QueryContext(ctx, s.query, args)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	nvs := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		nvs[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return nvs
+}
+
+// pinnedRows reads the rows of the pinned connection.
+type pinnedRows struct {
+	rows *sql.Rows
+}
+
+func (r *pinnedRows) Columns() []string {
+	columns, _ := r.rows.Columns()
+	return columns
+}
+
+func (r *pinnedRows) Close() error {
+	return r.rows.Close()
+}
+
+func (r *pinnedRows) Next(dest []driver.Value) error {
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	values := make([]interface{}, len(dest))
+	ptrs := make([]interface{}, len(dest))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := r.rows.Scan(ptrs...); err != nil {
+		return err
+	}
+	for i, v := range values {
+		dest[i] = v
+	}
+	return nil
+}
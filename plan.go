@@ -0,0 +1,184 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Plan lists the migrations the next call to Migrate would apply, e.g. to be reviewed in a GitOps pipeline before it
+// is passed to Apply. It is meant to be serialized as JSON.
+type Plan struct {
+	Created time.Time `json:"created"`
+	// State is the hex encoded SHA-256 hash of the migrations recorded in the database when the plan was generated.
+	State string     `json:"state"`
+	Steps []PlanStep `json:"steps"`
+}
+
+// PlanStep is a migration that is applied by a Plan.
+type PlanStep struct {
+	Version     Version `json:"version"`
+	Description string  `json:"description"`
+	Type        Type    `json:"type"`
+	Checksum    string  `json:"checksum,omitempty"`
+	// State is StatePending, StateFailed for failed migrations that are retried or, for repeatable migrations that are
+	// applied again, StateOutdated.
+	State State `json:"state"`
+	// Statements is the estimated number of statements of a SQL migration. It is zero for Go migrations.
+	Statements int `json:"statements"`
+}
+
+func (s PlanStep) String() string {
+	return fmt.Sprintf("@PlanStep|version=%s|description=%s|type=%s|checksum=%s|state=%s", s.Version, s.Description, s.Type, s.Checksum, s.State)
+}
+
+// Plan returns the plan of the migrations the next call to Migrate would apply.
+func (m *Migrator) Plan() (Plan, error) {
+	exists, err := m.support.ExistsMigrationsTable(m.db)
+	if err != nil {
+		return Plan{}, err
+	}
+	var installed Migrations
+	if exists {
+		if installed, err = m.findMigrations(MigrationFilter{Latest: true}); err != nil {
+			return Plan{}, err
+		}
+	}
+	migrations, repeatable := m.registered()
+	steps, err := m.planSteps(migrations, repeatable, installed)
+	if err != nil {
+		return Plan{}, err
+	}
+	return Plan{
+		Created: m.now().UTC(),
+		State:   planState(installed),
+		Steps:   steps,
+	}, nil
+}
+
+// Apply migrates the database like Migrate if it is still in the state p has been generated for. It fails with
+// ErrPlanOutdated without applying any migration if the recorded or the registered migrations have changed since.
+func (m *Migrator) Apply(p Plan) error {
+	_, err := m.migrateWithResult(context.Background(), &p)
+	return err
+}
+
+// planSteps returns the steps that install the migrations Migrate selects, see schedule.
+func (m *Migrator) planSteps(migrations Migrations, repeatable Migrations, installed Migrations) ([]PlanStep, error) {
+	s, err := m.schedule(migrations, repeatable, installed)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := m.schemaVersion(s.repeatable)
+	if err != nil {
+		return nil, err
+	}
+	selected := []scheduledStep{}
+	for _, st := range s.versioned {
+		if st.kind == stepInstall || st.kind == stepRetry {
+			selected = append(selected, st)
+			schema = m.versionOrdering.later(schema, st.mig.Version)
+		}
+	}
+	for _, st := range m.repeatableSteps(s, schema) {
+		if st.kind == stepInstall {
+			selected = append(selected, st)
+		}
+	}
+	steps := []PlanStep{}
+	for _, st := range selected {
+		mig := st.mig
+		step := PlanStep{
+			Version:     mig.Version,
+			Description: mig.Description,
+			Type:        mig.Type,
+			Checksum:    mig.Checksum,
+			State:       st.state,
+		}
+		if mig.Type == TypeSQL && (mig.Script != "" || mig.Source != nil) {
+			stmts, err := m.statements(mig)
+			if err != nil {
+				return nil, fmt.Errorf("plan: %s: %+v", mig, err)
+			}
+			step.Statements = len(stmts)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// checkPlan verifies the state of the database and the registered migrations against the plan passed to Apply.
+func (m *Migrator) checkPlan(migrations Migrations, repeatable Migrations, installed Migrations) error {
+	if m.plan == nil {
+		return nil
+	}
+	if state := planState(installed); state != m.plan.State {
+		return &PlanError{Detail: fmt.Sprintf("database state is %s, plan has %s", state, m.plan.State)}
+	}
+	steps, err := m.planSteps(migrations, repeatable, installed)
+	if err != nil {
+		return err
+	}
+	if len(steps) != len(m.plan.Steps) {
+		return &PlanError{Detail: fmt.Sprintf("%d steps, plan has %d", len(steps), len(m.plan.Steps))}
+	}
+	for i, step := range steps {
+		if step != m.plan.Steps[i] {
+			return &PlanError{Detail: fmt.Sprintf("step %d is %s, plan has %s", i+1, step, m.plan.Steps[i])}
+		}
+	}
+	return nil
+}
+
+// PlanError reports that the database or the registered migrations have changed since the plan passed to Apply has
+// been generated.
+type PlanError struct {
+	Detail string
+}
+
+func (e *PlanError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrPlanOutdated, e.Detail)
+}
+
+func (e *PlanError) Unwrap() error {
+	return ErrPlanOutdated
+}
+
+// planState returns the hash of the recorded migrations.
+func planState(installed Migrations) string {
+	buf := &bytes.Buffer{}
+	for _, mig := range installed {
+		fmt.Fprintf(buf, "%d\x00%s\x00%s\x00%s\x00%s\x00%s\n", mig.Rank, mig.Version, mig.Description, mig.Type, mig.Checksum, mig.Status)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(sum[:])
+}
+
+// SetDryRun makes Migrate only log the migrations it would apply, like Plan, without changing the database. The
+// MigrationResult of a dry run counts the pending migrations.
+func (m *Migrator) SetDryRun(dryRun bool) {
+	m.dryRun = dryRun
+}
+
+// migrateDry logs the steps of the plan of a dry run and counts them in res.
+func (m *Migrator) migrateDry(res *MigrationResult) error {
+	if err := m.validateBeforeMigrate(); err != nil {
+		return err
+	}
+	p, err := m.Plan()
+	if err != nil {
+		return err
+	}
+	for _, step := range p.Steps {
+		fields := Fields{"version": step.Version, "description": step.Description, "type": step.Type, "state": step.State}
+		if step.Statements > 0 {
+			fields["statements"] = step.Statements
+		}
+		m.log(LevelInfo, "would install", fields)
+	}
+	res.Pending = len(p.Steps)
+	return nil
+}
@@ -0,0 +1,88 @@
+package migrate
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPlanApply(t *testing.T) {
+	db, _ := openFake(t.Name())
+	defer db.Close()
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);")
+	m.AddGoMigration("2", "two", func(con *sql.DB) error { return nil })
+	p, err := m.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := Plan{}
+	if err := json.Unmarshal(bs, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Steps) != 2 || decoded.Steps[0].Statements != 2 || decoded.Steps[0].State != StatePending || decoded.Steps[1].Type != TypeGo {
+		t.Fatalf("unexpected plan: %+v", decoded)
+	}
+
+	m.AddSQLMigration("3", "three", "CREATE TABLE c (id INT);")
+	if err := m.Apply(decoded); !errors.Is(err, ErrPlanOutdated) {
+		t.Fatalf("want outdated plan for a new migration, got: %v", err)
+	}
+	if len(s.migrations) != 0 {
+		t.Fatalf("no migration must be applied: %d", len(s.migrations))
+	}
+	if p, err = m.Plan(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Apply(p); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.migrations) != 3 {
+		t.Errorf("want applied migrations, got: %d", len(s.migrations))
+	}
+	if err := m.Apply(p); !errors.Is(err, ErrPlanOutdated) {
+		t.Errorf("want outdated plan for a changed database, got: %v", err)
+	}
+}
+
+func TestPlanSelection(t *testing.T) {
+	db, _ := openFake(t.Name())
+	defer db.Close()
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	noop := func(con *sql.DB) error { return nil }
+	m.AddGoMigration("1", "one", noop)
+	m.AddGoMigration("2", "two", noop)
+	m.AddGoMigration("3", "three", noop, OnlyIn("test"))
+	m.AddGoMigration("4", "four", noop)
+	m.SetSkipVersions("2")
+	m.SetEnvironment("prod")
+	p, err := m.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := []Version{"1", "4"}, planVersions(p); !reflect.DeepEqual(want, got) {
+		t.Fatalf("want: %v, got: %v", want, got)
+	}
+	if err := m.Apply(p); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 3, len(s.migrations); want != got {
+		t.Errorf("want %d records, got: %d", want, got)
+	}
+}
+
+func planVersions(p Plan) []Version {
+	vs := []Version{}
+	for _, step := range p.Steps {
+		vs = append(vs, step.Version)
+	}
+	return vs
+}
@@ -0,0 +1,30 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Querier is satisfied by *sql.DB, *sql.Conn and *sql.Tx.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+var (
+	_ Querier = &sql.DB{}
+	_ Querier = &sql.Conn{}
+	_ Querier = &sql.Tx{}
+)
+
+// ContextSupport is the context aware variant of Support. Its methods operate on a Querier, which allows
+// migrations executed in a transaction (see Batch.Transaction) to be recorded within that same transaction,
+// so that a migration and its record are committed atomically.
+type ContextSupport interface {
+	Support
+	ExistsMigrationsTableContext(ctx context.Context, q Querier) (bool, error)
+	CreateMigrationsTableContext(ctx context.Context, q Querier) error
+	RecordMigrationContext(ctx context.Context, q Querier, m Migration) error
+	ListMigrationsContext(ctx context.Context, q Querier) (Migrations, error)
+}
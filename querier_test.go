@@ -0,0 +1,56 @@
+package migrate
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type txSupport struct {
+	memSupport
+}
+
+func (s *txSupport) ExistsMigrationsTableContext(ctx context.Context, q Querier) (bool, error) {
+	return s.exists, nil
+}
+
+func (s *txSupport) CreateMigrationsTableContext(ctx context.Context, q Querier) error {
+	s.exists = true
+	return nil
+}
+
+func (s *txSupport) RecordMigrationContext(ctx context.Context, q Querier, m Migration) error {
+	if _, err := q.ExecContext(ctx, "RECORD "+string(m.Version)); err != nil {
+		return err
+	}
+	s.migrations = append(s.migrations, m)
+	return nil
+}
+
+func (s *txSupport) ListMigrationsContext(ctx context.Context, q Querier) (Migrations, error) {
+	return append(Migrations{}, s.migrations...), nil
+}
+
+func TestMigrateRecordInTransaction(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &txSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.SetBatch(Batch{Transaction: true})
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	m.AddSQLMigration("2", "two", "CREATE TABLE b (id INT);")
+	log.fail = "TABLE b"
+	if err := m.Migrate(); err == nil {
+		t.Fatalf("want error")
+	}
+	want := []string{
+		"BEGIN", "CREATE TABLE a (id INT);", "RECORD 1", "COMMIT",
+		"BEGIN", "CREATE TABLE b (id INT);", "ROLLBACK",
+	}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	if len(s.migrations) != 2 || s.migrations[0].Status != StatusSuccess || s.migrations[1].Status != StatusFailed {
+		t.Errorf("unexpected migrations:\n%s", s.migrations)
+	}
+}
@@ -0,0 +1,60 @@
+package migrate
+
+import (
+	"context"
+	"time"
+)
+
+// IsUpToDate reports whether every local migration has been applied successfully and no applied repeatable migration
+// is outdated. It only reads the metadata table, so replicas that do not run Migrate themselves can check whether the
+// designated instance has finished.
+func (m *Migrator) IsUpToDate() (bool, error) {
+	_, err := m.outstanding()
+	if err == ErrPendingMigrations {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// WaitUntilCurrent polls the metadata table every pollInterval until every local migration has been applied
+// successfully and no applied repeatable migration is outdated. If ctx is done before, the error wraps
+// ErrPendingMigrations and names the first outstanding migration. If a migration failed, the error wraps
+// ErrFailedMigrationDetected without further waiting.
+func (m *Migrator) WaitUntilCurrent(ctx context.Context, pollInterval time.Duration) error {
+	for {
+		mig, err := m.outstanding()
+		if err != ErrPendingMigrations {
+			return err
+		}
+		if mig.State == StateFailed {
+			return migrationError(ErrFailedMigrationDetected, mig)
+		}
+		m.log(LevelDebug, "waiting for pending migrations", migrationFields(mig))
+		select {
+		case <-ctx.Done():
+			return &MigrationError{Err: ErrPendingMigrations, Migration: mig, Detail: ctx.Err().Error()}
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// outstanding returns the first migration that failed, or else the first pending or outdated one, and
+// ErrPendingMigrations, or nil if there is none.
+func (m *Migrator) outstanding() (Migration, error) {
+	migrations, repeatable := m.registered()
+	installed := Migrations{}
+	exists, err := m.support.ExistsMigrationsTable(m.db)
+	if err != nil {
+		return Migration{}, err
+	}
+	if exists {
+		if installed, err = m.findMigrations(MigrationFilter{}); err != nil {
+			return Migration{}, err
+		}
+	}
+	info := newInfo(migrations, repeatable, installed, m.versionOrdering)
+	for _, mig := range append(info.filter(StateFailed), info.filter(StatePending, StateOutdated)...) {
+		return mig, ErrPendingMigrations
+	}
+	return Migration{}, nil
+}
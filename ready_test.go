@@ -0,0 +1,55 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitUntilCurrent(t *testing.T) {
+	s := &memSupport{}
+	noop := func(con *sql.DB) error { return nil }
+	replica := newTestMigrator(t, s)
+	replica.AddGoMigration("1", "one", noop)
+	if ok, err := replica.IsUpToDate(); ok || err != nil {
+		t.Fatalf("want pending migrations, got: %t, %v", ok, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := replica.WaitUntilCurrent(ctx, time.Millisecond); !errors.Is(err, ErrPendingMigrations) {
+		t.Fatalf("want pending migrations, got: %v", err)
+	}
+
+	m := newTestMigrator(t, s)
+	m.AddGoMigration("1", "one", noop)
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := replica.WaitUntilCurrent(context.Background(), time.Millisecond); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	if ok, err := replica.IsUpToDate(); !ok || err != nil {
+		t.Fatalf("want up to date, got: %t, %v", ok, err)
+	}
+}
+
+func TestIsUpToDateOutdatedRepeatable(t *testing.T) {
+	s := &memSupport{}
+	noop := func(con *sql.DB) error { return nil }
+	m := newTestMigrator(t, s)
+	m.AddRepeatableGoMigration("views", noop, Fingerprint("1"))
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	replica := newTestMigrator(t, s)
+	replica.AddRepeatableGoMigration("views", noop, Fingerprint("2"))
+	mig, err := replica.outstanding()
+	if err != ErrPendingMigrations || mig.State != StateOutdated {
+		t.Errorf("want the outdated view outstanding, got: %s %s, %v", mig.State, mig, err)
+	}
+	if ok, err := replica.IsUpToDate(); ok || err != nil {
+		t.Errorf("want outdated, got: %t, %v", ok, err)
+	}
+}
@@ -0,0 +1,111 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ForeignKeySupport is implemented by Support implementations whose enforcement of foreign keys has to be disabled
+// outside of the transaction of a migration that rebuilds tables, e.g. SQLite, see RebuildsTables.
+type ForeignKeySupport interface {
+	// DisableForeignKeys disables the enforcement of foreign keys on con and returns whether it has been enabled.
+	DisableForeignKeys(ctx context.Context, con *sql.Conn) (bool, error)
+	// EnableForeignKeys enables the enforcement of foreign keys on con.
+	EnableForeignKeys(ctx context.Context, con *sql.Conn) error
+	// CheckForeignKeys lists the rows that violate a foreign key.
+	CheckForeignKeys(ctx context.Context, q Querier) ([]ForeignKeyViolation, error)
+}
+
+// ForeignKeyViolation is a row that references a missing row of its parent table.
+type ForeignKeyViolation struct {
+	// Table is the table of the row, qualified by its schema if it is not the main one.
+	Table  string
+	RowID  int64
+	Parent string
+}
+
+func (v ForeignKeyViolation) String() string {
+	return fmt.Sprintf("%s row %d references a missing row of %s", v.Table, v.RowID, v.Parent)
+}
+
+// RebuildsTables marks a migration that rebuilds tables by creating a new table, copying the rows and dropping the
+// old one, e.g. to change a column in SQLite. If the Support is a ForeignKeySupport, the enforcement of foreign keys is
+// disabled while the migration runs, so that dropping the old table neither fails nor cascades, and the foreign keys
+// are checked before it is committed. The migration runs on a single connection, so it has to be a SQL migration or a
+// Go migration with a MigrationContext.
+func RebuildsTables() MigrationOption {
+	return func(o *MigrationOptions) {
+		o.RebuildsTables = true
+	}
+}
+
+// executeRebuilding executes mig on a connection whose enforcement of foreign keys is disabled by fs.
+func (m *Migrator) executeRebuilding(ctx context.Context, fs ForeignKeySupport, mig Migration, record func(ctx context.Context, q Querier) error) (recorded bool, err error) {
+	con, err := m.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer con.Close()
+	if err := m.initConn(ctx, con); err != nil {
+		return false, err
+	}
+	enabled, err := fs.DisableForeignKeys(ctx, con)
+	if err != nil {
+		return false, fmt.Errorf("disable foreign keys: %+v", err)
+	}
+	if enabled {
+		m.log(LevelInfo, "disabled foreign keys", migrationFields(mig))
+		defer func() {
+			if fErr := fs.EnableForeignKeys(ctx, con); fErr != nil && err == nil {
+				err = fmt.Errorf("enable foreign keys: %+v", fErr)
+			}
+		}()
+	}
+	if m.batch.Transaction && !mig.Options.NoTransaction {
+		err = m.retry(ctx, func() error {
+			var err error
+			recorded, err = m.execTx(ctx, con, mig, record)
+			return err
+		})
+		return recorded, err
+	}
+	if mig.ExecuteContext != nil {
+		return false, fmt.Errorf("rebuilding tables outside of a transaction requires a SQL migration: %s", mig)
+	}
+	restore, err := m.limitSession(ctx, con, mig)
+	if err != nil {
+		return false, err
+	}
+	err = m.execBatched(ctx, con, mig)
+	if rErr := restore(); rErr != nil && err == nil {
+		err = rErr
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := m.checkForeignKeys(ctx, con, mig); err != nil {
+		return false, err
+	}
+	return false, m.verifyOrUndo(ctx, mig)
+}
+
+// checkForeignKeys reports an error if mig rebuilds tables and left rows behind that violate a foreign key.
+func (m *Migrator) checkForeignKeys(ctx context.Context, q Querier, mig Migration) error {
+	fs, ok := m.support.(ForeignKeySupport)
+	if !ok || !mig.Options.RebuildsTables {
+		return nil
+	}
+	violations, err := fs.CheckForeignKeys(ctx, q)
+	if err != nil {
+		return fmt.Errorf("check foreign keys: %+v", err)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return &MigrationError{
+		Err:       ErrForeignKeyViolation,
+		Migration: mig,
+		Detail:    fmt.Sprintf("%s (%d violations)", violations[0], len(violations)),
+	}
+}
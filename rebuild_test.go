@@ -0,0 +1,102 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type foreignKeySupport struct {
+	memSupport
+}
+
+func (s *foreignKeySupport) DisableForeignKeys(ctx context.Context, con *sql.Conn) (bool, error) {
+	return SQLiteSupport{}.DisableForeignKeys(ctx, con)
+}
+
+func (s *foreignKeySupport) EnableForeignKeys(ctx context.Context, con *sql.Conn) error {
+	return SQLiteSupport{}.EnableForeignKeys(ctx, con)
+}
+
+func (s *foreignKeySupport) CheckForeignKeys(ctx context.Context, q Querier) ([]ForeignKeyViolation, error) {
+	return SQLiteSupport{}.CheckForeignKeys(ctx, q)
+}
+
+func TestMigrateRebuildsTables(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	log.results = map[string]string{`PRAGMA foreign_keys;`: "1"}
+	s := &foreignKeySupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.SetBatch(Batch{Transaction: true})
+	m.AddSQLMigration("1", "rebuild users", "CREATE TABLE users_new (id INT);\nDROP TABLE users;", RebuildsTables())
+	m.AddSQLMigration("2", "index", "CREATE INDEX users_idx ON users (id);")
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`PRAGMA foreign_keys;`,
+		`PRAGMA foreign_keys = OFF;`,
+		`BEGIN`,
+		`CREATE TABLE users_new (id INT);`,
+		`DROP TABLE users;`,
+		`PRAGMA foreign_key_check;`,
+		`COMMIT`,
+		`PRAGMA foreign_keys = ON;`,
+		`BEGIN`,
+		`CREATE INDEX users_idx ON users (id);`,
+		`COMMIT`,
+	}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
+
+func TestMigrateRebuildsTablesGo(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &foreignKeySupport{}
+	m := NewMigrator(t.Logf, db, s)
+	ran := false
+	m.AddGoMigration("1", "rebuild users", func(con *sql.DB) error {
+		ran = true
+		return nil
+	}, RebuildsTables())
+	if err := m.Migrate(); err == nil {
+		t.Fatalf("want error for a Go migration without a MigrationContext")
+	}
+	if ran || len(log.Statements()) != 0 {
+		t.Errorf("want the migration not to run, got: %q", log.Statements())
+	}
+}
+
+func TestMigrateRebuildsTablesViolation(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	log.results = map[string]string{`PRAGMA foreign_keys;`: "1"}
+	log.rows = map[string][][]string{`PRAGMA foreign_key_check;`: {{"orders", "3", "users", "0"}}}
+	s := &foreignKeySupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.SetBatch(Batch{Transaction: true})
+	m.AddSQLMigration("1", "rebuild users", "DROP TABLE users;", RebuildsTables())
+	if err := m.Migrate(); !errors.Is(err, ErrForeignKeyViolation) {
+		t.Fatalf("want foreign key violation, got: %v", err)
+	}
+	want := []string{
+		`PRAGMA foreign_keys;`,
+		`PRAGMA foreign_keys = OFF;`,
+		`BEGIN`,
+		`DROP TABLE users;`,
+		`PRAGMA foreign_key_check;`,
+		`ROLLBACK`,
+		`PRAGMA foreign_keys = ON;`,
+	}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	if rec := s.migrations[0]; rec.Status != StatusFailed {
+		t.Errorf("want failed migration, got: %+v", rec)
+	}
+}
@@ -0,0 +1,112 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ReconcileOptions control Reconcile.
+type ReconcileOptions struct {
+	// Now is the time follow-ups are checked at. It defaults to the current time.
+	Now time.Time
+	// IgnoreChecksums does not report checksum mismatches of applied migrations.
+	IgnoreChecksums bool
+	// OutOfOrder does not report pending migrations below the last applied version, see SetOutOfOrder.
+	OutOfOrder bool
+	// Comparator orders the versions. It defaults to OrderNumeric, see SetVersionComparator.
+	Comparator VersionComparator
+}
+
+// Reconciliation is the result of comparing local migrations with the applied ones.
+type Reconciliation struct {
+	Info
+	// Problems are the inconsistencies reported by Validate in the order of the migrations.
+	Problems []error
+	// Overdue are the applied migrations whose follow-up is overdue.
+	Overdue Migrations
+}
+
+// Reconcile compares the local migrations, versioned and repeatable ones, with the applied ones as recorded in the
+// metadata table. It is what Migrator.Info and Migrator.Validate use and does not require a database.
+func Reconcile(local Migrations, applied Migrations, opts ReconcileOptions) Reconciliation {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	migrations := Migrations{}
+	repeatable := Migrations{}
+	versions := map[Version]Migration{}
+	for _, mig := range local {
+		if mig.IsRepeatable() {
+			repeatable = append(repeatable, mig)
+		} else {
+			migrations = append(migrations, mig)
+			versions[mig.Version] = mig
+		}
+	}
+	r := Reconciliation{
+		Info: newInfo(migrations, repeatable, applied, versionOrder{opts.Comparator}),
+	}
+	r.Overdue = r.Info.Overdue(opts.Now)
+	for _, mig := range r.Migrations {
+		if mig.IsRepeatable() {
+			continue
+		}
+		switch mig.State {
+		case StateFailed:
+			if !versions[mig.Version].Options.IgnoreFailure {
+				r.Problems = append(r.Problems, migrationError(ErrFailedMigrationDetected, mig))
+			}
+		case StateIgnored:
+			if opts.OutOfOrder {
+				continue
+			}
+			r.Problems = append(r.Problems, migrationError(ErrOutOfOrder, mig))
+		case StateMissing:
+			r.Problems = append(r.Problems, fmt.Errorf("applied migration not found locally: %s", mig))
+		case StateApplied:
+			if opts.IgnoreChecksums {
+				continue
+			}
+			if err := checksumMismatch(mig, versions[mig.Version]); err != nil {
+				r.Problems = append(r.Problems, err)
+			}
+		}
+	}
+	return r
+}
+
+// local returns all registered migrations.
+func (m *Migrator) local() Migrations {
+	migrations, repeatable := m.registered()
+	return append(migrations, repeatable...)
+}
+
+// SetValidateOnMigrate makes Migrate validate the applied migrations like Validate before it applies any migration,
+// so that e.g. a migration that has been applied but deleted locally stops the run. Failed migrations are left to
+// the Recovery.
+func (m *Migrator) SetValidateOnMigrate(validate bool) {
+	m.validateOnMigrate = validate
+}
+
+// validateBeforeMigrate validates the applied migrations if SetValidateOnMigrate is set.
+func (m *Migrator) validateBeforeMigrate() error {
+	if !m.validateOnMigrate {
+		return nil
+	}
+	exists, err := m.support.ExistsMigrationsTable(m.db)
+	if err != nil || !exists {
+		return err
+	}
+	installed, err := m.listMigrations()
+	if err != nil {
+		return err
+	}
+	r := Reconcile(m.local(), installed, ReconcileOptions{OutOfOrder: m.outOfOrder, Now: m.now(), Comparator: m.versionOrdering.VersionComparator})
+	for _, p := range r.Problems {
+		if !errors.Is(p, ErrFailedMigrationDetected) {
+			return p
+		}
+	}
+	return nil
+}
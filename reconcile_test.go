@@ -0,0 +1,30 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReconcile(t *testing.T) {
+	local := Migrations{
+		{Version: "1", Description: "one", Checksum: "a"},
+		{Version: "2", Description: "two", Checksum: "b"},
+		{Version: "3", Description: "three", Checksum: "c"},
+		{Version: VersionRepeatable, Description: "view", Checksum: "v"},
+	}
+	applied := Migrations{
+		{Rank: 1, Version: "1", Description: "one", Checksum: "x", Status: StatusSuccess},
+		{Rank: 2, Version: "3", Description: "three", Checksum: "c", Status: StatusSuccess},
+	}
+	r := Reconcile(local, applied, ReconcileOptions{})
+	if len(r.Problems) != 2 || !errors.Is(r.Problems[0], ErrChecksumMismatch) || !errors.Is(r.Problems[1], ErrOutOfOrder) {
+		t.Errorf("unexpected problems: %v", r.Problems)
+	}
+	if pending := r.Pending(); len(pending) != 1 || pending[0].Description != "view" {
+		t.Errorf("unexpected pending migrations:\n%s", pending)
+	}
+	r = Reconcile(local, applied, ReconcileOptions{IgnoreChecksums: true})
+	if len(r.Problems) != 1 {
+		t.Errorf("unexpected problems: %v", r.Problems)
+	}
+}
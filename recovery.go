@@ -0,0 +1,96 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Recovery is the policy applied by Migrate to failed migrations recorded in the metadata table.
+type Recovery int
+
+const (
+	// AbortOnFailed makes Migrate fail with ErrFailedMigrationDetected.
+	AbortOnFailed Recovery = iota
+	// RetryFailed installs the failed migration again and updates its record.
+	RetryFailed
+	// SkipFailed marks the failed migration as skipped and continues with the next one.
+	SkipFailed
+	// ResumeFailed installs a failed SQL migration again starting with the statement that failed, see
+	// Migration.FailedStatement. It is meant for migrations that have been executed outside of a transaction, whose
+	// statements before the failed one are in effect. The statements before the failed one must not be changed
+	// to fix the issue. Migrations that have been rolled back as a whole are installed again like by RetryFailed.
+	ResumeFailed
+)
+
+// MigrationUpdater is implemented by Support implementations that are able to update a record identified by its rank.
+// It is required by the recovery policies RetryFailed and SkipFailed.
+type MigrationUpdater interface {
+	UpdateMigration(con *sql.DB, m Migration) error
+}
+
+// SetRecovery sets the policy for failed migrations found by subsequent calls to Migrate.
+func (m *Migrator) SetRecovery(recovery Recovery) {
+	m.recovery = recovery
+}
+
+// recover applies the recovery policy to a failed record. It returns the record as skipped or to be retried.
+func (m *Migrator) recover(failed Migration) (Migration, error) {
+	if m.recovery == AbortOnFailed {
+		return failed, &MigrationError{Err: ErrFailedMigrationDetected, Migration: failed, Detail: failed.failure()}
+	}
+	if _, ok := m.support.(MigrationUpdater); !ok {
+		return failed, fmt.Errorf("recovery requires a support that updates migrations: %T", m.support)
+	}
+	fields := migrationFields(failed)
+	switch m.recovery {
+	case RetryFailed:
+		m.log(LevelWarn, "retrying failed migration", fields)
+		return failed, nil
+	case ResumeFailed:
+		fields["statement"] = failed.FailedStatement
+		m.log(LevelWarn, "resuming failed migration", fields)
+		return failed, nil
+	}
+	failed.Status = StatusSkipped
+	failed.Failure, failed.FailedStatement = "", 0
+	m.log(LevelWarn, "skipping failed migration", fields)
+	if err := m.record(failed, true); err != nil {
+		return failed, fmt.Errorf("record migration: %s: %+v", failed, err)
+	}
+	return failed, nil
+}
+
+// resume returns mig to continue with the statement of failed that failed if the recovery policy is ResumeFailed and
+// the statements before it have not been rolled back.
+func (m *Migrator) resume(mig Migration, failed Migration) Migration {
+	if m.recovery != ResumeFailed || failed.FailedStatement < 2 || mig.Type != TypeSQL || mig.ExecuteContext != nil {
+		return mig
+	}
+	if m.batch.Transaction && !mig.Options.NoTransaction {
+		return mig
+	}
+	if _, ok := m.support.(Savepointer); ok && m.savepoints {
+		return mig
+	}
+	mig.resumeAt = failed.FailedStatement
+	return mig
+}
+
+// record inserts mig into the metadata table or updates the record with the rank of mig.
+func (m *Migrator) record(mig Migration, update bool) error {
+	m.recording.Lock()
+	defer m.recording.Unlock()
+	if !update {
+		return m.retry(context.Background(), func() error {
+			return m.support.RecordMigration(m.db, mig)
+		})
+	}
+	u, ok := m.support.(MigrationUpdater)
+	if !ok {
+		return fmt.Errorf("support does not update migrations: %T", m.support)
+	}
+	return m.retry(context.Background(), func() error {
+		return u.UpdateMigration(m.db, mig)
+	})
+}
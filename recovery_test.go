@@ -0,0 +1,76 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMigrateRecovery(t *testing.T) {
+	noop := func(con *sql.DB) error { return nil }
+	fail := func(con *sql.DB) error { return fmt.Errorf("fail") }
+	tests := []struct {
+		name     string
+		recovery Recovery
+		want     []Status
+		err      error
+	}{
+		{"abort", AbortOnFailed, []Status{StatusSuccess, StatusFailed}, ErrFailedMigrationDetected},
+		{"retry", RetryFailed, []Status{StatusSuccess, StatusSuccess, StatusSuccess}, nil},
+		{"skip", SkipFailed, []Status{StatusSuccess, StatusSkipped, StatusSuccess}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &memSupport{}
+			m := newTestMigrator(t, s)
+			m.AddGoMigration("1", "one", noop)
+			m.AddGoMigration("2", "two", fail)
+			if err := m.Migrate(); err == nil {
+				t.Fatalf("want error")
+			}
+			m = newTestMigrator(t, s)
+			m.SetRecovery(test.recovery)
+			m.AddGoMigration("1", "one", noop)
+			m.AddGoMigration("2", "two", noop)
+			m.AddGoMigration("3", "three", noop)
+			if err := m.Migrate(); !errors.Is(err, test.err) {
+				t.Fatalf("want error %v, got: %v", test.err, err)
+			}
+			if len(s.migrations) != len(test.want) {
+				t.Fatalf("want %d migrations, got:\n%s", len(test.want), s.migrations)
+			}
+			for i, mig := range s.migrations {
+				if mig.Status != test.want[i] || mig.Rank != i+1 {
+					t.Errorf("%s: want status %s and rank %d, got %s and %d", mig, test.want[i], i+1, mig.Status, mig.Rank)
+				}
+			}
+		})
+	}
+}
+
+func TestRecoveryResumeFailed(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.SetRecovery(ResumeFailed)
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\nCREATE TABLE c (id INT);")
+	log.fail = "CREATE TABLE b"
+	if err := m.Migrate(); err == nil {
+		t.Fatal("want error")
+	}
+	log.fail = ""
+	before := len(log.Statements())
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(log.Statements()[before:], "\n")
+	if strings.Contains(got, "CREATE TABLE a") || !strings.Contains(got, "CREATE TABLE b") || !strings.Contains(got, "CREATE TABLE c") {
+		t.Errorf("want resume with the failed statement, got:\n%s", got)
+	}
+	if rec := s.migrations[0]; len(s.migrations) != 1 || rec.Status != StatusSuccess || rec.FailedStatement != 0 {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+}
@@ -0,0 +1,79 @@
+package migrate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	supportsMu sync.RWMutex
+	supports   = map[string]Support{}
+)
+
+func init() {
+	Register("sqlite", SQLiteSupport{})
+	Register("sqlite3", SQLiteSupport{})
+	Register("cockroach", CockroachSupport{})
+	Register("cockroachdb", CockroachSupport{})
+	Register("oracle", OracleSupport{})
+	Register("godror", OracleSupport{})
+	Register("clickhouse", ClickHouseSupport{})
+}
+
+// Register makes a Support available by name, typically the name of the database/sql driver or the scheme of DSNs.
+// If Register is called twice with the same name or if support is nil, it panics.
+func Register(name string, support Support) {
+	supportsMu.Lock()
+	defer supportsMu.Unlock()
+	if support == nil {
+		panic("migrate: support is nil")
+	}
+	if _, dup := supports[name]; dup {
+		panic("migrate: Register called twice for support " + name)
+	}
+	supports[name] = support
+}
+
+// SupportFor returns the Support registered under name.
+func SupportFor(name string) (Support, bool) {
+	supportsMu.RLock()
+	defer supportsMu.RUnlock()
+	s, ok := supports[name]
+	return s, ok
+}
+
+// SupportForDSN returns the Support registered under the scheme of dsn, e.g. "clickhouse" for clickhouse://host:9000/db.
+func SupportForDSN(dsn string) (Support, error) {
+	scheme := dsnScheme(dsn)
+	if scheme == "" {
+		return nil, fmt.Errorf("no scheme in dsn")
+	}
+	s, ok := SupportFor(scheme)
+	if !ok {
+		return nil, fmt.Errorf("unknown support: %s", scheme)
+	}
+	return s, nil
+}
+
+// dsnScheme returns the lower cased scheme of dsn, e.g. "sqlite" for sqlite:file.db or sqlite://file.db.
+func dsnScheme(dsn string) string {
+	i := strings.Index(dsn, ":")
+	if i <= 0 {
+		return ""
+	}
+	return strings.ToLower(dsn[:i])
+}
+
+// Supports returns a sorted list of the names of the registered supports.
+func Supports() []string {
+	supportsMu.RLock()
+	defer supportsMu.RUnlock()
+	names := []string{}
+	for name := range supports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
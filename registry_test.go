@@ -0,0 +1,43 @@
+package migrate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	Register("test-mem", &memSupport{})
+	defer func() {
+		supportsMu.Lock()
+		delete(supports, "test-mem")
+		supportsMu.Unlock()
+	}()
+	if _, ok := SupportFor("test-mem"); !ok {
+		t.Errorf("want registered support")
+	}
+	tests := []struct {
+		dsn  string
+		want Support
+	}{
+		{"clickhouse://localhost:9000/analytics", ClickHouseSupport{}},
+		{"sqlite:file.db", SQLiteSupport{}},
+		{"Cockroach://root@localhost:26257/app", CockroachSupport{}},
+	}
+	for _, test := range tests {
+		got, err := SupportForDSN(test.dsn)
+		if err != nil || reflect.TypeOf(got) != reflect.TypeOf(test.want) {
+			t.Errorf("%s: want %T, got %T %v", test.dsn, test.want, got, err)
+		}
+	}
+	for _, dsn := range []string{"file.db", "mysql://localhost/app"} {
+		if _, err := SupportForDSN(dsn); err == nil {
+			t.Errorf("%s: want error", dsn)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("want panic for duplicate registration")
+		}
+	}()
+	Register("sqlite", SQLiteSupport{})
+}
@@ -0,0 +1,316 @@
+// Package remote lets a central deployment controller drive the migrations of fleet databases over JSON-RPC 2.0 on
+// HTTP, without shelling into each host. A host serves its Migrator:
+//
+//	http.Handle("/rpc", remote.NewHandler(m, remote.WithBearerToken(os.Getenv("MIGRATE_TOKEN"))))
+//
+// and the controller calls it:
+//
+//	c := remote.NewClient("https://db-host-1:8443/rpc", remote.BearerToken(token))
+//	info, err := c.Migrate(ctx)
+//
+// The methods are Migrator.Migrate, Migrator.Info, Migrator.Validate and Migrator.Baseline. Migrate and Info respond
+// with the resulting Info, Validate with null, Baseline takes the parameters {"version": "...", "description": "..."}.
+// gRPC is not offered as the module has no dependencies, JSON-RPC only needs the standard library.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/cognicraft/migrate"
+)
+
+// Methods of the JSON-RPC service.
+const (
+	MethodMigrate  = "Migrator.Migrate"
+	MethodInfo     = "Migrator.Info"
+	MethodValidate = "Migrator.Validate"
+	MethodBaseline = "Migrator.Baseline"
+)
+
+// Error codes of JSON-RPC 2.0 and of the service.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	// CodeMigrationFailed is the code of errors reported by the Migrator.
+	CodeMigrationFailed = -32000
+	// CodeUnauthorized is the code of requests rejected by the Authenticator.
+	CodeUnauthorized = -32001
+)
+
+// ErrUnauthorized is returned by an Authenticator to reject a request.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Authenticator authorizes the call of method by r. It returns an error to reject it.
+type Authenticator func(r *http.Request, method string) error
+
+// Option configures the handler returned by NewHandler.
+type Option func(*handler)
+
+// WithAuthenticator sets the function that authorizes the calls of the methods that change the database, Migrate
+// and Baseline, and, if all is set, also of Info and Validate.
+func WithAuthenticator(a Authenticator, all bool) Option {
+	return func(h *handler) {
+		h.authenticate = a
+		h.authenticateAll = all
+	}
+}
+
+// WithBearerToken authorizes the calls of Migrate and Baseline that carry the header "Authorization: Bearer <token>".
+func WithBearerToken(token string) Option {
+	return WithAuthenticator(func(r *http.Request, method string) error {
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			return ErrUnauthorized
+		}
+		return nil
+	}, false)
+}
+
+type handler struct {
+	m               *migrate.Migrator
+	authenticate    Authenticator
+	authenticateAll bool
+}
+
+// NewHandler returns a handler that serves the JSON-RPC service for m. Migrate and Baseline are rejected unless an
+// Authenticator is configured with WithAuthenticator or WithBearerToken.
+func NewHandler(m *migrate.Migrator, opts ...Option) http.Handler {
+	h := &handler{m: m}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// Error is an error response of the service.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// BaselineParams are the parameters of Baseline.
+type BaselineParams struct {
+	Version     migrate.Version `json:"version"`
+	Description string          `json:"description"`
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	req := request{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeResponse(w, response{Error: &Error{Code: CodeParseError, Message: err.Error()}})
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		writeResponse(w, response{Error: &Error{Code: CodeInvalidRequest, Message: "invalid request"}, ID: req.ID})
+		return
+	}
+	result, rErr := h.call(r, req)
+	if len(req.ID) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeResponse(w, response{Result: result, Error: rErr, ID: req.ID})
+}
+
+// call calls the method of req.
+func (h *handler) call(r *http.Request, req request) (interface{}, *Error) {
+	changes := false
+	switch req.Method {
+	case MethodMigrate, MethodBaseline:
+		changes = true
+	case MethodInfo, MethodValidate:
+	default:
+		return nil, &Error{Code: CodeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+	if changes || h.authenticateAll {
+		if h.authenticate == nil {
+			return nil, &Error{Code: CodeUnauthorized, Message: ErrUnauthorized.Error()}
+		}
+		if err := h.authenticate(r, req.Method); err != nil {
+			return nil, &Error{Code: CodeUnauthorized, Message: err.Error()}
+		}
+	}
+	switch req.Method {
+	case MethodMigrate:
+		if err := h.m.Migrate(); err != nil {
+			return nil, &Error{Code: CodeMigrationFailed, Message: err.Error()}
+		}
+		return h.m.Info(), nil
+	case MethodInfo:
+		return h.m.Info(), nil
+	case MethodValidate:
+		if err := h.m.Validate(); err != nil {
+			return nil, &Error{Code: CodeMigrationFailed, Message: err.Error()}
+		}
+		return nil, nil
+	default:
+		p := BaselineParams{}
+		if err := json.Unmarshal(req.Params, &p); err != nil || p.Version == migrate.VersionNone {
+			return nil, &Error{Code: CodeInvalidParams, Message: "invalid params: version required"}
+		}
+		if err := h.m.Baseline(p.Version, p.Description); err != nil {
+			return nil, &Error{Code: CodeMigrationFailed, Message: err.Error()}
+		}
+		return h.m.Info(), nil
+	}
+}
+
+func writeResponse(w http.ResponseWriter, res response) {
+	res.JSONRPC = "2.0"
+	if res.ID == nil {
+		res.ID = json.RawMessage("null")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// BearerToken makes the Client send the header "Authorization: Bearer <token>".
+func BearerToken(token string) ClientOption {
+	return func(c *Client) {
+		c.header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// WithHTTPClient sets the HTTP client of a Client, e.g. one with client certificates. It defaults to
+// http.DefaultClient.
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+// Client calls the service served by NewHandler.
+type Client struct {
+	url    string
+	http   *http.Client
+	header http.Header
+	id     int64
+}
+
+// NewClient creates a Client for the service at url.
+func NewClient(url string, opts ...ClientOption) *Client {
+	c := &Client{
+		url:    url,
+		http:   http.DefaultClient,
+		header: http.Header{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Migrate runs Migrate on the remote database and returns the resulting Info.
+func (c *Client) Migrate(ctx context.Context) (migrate.Info, error) {
+	info := migrate.Info{}
+	err := c.Call(ctx, MethodMigrate, nil, &info)
+	return info, err
+}
+
+// Info returns the Info of the remote database.
+func (c *Client) Info(ctx context.Context) (migrate.Info, error) {
+	info := migrate.Info{}
+	err := c.Call(ctx, MethodInfo, nil, &info)
+	return info, err
+}
+
+// Validate runs Validate on the remote database.
+func (c *Client) Validate(ctx context.Context) error {
+	return c.Call(ctx, MethodValidate, nil, nil)
+}
+
+// Baseline baselines the remote database at version and returns the resulting Info.
+func (c *Client) Baseline(ctx context.Context, version migrate.Version, description string) (migrate.Info, error) {
+	info := migrate.Info{}
+	err := c.Call(ctx, MethodBaseline, BaselineParams{Version: version, Description: description}, &info)
+	return info, err
+}
+
+// Call calls method with params and decodes its result into result unless it is nil. Errors of the service are
+// returned as *Error.
+func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	req := request{
+		JSONRPC: "2.0",
+		Method:  method,
+		ID:      json.RawMessage(fmt.Sprintf("%d", atomic.AddInt64(&c.id, 1))),
+	}
+	if params != nil {
+		bs, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		req.Params = bs
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	hr, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	hr = hr.WithContext(ctx)
+	for k, vs := range c.header {
+		hr.Header[k] = vs
+	}
+	hr.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(hr)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("rpc: %s: %s", method, resp.Status)
+	}
+	res := struct {
+		Result json.RawMessage `json:"result"`
+		Error  *Error          `json:"error"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("rpc: %s: decode response: %+v", method, err)
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	if result == nil || len(res.Result) == 0 || string(res.Result) == "null" {
+		return nil
+	}
+	return json.Unmarshal(res.Result, result)
+}
@@ -0,0 +1,113 @@
+package remote
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cognicraft/migrate"
+)
+
+// memSupport keeps the metadata table in memory.
+type memSupport struct {
+	exists     bool
+	migrations migrate.Migrations
+}
+
+func (s *memSupport) ExistsMigrationsTable(con *sql.DB) (bool, error) {
+	return s.exists, nil
+}
+
+func (s *memSupport) CreateMigrationsTable(con *sql.DB) error {
+	s.exists = true
+	return nil
+}
+
+func (s *memSupport) RecordMigration(con *sql.DB, m migrate.Migration) error {
+	m.Execute = nil
+	s.migrations = append(s.migrations, m)
+	return nil
+}
+
+func (s *memSupport) ListMigrations(con *sql.DB) (migrate.Migrations, error) {
+	return append(migrate.Migrations{}, s.migrations...), nil
+}
+
+func (s *memSupport) Clean(con *sql.DB) error {
+	*s = memSupport{}
+	return nil
+}
+
+func TestService(t *testing.T) {
+	noop := func(con *sql.DB) error { return nil }
+	m := migrate.NewMigrator(t.Logf, nil, &memSupport{})
+	m.AddGoMigration("1", "one", noop)
+	srv := httptest.NewServer(NewHandler(m, WithBearerToken("secret")))
+	defer srv.Close()
+	ctx := context.Background()
+
+	info, err := NewClient(srv.URL).Info(ctx)
+	if err != nil {
+		t.Fatalf("info: %v", err)
+	}
+	if len(info.Pending()) != 1 {
+		t.Errorf("want a pending migration, got: %s", info.Migrations)
+	}
+	if _, err := NewClient(srv.URL, BearerToken("wrong")).Migrate(ctx); err == nil || err.(*Error).Code != CodeUnauthorized {
+		t.Errorf("want unauthorized, got: %v", err)
+	}
+	c := NewClient(srv.URL, BearerToken("secret"))
+	info, err = c.Migrate(ctx)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if len(info.Migrations) != 1 || info.Migrations[0].State != migrate.StateApplied {
+		t.Errorf("unexpected info: %s", info.Migrations)
+	}
+	if err := c.Validate(ctx); err != nil {
+		t.Errorf("validate: %v", err)
+	}
+	if _, err := c.Baseline(ctx, "5", "legacy"); err == nil || err.(*Error).Code != CodeMigrationFailed {
+		t.Errorf("want baseline of a migrated database to fail, got: %v", err)
+	}
+	if err := c.Call(ctx, "Migrator.Clean", nil, nil); err == nil || err.(*Error).Code != CodeMethodNotFound {
+		t.Errorf("want method not found, got: %v", err)
+	}
+
+	fresh := migrate.NewMigrator(t.Logf, nil, &memSupport{})
+	fresh.AddGoMigration("1", "one", noop)
+	fresh.AddGoMigration("6", "six", noop)
+	authorized := func(r *http.Request, method string) error { return nil }
+	srv2 := httptest.NewServer(NewHandler(fresh, WithAuthenticator(authorized, true)))
+	defer srv2.Close()
+	info, err = NewClient(srv2.URL).Baseline(ctx, "5", "legacy")
+	if err != nil {
+		t.Fatalf("baseline: %v", err)
+	}
+	if v, ok := info.Current(); !ok || v.Version != "6" {
+		t.Errorf("want version 6 after baseline, got: %s", info.Migrations)
+	}
+}
+
+func TestHandlerInvalidRequest(t *testing.T) {
+	h := NewHandler(migrate.NewMigrator(t.Logf, nil, &memSupport{}))
+	for body, code := range map[string]string{
+		`{`:                          "-32700",
+		`{"method":"Migrator.Info"}`: "-32600",
+		`{"jsonrpc":"2.0","method":"Migrator.Baseline","id":1}`: "-32001",
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		if !strings.Contains(w.Body.String(), `"code":`+code) {
+			t.Errorf("%s: want code %s, got: %s", body, code, w.Body)
+		}
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","method":"Migrator.Info"}`)))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("want no content for a notification, got: %d", w.Code)
+	}
+}
@@ -0,0 +1,159 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// MigrationDeleter is implemented by Support implementations that are able to delete a record identified by its rank.
+// It is required by SetPruneRepeatable.
+type MigrationDeleter interface {
+	DeleteMigration(con *sql.DB, m Migration) error
+}
+
+// repeatableKey identifies a repeatable migration. A repeatable Go migration and a repeatable SQL migration with the
+// same description are different migrations.
+type repeatableKey struct {
+	Description string
+	Type        Type
+}
+
+func keyOf(mig Migration) repeatableKey {
+	return repeatableKey{Description: mig.Description, Type: mig.Type}
+}
+
+// RequiresVersion makes a repeatable migration wait for the versioned migrations up to and including version, e.g. a
+// view selecting from a table that is created by version 12 in environments that have not been migrated that far. The
+// migration is skipped without being recorded until the schema has reached version, so a later run applies it. It is
+// ignored by versioned migrations.
+func RequiresVersion(version Version) MigrationOption {
+	return func(o *MigrationOptions) {
+		o.RequiresVersion = version
+	}
+}
+
+// SetPruneRepeatable makes Migrate delete the records of repeatable migrations that have been superseded by a later
+// application of the same migration, so that the metadata table only keeps the latest record of each. It requires a
+// Support that is a MigrationDeleter.
+func (m *Migrator) SetPruneRepeatable(prune bool) {
+	m.pruneRepeatable = prune
+}
+
+// renames returns the records of the renamed repeatable migrations by the key of the local migration they have been
+// renamed to. A record is renamed if its description is not registered anymore and the checksum of a registered
+// repeatable migration of the same type without a record matches. Renames require a Support that is a
+// MigrationUpdater, otherwise a renamed migration is applied again.
+func (m *Migrator) renames(installed Migrations, repeatable Migrations) map[repeatableKey]Migration {
+	if _, ok := m.support.(MigrationUpdater); !ok {
+		return nil
+	}
+	local := map[repeatableKey]bool{}
+	for _, mig := range repeatable {
+		local[keyOf(mig)] = true
+	}
+	latest := map[repeatableKey]Migration{}
+	order := []repeatableKey{}
+	for _, mig := range installed {
+		if !mig.IsRepeatable() {
+			continue
+		}
+		k := keyOf(mig)
+		if _, ok := latest[k]; !ok {
+			order = append(order, k)
+		}
+		latest[k] = mig
+	}
+	orphans := Migrations{}
+	for _, k := range order {
+		if mig := latest[k]; !local[k] && mig.Status == StatusSuccess && mig.Checksum != "" {
+			orphans = append(orphans, mig)
+		}
+	}
+	renames := map[repeatableKey]Migration{}
+	for _, mig := range repeatable {
+		k := keyOf(mig)
+		if _, ok := latest[k]; ok {
+			continue
+		}
+		for i, orphan := range orphans {
+			if orphan.Type == mig.Type && checksumMatches(orphan.Checksum, mig) {
+				renames[k] = orphan
+				orphans = append(orphans[:i], orphans[i+1:]...)
+				break
+			}
+		}
+	}
+	return renames
+}
+
+// rename updates the description of the record of a renamed repeatable migration instead of applying it again.
+func (m *Migrator) rename(old Migration, mig Migration) error {
+	renamed := old
+	renamed.Description = mig.Description
+	if err := m.record(renamed, true); err != nil {
+		return fmt.Errorf("rename repeatable migration: %s: %+v", old, err)
+	}
+	fields := migrationFields(mig)
+	fields["renamed_from"] = old.Description
+	m.log(LevelInfo, "renamed repeatable migration", fields)
+	return nil
+}
+
+// pruneSuperseded deletes the records of repeatable migrations but the latest one of each.
+func (m *Migrator) pruneSuperseded() error {
+	if !m.pruneRepeatable {
+		return nil
+	}
+	d, ok := m.support.(MigrationDeleter)
+	if !ok {
+		return fmt.Errorf("support does not delete migrations: %T", m.support)
+	}
+	installed, err := m.listMigrations()
+	if err != nil {
+		return err
+	}
+	latest := map[repeatableKey]int{}
+	for _, mig := range installed {
+		if mig.IsRepeatable() && mig.Rank > latest[keyOf(mig)] {
+			latest[keyOf(mig)] = mig.Rank
+		}
+	}
+	for _, mig := range installed {
+		if !mig.IsRepeatable() || mig.Rank == latest[keyOf(mig)] {
+			continue
+		}
+		m.recording.Lock()
+		err := m.retry(context.Background(), func() error {
+			return d.DeleteMigration(m.db, mig)
+		})
+		m.recording.Unlock()
+		if err != nil {
+			return fmt.Errorf("prune repeatable migration: %s: %+v", mig, err)
+		}
+		m.log(LevelDebug, "pruned superseded repeatable migration", migrationFields(mig))
+	}
+	return nil
+}
+
+// schemaVersion returns the highest version applied successfully if a migration of repeatable requires a version,
+// VersionNone otherwise.
+func (m *Migrator) schemaVersion(repeatable Migrations) (Version, error) {
+	for _, mig := range repeatable {
+		if mig.Options.RequiresVersion == VersionNone {
+			continue
+		}
+		s, err := m.componentState()
+		if err != nil {
+			return VersionNone, err
+		}
+		return s.installed, nil
+	}
+	return VersionNone, nil
+}
+
+// awaitsVersion reports whether the repeatable migration mig requires a version above schema.
+func (m *Migrator) awaitsVersion(mig Migration, schema Version) bool {
+	required := mig.Options.RequiresVersion
+	return required != VersionNone && (schema == VersionNone || m.versionOrdering.compare(required, schema) > 0)
+}
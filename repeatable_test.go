@@ -0,0 +1,128 @@
+package migrate
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+type deleterSupport struct {
+	memSupport
+}
+
+func (s *deleterSupport) DeleteMigration(con *sql.DB, m Migration) error {
+	for i, e := range s.migrations {
+		if e.Rank == m.Rank {
+			s.migrations = append(s.migrations[:i], s.migrations[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func TestMigrateRepeatableKeyedByType(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	calls := 0
+	m.AddRepeatableGoMigration("views", func(con *sql.DB) error {
+		calls++
+		return nil
+	}, Fingerprint("views/v1"))
+	m.AddRepeatableSQLMigration("views", "CREATE VIEW v AS SELECT 1;")
+	for i := 0; i < 2; i++ {
+		if err := m.Migrate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("want the Go migration to be applied once, got: %d", calls)
+	}
+	if want, got := []string{"CREATE VIEW v AS SELECT 1;"}, log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	if len(s.migrations) != 2 {
+		t.Errorf("want 2 records, got: %v", s.migrations)
+	}
+	if pending := m.Info().Pending(); len(pending) != 0 {
+		t.Errorf("want no pending migrations, got: %v", pending)
+	}
+}
+
+func TestMigrateRepeatableRenamed(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.AddRepeatableSQLMigration("views", "CREATE VIEW v AS SELECT 1;")
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	renamed := NewMigrator(t.Logf, db, s)
+	renamed.AddRepeatableSQLMigration("report views", "CREATE VIEW v AS SELECT 1;")
+	if err := renamed.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := log.Statements(); len(got) != 1 {
+		t.Errorf("want a renamed migration not to be applied again, got: %q", got)
+	}
+	if len(s.migrations) != 1 || s.migrations[0].Description != "report views" {
+		t.Errorf("want the record to be renamed, got: %v", s.migrations)
+	}
+	for _, mig := range renamed.Info().Migrations {
+		if mig.State != StateApplied {
+			t.Errorf("want applied, got: %s %s", mig, mig.State)
+		}
+	}
+}
+
+func TestMigrateRepeatablePrune(t *testing.T) {
+	db, _ := openFake(t.Name())
+	defer db.Close()
+	s := &deleterSupport{}
+	for i, script := range []string{"CREATE VIEW v AS SELECT 1;", "CREATE VIEW v AS SELECT 2;", "CREATE VIEW v AS SELECT 3;"} {
+		m := NewMigrator(t.Logf, db, s)
+		m.SetPruneRepeatable(i > 0)
+		m.AddRepeatableSQLMigration("views", script)
+		if err := m.Migrate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(s.migrations) != 1 || s.migrations[0].Rank != 3 {
+		t.Errorf("want only the latest record, got: %v", s.migrations)
+	}
+
+	m := NewMigrator(t.Logf, db, &memSupport{})
+	m.SetPruneRepeatable(true)
+	m.AddRepeatableSQLMigration("views", "CREATE VIEW v AS SELECT 1;")
+	if err := m.Migrate(); err == nil {
+		t.Errorf("want error for a support that does not delete migrations")
+	}
+}
+
+func TestMigrateRepeatableRequiresVersion(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	m.AddRepeatableSQLMigration("views", "-- requires-version: 2\nCREATE VIEW v AS SELECT * FROM b;")
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := []string{"CREATE TABLE a (id INT);"}, log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want the repeatable migration deferred, got: %q", got)
+	}
+	if pending := m.Info().Pending(); len(pending) != 1 || pending[0].Description != "views" {
+		t.Errorf("want the repeatable migration pending, got: %v", pending)
+	}
+	m.AddSQLMigration("2", "two", "CREATE TABLE b (id INT);")
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"CREATE TABLE a (id INT);", "CREATE TABLE b (id INT);", "-- requires-version: 2\nCREATE VIEW v AS SELECT * FROM b;"}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
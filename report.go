@@ -0,0 +1,240 @@
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReportFormat is the format of a run report.
+type ReportFormat string
+
+const (
+	ReportJSON ReportFormat = "json"
+	ReportHTML ReportFormat = "html"
+)
+
+// Report describes a run of Migrate for CI/CD job artifacts and change-management tickets.
+type Report struct {
+	Started  time.Time         `json:"started"`
+	Finished time.Time         `json:"finished"`
+	Duration time.Duration     `json:"duration_ns"`
+	Status   Status            `json:"status"`
+	Error    string            `json:"error,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	// Version is the version of the schema after the run.
+	Version    Version           `json:"version,omitempty"`
+	Server     *Server           `json:"server,omitempty"`
+	Migrations []ReportMigration `json:"migrations"`
+	// Warnings are the entries logged with LevelWarn or above during the run.
+	Warnings []ReportEntry `json:"warnings,omitempty"`
+}
+
+// ReportMigration is a migration installed by a reported run.
+type ReportMigration struct {
+	Version     Version       `json:"version"`
+	Description string        `json:"description"`
+	Type        Type          `json:"type"`
+	Status      Status        `json:"status"`
+	Duration    time.Duration `json:"duration_ns"`
+	// Statements is the number of statements executed by a SQL migration.
+	Statements int    `json:"statements,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ReportEntry is a log entry of a reported run.
+type ReportEntry struct {
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// SetReport makes Migrate write a report of every run to w in format, whether the run succeeds or fails.
+func (m *Migrator) SetReport(w io.Writer, format ReportFormat) {
+	m.reportWriter = w
+	m.reportFormat = format
+	m.reportFile = ""
+}
+
+// SetReportFile makes Migrate write a report of every run to the file path, replacing the report of the previous run.
+// The format is HTML if path ends with ".html" and JSON otherwise.
+func (m *Migrator) SetReportFile(path string) {
+	m.reportWriter = nil
+	m.reportFormat = ReportJSON
+	if strings.HasSuffix(path, ".html") {
+		m.reportFormat = ReportHTML
+	}
+	m.reportFile = path
+}
+
+// startReport starts collecting the report of a run if configured by SetReport or SetReportFile.
+func (m *Migrator) startReport(start time.Time) {
+	if m.reportWriter == nil && m.reportFile == "" {
+		return
+	}
+	m.reporting.Lock()
+	defer m.reporting.Unlock()
+	m.report = &Report{Started: start.UTC(), Labels: m.runLabels, Migrations: []ReportMigration{}}
+}
+
+// reported passes the report of the current run to f, if it is collected.
+func (m *Migrator) reported(f func(r *Report)) {
+	m.reporting.Lock()
+	defer m.reporting.Unlock()
+	if m.report != nil {
+		f(m.report)
+	}
+}
+
+// observe adds the events of a run to its report.
+func (r *Report) observe(e Event) {
+	switch e := e.(type) {
+	case StatementExecuted:
+		for i := len(r.Migrations) - 1; i >= 0; i-- {
+			if rm := &r.Migrations[i]; rm.Version == e.Migration.Version && rm.Description == e.Migration.Description {
+				rm.Statements = e.Statements
+				return
+			}
+		}
+		r.Migrations = append(r.Migrations, ReportMigration{
+			Version:     e.Migration.Version,
+			Description: e.Migration.Description,
+			Type:        e.Migration.Type,
+			Statements:  e.Statements,
+		})
+	case MigrationFinished:
+		rm := ReportMigration{
+			Version:     e.Migration.Version,
+			Description: e.Migration.Description,
+			Type:        e.Migration.Type,
+		}
+		if n := len(r.Migrations); n > 0 && r.Migrations[n-1].Status == "" &&
+			r.Migrations[n-1].Version == rm.Version && r.Migrations[n-1].Description == rm.Description {
+			rm = r.Migrations[n-1]
+			r.Migrations = r.Migrations[:n-1]
+		}
+		rm.Status = e.Migration.Status
+		rm.Duration = e.Duration
+		if e.Err != nil {
+			rm.Error = e.Err.Error()
+		}
+		r.Migrations = append(r.Migrations, rm)
+	}
+}
+
+// warn adds a log entry to the report.
+func (r *Report) warn(t time.Time, level Level, msg string, fields Fields) {
+	e := ReportEntry{Time: t.UTC(), Level: level.String(), Message: msg}
+	if len(fields) > 0 {
+		e.Fields = map[string]string{}
+		for k, v := range fields {
+			e.Fields[k] = fmt.Sprint(v)
+		}
+	}
+	r.Warnings = append(r.Warnings, e)
+}
+
+// finishReport writes the report of a run.
+func (m *Migrator) finishReport(res MigrationResult, err error) {
+	m.reporting.Lock()
+	r := m.report
+	m.report = nil
+	m.reporting.Unlock()
+	if r == nil {
+		return
+	}
+	r.Finished = m.now().UTC()
+	r.Duration = res.Duration
+	r.Version = res.Version
+	r.Server = res.Server
+	r.Status = StatusSuccess
+	if err != nil {
+		r.Status = StatusFailed
+		r.Error = err.Error()
+	}
+	if wErr := m.writeReport(*r); wErr != nil {
+		m.log(LevelWarn, "write report", Fields{"error": wErr})
+	}
+}
+
+func (m *Migrator) writeReport(r Report) error {
+	buf := &bytes.Buffer{}
+	if err := r.Write(buf, m.reportFormat); err != nil {
+		return err
+	}
+	if m.reportWriter != nil {
+		_, err := m.reportWriter.Write(buf.Bytes())
+		return err
+	}
+	m.log(LevelDebug, "wrote report", Fields{"file": m.reportFile})
+	return ioutil.WriteFile(m.reportFile, buf.Bytes(), 0644)
+}
+
+// Write writes the report to w in format.
+func (r Report) Write(w io.Writer, format ReportFormat) error {
+	switch format {
+	case ReportJSON, "":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case ReportHTML:
+		return reportTemplate.Execute(w, r)
+	default:
+		return fmt.Errorf("unknown report format: %s", format)
+	}
+}
+
+// fieldList returns the fields of an entry as key=value pairs ordered by key.
+func fieldList(fields map[string]string) string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + fields[k]
+	}
+	return strings.Join(pairs, " ")
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"time":   func(t time.Time) string { return t.Format(time.RFC3339) },
+	"fields": fieldList,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Migration run {{time .Started}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+.failed { color: #b00; }
+</style>
+</head>
+<body>
+<h1>Migration run <span class="{{.Status}}">{{.Status}}</span></h1>
+<p>Started {{time .Started}}, finished {{time .Finished}}, took {{.Duration}}.{{if .Version}} Schema version {{.Version}}.{{end}}{{if .Server}} Server {{.Server}}.{{end}}</p>
+{{if .Error}}<p class="failed">{{.Error}}</p>{{end}}
+{{if .Labels}}<p>{{range $k, $v := .Labels}}{{$k}}={{$v}} {{end}}</p>{{end}}
+<h2>Migrations</h2>
+<table>
+<tr><th>Version</th><th>Description</th><th>Type</th><th>Status</th><th>Duration</th><th>Statements</th><th>Error</th></tr>
+{{range .Migrations}}<tr><td>{{.Version}}</td><td>{{.Description}}</td><td>{{.Type}}</td><td class="{{.Status}}">{{.Status}}</td><td>{{.Duration}}</td><td>{{.Statements}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>
+{{if .Warnings}}<h2>Warnings</h2>
+<table>
+<tr><th>Time</th><th>Level</th><th>Message</th><th>Fields</th></tr>
+{{range .Warnings}}<tr><td>{{time .Time}}</td><td>{{.Level}}</td><td>{{.Message}}</td><td>{{fields .Fields}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,68 @@
+package migrate
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateReport(t *testing.T) {
+	db, _ := openFake(t.Name())
+	defer db.Close()
+	m := NewMigrator(t.Logf, db, &memSupport{})
+	buf := &bytes.Buffer{}
+	m.SetReport(buf, ReportJSON)
+	m.AddSQLMigration("1", "create", "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);")
+	m.AddSQLMigration("2", "drop", "DROP TABLE b;")
+	m.AddGoMigration("3", "fail", func(con *sql.DB) error { return fmt.Errorf("boom") })
+	if err := m.Migrate(); err == nil {
+		t.Fatal("want error")
+	}
+	r := Report{}
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatalf("decode report: %v\n%s", err, buf)
+	}
+	if r.Status != StatusFailed || !strings.Contains(r.Error, "boom") || r.Version != "2" {
+		t.Errorf("unexpected run: %s %q %s", r.Status, r.Error, r.Version)
+	}
+	if len(r.Migrations) != 3 {
+		t.Fatalf("want 3 migrations, got: %+v", r.Migrations)
+	}
+	if m := r.Migrations[0]; m.Status != StatusSuccess || m.Statements != 2 {
+		t.Errorf("unexpected first migration: %+v", m)
+	}
+	if m := r.Migrations[2]; m.Status != StatusFailed || m.Error != "boom" {
+		t.Errorf("unexpected failed migration: %+v", m)
+	}
+	if len(r.Warnings) == 0 || r.Warnings[0].Message != "destructive statement" {
+		t.Errorf("want the destructive statement warning, got: %+v", r.Warnings)
+	}
+}
+
+func TestMigrateReportFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "report.html")
+	m := newTestMigrator(t, &memSupport{})
+	m.SetReportFile(file)
+	m.AddGoMigration("1", "<one>", func(con *sql.DB) error { return nil })
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	bs, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if html := string(bs); !strings.Contains(html, "&lt;one&gt;") || !strings.Contains(html, `class="success"`) {
+		t.Errorf("unexpected report:\n%s", html)
+	}
+}
@@ -0,0 +1,119 @@
+package migrate
+
+import (
+	"context"
+	"time"
+)
+
+// MigrationResult summarizes a call to MigrateWithResult.
+type MigrationResult struct {
+	// Applied are the migrations that have been installed successfully, in the order of their installation.
+	Applied Migrations
+	// Failed are the migrations that have been installed with StatusFailed, including those with IgnoreFailure.
+	Failed Migrations
+	// Skipped are the pending migrations that have been recorded without being executed, e.g. for an inactive environment.
+	Skipped Migrations
+	// UpToDate is the number of versioned migrations that had been applied before.
+	UpToDate int
+	// Pending is the number of migrations that were pending at the start of the run.
+	Pending int
+	// Version is the version of the schema after the run, VersionNone if no versioned migration has been applied.
+	Version Version
+	// Duration is the duration of the whole run.
+	Duration time.Duration
+	// Server is the database server detected at the start of the run, nil if the Support is no ServerDetector.
+	Server *Server
+}
+
+// MigrateWithResult is Migrate, returning a summary of what the run did. The summary covers the run up to its error.
+// It is empty if the run has been skipped because of its run token or because another instance has been elected to
+// migrate.
+func (m *Migrator) MigrateWithResult() (MigrationResult, error) {
+	return m.migrateWithResult(context.Background(), nil)
+}
+
+// migrateWithResult runs MigrateWithResult, aborting gracefully when abort is done. If p is not nil, the run applies
+// the plan p, see Apply.
+func (m *Migrator) migrateWithResult(abort context.Context, p *Plan) (MigrationResult, error) {
+	start := m.now()
+	res := MigrationResult{}
+	m.startReport(start)
+	ctx := context.Background()
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+	err := m.awaitConnection(ctx)
+	current := false
+	if err == nil {
+		current, err = m.checkFast(&res)
+	}
+	switch {
+	case err != nil, current:
+	case m.dryRun:
+		err = m.migrateDry(&res)
+	default:
+		err = m.withLeaderLock(func() error {
+			if m.timeout > 0 {
+				m.deadline = start.Add(m.timeout)
+				defer func() { m.deadline = time.Time{} }()
+			}
+			m.abort = abort
+			defer func() { m.abort = nil }()
+			m.plan = p
+			defer func() { m.plan = nil }()
+			m.mu.Lock()
+			m.result = &res
+			m.mu.Unlock()
+			defer func() {
+				m.mu.Lock()
+				m.result = nil
+				m.mu.Unlock()
+			}()
+			return m.migrateRun()
+		})
+	}
+	res.Duration = m.since(start)
+	m.observeRun(start, err)
+	m.emit(RunFinished{Duration: res.Duration, Err: err})
+	m.notify(res, err)
+	m.finishReport(res, err)
+	return res, err
+}
+
+// collect passes the result of the current run to f, if it is collected.
+func (m *Migrator) collect(f func(r *MigrationResult)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.result != nil {
+		f(m.result)
+	}
+}
+
+func (r *MigrationResult) observe(e Event, o versionOrder) {
+	switch e := e.(type) {
+	case RunStarted:
+		r.Pending = e.Pending
+	case MigrationFinished:
+		switch e.Migration.Status {
+		case StatusSuccess:
+			r.Applied = append(r.Applied, e.Migration)
+			r.advance(e.Migration, o)
+		case StatusCherryPicked:
+			r.Applied = append(r.Applied, e.Migration)
+		case StatusFailed:
+			r.Failed = append(r.Failed, e.Migration)
+			if e.Migration.Options.IgnoreFailure {
+				r.advance(e.Migration, o)
+			}
+		}
+	}
+}
+
+// advance sets the version of the schema to the one of mig unless mig has been installed out of order.
+func (r *MigrationResult) advance(mig Migration, o versionOrder) {
+	if !mig.IsRepeatable() {
+		r.Version = o.later(r.Version, mig.Version)
+	}
+}
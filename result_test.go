@@ -0,0 +1,38 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestMigrateWithResult(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	noop := func(con *sql.DB) error { return nil }
+	m.AddGoMigration("1", "one", noop)
+	m.AddGoMigration("2", "two", noop)
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	m.SetEnvironment("prod")
+	m.AddGoMigration("3", "three", noop, OnlyIn("dev"))
+	m.AddGoMigration("4", "four", noop)
+	m.AddGoMigration("5", "five", func(con *sql.DB) error { return errors.New("boom") })
+	res, err := m.MigrateWithResult()
+	if err == nil {
+		t.Fatalf("want error")
+	}
+	if len(res.Applied) != 1 || res.Applied[0].Version != "4" {
+		t.Errorf("unexpected applied: %v", res.Applied)
+	}
+	if len(res.Skipped) != 1 || res.Skipped[0].Version != "3" {
+		t.Errorf("unexpected skipped: %v", res.Skipped)
+	}
+	if len(res.Failed) != 1 || res.Failed[0].Version != "5" {
+		t.Errorf("unexpected failed: %v", res.Failed)
+	}
+	if res.UpToDate != 2 || res.Pending != 3 || res.Version != "4" || res.Duration <= 0 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
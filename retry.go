@@ -0,0 +1,95 @@
+package migrate
+
+import (
+	"context"
+	"time"
+)
+
+// RetryClassifier is implemented by Support implementations for databases that ask clients to retry
+// transactions, e.g. CockroachDB with SQLSTATE 40001.
+type RetryClassifier interface {
+	IsRetryable(err error) bool
+}
+
+// transactionRetries is the number of times a retryable failure is retried.
+const transactionRetries = 5
+
+// transactionRetryDelay is the pause before the first retry. It doubles with every further retry.
+var transactionRetryDelay = 50 * time.Millisecond
+
+// RetryPolicy configures how transient failures, e.g. deadlocks, serialization failures or dropped connections, are
+// retried. It applies to the statements of SQL migrations, to migrations in a transaction as a whole and to the
+// writes of the metadata table.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first one. It defaults to 6.
+	MaxAttempts int
+	// Backoff returns the pause before the given retry, starting at 1. It defaults to an exponential backoff starting at 50ms.
+	Backoff func(retry int) time.Duration
+	// Retryable reports whether a failure is transient. It defaults to the RetryClassifier of the Support; without
+	// one, no failure is retried.
+	Retryable func(err error) bool
+}
+
+// ExponentialBackoff returns a backoff that starts at initial and doubles with every retry up to max. A max of 0 is unbounded.
+func ExponentialBackoff(initial time.Duration, max time.Duration) func(retry int) time.Duration {
+	return func(retry int) time.Duration {
+		d := initial
+		for i := 1; i < retry && (max <= 0 || d < max); i++ {
+			d *= 2
+		}
+		if max > 0 && d > max {
+			return max
+		}
+		return d
+	}
+}
+
+// SetRetryPolicy configures the retries of transient failures.
+func (m *Migrator) SetRetryPolicy(p RetryPolicy) {
+	m.retryPolicy = p
+}
+
+// retry calls f until it succeeds or fails with an error the retry policy does not classify as retryable.
+func (m *Migrator) retry(ctx context.Context, f func() error) error {
+	retryable := m.retryPolicy.Retryable
+	if retryable == nil {
+		rc, ok := m.support.(RetryClassifier)
+		if !ok {
+			return f()
+		}
+		retryable = rc.IsRetryable
+	}
+	return m.retryPolicy.do(ctx, retryable, func(retry int, err error) {
+		m.log(LevelWarn, "retrying", Fields{"retry": retry, "error": err})
+	}, f)
+}
+
+func retryRetryable(ctx context.Context, rc RetryClassifier, f func() error) error {
+	return RetryPolicy{}.do(ctx, rc.IsRetryable, nil, f)
+}
+
+// do calls f until it succeeds, fails with an error that is not retryable or the attempts are exhausted.
+// onRetry is called before every retry if not nil.
+func (p RetryPolicy) do(ctx context.Context, retryable func(err error) bool, onRetry func(retry int, err error), f func() error) error {
+	attempts := p.MaxAttempts
+	if attempts < 1 {
+		attempts = transactionRetries + 1
+	}
+	backoff := p.Backoff
+	if backoff == nil {
+		backoff = ExponentialBackoff(transactionRetryDelay, 0)
+	}
+	err := f()
+	for retry := 1; err != nil && retry < attempts && retryable(err) && ctx.Err() == nil; retry++ {
+		if onRetry != nil {
+			onRetry(retry, err)
+		}
+		select {
+		case <-time.After(backoff(retry)):
+		case <-ctx.Done():
+			return err
+		}
+		err = f()
+	}
+	return err
+}
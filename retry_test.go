@@ -0,0 +1,82 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type flakySupport struct {
+	memSupport
+	failures int
+}
+
+func (s *flakySupport) RecordMigration(con *sql.DB, m Migration) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("connection reset")
+	}
+	return s.memSupport.RecordMigration(con, m)
+}
+
+func TestMigrateRetryPolicy(t *testing.T) {
+	transient := func(err error) bool {
+		return strings.Contains(err.Error(), "40001") || strings.Contains(err.Error(), "connection reset")
+	}
+	noWait := func(retry int) time.Duration { return 0 }
+
+	db, log := openFake(t.Name())
+	defer db.Close()
+	m := NewMigrator(t.Logf, db, &memSupport{})
+	m.SetRetryPolicy(RetryPolicy{MaxAttempts: 2, Backoff: noWait, Retryable: transient})
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	log.fail, log.failTimes, log.failMessage = "CREATE TABLE a", 2, "fake: 40001"
+	if err := m.Migrate(); err == nil {
+		t.Fatalf("want failure after exhausted attempts")
+	}
+
+	db, log = openFake(t.Name() + "-again")
+	defer db.Close()
+	s := &flakySupport{failures: 1}
+	m = NewMigrator(t.Logf, db, s)
+	m.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, Backoff: noWait, Retryable: transient})
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	log.fail, log.failTimes, log.failMessage = "CREATE TABLE a", 2, "fake: 40001"
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if len(s.migrations) != 1 || s.migrations[0].Status != StatusSuccess {
+		t.Fatalf("want successful record, got: %s", s.migrations)
+	}
+}
+
+func TestRecordRetriesOnce(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	m := NewMigrator(t.Logf, db, NewCockroachSupport())
+	m.SetRetryPolicy(RetryPolicy{MaxAttempts: 2, Backoff: func(retry int) time.Duration { return 0 }})
+	log.fail, log.failMessage = "INSERT INTO", "fake: 40001"
+	if err := m.record(Migration{Version: "1", Description: "one", Type: TypeSQL}, false); err == nil {
+		t.Fatal("want failure after exhausted attempts")
+	}
+	attempts := 0
+	for _, stmt := range log.Statements() {
+		if strings.HasPrefix(stmt, "INSERT INTO") {
+			attempts++
+		}
+	}
+	if attempts != 2 {
+		t.Errorf("want 2 attempts, got: %d", attempts)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	for retry, want := range map[int]time.Duration{1: 10 * time.Millisecond, 2: 20 * time.Millisecond, 3: 40 * time.Millisecond, 4: 50 * time.Millisecond, 10: 50 * time.Millisecond} {
+		if got := backoff(retry); got != want {
+			t.Errorf("retry %d: want: %s, got: %s", retry, want, got)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ScriptDeleter is implemented by Support implementations that are able to render the statement that deletes the
+// record of a migration from the metadata table. It is used by GenerateRollbackPlan.
+type ScriptDeleter interface {
+	DeleteMigrationScript(m Migration) string
+}
+
+// GenerateRollbackPlan renders the undo scripts that revert the database from its current version to target into a
+// single annotated SQL script for review by a DBA, newest migration first. The statements that delete the records of
+// the reverted migrations are included if the Support is a ScriptDeleter. Nothing is executed. It fails if a reverted
+// migration has no undo script set by UndoSQL or loaded from an undo file. Repeatable migrations are not reverted.
+func (m *Migrator) GenerateRollbackPlan(w io.Writer, target Version) error {
+	installed, err := m.findMigrations(MigrationFilter{Versioned: true, Statuses: []Status{StatusSuccess, StatusCherryPicked}})
+	if err != nil {
+		return err
+	}
+	migrations, _ := m.registered()
+	local := map[Version]Migration{}
+	for _, mig := range migrations {
+		local[mig.Version] = mig
+	}
+	reverted := Migrations{}
+	for _, mig := range installed {
+		if mig.Type != TypeBaseline && m.versionOrdering.compare(mig.Version, target) > 0 {
+			reverted = append(reverted, mig)
+		}
+	}
+	sort.SliceStable(reverted, func(i, j int) bool {
+		return reverted[i].Rank > reverted[j].Rank
+	})
+	sd, deletes := m.support.(ScriptDeleter)
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "-- Rollback plan generated at %s\n", m.now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(b, "-- Target version: %s\n", target)
+	fmt.Fprintf(b, "-- Reverted migrations: %d\n\n", len(reverted))
+	for _, applied := range reverted {
+		mig, ok := local[applied.Version]
+		if !ok || mig.Options.UndoScript == "" {
+			return fmt.Errorf("rollback plan: no undo script: %s", applied)
+		}
+		fmt.Fprintf(b, "-- Undo %s: %s\n", mig.Version, mig.Description)
+		for _, stmt := range m.splitter().Parse(mig.Options.UndoScript) {
+			b.WriteString(stmt.SQL + "\n")
+		}
+		if deletes {
+			b.WriteString(sd.DeleteMigrationScript(applied) + "\n")
+		}
+		b.WriteString("\n")
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
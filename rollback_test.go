@@ -0,0 +1,56 @@
+package migrate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateRollbackPlan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate-rollback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"V1__users.sql":  "CREATE TABLE users (id INT);",
+		"U1__users.sql":  "DROP TABLE users;",
+		"V2__orders.sql": "CREATE TABLE orders (id INT);\nCREATE INDEX orders_id ON orders (id);",
+		"U2__orders.sql": "DROP INDEX orders_id;\nDROP TABLE orders;",
+		"V3__notes.sql":  "CREATE TABLE notes (id INT);",
+	}
+	for name, script := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, _ := openFake(t.Name())
+	defer db.Close()
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	if err := m.AddDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	b := &strings.Builder{}
+	if err := m.GenerateRollbackPlan(b, "2"); err == nil {
+		t.Errorf("want error for a migration without undo script")
+	}
+	s.migrations = s.migrations[:2]
+	b.Reset()
+	if err := m.GenerateRollbackPlan(b, VersionNone); err != nil {
+		t.Fatal(err)
+	}
+	plan := b.String()
+	want := "-- Undo 2: orders\nDROP INDEX orders_id;\nDROP TABLE orders;\n\n-- Undo 1: users\nDROP TABLE users;\n\n"
+	if !strings.Contains(plan, "-- Reverted migrations: 2\n") || !strings.HasSuffix(plan, want) {
+		t.Errorf("unexpected plan:\n%s", plan)
+	}
+	if got := (SQLiteSupport{}).DeleteMigrationScript(Migration{Rank: 3}); got != `DELETE FROM "migrations" WHERE rank = 3;` {
+		t.Errorf("unexpected delete: %s", got)
+	}
+}
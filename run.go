@@ -0,0 +1,30 @@
+package migrate
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Run is the record of a single Migrate invocation identified by a token.
+type Run struct {
+	Token    string
+	Started  time.Time
+	Finished time.Time
+	Status   Status
+	// Labels is arbitrary context of the run, e.g. feature flags, a release ticket or a deploy ID.
+	Labels map[string]string
+}
+
+// RunRecorder is implemented by Support implementations that are able to persist runs.
+type RunRecorder interface {
+	CreateRunsTable(con *sql.DB) error
+	RecordRun(con *sql.DB, r Run) error
+	FindRun(con *sql.DB, token string) (Run, bool, error)
+	ListRuns(con *sql.DB) ([]Run, error)
+}
+
+// SetRunLabels attaches labels to the runs recorded by subsequent calls to Migrate.
+// Runs with labels are recorded even without a run token.
+func (m *Migrator) SetRunLabels(labels map[string]string) {
+	m.runLabels = labels
+}
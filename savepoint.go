@@ -0,0 +1,67 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+)
+
+// Savepointer is implemented by Support implementations that are able to roll back part of a session with savepoints.
+type Savepointer interface {
+	Savepoint(name string) string
+	RollbackToSavepoint(name string) string
+	ReleaseSavepoint(name string) string
+}
+
+// StatementError is an error caused by a statement of a SQL migration.
+type StatementError struct {
+	// Index is the zero based position of the statement in the script. If statements are batched, it is the
+	// position of the first statement of the failed batch.
+	Index int
+	SQL   string
+	Err   error
+}
+
+func (e *StatementError) Error() string {
+	return fmt.Sprintf("statement %d: %v", e.Index+1, e.Err)
+}
+
+func (e *StatementError) Unwrap() error {
+	return e.Err
+}
+
+// SetSavepoints makes SQL migrations that are executed outside of a transaction run on a single connection within a
+// savepoint if the Support is a Savepointer. If a statement fails, the statements executed before are rolled back to
+// the savepoint where the database supports it, e.g. on SQLite.
+func (m *Migrator) SetSavepoints(savepoints bool) {
+	m.savepoints = savepoints
+}
+
+// execSavepoint executes a SQL migration within a savepoint on a single connection.
+func (m *Migrator) execSavepoint(ctx context.Context, sp Savepointer, mig Migration) error {
+	con, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer con.Close()
+	if err := m.initConn(ctx, con); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("migrate_%d", mig.Rank)
+	if _, err := con.ExecContext(ctx, sp.Savepoint(name)); err != nil {
+		return fmt.Errorf("savepoint: %+v", err)
+	}
+	if err := m.execBatched(ctx, con, mig); err != nil {
+		if _, rErr := con.ExecContext(ctx, sp.RollbackToSavepoint(name)); rErr != nil {
+			return fmt.Errorf("%v: rollback to savepoint: %+v", err, rErr)
+		}
+		fields := migrationFields(mig)
+		fields["error"] = err
+		m.log(LevelWarn, "rolled back to savepoint", fields)
+		con.ExecContext(ctx, sp.ReleaseSavepoint(name))
+		return err
+	}
+	if _, err := con.ExecContext(ctx, sp.ReleaseSavepoint(name)); err != nil {
+		return fmt.Errorf("release savepoint: %+v", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package migrate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type savepointSupport struct {
+	memSupport
+}
+
+func (s *savepointSupport) Savepoint(name string) string {
+	return SQLiteSupport{}.Savepoint(name)
+}
+
+func (s *savepointSupport) RollbackToSavepoint(name string) string {
+	return SQLiteSupport{}.RollbackToSavepoint(name)
+}
+
+func (s *savepointSupport) ReleaseSavepoint(name string) string {
+	return SQLiteSupport{}.ReleaseSavepoint(name)
+}
+
+func TestMigrateSavepoint(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &savepointSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.SetSavepoints(true)
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);")
+	log.fail = "CREATE TABLE b"
+	err := m.Migrate()
+	var se *StatementError
+	if !errors.As(err, &se) || se.Index != 1 || se.SQL != "CREATE TABLE b (id INT);" {
+		t.Fatalf("want error of second statement, got: %v", err)
+	}
+	want := []string{
+		"SAVEPOINT migrate_1;",
+		"CREATE TABLE a (id INT);",
+		"CREATE TABLE b (id INT);",
+		"ROLLBACK TO SAVEPOINT migrate_1;",
+		"RELEASE SAVEPOINT migrate_1;",
+	}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
@@ -0,0 +1,208 @@
+package migrate
+
+import "fmt"
+
+// stepKind is what Migrate does with a registered migration.
+type stepKind int
+
+const (
+	// stepIgnore ignores a migration below the last installed version that has not been applied.
+	stepIgnore stepKind = iota
+	// stepApplied skips an applied migration, verifying the checksum of a versioned one.
+	stepApplied
+	// stepExclude skips a migration excluded by SetSkipVersions or the tags.
+	stepExclude
+	// stepDefer defers a contract migration and the ones after it.
+	stepDefer
+	// stepRetry installs a failed migration again.
+	stepRetry
+	// stepSkipInactive records a migration of another environment as skipped.
+	stepSkipInactive
+	// stepInstall installs a migration.
+	stepInstall
+	// stepRename renames the record of a repeatable migration.
+	stepRename
+	// stepAwait defers a repeatable migration until the schema reaches its required version.
+	stepAwait
+)
+
+// scheduledStep is the decision of Migrate for a registered migration.
+type scheduledStep struct {
+	kind stepKind
+	mig  Migration
+	// record is the recorded migration that is verified, retried or renamed.
+	record Migration
+	// state is the state of a migration that is installed or retried.
+	state      State
+	outOfOrder bool
+	outOfBand  bool
+}
+
+// schedule is the selection of the steps of a run of Migrate. It is computed without side effects, so that Plan
+// lists the steps Migrate runs.
+type schedule struct {
+	info    Info
+	pending Migrations
+	// failed are the failed records the recovery policy is applied to.
+	failed        Migrations
+	lastInstalled Version
+	baseline      Version
+	rank          int
+	versioned     []scheduledStep
+	// deferred reports whether a contract migration has been deferred, which defers the repeatable migrations.
+	deferred   bool
+	repeatable Migrations
+	checksums  map[repeatableKey]string
+	renames    map[repeatableKey]Migration
+}
+
+// schedule selects the steps that install the registered migrations and repeatable migrations on top of installed.
+func (m *Migrator) schedule(migrations Migrations, repeatable Migrations, installed Migrations) (*schedule, error) {
+	s := &schedule{
+		lastInstalled: VersionNone,
+		baseline:      VersionNone,
+		checksums:     map[repeatableKey]string{},
+	}
+	retries := m.recovery == RetryFailed || m.recovery == ResumeFailed
+	applied := map[Version]Migration{}
+	retry := map[Version]Migration{}
+	local := map[Version]Migration{}
+	for _, mig := range migrations {
+		local[mig.Version] = mig
+	}
+	for _, mig := range installed {
+		if mig.Type == TypeBaseline {
+			s.baseline = mig.Version
+		}
+		if mig.IsRepeatable() {
+			s.checksums[keyOf(mig)] = mig.Checksum
+		} else {
+			applied[mig.Version] = mig
+			switch mig.Status {
+			case StatusFailed:
+				if local[mig.Version].Options.IgnoreFailure {
+					s.lastInstalled = m.versionOrdering.later(s.lastInstalled, mig.Version)
+					break
+				}
+				s.failed = append(s.failed, mig)
+				if retries {
+					retry[mig.Version] = mig
+				} else {
+					s.lastInstalled = m.versionOrdering.later(s.lastInstalled, mig.Version)
+				}
+			case StatusSuccess, StatusSkipped:
+				s.lastInstalled = m.versionOrdering.later(s.lastInstalled, mig.Version)
+			case StatusCherryPicked:
+			default:
+				return nil, fmt.Errorf("unknown status in migration: %s", mig)
+			}
+		}
+		if mig.Rank > s.rank {
+			s.rank = mig.Rank
+		}
+	}
+	if m.target != VersionNone {
+		upTo := Migrations{}
+		for _, mig := range migrations {
+			if m.versionOrdering.compare(mig.Version, m.target) <= 0 {
+				upTo = append(upTo, mig)
+			} else {
+				delete(retry, mig.Version)
+			}
+		}
+		migrations, repeatable = upTo, nil
+	}
+	s.info = newInfo(migrations, repeatable, installed, m.versionOrdering)
+	s.pending = append(s.info.Pending(), m.outOfOrderPending(s.info)...)
+	outOfBand := false
+	if m.cherryPick != nil || m.skipVersions != nil || m.filtersTags() {
+		s.pending, repeatable = m.selectPending(s.info, repeatable)
+		outOfBand = m.cherryPick != nil
+	}
+	for _, mig := range migrations {
+		a, ok := applied[mig.Version]
+		outOfOrder := !ok && (m.cherryPick[mig.Version] || m.outOfOrder && (s.baseline == VersionNone || m.versionOrdering.compare(mig.Version, s.baseline) > 0))
+		if m.versionOrdering.compare(mig.Version, s.lastInstalled) <= 0 && !outOfOrder {
+			if !ok {
+				s.versioned = append(s.versioned, scheduledStep{kind: stepIgnore, mig: mig})
+				continue
+			}
+			s.versioned = append(s.versioned, scheduledStep{kind: stepApplied, mig: mig, record: a})
+			continue
+		}
+		if ok && a.Status == StatusCherryPicked {
+			s.versioned = append(s.versioned, scheduledStep{kind: stepApplied, mig: mig, record: a})
+			continue
+		}
+		if m.excluded(mig) {
+			delete(retry, mig.Version)
+			if m.skipVersions[mig.Version] || m.taggedOut(mig) {
+				s.versioned = append(s.versioned, scheduledStep{kind: stepExclude, mig: mig})
+				outOfBand = true
+			}
+			continue
+		}
+		if !m.contractable(mig) {
+			s.versioned = append(s.versioned, scheduledStep{kind: stepDefer, mig: mig})
+			s.deferred = true
+			break
+		}
+		if failed, ok := retry[mig.Version]; ok {
+			mig.Rank = failed.Rank
+			s.versioned = append(s.versioned, scheduledStep{kind: stepRetry, mig: mig, record: failed, state: StateFailed, outOfBand: outOfBand})
+			delete(retry, mig.Version)
+			continue
+		}
+		s.rank++
+		mig.Rank = s.rank
+		if !m.inEnvironment(mig) {
+			s.versioned = append(s.versioned, scheduledStep{kind: stepSkipInactive, mig: mig})
+			continue
+		}
+		s.versioned = append(s.versioned, scheduledStep{kind: stepInstall, mig: mig, state: StatePending, outOfOrder: outOfOrder && m.cherryPick == nil, outOfBand: outOfBand})
+	}
+	for _, mig := range retry {
+		if s.deferred {
+			break
+		}
+		return nil, fmt.Errorf("unable to retry failed migration: not found locally: %s", mig)
+	}
+	if !s.deferred {
+		s.repeatable = repeatable
+	}
+	s.renames = m.renames(installed, s.repeatable)
+	return s, nil
+}
+
+// repeatableSteps selects the steps of the repeatable migrations of s once the schema is at version schema. It
+// numbers the installed ones after the versioned migrations, so it is called once per schedule.
+func (m *Migrator) repeatableSteps(s *schedule, schema Version) []scheduledStep {
+	steps := []scheduledStep{}
+	for _, mig := range s.repeatable {
+		cs, exists := s.checksums[keyOf(mig)]
+		if exists && checksumMatches(cs, mig) {
+			steps = append(steps, scheduledStep{kind: stepApplied, mig: mig})
+			continue
+		}
+		if old, ok := s.renames[keyOf(mig)]; ok {
+			steps = append(steps, scheduledStep{kind: stepRename, mig: mig, record: old})
+			continue
+		}
+		if m.awaitsVersion(mig, schema) {
+			steps = append(steps, scheduledStep{kind: stepAwait, mig: mig})
+			continue
+		}
+		s.rank++
+		mig.Rank = s.rank
+		if !m.inEnvironment(mig) {
+			steps = append(steps, scheduledStep{kind: stepSkipInactive, mig: mig})
+			continue
+		}
+		state := StatePending
+		if exists {
+			state = StateOutdated
+		}
+		steps = append(steps, scheduledStep{kind: stepInstall, mig: mig, state: state})
+	}
+	return steps
+}
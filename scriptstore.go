@@ -0,0 +1,75 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+)
+
+// ScriptStore is implemented by Support implementations that are able to store the script of a migration with its
+// record in the metadata table, compressed with the Compression of the Support. It is required by SetRecordScripts.
+type ScriptStore interface {
+	StoreScript(con *sql.DB, m Migration, script string) error
+	// LoadScript returns the script stored with the record with the rank of m and false if none has been stored.
+	LoadScript(con *sql.DB, m Migration) (string, bool, error)
+}
+
+// SetRecordScripts makes Migrate store the script of every installed SQL migration with its record, so that the
+// applied DDL can be retrieved from the database itself with RecordedScript, e.g. during an audit after the history
+// of the repository has been rewritten. Scripts are stored after their templates have been rendered and before their
+// secret placeholders are resolved. It requires a Support that is a ScriptStore.
+func (m *Migrator) SetRecordScripts(record bool) {
+	m.recordScripts = record
+}
+
+// RecordedScript returns the script stored with the applied migration mig, as listed by Info, and false if none has
+// been stored.
+func (m *Migrator) RecordedScript(mig Migration) (string, bool, error) {
+	ss, ok := m.support.(ScriptStore)
+	if !ok {
+		return "", false, fmt.Errorf("support does not store scripts: %T", m.support)
+	}
+	if err := m.upgradeMetadata(); err != nil {
+		return "", false, err
+	}
+	return ss.LoadScript(m.db, mig)
+}
+
+// storeScript stores the script of the recorded SQL migration mig if configured by SetRecordScripts.
+func (m *Migrator) storeScript(mig Migration) error {
+	if !m.recordScripts || mig.Type != TypeSQL || (mig.Script == "" && mig.Source == nil) {
+		return nil
+	}
+	ss, ok := m.support.(ScriptStore)
+	if !ok {
+		return fmt.Errorf("support does not store scripts: %T", m.support)
+	}
+	script, err := m.scriptText(mig)
+	if err != nil {
+		return fmt.Errorf("store script: %s: %+v", mig, err)
+	}
+	m.recording.Lock()
+	defer m.recording.Unlock()
+	if err := ss.StoreScript(m.db, mig, script); err != nil {
+		return fmt.Errorf("store script: %s: %+v", mig, err)
+	}
+	return nil
+}
+
+// scriptText returns the rendered script of a SQL migration, which is read from its Source if set.
+func (m *Migrator) scriptText(mig Migration) (string, error) {
+	mig, err := m.render(mig)
+	if err != nil {
+		return "", err
+	}
+	if mig.Source == nil {
+		return mig.Script, nil
+	}
+	rc, err := mig.Source()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	bs, err := ioutil.ReadAll(rc)
+	return string(bs), err
+}
@@ -0,0 +1,69 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+type scriptSupport struct {
+	memSupport
+	compression Compression
+	scripts     map[int][]byte
+}
+
+func (s *scriptSupport) StoreScript(con *sql.DB, m Migration, script string) error {
+	data, err := s.compression.Encode(script)
+	if err != nil {
+		return err
+	}
+	if s.scripts == nil {
+		s.scripts = map[int][]byte{}
+	}
+	s.scripts[m.Rank] = data
+	return nil
+}
+
+func (s *scriptSupport) LoadScript(con *sql.DB, m Migration) (string, bool, error) {
+	data, ok := s.scripts[m.Rank]
+	if !ok {
+		return "", false, nil
+	}
+	script, err := decodeStored(data)
+	return script, err == nil, err
+}
+
+func TestRecordScripts(t *testing.T) {
+	db, _ := openFake(t.Name())
+	defer db.Close()
+	s := &scriptSupport{compression: CompressionGzip}
+	m := NewMigrator(t.Logf, db, s)
+	m.SetRecordScripts(true)
+	m.SetSecretResolver(SecretResolverFunc(func(ctx context.Context, name string) (string, bool, error) {
+		return "s3cr3t", true, nil
+	}))
+	script := "CREATE ROLE replication PASSWORD '{replication_password}';"
+	m.AddSQLMigration("1", "roles", script)
+	m.AddGoMigration("2", "backfill", func(con *sql.DB) error { return nil })
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	applied := s.migrations
+	got, ok, err := m.RecordedScript(applied[0])
+	if err != nil || !ok {
+		t.Fatalf("want recorded script, got: %v %v", ok, err)
+	}
+	if got != script {
+		t.Errorf("want script with placeholders: %q, got: %q", script, got)
+	}
+	if _, ok, err := m.RecordedScript(applied[1]); ok || err != nil {
+		t.Errorf("want no script for a Go migration, got: %v %v", ok, err)
+	}
+
+	other := NewMigrator(t.Logf, db, &memSupport{})
+	other.SetRecordScripts(true)
+	other.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	if err := other.Migrate(); err == nil {
+		t.Errorf("want error for a support that does not store scripts")
+	}
+}
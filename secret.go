@@ -0,0 +1,106 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// SecretResolver resolves the secret placeholders of SQL scripts, e.g. {replication_password}, when their statements
+// are executed, e.g. from the environment, Vault or a KMS. ok is false if name is not a secret, the placeholder is
+// kept unchanged then.
+type SecretResolver interface {
+	ResolveSecret(ctx context.Context, name string) (value string, ok bool, err error)
+}
+
+// SecretResolverFunc is a function that implements SecretResolver.
+type SecretResolverFunc func(ctx context.Context, name string) (string, bool, error)
+
+// ResolveSecret calls f.
+func (f SecretResolverFunc) ResolveSecret(ctx context.Context, name string) (string, bool, error) {
+	return f(ctx, name)
+}
+
+// EnvSecrets resolves a secret from the environment variable named by prefix and the upper cased name, e.g.
+// MIGRATE_REPLICATION_PASSWORD for {replication_password} with the prefix MIGRATE_.
+func EnvSecrets(prefix string) SecretResolver {
+	return SecretResolverFunc(func(ctx context.Context, name string) (string, bool, error) {
+		v, ok := os.LookupEnv(prefix + strings.ToUpper(name))
+		return v, ok, nil
+	})
+}
+
+// SetSecretResolver makes Migrate replace the secret placeholders of SQL scripts by the values of r right before the
+// statements are executed. Checksums, logged statements, recorded failures and bundles keep the placeholders, and the
+// values are masked in the errors of the statements.
+func (m *Migrator) SetSecretResolver(r SecretResolver) {
+	m.secrets = r
+}
+
+var secretPlaceholder = regexp.MustCompile(regexp.QuoteMeta(placeholderPrefix) + `([A-Za-z_][A-Za-z0-9_.]*)` + regexp.QuoteMeta(placeholderSuffix))
+
+// withSecrets returns con, resolving the secret placeholders of the statements it executes if a SecretResolver is set.
+func (m *Migrator) withSecrets(con execer) execer {
+	if m.secrets == nil {
+		return con
+	}
+	return secretExecer{execer: con, resolver: m.secrets}
+}
+
+// secretExecer resolves the secret placeholders of the statements executed by an execer.
+type secretExecer struct {
+	execer
+	resolver SecretResolver
+}
+
+func (e secretExecer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var values []string
+	var err error
+	resolved := secretPlaceholder.ReplaceAllStringFunc(query, func(placeholder string) string {
+		if err != nil {
+			return placeholder
+		}
+		name := placeholder[len(placeholderPrefix) : len(placeholder)-len(placeholderSuffix)]
+		v, ok, rErr := e.resolver.ResolveSecret(ctx, name)
+		if rErr != nil {
+			err = fmt.Errorf("resolve secret: %s: %+v", name, rErr)
+			return placeholder
+		}
+		if !ok {
+			return placeholder
+		}
+		if v != "" {
+			values = append(values, v)
+		}
+		return v
+	})
+	if err != nil {
+		return nil, err
+	}
+	res, err := e.execer.ExecContext(ctx, resolved, args...)
+	if err != nil && len(values) > 0 {
+		return res, &maskedError{err: err, values: values}
+	}
+	return res, err
+}
+
+// maskedError masks the values of secrets in the message of err.
+type maskedError struct {
+	err    error
+	values []string
+}
+
+func (e *maskedError) Error() string {
+	msg := e.err.Error()
+	for _, v := range e.values {
+		msg = strings.ReplaceAll(msg, v, "***")
+	}
+	return msg
+}
+
+func (e *maskedError) Unwrap() error {
+	return e.err
+}
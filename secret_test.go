@@ -0,0 +1,58 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMigrateSecrets(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	var lines []string
+	s := &memSupport{}
+	m := NewMigrator(func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}, db, s)
+	m.SetStatementLog(StatementLog{})
+	t.Setenv("MIGRATE_REPLICATION_PASSWORD", "s3cr3t")
+	m.SetSecretResolver(EnvSecrets("MIGRATE_"))
+	script := "CREATE ROLE replication PASSWORD '{replication_password}';\nSELECT '{a}';"
+	m.AddSQLMigration("1", "roles", script)
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	want := []string{"CREATE ROLE replication PASSWORD 's3cr3t';", "SELECT '{a}';"}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	if got := s.migrations[0].Checksum; got != SQLChecksum(script) {
+		t.Errorf("want checksum of the placeholders, got: %s", got)
+	}
+	for _, line := range lines {
+		if strings.Contains(line, "s3cr3t") {
+			t.Errorf("secret logged: %s", line)
+		}
+	}
+
+	log.fail = "GRANT"
+	m.AddSQLMigration("2", "grant", "GRANT admin TO replication IDENTIFIED BY '{replication_password}';")
+	err := m.Migrate()
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if strings.Contains(err.Error(), "s3cr3t") || strings.Contains(s.migrations[1].Failure, "s3cr3t") {
+		t.Errorf("secret in error: %v", err)
+	}
+
+	m = NewMigrator(t.Logf, db, &memSupport{})
+	m.SetSecretResolver(SecretResolverFunc(func(ctx context.Context, name string) (string, bool, error) {
+		return "", false, fmt.Errorf("vault sealed")
+	}))
+	m.AddSQLMigration("1", "roles", script)
+	if err := m.Migrate(); err == nil || !strings.Contains(err.Error(), "resolve secret: replication_password") {
+		t.Errorf("want resolve error, got: %v", err)
+	}
+}
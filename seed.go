@@ -0,0 +1,197 @@
+package migrate
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultSeedTable is the name of the table seeds are tracked in, see NewSeeder.
+const DefaultSeedTable = DefaultTable + "_seeds"
+
+// Seeder applies seed data, e.g. reference data or fixtures for development. Seeds are tracked apart from the schema
+// migrations: they have no version, are applied again whenever their content changes or on Reseed, and are restricted
+// to environments with OnlyIn.
+type Seeder struct {
+	m *Migrator
+}
+
+// NewSeeder creates a Seeder for db. support has to keep a table of its own for the seeds, e.g.
+// NewSQLiteSupport(WithTable(DefaultSeedTable)).
+func NewSeeder(log LogFunc, db *sql.DB, support Support) *Seeder {
+	return &Seeder{m: NewMigrator(log, db, support)}
+}
+
+// Migrator returns the Migrator that applies the seeds, e.g. to configure its logger or hooks.
+func (s *Seeder) Migrator() *Migrator {
+	return s.m
+}
+
+// SetEnvironment sets the active environments. Seeds restricted by OnlyIn to none of them are skipped.
+func (s *Seeder) SetEnvironment(active ...string) {
+	s.m.SetEnvironment(active...)
+}
+
+// AddSQLSeed adds a seed that executes script.
+func (s *Seeder) AddSQLSeed(name string, script string, opts ...MigrationOption) {
+	s.m.AddRepeatableSQLMigration(name, script, opts...)
+}
+
+// AddGoSeed adds a seed that executes execute. It is applied again whenever its Fingerprint changes.
+func (s *Seeder) AddGoSeed(name string, execute CommandFunc, opts ...MigrationOption) {
+	s.m.AddRepeatableGoMigration(name, execute, opts...)
+}
+
+// AddFixture adds a seed that inserts the rows read from r in the format into table, see FixtureScript.
+func (s *Seeder) AddFixture(name string, table string, format ExportFormat, r io.Reader, opts ...MigrationOption) error {
+	script, err := FixtureScript(table, format, r)
+	if err != nil {
+		return fmt.Errorf("fixture: %s: %+v", name, err)
+	}
+	s.AddSQLSeed(name, script, opts...)
+	return nil
+}
+
+// AddFixtureFile adds a seed that inserts the rows of a .csv or .json file into table. The seed is named after the file.
+func (s *Seeder) AddFixtureFile(table string, path string, opts ...MigrationOption) error {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	ext := filepath.Ext(path)
+	return s.AddFixture(filepath.Base(path), table, ExportFormat(strings.TrimPrefix(ext, ".")), bytes.NewReader(bs), opts...)
+}
+
+// Seed applies the seeds that have not been applied yet or whose content changed.
+func (s *Seeder) Seed() error {
+	return s.m.Migrate()
+}
+
+// Reseed applies all seeds of the active environments again, e.g. after the tables have been truncated.
+func (s *Seeder) Reseed() error {
+	return s.m.withLock(func() error {
+		if err := s.m.initSession(); err != nil {
+			return err
+		}
+		exists, err := s.m.support.ExistsMigrationsTable(s.m.db)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if err := s.m.createMigrationsTable(); err != nil {
+				return err
+			}
+		}
+		installed, err := s.m.listMigrations()
+		if err != nil {
+			return err
+		}
+		rank := 0
+		for _, mig := range installed {
+			if mig.Rank > rank {
+				rank = mig.Rank
+			}
+		}
+		_, seeds := s.m.registered()
+		for _, mig := range seeds {
+			rank++
+			mig.Rank = rank
+			if !s.m.inEnvironment(mig) {
+				if err := s.m.skipInactive(mig); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := s.m.install(mig); err != nil {
+				return s.m.onError(mig, err)
+			}
+		}
+		return nil
+	})
+}
+
+// FixtureScript returns the INSERT statements for the rows read from r. A CSV fixture starts with a header of the column
+// names and has empty fields for NULL. A JSON fixture is an array of objects with the column names as keys.
+func FixtureScript(table string, format ExportFormat, r io.Reader) (string, error) {
+	var columns []string
+	var rows [][]string
+	switch format {
+	case FormatCSV:
+		records, err := csv.NewReader(r).ReadAll()
+		if err != nil {
+			return "", err
+		}
+		if len(records) == 0 {
+			return "", fmt.Errorf("no header")
+		}
+		columns = records[0]
+		for _, rec := range records[1:] {
+			row := make([]string, len(rec))
+			for i, v := range rec {
+				row[i] = sqlLiteral(v)
+			}
+			rows = append(rows, row)
+		}
+	case FormatJSON:
+		objects := []map[string]interface{}{}
+		dec := json.NewDecoder(r)
+		dec.UseNumber()
+		if err := dec.Decode(&objects); err != nil {
+			return "", err
+		}
+		seen := map[string]bool{}
+		for _, o := range objects {
+			for k := range o {
+				if !seen[k] {
+					seen[k] = true
+					columns = append(columns, k)
+				}
+			}
+		}
+		sort.Strings(columns)
+		for _, o := range objects {
+			row := make([]string, len(columns))
+			for i, c := range columns {
+				v, err := jsonLiteral(o[c])
+				if err != nil {
+					return "", fmt.Errorf("column %s: %+v", c, err)
+				}
+				row[i] = v
+			}
+			rows = append(rows, row)
+		}
+	default:
+		return "", fmt.Errorf("unknown format: %s", format)
+	}
+	b := &strings.Builder{}
+	for _, row := range rows {
+		fmt.Fprintf(b, "INSERT INTO %s (%s) VALUES (%s);\n", table, strings.Join(columns, ", "), strings.Join(row, ", "))
+	}
+	return b.String(), nil
+}
+
+// jsonLiteral renders a decoded JSON value as a SQL literal.
+func jsonLiteral(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return "'" + strings.Replace(v, "'", "''", -1) + "'", nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	default:
+		return "", fmt.Errorf("unsupported value: %v", v)
+	}
+}
@@ -0,0 +1,41 @@
+package migrate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSeeder(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := NewSeeder(t.Logf, db, &memSupport{})
+	s.SetEnvironment("dev")
+	if err := s.AddFixture("countries", "countries", FormatCSV, strings.NewReader("code,name\nde,Germany\nfr,\n")); err != nil {
+		t.Fatalf("add fixture: %v", err)
+	}
+	if err := s.AddFixture("users", "users", FormatJSON, strings.NewReader(`[{"id": 1, "name": "O'Brien", "admin": true}]`), OnlyIn("dev")); err != nil {
+		t.Fatalf("add fixture: %v", err)
+	}
+	s.AddSQLSeed("demo", "INSERT INTO demo VALUES (1);", OnlyIn("demo"))
+	if err := s.Seed(); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	if err := s.Seed(); err != nil {
+		t.Fatalf("seed again: %v", err)
+	}
+	want := []string{
+		"INSERT INTO countries (code, name) VALUES ('de', 'Germany');",
+		"INSERT INTO countries (code, name) VALUES ('fr', NULL);",
+		"INSERT INTO users (admin, id, name) VALUES (TRUE, 1, 'O''Brien');",
+	}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	if err := s.Reseed(); err != nil {
+		t.Fatalf("reseed: %v", err)
+	}
+	if got := log.Statements(); !reflect.DeepEqual(append(want, want...), got) {
+		t.Errorf("want seeds applied again, got: %q", got)
+	}
+}
@@ -0,0 +1,94 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrServerVersion is reported if a pending migration requires a newer version of the database server.
+var ErrServerVersion = errors.New("server version too old")
+
+// Server identifies the product and version of a database server.
+type Server struct {
+	Product string `json:"product"`
+	Version string `json:"version"`
+}
+
+func (s Server) String() string {
+	return s.Product + " " + s.Version
+}
+
+// ServerDetector is implemented by Support implementations that detect the product and version of the server.
+// It is required by migrations with MinServerVersion.
+type ServerDetector interface {
+	DetectServer(con *sql.DB) (Server, error)
+}
+
+// MinServerVersion restricts a migration to servers of at least version, e.g. MinServerVersion("3.31") for a SQLite
+// script using generated columns. Migrate checks the requirements of all pending migrations before executing any.
+func MinServerVersion(version string) MigrationOption {
+	return func(o *MigrationOptions) {
+		o.MinServerVersion = version
+	}
+}
+
+// Server returns the product and version of the database server. The Support has to implement ServerDetector.
+func (m *Migrator) Server() (Server, error) {
+	sd, ok := m.support.(ServerDetector)
+	if !ok {
+		return Server{}, fmt.Errorf("support does not detect the server: %T", m.support)
+	}
+	return sd.DetectServer(m.db)
+}
+
+// checkServer detects the server at the start of a run and verifies the server version requirements of pending. A
+// server that cannot be detected only fails the run if a pending migration has a requirement.
+func (m *Migrator) checkServer(pending Migrations) error {
+	required := false
+	for _, mig := range pending {
+		if mig.Options.MinServerVersion != "" {
+			required = true
+		}
+	}
+	sd, ok := m.support.(ServerDetector)
+	if !ok {
+		if required {
+			return fmt.Errorf("server version requirement needs a support that detects the server: %T", m.support)
+		}
+		return nil
+	}
+	s, err := sd.DetectServer(m.db)
+	if err != nil && required {
+		return fmt.Errorf("detect server: %+v", err)
+	}
+	if err != nil {
+		m.log(LevelWarn, "unable to detect server", Fields{"error": err})
+		return nil
+	}
+	m.log(LevelDebug, "detected server", Fields{"product": s.Product, "version": s.Version})
+	m.collect(func(r *MigrationResult) { r.Server = &s })
+	for _, mig := range pending {
+		if min := mig.Options.MinServerVersion; min != "" && compareDottedVersions(s.Version, min) < 0 {
+			return &MigrationError{
+				Err:       ErrServerVersion,
+				Migration: mig,
+				Detail:    fmt.Sprintf("requires %s, found %s", min, s),
+			}
+		}
+	}
+	return nil
+}
+
+// serverVersion returns the first word of text that starts with a digit or with "v" followed by a digit, e.g.
+// "v23.1.2" of "CockroachDB CCL v23.1.2 (x86_64-pc-linux-gnu, ...)".
+func serverVersion(text string) string {
+	for _, word := range strings.Fields(text) {
+		w := strings.TrimPrefix(word, "v")
+		if w != "" && w[0] >= '0' && w[0] <= '9' {
+			return strings.TrimRight(word, ",")
+		}
+	}
+	return text
+}
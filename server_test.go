@@ -0,0 +1,86 @@
+package migrate
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type serverSupport struct {
+	memSupport
+	server Server
+	err    error
+}
+
+func (s *serverSupport) DetectServer(con *sql.DB) (Server, error) {
+	return s.server, s.err
+}
+
+func TestMigrateMinServerVersion(t *testing.T) {
+	noop := func(con *sql.DB) error { return nil }
+	s := &serverSupport{server: Server{Product: "SQLite", Version: "3.22.0"}}
+	m := newTestMigrator(t, s)
+	m.AddGoMigration("1", "one", noop)
+	res, err := m.MigrateWithResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Server == nil || *res.Server != s.server {
+		t.Errorf("want server in result, got: %v", res.Server)
+	}
+	m.AddGoMigration("2", "generated columns", noop, MinServerVersion("3.31"))
+	m.AddGoMigration("3", "three", noop)
+	if err := m.Migrate(); !errors.Is(err, ErrServerVersion) {
+		t.Fatalf("want server version error, got: %v", err)
+	}
+	if len(s.migrations) != 1 {
+		t.Errorf("no migration must be applied before the requirements are checked: %d", len(s.migrations))
+	}
+	s.server.Version = "3.31.1"
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	info := m.Info()
+	bs, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := Info{}
+	if err := json.Unmarshal(bs, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Server == nil || decoded.Server.String() != "SQLite 3.31.1" {
+		t.Errorf("want server in info, got: %v", decoded.Server)
+	}
+
+	other := newTestMigrator(t, &memSupport{})
+	other.AddGoMigration("1", "one", noop, MinServerVersion("1"))
+	if err := other.Migrate(); err == nil {
+		t.Errorf("want error for a support that does not detect the server")
+	}
+}
+
+func TestMigrateUndetectedServer(t *testing.T) {
+	noop := func(con *sql.DB) error { return nil }
+	s := &serverSupport{err: errors.New("permission denied")}
+	m := newTestMigrator(t, s)
+	m.AddGoMigration("1", "one", noop)
+	res, err := m.MigrateWithResult()
+	if err != nil {
+		t.Fatalf("want no error without a server requirement, got: %v", err)
+	}
+	if res.Server != nil {
+		t.Errorf("want no server in result, got: %v", res.Server)
+	}
+	m.AddGoMigration("2", "two", noop, MinServerVersion("3.31"))
+	if err := m.Migrate(); err == nil {
+		t.Errorf("want error for an undetected server with a requirement")
+	}
+}
+
+func TestServerVersion(t *testing.T) {
+	if got := serverVersion("CockroachDB CCL v23.1.2 (x86_64-pc-linux-gnu, built 2023/05/25)"); got != "v23.1.2" {
+		t.Errorf("unexpected version: %s", got)
+	}
+}
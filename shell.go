@@ -0,0 +1,84 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const TypeShell Type = "Shell"
+
+// ShellError is returned by a shell migration whose command exited with a non-zero code.
+type ShellError struct {
+	ExitCode int
+	Output   string
+}
+
+func (e *ShellError) Error() string {
+	return fmt.Sprintf("exit code %d: %s", e.ExitCode, e.Output)
+}
+
+// SetAllowShell allows the execution of shell migrations. They are refused by default.
+func (m *Migrator) SetAllowShell(allow bool) {
+	m.allowShell = allow
+}
+
+// shellRun is the outcome of the last run of the command of a shell migration.
+type shellRun struct {
+	output string
+}
+
+// AddShellMigration adds a migration that runs command with "sh -c".
+// The exit code and the combined output of the command are logged and recorded: the output in Migration.Output and
+// the exit code of a failed command, as a ShellError, in Migration.Failure.
+func (m *Migrator) AddShellMigration(version Version, description string, command string) {
+	run := &shellRun{}
+	m.Add(Migration{
+		Version:     version,
+		Description: description,
+		Type:        TypeShell,
+		Checksum:    SQLChecksum(command),
+		Script:      command,
+		Execute:     m.shellExecutor(version, description, command, run),
+		shell:       run,
+	})
+}
+
+func (m *Migrator) AddRepeatableShellMigration(description string, command string) {
+	m.AddShellMigration(VersionRepeatable, description, command)
+}
+
+func (m *Migrator) shellExecutor(version Version, description string, command string, run *shellRun) CommandFunc {
+	return func(con *sql.DB) error {
+		run.output = ""
+		if !m.allowShell {
+			return fmt.Errorf("shell migrations are not allowed: %s", description)
+		}
+		out, err := exec.Command("sh", "-c", command).CombinedOutput()
+		run.output = strings.TrimSpace(string(out))
+		code := 0
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			code = exitErr.ExitCode()
+			err = &ShellError{
+				ExitCode: code,
+				Output:   run.output,
+			}
+		}
+		m.log(LevelInfo, "shell output", Fields{
+			"version":     version,
+			"description": description,
+			"exit_code":   code,
+			"output":      run.output,
+		})
+		return err
+	}
+}
+
+// withOutput records the output of the last run of the command of the shell migration mig.
+func (mig Migration) withOutput() Migration {
+	if mig.shell != nil {
+		mig.Output = mig.shell.output
+	}
+	return mig
+}
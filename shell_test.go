@@ -0,0 +1,32 @@
+package migrate
+
+import "testing"
+
+func TestShellMigration(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	m.AddShellMigration("1", "echo", "echo hello")
+	if err := m.Migrate(); err == nil {
+		t.Fatalf("want shell migrations to be refused")
+	}
+	s.migrations = nil
+	m.SetAllowShell(true)
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	m.AddShellMigration("2", "exit", "echo failing; exit 3")
+	err := m.Migrate()
+	shellErr, ok := err.(*ShellError)
+	if !ok {
+		t.Fatalf("want shell error, got: %v", err)
+	}
+	if shellErr.ExitCode != 3 || shellErr.Output != "failing" {
+		t.Errorf("unexpected shell error: %+v", shellErr)
+	}
+	if want, got := "hello", s.migrations[0].Output; want != got {
+		t.Errorf("want recorded output: %q, got: %q", want, got)
+	}
+	if rec := s.migrations[1]; rec.Output != "failing" || rec.Failure != "exit code 3: failing" {
+		t.Errorf("want recorded output and exit code, got: %q, %q", rec.Output, rec.Failure)
+	}
+}
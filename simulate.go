@@ -0,0 +1,49 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// sqliteDrivers are the names under which common SQLite drivers register themselves.
+var sqliteDrivers = []string{"sqlite3", "sqlite"}
+
+// Simulate applies ms to a fresh in-memory SQLite database, validates the result and returns its Info.
+// It is meant as a fast smoke test for a migration set and requires a SQLite driver to be registered.
+func Simulate(ms Migrations) (Info, error) {
+	driver := sqliteDriver()
+	if driver == "" {
+		return Info{}, fmt.Errorf("simulate: no sqlite driver registered")
+	}
+	db, err := sql.Open(driver, ":memory:")
+	if err != nil {
+		return Info{}, err
+	}
+	defer db.Close()
+	// every connection to :memory: opens a new database
+	db.SetMaxOpenConns(1)
+	m := NewMigrator(func(format string, args ...interface{}) {}, db, SQLiteSupport{})
+	for _, mig := range ms {
+		m.Add(mig)
+	}
+	if err := m.Migrate(); err != nil {
+		return m.Info(), err
+	}
+	if err := m.Validate(); err != nil {
+		return m.Info(), err
+	}
+	return m.Info(), nil
+}
+
+func sqliteDriver() string {
+	registered := map[string]bool{}
+	for _, d := range sql.Drivers() {
+		registered[d] = true
+	}
+	for _, d := range sqliteDrivers {
+		if registered[d] {
+			return d
+		}
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Snapshotter is implemented by Support implementations that are able to capture a migrated database as a template
+// and to stamp out fresh copies of it, which is a lot faster than replaying all migrations for every test database.
+type Snapshotter interface {
+	// Snapshot captures the database of con as template, e.g. a file for SQLite or a database name for Postgres.
+	Snapshot(con *sql.DB, template string) error
+	// Restore creates target as a copy of template.
+	Restore(con *sql.DB, template string, target string) error
+}
+
+// Snapshot captures the current state of the database as template.
+func (m *Migrator) Snapshot(template string) error {
+	s, ok := m.support.(Snapshotter)
+	if !ok {
+		return fmt.Errorf("support does not snapshot: %T", m.support)
+	}
+	if err := m.initSession(); err != nil {
+		return err
+	}
+	m.log(LevelInfo, "snapshot", Fields{"template": template})
+	return s.Snapshot(m.db, template)
+}
+
+// Restore creates target as a copy of a template captured by Snapshot.
+func (m *Migrator) Restore(template string, target string) error {
+	s, ok := m.support.(Snapshotter)
+	if !ok {
+		return fmt.Errorf("support does not snapshot: %T", m.support)
+	}
+	m.log(LevelInfo, "restore", Fields{"template": template, "target": target})
+	return s.Restore(m.db, template, target)
+}
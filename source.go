@@ -0,0 +1,69 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+)
+
+// NewSQLSourceMigration creates a SQL migration whose script is opened by open whenever it is read. The script is
+// streamed once to compute its checksum and again when it is executed, so it is never held in memory as a whole.
+func NewSQLSourceMigration(version Version, description string, open func() (io.ReadCloser, error), opts ...MigrationOption) (Migration, error) {
+	checksum, err := sourceChecksum(open)
+	if err != nil {
+		return Migration{}, fmt.Errorf("checksum: %s: %+v", description, err)
+	}
+	header, err := sourceHeader(open)
+	if err != nil {
+		return Migration{}, fmt.Errorf("header: %s: %+v", description, err)
+	}
+	return Migration{
+		Version:     version,
+		Description: description,
+		Type:        TypeSQL,
+		Checksum:    checksum,
+		Source:      open,
+		Execute:     sourceExecutor(open),
+	}.withHeader(header).withOptions(opts), nil
+}
+
+// AddSQLFileMigration adds a SQL migration whose script is streamed from the file path, e.g. a large seed script.
+func (m *Migrator) AddSQLFileMigration(version Version, description string, path string, opts ...MigrationOption) error {
+	mig, err := NewSQLSourceMigration(version, description, func() (io.ReadCloser, error) {
+		return os.Open(path)
+	}, opts...)
+	if err != nil {
+		return err
+	}
+	m.Add(mig)
+	return nil
+}
+
+// sourceChecksum computes the same checksum as SQLChecksum for the script read from open.
+func sourceChecksum(open func() (io.ReadCloser, error)) (string, error) {
+	rc, err := open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	return ChecksumMD5.SumReader(rc)
+}
+
+func sourceExecutor(open func() (io.ReadCloser, error)) CommandFunc {
+	return func(db *sql.DB) error {
+		rc, err := open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		sr := StatementsFromReader(rc)
+		for sr.Next() {
+			if err := execStatement(context.Background(), db, sr.Statement()); err != nil {
+				return err
+			}
+		}
+		return sr.Err()
+	}
+}
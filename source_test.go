@@ -0,0 +1,59 @@
+package migrate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStatementsFromReader(t *testing.T) {
+	long := "INSERT INTO t VALUES ('" + strings.Repeat("x", 1<<20) + "');"
+	sr := StatementsFromReader(strings.NewReader("CREATE TABLE t (v TEXT);\r\n" + long + "\nSELECT 1"))
+	got := []string{}
+	for sr.Next() {
+		got = append(got, sr.Statement().SQL)
+	}
+	if err := sr.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"CREATE TABLE t (v TEXT);", long, "SELECT 1"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("unexpected statements: %d", len(got))
+	}
+}
+
+func TestAddSQLFileMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	script := "INSERT INTO t VALUES (1);\nINSERT INTO t VALUES (2);\n"
+	path := filepath.Join(dir, "seed.sql")
+	if err := ioutil.WriteFile(path, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	if err := m.AddSQLFileMigration("1", "seed", path); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddSQLFileMigration("2", "missing", filepath.Join(dir, "missing.sql")); err == nil {
+		t.Errorf("want error for missing file")
+	}
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"INSERT INTO t VALUES (1);", "INSERT INTO t VALUES (2);"}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	if got := s.migrations[0].Checksum; got != SQLChecksum(script) {
+		t.Errorf("want checksum %s, got %s", SQLChecksum(script), got)
+	}
+}
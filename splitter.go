@@ -0,0 +1,24 @@
+package migrate
+
+// SplitterSupport is implemented by Support implementations whose dialect needs a Splitter other than DefaultSplitter.
+type SplitterSupport interface {
+	Splitter() Splitter
+}
+
+// SetSplitter overrides the Splitter used to split SQL migrations into statements.
+func (m *Migrator) SetSplitter(splitter Splitter) {
+	m.customSplitter = &splitter
+}
+
+func (m *Migrator) splitter() Splitter {
+	s := DefaultSplitter
+	if m.customSplitter != nil {
+		s = *m.customSplitter
+	} else if ss, ok := m.support.(SplitterSupport); ok {
+		s = ss.Splitter()
+	}
+	if m.normalize {
+		s.Normalize = true
+	}
+	return s
+}
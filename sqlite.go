@@ -1,53 +1,598 @@
 package migrate
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
 	"time"
 )
 
 var (
-	_ Support = SQLiteSupport{}
+	_ Support                   = SQLiteSupport{}
+	_ ContextSupport            = SQLiteSupport{}
+	_ RunRecorder               = SQLiteSupport{}
+	_ Locker                    = SQLiteSupport{}
+	_ SchemaChecker             = SQLiteSupport{}
+	_ Snapshotter               = SQLiteSupport{}
+	_ Cleaner                   = SQLiteSupport{}
+	_ ReportingCleaner          = SQLiteSupport{}
+	_ Maintainer                = SQLiteSupport{}
+	_ LockWaitSupport           = SQLiteSupport{}
+	_ ForceUnlocker             = SQLiteSupport{}
+	_ SessionInitializer        = SQLiteSupport{}
+	_ SessionInitializerContext = SQLiteSupport{}
+	_ MigrationUpdater          = SQLiteSupport{}
+	_ SchemaDumper              = SQLiteSupport{}
+	_ MigrationFinder           = SQLiteSupport{}
+	_ Backuper                  = SQLiteSupport{}
+	_ ScriptDeleter             = SQLiteSupport{}
+	_ MigrationDeleter          = SQLiteSupport{}
+	_ ScriptStore               = SQLiteSupport{}
+	_ ConfigurableSupport       = SQLiteSupport{}
+	_ Summarizer                = SQLiteSupport{}
+	_ ForeignKeySupport         = SQLiteSupport{}
 )
 
-type SQLiteSupport struct{}
+// NewSQLiteSupport creates a SQLiteSupport. The zero value uses the table "migrations" in the main database.
+func NewSQLiteSupport(opts ...SupportOption) SQLiteSupport {
+	return SQLiteSupport{
+		config: newSupportConfig(opts),
+	}
+}
+
+// Configure returns a copy of the SQLiteSupport with opts applied to its configuration.
+func (s SQLiteSupport) Configure(opts ...SupportOption) Support {
+	for _, opt := range opts {
+		opt(&s.config)
+	}
+	return s
+}
+
+type SQLiteSupport struct {
+	config SupportConfig
+}
+
+// master returns the sqlite_master table of the configured schema.
+func (s SQLiteSupport) master() string {
+	return sqliteMaster(s.config.Schema)
+}
+
+// sqliteMaster returns the sqlite_master table of schema.
+func sqliteMaster(schema string) string {
+	if schema == "" {
+		return "sqlite_master"
+	}
+	return quoteIdent(schema) + ".sqlite_master"
+}
+
+// schemas returns the configured schema followed by the attached ones. An attached database that is the configured
+// schema, i.e. the metadata tables live in it, is listed once.
+func (s SQLiteSupport) schemas() []string {
+	schemas := []string{s.config.Schema}
+	for _, a := range s.config.Attachments {
+		if a.Schema != s.config.Schema {
+			schemas = append(schemas, a.Schema)
+		}
+	}
+	return schemas
+}
+
+// sqlitePragma returns the statement of the pragma for schema, the main database if schema is empty.
+func sqlitePragma(schema string, pragma string) string {
+	if schema == "" {
+		return "PRAGMA " + pragma + ";"
+	}
+	return "PRAGMA " + quoteIdent(schema) + "." + pragma + ";"
+}
 
-func (SQLiteSupport) ExistsMigrationsTable(db *sql.DB) (bool, error) {
+// InitSession attaches the configured databases unless they are already attached.
+func (s SQLiteSupport) InitSession(db *sql.DB) error {
+	return s.InitSessionContext(context.Background(), db)
+}
+
+// InitSessionContext attaches the configured databases on q unless they are already attached.
+func (s SQLiteSupport) InitSessionContext(ctx context.Context, q Querier) error {
+	if len(s.config.Attachments) == 0 {
+		return nil
+	}
+	rows, err := q.QueryContext(ctx, `PRAGMA database_list;`)
+	if err != nil {
+		return err
+	}
+	attached := map[string]bool{}
+	for rows.Next() {
+		var seq int
+		var name string
+		var file sql.NullString
+		if err := rows.Scan(&seq, &name, &file); err != nil {
+			rows.Close()
+			return err
+		}
+		attached[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, a := range s.config.Attachments {
+		if attached[a.Schema] {
+			continue
+		}
+		if _, err := q.ExecContext(ctx, `ATTACH DATABASE ? AS `+quoteIdent(a.Schema)+`;`, a.File); err != nil {
+			return fmt.Errorf("attach %s: %+v", a.Schema, err)
+		}
+	}
+	return nil
+}
+
+func (s SQLiteSupport) ExistsMigrationsTable(db *sql.DB) (bool, error) {
+	return s.ExistsMigrationsTableContext(context.Background(), db)
+}
+
+func (s SQLiteSupport) ExistsMigrationsTableContext(ctx context.Context, q Querier) (bool, error) {
 	var exists bool
-	row := db.QueryRow(`SELECT count(tbl_name) FROM sqlite_master WHERE type='table' AND tbl_name='migrations';`)
+	row := q.QueryRowContext(ctx, `SELECT count(tbl_name) FROM `+s.master()+` WHERE type='table' AND tbl_name=?;`, s.config.TableName(""))
 	err := row.Scan(&exists)
 	return exists, err
 }
 
-func (SQLiteSupport) CreateMigrationsTable(db *sql.DB) error {
-	_, err := db.Exec(sqliteMigrations)
+func (s SQLiteSupport) IsSchemaEmpty(db *sql.DB) (bool, error) {
+	var count int
+	row := db.QueryRow(`SELECT count(*) FROM `+s.master()+` WHERE type='table' AND name NOT LIKE 'sqlite_%' AND name NOT IN (?, ?, ?, ?);`,
+		s.config.TableName(""),
+		s.config.TableName("_runs"),
+		s.config.TableName("_lock"),
+		s.config.TableName("_schema"),
+	)
+	err := row.Scan(&count)
+	return count == 0, err
+}
+
+// DumpSchema writes the DDL of the tables, indexes, views and triggers of the configured schema ordered by type and
+// name. The metadata tables are omitted.
+func (s SQLiteSupport) DumpSchema(db *sql.DB, w io.Writer) error {
+	rows, err := db.Query(`SELECT sql FROM `+s.master()+` WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%' AND tbl_name NOT IN (?, ?, ?, ?)
+ORDER BY CASE type WHEN 'table' THEN 1 WHEN 'index' THEN 2 WHEN 'view' THEN 3 ELSE 4 END, name;`,
+		s.config.TableName(""),
+		s.config.TableName("_runs"),
+		s.config.TableName("_lock"),
+		s.config.TableName("_schema"),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ddl string
+		if err := rows.Scan(&ddl); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, ddl+";\n\n"); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s SQLiteSupport) CreateMigrationsTable(db *sql.DB) error {
+	return s.CreateMigrationsTableContext(context.Background(), db)
+}
+
+func (s SQLiteSupport) CreateMigrationsTableContext(ctx context.Context, q Querier) error {
+	_, err := q.ExecContext(ctx, fmt.Sprintf(sqliteMigrations, s.config.QualifiedName("")))
 	return err
 }
 
-func (SQLiteSupport) Clean(db *sql.DB) error {
-	var err error
-	_, err = db.Exec(`PRAGMA writable_schema = 1;`)
-	_, err = db.Exec(`DELETE FROM sqlite_master WHERE type in ('table', 'index', 'trigger');`)
-	_, err = db.Exec(`PRAGMA writable_schema = 0;`)
-	_, err = db.Exec(`VACUUM;`)
+func (s SQLiteSupport) CreateMigrationsTableScript() string {
+	return fmt.Sprintf(sqliteMigrations, s.config.QualifiedName(""))
+}
+
+// StoreScript stores script with the record with the rank of m.
+func (s SQLiteSupport) StoreScript(db *sql.DB, m Migration, script string) error {
+	data, err := s.config.Compression.Encode(script)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`UPDATE `+s.config.QualifiedName("")+` SET script = ? WHERE rank = ?;`, data, m.Rank)
+	return err
+}
+
+func (s SQLiteSupport) LoadScript(db *sql.DB, m Migration) (string, bool, error) {
+	var data []byte
+	err := db.QueryRow(`SELECT script FROM `+s.config.QualifiedName("")+` WHERE rank = ?;`, m.Rank).Scan(&data)
+	if err == sql.ErrNoRows || (err == nil && data == nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	script, err := decodeStored(data)
+	return script, err == nil, err
+}
+
+// DeleteMigration deletes the record with the rank of m.
+func (s SQLiteSupport) DeleteMigration(db *sql.DB, m Migration) error {
+	_, err := db.Exec(s.DeleteMigrationScript(m))
+	return err
+}
+
+// DeleteMigrationScript renders the statement that deletes the record of m.
+func (s SQLiteSupport) DeleteMigrationScript(m Migration) string {
+	return `DELETE FROM ` + s.config.QualifiedName("") + ` WHERE rank = ` + sqlInt(m.Rank) + `;`
+}
+
+func (s SQLiteSupport) RecordMigrationScript(m Migration) string {
+	return `INSERT INTO ` + s.config.QualifiedName("") + ` (rank, version, description, type, checksum, date, execution_time, status, installed_by, author, ticket) VALUES (` +
+		strings.Join([]string{
+			sqlInt(m.Rank),
+			sqlLiteral(string(m.Version)),
+			sqlLiteral(m.Description),
+			sqlLiteral(string(m.Type)),
+			sqlLiteral(m.Checksum),
+			sqlLiteral(m.Date.Format(time.RFC3339)),
+			sqlInt(int(milliseconds(m.ExecutionTime))),
+			sqlLiteral(string(m.Status)),
+			sqlLiteral(m.InstalledBy),
+			sqlLiteral(m.Author),
+			sqlLiteral(m.Ticket),
+		}, ", ") + `);`
+}
+
+// Clean drops the objects of the configured schema and the attached ones.
+func (s SQLiteSupport) Clean(db *sql.DB) error {
+	return s.CleanReporting(db, func(Object) {})
+}
+
+// CleanReporting drops the triggers, views, indexes and tables of the configured schema and the attached ones with
+// DROP statements and calls dropped for each of them. Foreign keys are disabled while dropping and restored afterwards.
+// The databases are vacuumed afterwards, those in WAL mode after a checkpoint that truncates their WAL.
+func (s SQLiteSupport) CleanReporting(db *sql.DB, dropped func(Object)) (err error) {
+	ctx := context.Background()
+	// pragmas apply to a single connection
+	con, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer con.Close()
+	foreignKeys, err := s.DisableForeignKeys(ctx, con)
+	if err != nil {
+		return fmt.Errorf("disable foreign keys: %+v", err)
+	}
+	if foreignKeys {
+		defer func() {
+			if ferr := s.EnableForeignKeys(ctx, con); ferr != nil && err == nil {
+				err = fmt.Errorf("enable foreign keys: %+v", ferr)
+			}
+		}()
+	}
+	objects, err := s.listObjects(ctx, con)
+	if err != nil {
+		return fmt.Errorf("list objects: %+v", err)
+	}
+	for _, o := range (CleanOptions{}).filter(objects) {
+		stmt := s.DropStatement(o)
+		if _, err := con.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("clean: %s: %+v", stmt, err)
+		}
+		dropped(o)
+	}
+	for _, schema := range s.schemas() {
+		if err := s.checkpoint(ctx, con, schema); err != nil {
+			return fmt.Errorf("checkpoint: %+v", err)
+		}
+		stmt := `VACUUM;`
+		if schema != "" {
+			stmt = `VACUUM ` + quoteIdent(schema) + `;`
+		}
+		if _, err := con.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("vacuum: %+v", err)
+		}
+	}
+	return nil
+}
+
+// checkpoint writes the WAL of schema back into the database and truncates it if the database is in WAL mode, so that
+// VACUUM does not have to work around the frames of the WAL and leaves it small.
+func (s SQLiteSupport) checkpoint(ctx context.Context, q Querier, schema string) error {
+	var mode string
+	if err := q.QueryRowContext(ctx, sqlitePragma(schema, "journal_mode")).Scan(&mode); err != nil {
+		return err
+	}
+	if !strings.EqualFold(mode, "wal") {
+		return nil
+	}
+	var busy, frames, checkpointed int
+	if err := q.QueryRowContext(ctx, sqlitePragma(schema, "wal_checkpoint(TRUNCATE)")).Scan(&busy, &frames, &checkpointed); err != nil {
+		return err
+	}
+	if busy != 0 {
+		return fmt.Errorf("%s: WAL is in use by another connection", sqliteSchemaName(schema))
+	}
+	return nil
+}
+
+func sqliteSchemaName(schema string) string {
+	if schema == "" {
+		return "main"
+	}
+	return schema
+}
+
+// DisableForeignKeys disables the enforcement of foreign keys on con. SQLite ignores the pragma within a transaction,
+// so it has to be set on the connection before the transaction of a migration begins.
+func (s SQLiteSupport) DisableForeignKeys(ctx context.Context, con *sql.Conn) (bool, error) {
+	var enabled int
+	if err := con.QueryRowContext(ctx, `PRAGMA foreign_keys;`).Scan(&enabled); err != nil {
+		return false, err
+	}
+	if enabled == 0 {
+		return false, nil
+	}
+	if _, err := con.ExecContext(ctx, `PRAGMA foreign_keys = OFF;`); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// EnableForeignKeys enables the enforcement of foreign keys on con.
+func (s SQLiteSupport) EnableForeignKeys(ctx context.Context, con *sql.Conn) error {
+	_, err := con.ExecContext(ctx, `PRAGMA foreign_keys = ON;`)
+	return err
+}
+
+// CheckForeignKeys lists the rows of the configured schema and the attached ones that violate a foreign key.
+func (s SQLiteSupport) CheckForeignKeys(ctx context.Context, q Querier) ([]ForeignKeyViolation, error) {
+	violations := []ForeignKeyViolation{}
+	for _, schema := range s.schemas() {
+		rows, err := q.QueryContext(ctx, sqlitePragma(schema, "foreign_key_check"))
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var v ForeignKeyViolation
+			var rowid sql.NullInt64
+			var fkid int
+			if err := rows.Scan(&v.Table, &rowid, &v.Parent, &fkid); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			v.RowID = rowid.Int64
+			if schema != "" {
+				v.Table = schema + "." + v.Table
+			}
+			violations = append(violations, v)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return violations, nil
+}
+
+// SetLockWaitTimeout sets busy_timeout, which limits how long a statement waits for a locked database.
+func (s SQLiteSupport) SetLockWaitTimeout(ctx context.Context, q Querier, timeout time.Duration) error {
+	_, err := q.ExecContext(ctx, fmt.Sprintf("PRAGMA busy_timeout = %d;", timeout/time.Millisecond))
+	return err
+}
+
+// Dialects returns sqlite.
+func (s SQLiteSupport) Dialects() []string {
+	return []string{"sqlite"}
+}
+
+// DetectServer returns the version of the SQLite library.
+func (s SQLiteSupport) DetectServer(db *sql.DB) (Server, error) {
+	srv := Server{Product: "SQLite"}
+	err := db.QueryRow(`SELECT sqlite_version();`).Scan(&srv.Version)
+	return srv, err
+}
+
+// Maintain analyzes the configured schema and the attached ones.
+func (s SQLiteSupport) Maintain(db *sql.DB) error {
+	for _, schema := range s.schemas() {
+		stmt := `ANALYZE;`
+		if schema != "" {
+			stmt = `ANALYZE ` + quoteIdent(schema) + `;`
+		}
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListObjects lists the objects of the configured schema and the attached ones.
+func (s SQLiteSupport) ListObjects(db *sql.DB) ([]Object, error) {
+	return s.listObjects(context.Background(), db)
+}
+
+func (s SQLiteSupport) listObjects(ctx context.Context, q Querier) ([]Object, error) {
+	objects := []Object{}
+	for i, schema := range s.schemas() {
+		rows, err := q.QueryContext(ctx, `SELECT type, name, tbl_name FROM `+sqliteMaster(schema)+` WHERE type IN ('table', 'view', 'index', 'trigger') AND name NOT LIKE 'sqlite_%' ORDER BY name;`)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var o Object
+			if err := rows.Scan(&o.Type, &o.Name, &o.Table); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if o.Table == o.Name {
+				o.Table = ""
+			}
+			if i > 0 {
+				o.Schema = schema
+			}
+			objects = append(objects, o)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return objects, nil
+}
+
+func (s SQLiteSupport) DropStatement(o Object) string {
+	schema := o.Schema
+	if schema == "" {
+		schema = s.config.Schema
+	}
+	name := quoteIdent(o.Name)
+	if schema != "" {
+		name = quoteIdent(schema) + "." + name
+	}
+	return `DROP ` + strings.ToUpper(o.Type) + ` IF EXISTS ` + name + `;`
+}
+
+func (s SQLiteSupport) MetadataUpgrades() []MetadataUpgrade {
+	return []MetadataUpgrade{
+		{Version: 1, Description: "failure diagnostics", Apply: func(db *sql.DB) error {
+			if err := s.addColumn(db, "", "failure", "TEXT"); err != nil {
+				return err
+			}
+			return s.addColumn(db, "", "failed_statement", "INTEGER")
+		}},
+		{Version: 2, Description: "installed by", Apply: func(db *sql.DB) error {
+			return s.addColumn(db, "", "installed_by", "TEXT")
+		}},
+		{Version: 3, Description: "script headers", Apply: func(db *sql.DB) error {
+			if err := s.addColumn(db, "", "author", "TEXT"); err != nil {
+				return err
+			}
+			return s.addColumn(db, "", "ticket", "TEXT")
+		}},
+		{Version: 4, Description: "recorded scripts", Apply: func(db *sql.DB) error {
+			return s.addColumn(db, "", "script", "BLOB")
+		}},
+		{Version: 5, Description: "shell output", Apply: func(db *sql.DB) error {
+			return s.addColumn(db, "", "output", "TEXT")
+		}},
+	}
+}
+
+func (s SQLiteSupport) MetadataVersion(db *sql.DB) (int, error) {
+	if _, err := db.Exec(fmt.Sprintf(sqliteSchemaVersion, s.config.QualifiedName("_schema"))); err != nil {
+		return 0, err
+	}
+	var version int
+	err := db.QueryRow(`SELECT version FROM ` + s.config.QualifiedName("_schema") + ` WHERE id = 1;`).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return version, err
+}
+
+func (s SQLiteSupport) SetMetadataVersion(db *sql.DB, version int) error {
+	if _, err := db.Exec(fmt.Sprintf(sqliteSchemaVersion, s.config.QualifiedName("_schema"))); err != nil {
+		return err
+	}
+	_, err := db.Exec(`INSERT OR REPLACE INTO `+s.config.QualifiedName("_schema")+` (id, version) VALUES (1, ?);`, version)
 	return err
 }
 
-func (SQLiteSupport) RecordMigration(db *sql.DB, m Migration) error {
-	_, err := db.Exec(`INSERT INTO migrations (rank, version, description, type, checksum, date, execution_time, status) VALUES (?, ?, ?, ?, ?, ?, ?, ?);`,
+func (s SQLiteSupport) RecordMigration(db *sql.DB, m Migration) error {
+	return s.RecordMigrationContext(context.Background(), db, m)
+}
+
+func (s SQLiteSupport) RecordMigrationContext(ctx context.Context, q Querier, m Migration) error {
+	_, err := q.ExecContext(ctx, `INSERT INTO `+s.config.QualifiedName("")+` (rank, version, description, type, checksum, date, execution_time, status, failure, failed_statement, installed_by, author, ticket, output) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
 		m.Rank,
 		string(m.Version),
 		m.Description,
 		string(m.Type),
 		m.Checksum,
 		m.Date.Format(time.RFC3339),
-		int64(m.ExecutionTime),
+		milliseconds(m.ExecutionTime),
 		string(m.Status),
+		nullString(m.Failure),
+		nullInt(m.FailedStatement),
+		nullString(m.InstalledBy),
+		nullString(m.Author),
+		nullString(m.Ticket),
+		nullString(m.Output),
 	)
 	return err
 }
 
-func (SQLiteSupport) ListMigrations(con *sql.DB) (Migrations, error) {
-	rows, err := con.Query(`SELECT rank, version, description, type, checksum, date, execution_time, status FROM migrations;`)
+func (s SQLiteSupport) UpdateMigration(db *sql.DB, m Migration) error {
+	_, err := db.Exec(`UPDATE `+s.config.QualifiedName("")+` SET version = ?, description = ?, type = ?, checksum = ?, date = ?, execution_time = ?, status = ?, failure = ?, failed_statement = ?, installed_by = ?, author = ?, ticket = ?, output = ? WHERE rank = ?;`,
+		string(m.Version),
+		m.Description,
+		string(m.Type),
+		m.Checksum,
+		m.Date.Format(time.RFC3339),
+		milliseconds(m.ExecutionTime),
+		string(m.Status),
+		nullString(m.Failure),
+		nullInt(m.FailedStatement),
+		nullString(m.InstalledBy),
+		nullString(m.Author),
+		nullString(m.Ticket),
+		nullString(m.Output),
+		m.Rank,
+	)
+	return err
+}
+
+func (s SQLiteSupport) Savepoint(name string) string {
+	return "SAVEPOINT " + name + ";"
+}
+
+func (s SQLiteSupport) RollbackToSavepoint(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + name + ";"
+}
+
+func (s SQLiteSupport) ReleaseSavepoint(name string) string {
+	return "RELEASE SAVEPOINT " + name + ";"
+}
+
+func (s SQLiteSupport) ListMigrations(db *sql.DB) (Migrations, error) {
+	return s.ListMigrationsContext(context.Background(), db)
+}
+
+func (s SQLiteSupport) ListMigrationsContext(ctx context.Context, q Querier) (Migrations, error) {
+	return s.findMigrations(ctx, q, MigrationFilter{})
+}
+
+// FindMigrations lists the recorded migrations selected by f ordered by rank.
+func (s SQLiteSupport) FindMigrations(db *sql.DB, f MigrationFilter) (Migrations, error) {
+	return s.findMigrations(context.Background(), db, f)
+}
+
+// CountMigrations returns the number of recorded migrations selected by f.
+func (s SQLiteSupport) CountMigrations(db *sql.DB, f MigrationFilter) (int, error) {
+	where, args := f.where(lowerCaseColumns(s.config.QualifiedName("")), questionMark)
+	var n int
+	err := db.QueryRow(`SELECT count(*) FROM `+s.config.QualifiedName("")+where+`;`, args...).Scan(&n)
+	return n, err
+}
+
+// SummarizeMigrations summarizes the recorded versioned migrations with a single query.
+func (s SQLiteSupport) SummarizeMigrations(db *sql.DB) (Summary, error) {
+	table := s.config.QualifiedName("")
+	sum := Summary{}
+	var latest sql.NullString
+	row := db.QueryRow(`SELECT count(*), count(CASE WHEN status = ? THEN 1 END), (SELECT version FROM `+table+` WHERE version <> ? ORDER BY rank DESC LIMIT 1) FROM `+table+` WHERE version <> ?;`,
+		string(StatusFailed), string(VersionRepeatable), string(VersionRepeatable))
+	if err := row.Scan(&sum.Versioned, &sum.Failed, &latest); err != nil {
+		return Summary{}, err
+	}
+	sum.Latest = Version(latest.String)
+	return sum, nil
+}
+
+func (s SQLiteSupport) findMigrations(ctx context.Context, q Querier, f MigrationFilter) (Migrations, error) {
+	where, args := f.where(lowerCaseColumns(s.config.QualifiedName("")), questionMark)
+	rows, err := q.QueryContext(ctx, `SELECT rank, version, description, type, checksum, date, execution_time, status, failure, failed_statement, installed_by, author, ticket, output FROM `+s.config.QualifiedName("")+where+` ORDER BY rank;`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -60,30 +605,342 @@ func (SQLiteSupport) ListMigrations(con *sql.DB) (Migrations, error) {
 		var typ string
 		var checksum string
 		var date string
-		var execution_time int
+		var execution_time int64
 		var status string
-		err := rows.Scan(&rank, &version, &description, &typ, &checksum, &date, &execution_time, &status)
+		var failure sql.NullString
+		var failedStatement sql.NullInt64
+		var installedBy sql.NullString
+		var author sql.NullString
+		var ticket sql.NullString
+		var output sql.NullString
+		err := rows.Scan(&rank, &version, &description, &typ, &checksum, &date, &execution_time, &status, &failure, &failedStatement, &installedBy, &author, &ticket, &output)
 		if err != nil {
 			return nil, err
 		}
 		d, _ := time.Parse(time.RFC3339, date)
 		m := Migration{
-			Rank:          rank,
-			Version:       Version(version),
-			Description:   description,
-			Type:          Type(typ),
-			Checksum:      checksum,
-			Date:          d,
-			ExecutionTime: execution_time,
-			Status:        Status(status),
+			Rank:            rank,
+			Version:         Version(version),
+			Description:     description,
+			Type:            Type(typ),
+			Checksum:        checksum,
+			Date:            d,
+			ExecutionTime:   fromMilliseconds(execution_time),
+			Status:          Status(status),
+			Failure:         failure.String,
+			FailedStatement: int(failedStatement.Int64),
+			InstalledBy:     installedBy.String,
+			Author:          author.String,
+			Ticket:          ticket.String,
+			Output:          output.String,
 		}
 		ms = append(ms, m)
 	}
 	return ms, nil
 }
 
+func (s SQLiteSupport) CreateRunsTable(db *sql.DB) error {
+	if _, err := db.Exec(fmt.Sprintf(sqliteRuns, s.config.QualifiedName("_runs"))); err != nil {
+		return err
+	}
+	return s.addColumn(db, "_runs", "labels", "TEXT")
+}
+
+func (s SQLiteSupport) RecordRun(db *sql.DB, r Run) error {
+	labels, err := json.Marshal(r.Labels)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`INSERT OR REPLACE INTO `+s.config.QualifiedName("_runs")+` (token, started, finished, status, labels) VALUES (?, ?, ?, ?, ?);`,
+		r.Token,
+		r.Started.Format(time.RFC3339),
+		r.Finished.Format(time.RFC3339),
+		string(r.Status),
+		string(labels),
+	)
+	return err
+}
+
+func (s SQLiteSupport) FindRun(db *sql.DB, token string) (Run, bool, error) {
+	row := db.QueryRow(`SELECT token, started, finished, status, labels FROM `+s.config.QualifiedName("_runs")+` WHERE token = ?;`, token)
+	r, err := scanSQLiteRun(row)
+	switch err {
+	case nil:
+		return r, true, nil
+	case sql.ErrNoRows:
+		return Run{}, false, nil
+	default:
+		return Run{}, false, err
+	}
+}
+
+func (s SQLiteSupport) ListRuns(db *sql.DB) ([]Run, error) {
+	rows, err := db.Query(`SELECT token, started, finished, status, labels FROM ` + s.config.QualifiedName("_runs") + ` ORDER BY started;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	rs := []Run{}
+	for rows.Next() {
+		r, err := scanSQLiteRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, r)
+	}
+	return rs, rows.Err()
+}
+
+func scanSQLiteRun(row interface{ Scan(...interface{}) error }) (Run, error) {
+	var token string
+	var started string
+	var finished string
+	var status string
+	var labels sql.NullString
+	if err := row.Scan(&token, &started, &finished, &status, &labels); err != nil {
+		return Run{}, err
+	}
+	st, _ := time.Parse(time.RFC3339, started)
+	fi, _ := time.Parse(time.RFC3339, finished)
+	r := Run{
+		Token:    token,
+		Started:  st,
+		Finished: fi,
+		Status:   Status(status),
+	}
+	if labels.Valid {
+		if err := json.Unmarshal([]byte(labels.String), &r.Labels); err != nil {
+			return Run{}, err
+		}
+	}
+	return r, nil
+}
+
+// columns returns the names of the columns of the metadata table with suffix.
+func (s SQLiteSupport) columns(db *sql.DB, suffix string) (map[string]bool, error) {
+	pragma := "PRAGMA "
+	if s.config.Schema != "" {
+		pragma += quoteIdent(s.config.Schema) + "."
+	}
+	rows, err := db.Query(pragma + "table_info(" + quoteIdent(s.config.TableName(suffix)) + ");")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols := map[string]bool{}
+	for rows.Next() {
+		var cid int
+		var name string
+		var typ, notNull, dflt, pk interface{}
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		cols[name] = true
+	}
+	return cols, rows.Err()
+}
+
+// addColumn adds a column to a metadata table created by an earlier version of this package.
+func (s SQLiteSupport) addColumn(db *sql.DB, suffix string, column string, definition string) error {
+	cols, err := s.columns(db, suffix)
+	if err != nil || cols[column] {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE ` + s.config.QualifiedName(suffix) + ` ADD COLUMN ` + column + ` ` + definition + `;`)
+	return err
+}
+
+// Lock inserts the lock row. The row outlives a migrator that crashed while holding the lock, it is removed by
+// ForceUnlock.
+func (s SQLiteSupport) Lock(db *sql.DB, timeout time.Duration) error {
+	table := s.config.QualifiedName("_lock")
+	if _, err := db.Exec(fmt.Sprintf(sqliteLock, table)); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		_, err := db.Exec(`INSERT INTO `+table+` (id, acquired) VALUES (1, ?);`, time.Now().UTC().Format(time.RFC3339))
+		if err == nil {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("%w: %v", ErrLockTimeout, err)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+func (s SQLiteSupport) Unlock(db *sql.DB) error {
+	_, err := db.Exec(`DELETE FROM ` + s.config.QualifiedName("_lock") + ` WHERE id = 1;`)
+	return err
+}
+
+// ForceUnlock removes the lock row whoever holds it.
+func (s SQLiteSupport) ForceUnlock(db *sql.DB) error {
+	return s.Unlock(db)
+}
+
+// Snapshot writes a copy of the database to the file template using VACUUM INTO, which requires SQLite 3.27.
+// Attached databases are written to <template>.<schema>.
+func (s SQLiteSupport) Snapshot(db *sql.DB, template string) error {
+	schema := "main"
+	if s.config.Schema != "" {
+		schema = quoteIdent(s.config.Schema)
+	}
+	if _, err := db.Exec(`VACUUM `+schema+` INTO ?;`, template); err != nil {
+		return err
+	}
+	for _, a := range s.config.Attachments {
+		if _, err := db.Exec(`VACUUM `+quoteIdent(a.Schema)+` INTO ?;`, template+"."+a.Schema); err != nil {
+			return fmt.Errorf("snapshot %s: %+v", a.Schema, err)
+		}
+	}
+	return nil
+}
+
+// Restore copies the file template to the file target and the files of the attached databases to <target>.<schema>.
+// The target is opened by the caller.
+func (s SQLiteSupport) Restore(db *sql.DB, template string, target string) error {
+	if err := copyFile(template, target); err != nil {
+		return err
+	}
+	for _, a := range s.config.Attachments {
+		if err := copyFile(template+"."+a.Schema, target+"."+a.Schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Backup writes a copy of the database to file using VACUUM INTO, like Snapshot.
+func (s SQLiteSupport) Backup(db *sql.DB, file string) error {
+	return s.Snapshot(db, file)
+}
+
+// RestoreBackup replaces the objects of the configured schema and the attached ones with those of the backup written
+// by Backup to file and <file>.<schema>. The objects are dropped and recreated with their rows in a single transaction,
+// so the database can stay open.
+func (s SQLiteSupport) RestoreBackup(db *sql.DB, file string) (err error) {
+	ctx := context.Background()
+	// pragmas and attachments apply to a single connection
+	con, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer con.Close()
+	if err := s.InitSessionContext(ctx, con); err != nil {
+		return fmt.Errorf("init session: %+v", err)
+	}
+	if _, err := con.ExecContext(ctx, `PRAGMA foreign_keys = OFF;`); err != nil {
+		return fmt.Errorf("disable foreign keys: %+v", err)
+	}
+	defer con.ExecContext(ctx, `PRAGMA foreign_keys = ON;`)
+	files := []string{file}
+	for _, a := range s.config.Attachments {
+		files = append(files, file+"."+a.Schema)
+	}
+	for i, f := range files {
+		if _, err := con.ExecContext(ctx, `ATTACH DATABASE ? AS `+sqliteBackupSchema(i)+`;`, f); err != nil {
+			return fmt.Errorf("attach backup: %s: %+v", f, err)
+		}
+		defer con.ExecContext(ctx, `DETACH DATABASE `+sqliteBackupSchema(i)+`;`)
+	}
+	tx, err := con.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	objects, err := s.listObjects(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("list objects: %+v", err)
+	}
+	for _, o := range objects {
+		if _, err := tx.ExecContext(ctx, s.DropStatement(o)); err != nil {
+			return fmt.Errorf("drop: %s: %+v", o.Name, err)
+		}
+	}
+	for i, schema := range s.schemas() {
+		if err := restoreSQLiteSchema(ctx, tx, sqliteBackupSchema(i), schema); err != nil {
+			return fmt.Errorf("restore %s: %+v", files[i], err)
+		}
+	}
+	return tx.Commit()
+}
+
+func sqliteBackupSchema(i int) string {
+	return fmt.Sprintf("migrate_backup_%d", i)
+}
+
+var sqliteCreate = regexp.MustCompile(`(?is)^\s*CREATE\s+(?:UNIQUE\s+)?(?:TABLE|INDEX|VIEW|TRIGGER)\s+(?:IF\s+NOT\s+EXISTS\s+)?`)
+
+// restoreSQLiteSchema recreates the tables with their rows, indexes, views and triggers of the attached backup in
+// schema, the main database if schema is empty.
+func restoreSQLiteSchema(ctx context.Context, tx *sql.Tx, backup string, schema string) error {
+	rows, err := tx.QueryContext(ctx, `SELECT type, name, sql FROM `+backup+`.sqlite_master WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%'
+ORDER BY CASE type WHEN 'table' THEN 1 WHEN 'index' THEN 2 WHEN 'view' THEN 3 ELSE 4 END, rowid;`)
+	if err != nil {
+		return err
+	}
+	var objects []Object
+	var stmts []string
+	for rows.Next() {
+		var o Object
+		var stmt string
+		if err := rows.Scan(&o.Type, &o.Name, &stmt); err != nil {
+			rows.Close()
+			return err
+		}
+		objects = append(objects, o)
+		stmts = append(stmts, stmt)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	target := ""
+	if schema != "" {
+		target = quoteIdent(schema) + "."
+	}
+	for i, o := range objects {
+		stmt := stmts[i]
+		if loc := sqliteCreate.FindStringIndex(stmt); loc != nil && target != "" {
+			stmt = stmt[:loc[1]] + target + stmt[loc[1]:]
+		}
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("%s: %+v", stmt, err)
+		}
+		if o.Type == "table" {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO `+target+quoteIdent(o.Name)+` SELECT * FROM `+backup+`.`+quoteIdent(o.Name)+`;`); err != nil {
+				return fmt.Errorf("copy %s: %+v", o.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
 const sqliteMigrations = `
-CREATE TABLE migrations (
+CREATE TABLE %s (
   rank INTEGER NOT NULL,
   version TEXT NOT NULL,
   description TEXT NOT NULL,
@@ -92,5 +949,36 @@ CREATE TABLE migrations (
   date TEXT NOT NULL,
   execution_time INTEGER NOT NULL,
   status TEXT NOT NULL,
+  failure TEXT,
+  failed_statement INTEGER,
+  installed_by TEXT,
+  author TEXT,
+  ticket TEXT,
+  script BLOB,
+  output TEXT,
   PRIMARY KEY (rank)
 );`
+
+const sqliteRuns = `
+CREATE TABLE IF NOT EXISTS %s (
+  token TEXT NOT NULL,
+  started TEXT NOT NULL,
+  finished TEXT NOT NULL,
+  status TEXT NOT NULL,
+  labels TEXT,
+  PRIMARY KEY (token)
+);`
+
+const sqliteSchemaVersion = `
+CREATE TABLE IF NOT EXISTS %s (
+  id INTEGER NOT NULL,
+  version INTEGER NOT NULL,
+  PRIMARY KEY (id)
+);`
+
+const sqliteLock = `
+CREATE TABLE IF NOT EXISTS %s (
+  id INTEGER NOT NULL,
+  acquired TEXT NOT NULL,
+  PRIMARY KEY (id)
+);`
@@ -0,0 +1,125 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSQLiteRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	template := filepath.Join(dir, "template.db")
+	if err := ioutil.WriteFile(template, []byte("migrated"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewMigrator(t.Logf, nil, SQLiteSupport{})
+	target := filepath.Join(dir, "worker-1.db")
+	if err := m.Restore(template, target); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if got, _ := ioutil.ReadFile(target); string(got) != "migrated" {
+		t.Errorf("unexpected copy: %q", got)
+	}
+	if err := m.Restore(template, target); err == nil {
+		t.Errorf("want error for existing target")
+	}
+}
+
+func TestSQLiteClean(t *testing.T) {
+	db, log := openFake(t.Name())
+	log.results = map[string]string{`PRAGMA foreign_keys;`: "1", `PRAGMA journal_mode;`: "delete"}
+	log.rows = map[string][][]string{
+		`SELECT type, name, tbl_name FROM sqlite_master WHERE type IN ('table', 'view', 'index', 'trigger') AND name NOT LIKE 'sqlite_%' ORDER BY name;`: {
+			{"table", "orders", "orders"},
+			{"index", "orders_idx", "orders"},
+			{"trigger", "orders_trg", "orders"},
+			{"view", "orders_view", "orders_view"},
+		},
+	}
+	var dropped []string
+	m := NewMigrator(func(format string, args ...interface{}) {
+		dropped = append(dropped, fmt.Sprintf(format, args...))
+	}, db, SQLiteSupport{})
+	if err := m.Clean(); err != nil {
+		t.Fatalf("clean: %v", err)
+	}
+	want := []string{
+		`PRAGMA foreign_keys;`,
+		`PRAGMA foreign_keys = OFF;`,
+		`SELECT type, name, tbl_name FROM sqlite_master WHERE type IN ('table', 'view', 'index', 'trigger') AND name NOT LIKE 'sqlite_%' ORDER BY name;`,
+		`DROP VIEW IF EXISTS "orders_view";`,
+		`DROP TRIGGER IF EXISTS "orders_trg";`,
+		`DROP INDEX IF EXISTS "orders_idx";`,
+		`DROP TABLE IF EXISTS "orders";`,
+		`PRAGMA journal_mode;`,
+		`VACUUM;`,
+		`PRAGMA foreign_keys = ON;`,
+	}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	wantDropped := []string{
+		"dropped name=orders_view type=view",
+		"dropped name=orders_trg type=trigger",
+		"dropped name=orders_idx type=index",
+		"dropped name=orders type=table",
+	}
+	if !reflect.DeepEqual(wantDropped, dropped) {
+		t.Errorf("want: %q, got: %q", wantDropped, dropped)
+	}
+}
+
+func TestSQLiteCleanWAL(t *testing.T) {
+	db, log := openFake(t.Name())
+	log.results = map[string]string{
+		`PRAGMA foreign_keys;`:       "0",
+		`PRAGMA journal_mode;`:       "delete",
+		`PRAGMA "aux".journal_mode;`: "wal",
+	}
+	log.rows = map[string][][]string{
+		`PRAGMA "aux".wal_checkpoint(TRUNCATE);`: {{"0", "12", "12"}},
+	}
+	s := NewSQLiteSupport(WithAttachment("aux", "aux.db"), WithSchema("aux"))
+	if err := s.Clean(db); err != nil {
+		t.Fatalf("clean: %v", err)
+	}
+	want := []string{
+		`PRAGMA foreign_keys;`,
+		`SELECT type, name, tbl_name FROM "aux".sqlite_master WHERE type IN ('table', 'view', 'index', 'trigger') AND name NOT LIKE 'sqlite_%' ORDER BY name;`,
+		`PRAGMA "aux".journal_mode;`,
+		`PRAGMA "aux".wal_checkpoint(TRUNCATE);`,
+		`VACUUM "aux";`,
+	}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+
+	log.rows[`PRAGMA "aux".wal_checkpoint(TRUNCATE);`] = [][]string{{"1", "12", "3"}}
+	if err := s.Clean(db); err == nil {
+		t.Errorf("want error for busy checkpoint")
+	}
+}
+
+func TestSQLiteCheckForeignKeys(t *testing.T) {
+	db, log := openFake(t.Name())
+	log.rows = map[string][][]string{
+		`PRAGMA "aux".foreign_key_check;`: {{"orders", "7", "customers", "0"}},
+	}
+	s := NewSQLiteSupport(WithAttachment("aux", "aux.db"))
+	got, err := s.CheckForeignKeys(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ForeignKeyViolation{{Table: "aux.orders", RowID: 7, Parent: "customers"}}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %+v, got: %+v", want, got)
+	}
+}
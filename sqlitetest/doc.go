@@ -0,0 +1,3 @@
+// Package sqlitetest holds the examples and tests of the migrate package that run against a real SQLite database. It
+// is a module of its own, so that the migrate module stays without dependencies.
+package sqlitetest
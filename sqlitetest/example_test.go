@@ -0,0 +1,40 @@
+package sqlitetest_test
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/cognicraft/migrate"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func Example_simulate() {
+	info, err := migrate.Simulate(migrate.Migrations{
+		migrate.NewSQLMigration("1", "create users", `
+			CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL);
+		`),
+		migrate.NewSQLMigration("2", "create index on users", `
+			CREATE INDEX users_name ON users (name);
+		`),
+		migrate.NewGoMigration("3", "seed users", func(db *sql.DB) error {
+			_, err := db.Exec(`INSERT INTO users (name) VALUES ('admin');`)
+			return err
+		}),
+		migrate.NewSQLMigration(migrate.VersionRepeatable, "user names view", `
+			DROP VIEW IF EXISTS user_names;
+			CREATE VIEW user_names AS SELECT name FROM users;
+		`),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, mig := range info.Migrations {
+		fmt.Println(mig.Version, mig.State, mig.Description)
+	}
+	// Output:
+	// 1 applied create users
+	// 2 applied create index on users
+	// 3 applied seed users
+	// R applied user names view
+}
@@ -0,0 +1,10 @@
+module github.com/cognicraft/migrate/sqlitetest
+
+go 1.17
+
+require (
+	github.com/cognicraft/migrate v0.0.0
+	github.com/mattn/go-sqlite3 v1.14.16
+)
+
+replace github.com/cognicraft/migrate => ../
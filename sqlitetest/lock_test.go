@@ -0,0 +1,75 @@
+package sqlitetest
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cognicraft/migrate"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func open(t *testing.T, file string) *sql.DB {
+	db, err := sql.Open("sqlite3", file+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestLockHeldByLongRun(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.db")
+	started, release := make(chan struct{}), make(chan struct{})
+	first := migrate.NewMigrator(t.Logf, open(t, file), migrate.SQLiteSupport{})
+	first.AddGoMigration("1", "slow", func(db *sql.DB) error {
+		close(started)
+		<-release
+		_, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY);`)
+		return err
+	})
+	done := make(chan error, 1)
+	go func() {
+		done <- first.Migrate()
+	}()
+	<-started
+
+	second := migrate.NewMigrator(t.Logf, open(t, file), migrate.SQLiteSupport{})
+	second.SetLockTimeout(300 * time.Millisecond)
+	second.AddGoMigration("1", "slow", func(db *sql.DB) error {
+		t.Error("want the migration not to run while the lock is held")
+		return nil
+	})
+	if err := second.Migrate(); !errors.Is(err, migrate.ErrLockTimeout) {
+		t.Errorf("want lock timeout, got: %v", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("first migrator: %v", err)
+	}
+	if err := second.Migrate(); err != nil {
+		t.Fatalf("second migrator after release: %v", err)
+	}
+}
+
+func TestForceUnlock(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.db")
+	db := open(t, file)
+	// a migrator that crashed while holding the lock
+	if err := (migrate.SQLiteSupport{}).Lock(open(t, file), 0); err != nil {
+		t.Fatal(err)
+	}
+	m := migrate.NewMigrator(t.Logf, db, migrate.SQLiteSupport{})
+	m.AddSQLMigration("1", "create users", `CREATE TABLE users (id INTEGER PRIMARY KEY);`)
+	if err := m.Migrate(); !errors.Is(err, migrate.ErrLockTimeout) {
+		t.Fatalf("want lock timeout, got: %v", err)
+	}
+	if err := m.ForceUnlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate after force unlock: %v", err)
+	}
+}
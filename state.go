@@ -0,0 +1,28 @@
+package migrate
+
+// State is the planning state of a migration, derived from the local registrations and the recorded Status.
+type State string
+
+const (
+	// StatePending is a local migration that will be applied by the next call to Migrate.
+	StatePending State = "pending"
+	// StateApplied is a migration that has been applied successfully.
+	StateApplied State = "applied"
+	// StateFailed is a migration that has been applied but failed.
+	StateFailed State = "failed"
+	// StateOutdated is an applied repeatable migration whose local checksum has changed.
+	StateOutdated State = "outdated"
+	// StateSuperseded is an applied repeatable migration that has been applied again later.
+	StateSuperseded State = "superseded"
+	// StateBaseline is the baseline record.
+	StateBaseline State = "baseline"
+	// StateIgnored is a local migration that will not be applied because a newer version has already been applied.
+	StateIgnored State = "ignored"
+	// StateFuture is an applied migration that is newer than any local migration.
+	StateFuture State = "future"
+	// StateMissing is an applied migration that is not known locally.
+	StateMissing State = "missing"
+	// StateSkipped is a failed migration that has been skipped by the recovery policy SkipFailed
+	// or a migration that has been skipped because none of its environments was active.
+	StateSkipped State = "skipped"
+)
@@ -0,0 +1,75 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+)
+
+// StatementLog configures the logging of every statement executed by SQL migrations.
+type StatementLog struct {
+	// Redact returns the text that is logged for a statement, e.g. with secrets masked. Statements are logged
+	// unchanged if it is nil. See RedactStringLiterals.
+	Redact func(stmt string) string
+	// MaxLength truncates logged statements to MaxLength bytes. Zero does not truncate.
+	MaxLength int
+}
+
+// SetStatementLog makes Migrate log every statement, or batch of statements, of SQL migrations with its duration and
+// the number of affected rows, e.g. for audit trails. Statements are not logged by default.
+func (m *Migrator) SetStatementLog(l StatementLog) {
+	m.statementLog = &l
+}
+
+var stringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+// RedactStringLiterals replaces the string literals of stmt by '***', e.g. for StatementLog.Redact.
+func RedactStringLiterals(stmt string) string {
+	return stringLiteral.ReplaceAllString(stmt, "'***'")
+}
+
+// text returns the logged text of stmt.
+func (l StatementLog) text(stmt string) string {
+	if l.Redact != nil {
+		stmt = l.Redact(stmt)
+	}
+	if l.MaxLength > 0 && len(stmt) > l.MaxLength {
+		stmt = stmt[:l.MaxLength] + "..."
+	}
+	return stmt
+}
+
+// logged returns con, logging the statements it executes for mig if configured by SetStatementLog. Statements are
+// logged before their secret placeholders are resolved.
+func (m *Migrator) logged(con execer, mig Migration) execer {
+	con = m.withSecrets(con)
+	if m.statementLog == nil {
+		return con
+	}
+	return statementLogger{execer: con, m: m, mig: mig}
+}
+
+// statementLogger logs the statements executed by an execer.
+type statementLogger struct {
+	execer
+	m   *Migrator
+	mig Migration
+}
+
+func (l statementLogger) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := l.m.now()
+	res, err := l.execer.ExecContext(ctx, query, args...)
+	fields := migrationFields(l.mig)
+	fields["statement"] = l.m.statementLog.text(query)
+	fields["duration"] = l.m.since(start)
+	if err != nil {
+		fields["error"] = err
+		l.m.log(LevelError, "executed statement", fields)
+		return res, err
+	}
+	if rows, rErr := res.RowsAffected(); rErr == nil {
+		fields["rows"] = rows
+	}
+	l.m.log(LevelInfo, "executed statement", fields)
+	return res, err
+}
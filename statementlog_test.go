@@ -0,0 +1,42 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStatementLog(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	var lines []string
+	m := NewMigrator(func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}, db, &memSupport{})
+	m.SetStatementLog(StatementLog{Redact: RedactStringLiterals, MaxLength: 40})
+	m.AddSQLMigration("1", "users", "CREATE USER app IDENTIFIED BY 'secret';\nINSERT INTO audit (note) VALUES ('a rather long note that is truncated');")
+	log.fail = "INSERT"
+	if err := m.Migrate(); err == nil {
+		t.Fatal("want error")
+	}
+	executed := []string{}
+	for _, line := range lines {
+		if strings.Contains(line, "executed statement") {
+			executed = append(executed, line)
+		}
+	}
+	if len(executed) != 2 {
+		t.Fatalf("want 2 statements, got: %q", executed)
+	}
+	if !strings.HasPrefix(executed[0], "executed statement") || !strings.Contains(executed[0], "rows=0") ||
+		!strings.Contains(executed[0], "statement=CREATE USER app IDENTIFIED BY '***';") || strings.Contains(executed[0], "secret") {
+		t.Errorf("unexpected entry: %s", executed[0])
+	}
+	if !strings.HasPrefix(executed[1], "error: executed statement") ||
+		!strings.Contains(executed[1], "statement=INSERT INTO audit (note) VALUES ('***');") {
+		t.Errorf("unexpected entry: %s", executed[1])
+	}
+	if got := (StatementLog{MaxLength: 6}).text("SELECT 1;"); got != "SELECT..." {
+		t.Errorf("unexpected truncation: %q", got)
+	}
+}
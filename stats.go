@@ -0,0 +1,68 @@
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Stats describes a set of migrations.
+type Stats struct {
+	Migrations int
+	Repeatable int
+	Types      map[Type]int
+	// Statements is the total number of statements of all SQL scripts.
+	Statements int
+	// LargestScript is the migration with the largest script.
+	LargestScript Migration
+	// Slowest is the migration with the longest recorded execution time. It is only set for migrations that
+	// have been applied, e.g. the ones of Info.
+	Slowest Migration
+}
+
+// Stats computes statistics about the migrations.
+func (ms Migrations) Stats() Stats {
+	s := Stats{
+		Types: map[Type]int{},
+	}
+	for _, mig := range ms {
+		s.Migrations++
+		if mig.IsRepeatable() {
+			s.Repeatable++
+		}
+		s.Types[mig.Type]++
+		if mig.Script != "" {
+			s.Statements += len(ParseStatements(mig.Script))
+			if len(mig.Script) > len(s.LargestScript.Script) {
+				s.LargestScript = mig
+			}
+		}
+		if mig.ExecutionTime > s.Slowest.ExecutionTime {
+			s.Slowest = mig
+		}
+	}
+	return s
+}
+
+// Write writes s in a human readable form to w.
+func (s Stats) Write(w io.Writer) error {
+	types := make([]string, 0, len(s.Types))
+	for t := range s.Types {
+		types = append(types, string(t))
+	}
+	sort.Strings(types)
+	fmt.Fprintf(w, "migrations:     %d\n", s.Migrations)
+	fmt.Fprintf(w, "repeatable:     %d\n", s.Repeatable)
+	for _, t := range types {
+		fmt.Fprintf(w, "type %-10s %d\n", t+":", s.Types[Type(t)])
+	}
+	fmt.Fprintf(w, "statements:     %d\n", s.Statements)
+	if s.LargestScript.Script != "" {
+		fmt.Fprintf(w, "largest script: %s %s (%d bytes)\n", s.LargestScript.Version, s.LargestScript.Description, len(s.LargestScript.Script))
+	}
+	if s.Slowest.ExecutionTime > 0 {
+		_, err := fmt.Fprintf(w, "slowest:        %s %s (%s)\n", s.Slowest.Version, s.Slowest.Description, s.Slowest.ExecutionTime)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package migrate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMigrationsStats(t *testing.T) {
+	ms := Migrations{
+		NewSQLMigration("1", "one", "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);"),
+		NewSQLMigration("2", "two", "CREATE TABLE c (id INT);"),
+		NewGoMigration("3", "three", nil),
+		NewSQLMigration(VersionRepeatable, "view", "CREATE VIEW v AS SELECT 1;"),
+	}
+	ms[2].ExecutionTime = 250 * time.Millisecond
+	s := ms.Stats()
+	if s.Migrations != 4 || s.Repeatable != 1 {
+		t.Errorf("unexpected counts: %+v", s)
+	}
+	if s.Types[TypeSQL] != 3 || s.Types[TypeGo] != 1 {
+		t.Errorf("unexpected types: %v", s.Types)
+	}
+	if s.Statements != 4 {
+		t.Errorf("want 4 statements, got %d", s.Statements)
+	}
+	if s.LargestScript.Version != "1" {
+		t.Errorf("want largest script 1, got %s", s.LargestScript.Version)
+	}
+	if s.Slowest.Version != "3" {
+		t.Errorf("want slowest 3, got %s", s.Slowest.Version)
+	}
+}
@@ -0,0 +1,46 @@
+package migrate
+
+import "fmt"
+
+// SetStrict makes Migrate fail with ErrUnknownMigration before it applies anything if the database contains applied
+// versioned migrations that are not registered locally, the typical symptom of an old binary deployed against a newer
+// database. Baselines and repeatable migrations are not checked.
+func (m *Migrator) SetStrict(strict bool) {
+	m.strict = strict
+}
+
+// SetAllowNewerSchema makes a strict Migrator accept applied migrations above the latest local version, e.g. for a
+// roll forward only deployment that rolls the application back but keeps the newer schema. Unknown migrations at or
+// below the latest local version are still rejected.
+func (m *Migrator) SetAllowNewerSchema(allow bool) {
+	m.allowNewerSchema = allow
+}
+
+// checkUnknown rejects the applied versioned migrations that are not registered locally if the Migrator is strict.
+func (m *Migrator) checkUnknown(migrations Migrations, installed Migrations) error {
+	if !m.strict {
+		return nil
+	}
+	local := map[Version]bool{}
+	latest := VersionNone
+	for _, mig := range migrations {
+		local[mig.Version] = true
+		latest = m.versionOrdering.later(latest, mig.Version)
+	}
+	for _, mig := range installed {
+		if mig.IsRepeatable() || mig.Type == TypeBaseline || local[mig.Version] {
+			continue
+		}
+		newer := latest == VersionNone || m.versionOrdering.compare(mig.Version, latest) > 0
+		if newer && m.allowNewerSchema {
+			m.log(LevelWarn, "allowing migration newer than the local migrations", migrationFields(mig))
+			continue
+		}
+		detail := "not registered locally"
+		if newer {
+			detail = fmt.Sprintf("newer than the latest local version %s", latest)
+		}
+		return &MigrationError{Err: ErrUnknownMigration, Migration: mig, Detail: detail}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMigrateStrict(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	m.AddSQLMigration("2", "two", "CREATE TABLE b (id INT);")
+	m.AddSQLMigration("3", "three", "CREATE TABLE c (id INT);")
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	old := NewMigrator(t.Logf, db, s)
+	old.SetStrict(true)
+	old.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	old.AddSQLMigration("2", "two", "CREATE TABLE b (id INT);")
+	old.AddRepeatableSQLMigration("views", "CREATE VIEW v AS SELECT 1;")
+	err := old.Migrate()
+	var me *MigrationError
+	if !errors.Is(err, ErrUnknownMigration) || !errors.As(err, &me) || me.Migration.Version != "3" {
+		t.Fatalf("want unknown migration 3, got: %v", err)
+	}
+	if got := log.Statements(); len(got) != 3 {
+		t.Errorf("want nothing applied, got: %q", got)
+	}
+
+	old.SetAllowNewerSchema(true)
+	if err := old.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := log.Statements(); len(got) != 4 {
+		t.Errorf("want the repeatable migration applied, got: %q", got)
+	}
+
+	gap := NewMigrator(t.Logf, db, s)
+	gap.SetStrict(true)
+	gap.SetAllowNewerSchema(true)
+	gap.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	gap.AddSQLMigration("3", "three", "CREATE TABLE c (id INT);")
+	if err := gap.Migrate(); !errors.Is(err, ErrUnknownMigration) {
+		t.Errorf("want unknown migration 2, got: %v", err)
+	}
+}
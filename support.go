@@ -0,0 +1,83 @@
+package migrate
+
+import (
+	"database/sql"
+	"strings"
+)
+
+type Support interface {
+	ExistsMigrationsTable(con *sql.DB) (bool, error)
+	CreateMigrationsTable(con *sql.DB) error
+	RecordMigration(con *sql.DB, m Migration) error
+	// ListMigrations lists the recorded migrations, preferably ordered by rank. New migrations are recorded with
+	// ranks above the highest listed one.
+	ListMigrations(con *sql.DB) (Migrations, error)
+	Clean(con *sql.DB) error
+}
+
+// DefaultTable is the name of the metadata table unless configured otherwise.
+const DefaultTable = "migrations"
+
+// SupportConfig holds the settings shared by the Support implementations.
+type SupportConfig struct {
+	// Table is the name of the metadata table. Auxiliary tables are named after it, e.g. <Table>_lock.
+	Table string
+	// Schema is the schema (or attached database) the metadata tables live in.
+	Schema string
+	// Compression is applied to large texts stored in the metadata tables.
+	Compression Compression
+	// Attachments are the databases attached to every session, used by SQLite.
+	Attachments []Attachment
+}
+
+type SupportOption func(*SupportConfig)
+
+// ConfigurableSupport is implemented by Support implementations whose configuration can be changed after they have
+// been created. It is required by WithTableName.
+type ConfigurableSupport interface {
+	Configure(opts ...SupportOption) Support
+}
+
+// WithTable sets the name of the metadata table.
+func WithTable(name string) SupportOption {
+	return func(c *SupportConfig) {
+		c.Table = name
+	}
+}
+
+// WithSchema sets the schema of the metadata table.
+func WithSchema(name string) SupportOption {
+	return func(c *SupportConfig) {
+		c.Schema = name
+	}
+}
+
+func newSupportConfig(opts []SupportOption) SupportConfig {
+	c := SupportConfig{}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// TableName returns the unqualified name of the metadata table with suffix appended.
+func (c SupportConfig) TableName(suffix string) string {
+	table := c.Table
+	if table == "" {
+		table = DefaultTable
+	}
+	return table + suffix
+}
+
+// QualifiedName returns the quoted and schema qualified name of the metadata table with suffix appended.
+func (c SupportConfig) QualifiedName(suffix string) string {
+	name := quoteIdent(c.TableName(suffix))
+	if c.Schema == "" {
+		return name
+	}
+	return quoteIdent(c.Schema) + "." + name
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
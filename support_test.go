@@ -0,0 +1,27 @@
+package migrate
+
+import "testing"
+
+func TestSupportConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []SupportOption
+		table     string
+		qualified string
+	}{
+		{"default", nil, "migrations_lock", `"migrations_lock"`},
+		{"table", []SupportOption{WithTable("schema_history")}, "schema_history_lock", `"schema_history_lock"`},
+		{"schema", []SupportOption{WithTable("schema_history"), WithSchema("aux")}, "schema_history_lock", `"aux"."schema_history_lock"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newSupportConfig(test.opts)
+			if got := c.TableName("_lock"); got != test.table {
+				t.Errorf("want: %s, got: %s", test.table, got)
+			}
+			if got := c.QualifiedName("_lock"); got != test.qualified {
+				t.Errorf("want: %s, got: %s", test.qualified, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package migrate
+
+// Tags labels a migration, e.g. Tags("data", "long-running"), so that runs can select or exclude it with
+// SetOnlyTags and SetExcludeTags. SQL scripts declare tags in their header, see ScriptHeader.
+func Tags(tags ...string) MigrationOption {
+	return func(o *MigrationOptions) {
+		o.Tags = append(o.Tags, tags...)
+	}
+}
+
+// SetOnlyTags makes Migrate apply only the migrations with any of the given tags, e.g. data backfills in an off-peak
+// job. The other migrations are left pending like the ones of SetSkipVersions. Calling it without tags restores
+// normal runs.
+func (m *Migrator) SetOnlyTags(tags ...string) {
+	m.onlyTags = tagSet(tags)
+}
+
+// SetExcludeTags makes Migrate leave the migrations with any of the given tags pending like the ones of
+// SetSkipVersions, e.g. long-running data migrations at deploy time. Calling it without tags restores normal runs.
+func (m *Migrator) SetExcludeTags(tags ...string) {
+	m.excludeTags = tagSet(tags)
+}
+
+func tagSet(tags []string) map[string]bool {
+	if len(tags) == 0 {
+		return nil
+	}
+	set := map[string]bool{}
+	for _, tag := range tags {
+		set[tag] = true
+	}
+	return set
+}
+
+// filtersTags reports whether runs are restricted by SetOnlyTags or SetExcludeTags.
+func (m *Migrator) filtersTags() bool {
+	return m.onlyTags != nil || m.excludeTags != nil
+}
+
+// taggedOut reports whether mig is excluded from the run by SetOnlyTags or SetExcludeTags.
+func (m *Migrator) taggedOut(mig Migration) bool {
+	only := m.onlyTags == nil
+	for _, tag := range mig.Options.Tags {
+		if m.excludeTags[tag] {
+			return true
+		}
+		if m.onlyTags[tag] {
+			only = true
+		}
+	}
+	return !only
+}
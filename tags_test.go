@@ -0,0 +1,56 @@
+package migrate
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestMigrateTags(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	order := []Version{}
+	step := func(v Version) CommandFunc {
+		return func(con *sql.DB) error {
+			order = append(order, v)
+			return nil
+		}
+	}
+	m.AddGoMigration("1", "create users", step("1"), Tags("ddl"))
+	m.AddGoMigration("2", "backfill users", step("2"), Tags("data", "long-running"))
+	m.AddGoMigration("3", "create orders", step("3"), Tags("ddl"))
+	m.AddRepeatableGoMigration("refresh stats", step("R"), Tags("data"))
+
+	m.SetExcludeTags("long-running")
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []Version{"1", "3", "R"}; !reflect.DeepEqual(want, order) {
+		t.Errorf("want: %v, got: %v", want, order)
+	}
+	if got := s.migrations[1]; got.Version != "3" || got.Status != StatusCherryPicked {
+		t.Errorf("want version 3 after excluded version 2 to be cherry-picked, got: %s %s", got.Version, got.Status)
+	}
+
+	m.SetExcludeTags()
+	m.SetOnlyTags("data")
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []Version{"1", "3", "R", "2"}; !reflect.DeepEqual(want, order) {
+		t.Errorf("want: %v, got: %v", want, order)
+	}
+	if pending := m.Info().Pending(); len(pending) != 0 {
+		t.Errorf("want no pending migrations, got:\n%s", pending)
+	}
+
+	m.SetOnlyTags("ddl")
+	m.AddGoMigration("4", "create items", step("4"), Tags("ddl"))
+	m.AddRepeatableGoMigration("refresh items", step("RI"), Tags("data"))
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []Version{"1", "3", "R", "2", "4"}; !reflect.DeepEqual(want, order) {
+		t.Errorf("want: %v, got: %v", want, order)
+	}
+}
@@ -0,0 +1,69 @@
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"text/template"
+)
+
+// Template configures the rendering of SQL scripts as text/templates, e.g. to use per-environment table options.
+// Scripts are rendered with Data before they are split into statements.
+type Template struct {
+	// Data is passed to every script, e.g. {{.Engine}} refers to Data["Engine"].
+	Data map[string]interface{}
+	// Funcs are additional functions available in scripts.
+	Funcs template.FuncMap
+	// ChecksumRendered computes the checksums of SQL migrations from the rendered scripts instead of the templates,
+	// so that a change of Data is detected as a checksum mismatch.
+	ChecksumRendered bool
+}
+
+// SetTemplate makes the Migrator render SQL scripts as text/templates with the data of t. The checksums of registered
+// migrations are recomputed if they cover the rendered scripts.
+func (m *Migrator) SetTemplate(t Template) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.template = &t
+	m.recomputeChecksums()
+}
+
+// render returns mig with its script rendered if the Migrator renders templates and the conditional blocks selected
+// for the dialect of the Support. A streamed script is read into memory if it is rendered as a template.
+func (m *Migrator) render(mig Migration) (Migration, error) {
+	if mig.Type != TypeSQL || mig.ExecuteContext != nil || (mig.Script == "" && mig.Source == nil) {
+		return mig, nil
+	}
+	if m.template == nil {
+		return m.selectDialect(mig)
+	}
+	script := mig.Script
+	if mig.Source != nil {
+		rc, err := mig.Source()
+		if err != nil {
+			return mig, fmt.Errorf("open script: %+v", err)
+		}
+		defer rc.Close()
+		bs, err := ioutil.ReadAll(rc)
+		if err != nil {
+			return mig, fmt.Errorf("read script: %+v", err)
+		}
+		script = string(bs)
+	}
+	tmpl, err := template.New(mig.String()).Funcs(m.template.Funcs).Option("missingkey=error").Parse(script)
+	if err != nil {
+		return mig, fmt.Errorf("parse template: %+v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, m.template.Data); err != nil {
+		return mig, fmt.Errorf("render template: %+v", err)
+	}
+	mig.Script = buf.String()
+	mig.Source = nil
+	return m.selectDialect(mig)
+}
+
+// checksumRendered reports whether checksums cover the rendered scripts.
+func (m *Migrator) checksumRendered() bool {
+	return m.template != nil && m.template.ChecksumRendered
+}
@@ -0,0 +1,41 @@
+package migrate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMigrateTemplate(t *testing.T) {
+	script := "CREATE TABLE a (id INT) ENGINE={{.Engine}};"
+	db, log := openFake(t.Name())
+	defer db.Close()
+	m := NewMigrator(t.Logf, db, &memSupport{})
+	m.SetTemplate(Template{Data: map[string]interface{}{"Engine": "InnoDB"}})
+	m.AddSQLMigration("1", "one", script)
+	if err := m.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	want := []string{"CREATE TABLE a (id INT) ENGINE=InnoDB;"}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	if got := m.migrations[0].Checksum; got != SQLChecksum(script) {
+		t.Errorf("want checksum of template, got: %s", got)
+	}
+
+	m.SetTemplate(Template{Data: map[string]interface{}{"Engine": "MyISAM"}, ChecksumRendered: true})
+	if got := m.migrations[0].Checksum; got != SQLChecksum("CREATE TABLE a (id INT) ENGINE=MyISAM;") {
+		t.Errorf("want checksum of rendered script, got: %s", got)
+	}
+	m.SetTemplate(Template{Data: map[string]interface{}{"Engine": "MyISAM"}})
+	if got := m.migrations[0].Checksum; got != SQLChecksum(script) {
+		t.Errorf("want checksum of template once rendered checksums are off, got: %s", got)
+	}
+
+	m = NewMigrator(t.Logf, db, &memSupport{})
+	m.SetTemplate(Template{})
+	m.AddSQLMigration("1", "one", script)
+	if err := m.Migrate(); err == nil {
+		t.Fatalf("want error for missing template data")
+	}
+}
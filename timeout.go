@@ -0,0 +1,123 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TimeoutSupport is implemented by Support implementations that are able to limit the execution time of statements
+// on the server, e.g. with SET statement_timeout. It is called before the scripts of a migration are executed.
+type TimeoutSupport interface {
+	SetStatementTimeout(ctx context.Context, q Querier, timeout time.Duration) error
+}
+
+// LockWaitSupport is implemented by Support implementations that are able to limit how long a statement waits for a
+// lock on the server, e.g. with SET lock_timeout. It is called before the scripts of a migration are executed.
+type LockWaitSupport interface {
+	SetLockWaitTimeout(ctx context.Context, q Querier, timeout time.Duration) error
+}
+
+// SetTimeout limits the duration of subsequent calls to Migrate. When the timeout expires the context of the running
+// migration is cancelled and no further migrations are started. A running Go migration without a MigrationContext
+// cannot be cancelled, it completes before the run stops. A timeout of zero disables the limit.
+func (m *Migrator) SetTimeout(timeout time.Duration) {
+	m.timeout = timeout
+}
+
+// SetStatementTimeout limits the duration of every statement, or batch of statements, of a SQL migration.
+// The limit is also set on the database session if the Support is a TimeoutSupport, so a hung statement is aborted
+// by the server. As the session setting applies to a single connection, it is only reliable within a transaction
+// or with a pool limited to one connection.
+func (m *Migrator) SetStatementTimeout(timeout time.Duration) {
+	m.statementTimeout = timeout
+}
+
+// SetLockWaitTimeout limits how long the statements of SQL migrations wait for locks held by other transactions, so
+// that DDL queued behind a long transaction fails fast instead of blocking the traffic queued behind it. The failed
+// migration can be retried by the next run or by a RetryPolicy. The Support has to be a LockWaitSupport. Migrations
+// override the timeout with the option LockWaitTimeout, it is restored once they are done. As the session setting
+// applies to a single connection, it is only reliable within a transaction or with a pool limited to one connection.
+func (m *Migrator) SetLockWaitTimeout(timeout time.Duration) {
+	m.lockWaitTimeout = timeout
+}
+
+// runContext returns the context of a migration, which expires with the run or the timeout of the migration.
+func (m *Migrator) runContext(mig Migration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.Background(), func() {}
+	if !m.deadline.IsZero() {
+		ctx, cancel = context.WithDeadline(ctx, m.deadline)
+	}
+	if mig.Options.Timeout > 0 {
+		parent := cancel
+		var c context.CancelFunc
+		ctx, c = context.WithTimeout(ctx, mig.Options.Timeout)
+		cancel = func() {
+			c()
+			parent()
+		}
+	}
+	return ctx, cancel
+}
+
+// expired reports an error if the run timeout has been exceeded or the run has been aborted.
+func (m *Migrator) expired(mig Migration) error {
+	if m.aborted() {
+		return &MigrationError{
+			Err:       ErrAborted,
+			Migration: mig,
+			Detail:    "not started after run has been aborted: " + m.abort.Err().Error(),
+		}
+	}
+	if m.deadline.IsZero() || m.now().Before(m.deadline) {
+		return nil
+	}
+	return &MigrationError{
+		Err:       ErrTimeout,
+		Migration: mig,
+		Detail:    "not started after run timeout of " + m.timeout.String(),
+	}
+}
+
+// statementContext returns the context of a single statement.
+func (m *Migrator) statementContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.statementTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, m.statementTimeout)
+}
+
+// limitSession sets the statement timeout and the lock wait timeout of mig on the session of q if supported. The
+// returned function restores the lock wait timeout of the Migrator if mig overrides it, so that the override does not
+// apply to later migrations on the same session.
+func (m *Migrator) limitSession(ctx context.Context, q Querier, mig Migration) (restore func() error, err error) {
+	restore = func() error { return nil }
+	if ts, ok := m.support.(TimeoutSupport); ok && m.statementTimeout > 0 {
+		if err := ts.SetStatementTimeout(ctx, q, m.statementTimeout); err != nil {
+			return restore, fmt.Errorf("set statement timeout: %+v", err)
+		}
+	}
+	timeout := m.lockWaitTimeout
+	if mig.Options.LockWaitTimeout > 0 {
+		timeout = mig.Options.LockWaitTimeout
+	}
+	if timeout <= 0 {
+		return restore, nil
+	}
+	lw, ok := m.support.(LockWaitSupport)
+	if !ok {
+		return restore, fmt.Errorf("lock wait timeout requires a support that limits lock waits: %T", m.support)
+	}
+	if err := lw.SetLockWaitTimeout(ctx, q, timeout); err != nil {
+		return restore, fmt.Errorf("set lock wait timeout: %+v", err)
+	}
+	if timeout == m.lockWaitTimeout {
+		return restore, nil
+	}
+	return func() error {
+		if err := lw.SetLockWaitTimeout(ctx, q, m.lockWaitTimeout); err != nil {
+			return fmt.Errorf("restore lock wait timeout: %+v", err)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,105 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMigrateTimeout(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	m.SetTimeout(20 * time.Millisecond)
+	m.AddGoContextMigration("1", "hang", func(ctx *MigrationContext) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	started := false
+	m.AddGoMigration("2", "next", func(con *sql.DB) error {
+		started = true
+		return nil
+	})
+	start := time.Now()
+	if err := m.Migrate(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want deadline exceeded, got: %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("want migrate to be aborted, took: %s", d)
+	}
+	if started {
+		t.Fatalf("want no migration started after the timeout")
+	}
+}
+
+type timeoutSupport struct {
+	memSupport
+}
+
+func (s *timeoutSupport) SetStatementTimeout(ctx context.Context, q Querier, timeout time.Duration) error {
+	return CockroachSupport{}.SetStatementTimeout(ctx, q, timeout)
+}
+
+func TestMigrateStatementTimeout(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &timeoutSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.SetBatch(Batch{Transaction: true})
+	m.SetStatementTimeout(250 * time.Millisecond)
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"BEGIN", "SET statement_timeout = '250ms';", "CREATE TABLE a (id INT);", "COMMIT"}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
+
+type lockWaitSupport struct {
+	memSupport
+}
+
+func (s *lockWaitSupport) SetLockWaitTimeout(ctx context.Context, q Querier, timeout time.Duration) error {
+	return CockroachSupport{}.SetLockWaitTimeout(ctx, q, timeout)
+}
+
+func TestMigrateLockWaitTimeout(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	m := NewMigrator(t.Logf, db, &lockWaitSupport{})
+	m.SetBatch(Batch{Transaction: true})
+	m.SetLockWaitTimeout(2 * time.Second)
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	m.AddSQLMigration("2", "two", "CREATE INDEX a_id ON a (id);", LockWaitTimeout(100*time.Millisecond))
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"BEGIN", "SET lock_timeout = '2000ms';", "CREATE TABLE a (id INT);", "COMMIT",
+		"BEGIN", "SET lock_timeout = '100ms';", "CREATE INDEX a_id ON a (id);", "SET lock_timeout = '2000ms';", "COMMIT",
+	}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	log.statements = nil
+	m = NewMigrator(t.Logf, db, &lockWaitSupport{})
+	m.AddSQLMigration("1", "one", "CREATE INDEX a_id ON a (id);", LockWaitTimeout(100*time.Millisecond))
+	m.AddSQLMigration("2", "two", "CREATE TABLE b (id INT);")
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"SET lock_timeout = '100ms';", "CREATE INDEX a_id ON a (id);", "SET lock_timeout = '0ms';", "CREATE TABLE b (id INT);"}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	m = NewMigrator(t.Logf, db, &memSupport{})
+	m.SetLockWaitTimeout(time.Second)
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);")
+	if err := m.Migrate(); err == nil {
+		t.Errorf("want error for a support that does not limit lock waits")
+	}
+}
@@ -3,60 +3,409 @@ package migrate
 import (
 	"bufio"
 	"bytes"
+	"io"
 	"log"
-	"regexp"
+	"strconv"
 	"strings"
 )
 
+// directivePrefix starts a comment line that configures the execution of the following statement, e.g. "-- migrate:retry 3".
+const directivePrefix = "-- migrate:"
+
+// Statement is a single statement of a script together with the directives that apply to it.
+type Statement struct {
+	SQL string
+	// Retries is the number of times the statement is retried after a failure.
+	Retries int
+}
+
 func Statements(script string) []string {
 	ss := []string{}
-	builder := NewStatementBuilder()
-	scanner := bufio.NewScanner(strings.NewReader(script))
-	for scanner.Scan() {
-		builder.Append(scanner.Text())
-		if builder.IsTerminated() {
-			ss = append(ss, builder.Statement())
-			builder = NewStatementBuilder()
-		}
+	for _, stmt := range ParseStatements(script) {
+		ss = append(ss, stmt.SQL)
 	}
 	return ss
 }
 
-func NewStatementBuilder() *StatementBuilder {
-	return &StatementBuilder{
-		buffer: &bytes.Buffer{},
+func ParseStatements(script string) []Statement {
+	return DefaultSplitter.Parse(script)
+}
+
+// Splitter splits scripts into statements according to the conventions of a SQL dialect.
+// Delimiters inside string literals, quoted identifiers, comments and dollar-quoted bodies do not terminate a statement.
+type Splitter struct {
+	// Delimiter terminates statements. Statements terminated by a delimiter other than ";" are returned without it.
+	Delimiter string
+	// DelimiterDirective enables MySQL style "DELIMITER //" lines that change the delimiter.
+	DelimiterDirective bool
+	// BatchSeparator is a line that terminates the current statement, e.g. "GO" for SQL Server.
+	BatchSeparator string
+	// BlockTerminator is a line that terminates a PL/SQL block, e.g. "/" for Oracle. Delimiters within a statement
+	// starting with BEGIN or DECLARE or creating a function, procedure, package, trigger or type do not terminate it.
+	BlockTerminator string
+	// StripDelimiter returns statements terminated by ";" without it, e.g. for Oracle, which rejects it.
+	StripDelimiter bool
+	// DollarQuotes enables PostgreSQL style $tag$ quoted text.
+	DollarQuotes bool
+	// BackslashEscapes enables MySQL style backslash escapes in string literals.
+	BackslashEscapes bool
+	// Normalize strips a leading UTF-8 byte order mark. CRLF line endings are always accepted.
+	Normalize bool
+}
+
+var (
+	DefaultSplitter   = Splitter{Delimiter: ";", DollarQuotes: true}
+	MySQLSplitter     = Splitter{Delimiter: ";", DelimiterDirective: true, BackslashEscapes: true}
+	SQLServerSplitter = Splitter{BatchSeparator: "GO"}
+	OracleSplitter    = Splitter{Delimiter: ";", BlockTerminator: "/", StripDelimiter: true}
+)
+
+// Parse splits script into statements.
+// Every line outside of quoted text is trimmed and a trailing statement without delimiter is returned as well.
+func (s Splitter) Parse(script string) []Statement {
+	var stmts []Statement
+	sr := s.NewReader(strings.NewReader(script))
+	for sr.Next() {
+		stmts = append(stmts, sr.Statement())
+	}
+	return stmts
+}
+
+// NewReader returns a StatementReader that splits the script read from r like Parse.
+func (s Splitter) NewReader(r io.Reader) *StatementReader {
+	return &StatementReader{
+		t: &tokenizer{
+			splitter:  s,
+			delimiter: s.Delimiter,
+			buffer:    &bytes.Buffer{},
+			word:      &bytes.Buffer{},
+		},
+		r:     bufio.NewReader(r),
+		start: s.Normalize,
 	}
 }
 
-type StatementBuilder struct {
-	createTrigger bool
-	terminated    bool
-	buffer        *bytes.Buffer
+// StatementsFromReader returns a StatementReader that splits the script read from r with the DefaultSplitter.
+func StatementsFromReader(r io.Reader) *StatementReader {
+	return DefaultSplitter.NewReader(r)
+}
+
+// StatementReader reads the statements of a script from an io.Reader, so that large scripts are never held in memory
+// as a whole. Lines may be of any length. Only a single statement is buffered at a time.
+type StatementReader struct {
+	t    *tokenizer
+	r    *bufio.Reader
+	stmt Statement
+	err  error
+	eof  bool
+	// start is set until the first line has been read if a byte order mark has to be stripped.
+	start bool
 }
 
-func (b *StatementBuilder) Append(line string) {
-	line = strings.TrimSpace(line)
-	var err error
-	if b.buffer.Len() == 0 {
-		b.createTrigger, err = regexp.MatchString("CREATE( TEMP| TEMPORARY)? TRIGGER.*", line)
-		if err != nil {
-			log.Printf("match: %+v", err)
+// Next advances to the next statement, which is then available through Statement.
+// It returns false at the end of the script or if reading failed.
+func (sr *StatementReader) Next() bool {
+	for len(sr.t.statements) == 0 {
+		if sr.eof || sr.err != nil {
+			return false
+		}
+		line, err := sr.r.ReadString('\n')
+		if sr.start {
+			line = strings.TrimPrefix(line, bom)
+			sr.start = false
+		}
+		if len(line) > 0 {
+			sr.t.line(strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+		}
+		switch err {
+		case nil:
+		case io.EOF:
+			sr.t.flush()
+			sr.eof = true
+		default:
+			sr.err = err
+			return false
 		}
-	} else {
-		b.buffer.WriteString("\n")
 	}
-	b.buffer.WriteString(line)
-	if b.createTrigger {
-		b.terminated = strings.HasSuffix(line, "END;")
-	} else {
-		b.terminated = strings.HasSuffix(line, ";")
+	sr.stmt = sr.t.statements[0]
+	sr.t.statements = sr.t.statements[1:]
+	return true
+}
+
+// Statement returns the statement read by the last call to Next.
+func (sr *StatementReader) Statement() Statement {
+	return sr.stmt
+}
+
+// Err returns the first error other than io.EOF encountered while reading.
+func (sr *StatementReader) Err() error {
+	return sr.err
+}
+
+// tokenizer tracks quoting and comment state across the lines of a script.
+type tokenizer struct {
+	splitter   Splitter
+	delimiter  string
+	statements []Statement
+
+	quote     byte   // the quote character while inside quoted text
+	dollarTag string // the tag while inside dollar-quoted text
+	comments  int    // the depth of nested block comments
+
+	buffer  *bytes.Buffer
+	hasCode bool
+	retries int
+	word    *bytes.Buffer
+	words   []string // the leading keywords of the statement
+	trigger bool
+	depth   int  // the depth of BEGIN/CASE ... END blocks in a trigger
+	block   bool // whether the statement is a PL/SQL block terminated by the BlockTerminator
+}
+
+func (t *tokenizer) plain() bool {
+	return t.quote == 0 && t.dollarTag == "" && t.comments == 0
+}
+
+func (t *tokenizer) line(line string) {
+	if t.plain() {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, directivePrefix):
+			t.directive(strings.Fields(strings.TrimPrefix(line, directivePrefix)))
+			return
+		case t.splitter.DelimiterDirective && strings.HasPrefix(strings.ToUpper(line), "DELIMITER "):
+			t.delimiter = strings.TrimSpace(line[len("DELIMITER "):])
+			return
+		case t.splitter.BatchSeparator != "" && strings.EqualFold(line, t.splitter.BatchSeparator):
+			t.flush()
+			return
+		case t.splitter.BlockTerminator != "" && line == t.splitter.BlockTerminator:
+			t.flush()
+			return
+		case line == "" && t.buffer.Len() == 0:
+			return
+		}
+	}
+	if t.buffer.Len() > 0 {
+		t.buffer.WriteByte('\n')
+	}
+	for i := 0; i < len(line); {
+		i += t.next(line[i:])
+	}
+	t.endWord()
+	if t.plain() {
+		trimmed := bytes.TrimRight(t.buffer.Bytes(), " \t\r")
+		t.buffer.Truncate(len(trimmed))
 	}
 }
 
-func (b *StatementBuilder) IsTerminated() bool {
-	return b.terminated
+// next consumes the start of rest and returns the number of bytes consumed.
+func (t *tokenizer) next(rest string) int {
+	c := rest[0]
+	switch {
+	case t.comments > 0:
+		switch {
+		case strings.HasPrefix(rest, "*/"):
+			t.comments--
+			t.buffer.WriteString("*/")
+			return 2
+		case strings.HasPrefix(rest, "/*"):
+			t.comments++
+			t.buffer.WriteString("/*")
+			return 2
+		}
+		t.buffer.WriteByte(c)
+		return 1
+	case t.quote != 0:
+		t.buffer.WriteByte(c)
+		switch {
+		case c == '\\' && t.splitter.BackslashEscapes && len(rest) > 1:
+			t.buffer.WriteByte(rest[1])
+			return 2
+		case c == t.quote && len(rest) > 1 && rest[1] == t.quote:
+			t.buffer.WriteByte(rest[1])
+			return 2
+		case c == t.quote:
+			t.quote = 0
+		}
+		return 1
+	case t.dollarTag != "":
+		if strings.HasPrefix(rest, t.dollarTag) {
+			t.buffer.WriteString(t.dollarTag)
+			n := len(t.dollarTag)
+			t.dollarTag = ""
+			return n
+		}
+		t.buffer.WriteByte(c)
+		return 1
+	}
+	if isIdentChar(c) && !(c == '$' && t.word.Len() == 0) {
+		t.word.WriteByte(c)
+		t.buffer.WriteByte(c)
+		t.hasCode = true
+		return 1
+	}
+	t.endWord()
+	switch {
+	case t.delimiter != "" && strings.HasPrefix(rest, t.delimiter) && !(t.trigger && t.depth > 0) && !t.block:
+		if t.delimiter == ";" && !t.splitter.StripDelimiter {
+			t.buffer.WriteString(t.delimiter)
+		}
+		t.terminate()
+		n := len(t.delimiter)
+		return n + len(rest[n:]) - len(strings.TrimLeft(rest[n:], " \t\r"))
+	case strings.HasPrefix(rest, "--"):
+		t.buffer.WriteString(rest)
+		return len(rest)
+	case strings.HasPrefix(rest, "/*"):
+		t.comments++
+		t.buffer.WriteString("/*")
+		return 2
+	case c == '\'' || c == '"' || c == '`':
+		t.quote = c
+	case c == '$' && t.splitter.DollarQuotes:
+		if tag := dollarTag(rest); tag != "" {
+			t.dollarTag = tag
+			t.buffer.WriteString(tag)
+			t.hasCode = true
+			return len(tag)
+		}
+	}
+	if c != ' ' && c != '\t' && c != '\r' {
+		t.hasCode = true
+	}
+	t.buffer.WriteByte(c)
+	return 1
+}
+
+// endWord completes the current word and tracks the keywords relevant for splitting.
+func (t *tokenizer) endWord() {
+	if t.word.Len() == 0 {
+		return
+	}
+	w := strings.ToUpper(t.word.String())
+	t.word.Reset()
+	if len(t.words) < 5 {
+		t.words = append(t.words, w)
+		if len(t.words) <= 3 {
+			t.trigger = isCreateTrigger(t.words)
+		}
+		t.block = t.splitter.BlockTerminator != "" && isBlock(t.words)
+	}
+	if !t.trigger {
+		return
+	}
+	switch w {
+	case "BEGIN", "CASE":
+		t.depth++
+	case "END":
+		t.depth--
+	}
+}
+
+// terminate completes the current statement at a delimiter.
+func (t *tokenizer) terminate() {
+	if !t.hasCode {
+		t.reset()
+		return
+	}
+	sql := t.buffer.String()
+	if t.delimiter != ";" || t.splitter.StripDelimiter {
+		sql = strings.TrimSpace(sql)
+	}
+	t.statements = append(t.statements, Statement{
+		SQL:     sql,
+		Retries: t.retries,
+	})
+	t.reset()
+}
+
+// flush completes a statement that has not been terminated by a delimiter.
+func (t *tokenizer) flush() {
+	t.endWord()
+	if t.hasCode {
+		t.statements = append(t.statements, Statement{
+			SQL:     strings.TrimSpace(t.buffer.String()),
+			Retries: t.retries,
+		})
+	}
+	t.reset()
+}
+
+func (t *tokenizer) reset() {
+	t.buffer.Reset()
+	t.hasCode = false
+	t.retries = 0
+	t.words = nil
+	t.trigger = false
+	t.depth = 0
+	t.block = false
+}
+
+func (t *tokenizer) directive(fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	switch fields[0] {
+	case "retry":
+		t.retries = 1
+		if len(fields) > 1 {
+			n, err := strconv.Atoi(fields[1])
+			if err != nil {
+				log.Printf("retry directive: %+v", err)
+			}
+			t.retries = n
+		}
+	default:
+		log.Printf("unknown directive: %s", fields[0])
+	}
+}
+
+func isCreateTrigger(words []string) bool {
+	if len(words) < 2 || words[0] != "CREATE" {
+		return false
+	}
+	if words[1] == "TRIGGER" {
+		return true
+	}
+	return len(words) == 3 && (words[1] == "TEMP" || words[1] == "TEMPORARY") && words[2] == "TRIGGER"
 }
 
-func (b *StatementBuilder) Statement() string {
-	return b.buffer.String()
+// isBlock reports whether the leading keywords of a statement start a PL/SQL block.
+func isBlock(words []string) bool {
+	switch words[0] {
+	case "BEGIN", "DECLARE":
+		return true
+	case "CREATE":
+	default:
+		return false
+	}
+	for _, w := range words[1:] {
+		switch w {
+		case "OR", "REPLACE", "EDITIONABLE", "NONEDITIONABLE":
+		case "FUNCTION", "PROCEDURE", "PACKAGE", "TRIGGER", "TYPE":
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// dollarTag returns the opening $tag$ at the start of s, if any.
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '$':
+			return s[:i+1]
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 1 && c >= '0' && c <= '9':
+		default:
+			return ""
+		}
+	}
+	return ""
 }
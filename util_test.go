@@ -33,6 +33,51 @@ func TestStatements(t *testing.T) {
 			`,
 			[]string{"CREATE TRIGGER IF NOT EXISTS stream_version AFTER INSERT ON events\nFOR EACH ROW\nBEGIN\nUPDATE streams SET version = NEW.streamIndex+1 WHERE id=NEW.streamID;\nEND;"},
 		},
+		{
+			"string literals",
+			`
+			INSERT INTO foo VALUES ('a;b', 'it''s; fine', "c;d");
+			INSERT INTO foo VALUES ('multi
+			  line;');
+			`,
+			[]string{"INSERT INTO foo VALUES ('a;b', 'it''s; fine', \"c;d\");", "INSERT INTO foo VALUES ('multi\n\t\t\t  line;');"},
+		},
+		{
+			"comments",
+			`
+			-- drop foo; it is obsolete
+			DROP TABLE foo; /* and bar; too */ DROP TABLE bar;
+			/* trailing; comment */
+			`,
+			[]string{"-- drop foo; it is obsolete\nDROP TABLE foo;", "/* and bar; too */ DROP TABLE bar;"},
+		},
+		{
+			"dollar quotes",
+			`
+			CREATE FUNCTION inc(i integer) RETURNS integer AS $$
+			BEGIN
+			  RETURN i + 1;
+			END;
+			$$ LANGUAGE plpgsql;
+			SELECT $body$;$body$;
+			`,
+			[]string{"CREATE FUNCTION inc(i integer) RETURNS integer AS $$\n\t\t\tBEGIN\n\t\t\t  RETURN i + 1;\n\t\t\tEND;\n\t\t\t$$ LANGUAGE plpgsql;", "SELECT $body$;$body$;"},
+		},
+		{
+			"trigger with case",
+			`
+			CREATE TEMP TRIGGER foo AFTER INSERT ON bar BEGIN
+			UPDATE baz SET qux = CASE WHEN NEW.a THEN 1 ELSE 0 END;
+			END;
+			SELECT 1;
+			`,
+			[]string{"CREATE TEMP TRIGGER foo AFTER INSERT ON bar BEGIN\nUPDATE baz SET qux = CASE WHEN NEW.a THEN 1 ELSE 0 END;\nEND;", "SELECT 1;"},
+		},
+		{
+			"unterminated",
+			"CREATE TABLE foo (bar PRIMARY KEY);\nDROP TABLE foo",
+			[]string{"CREATE TABLE foo (bar PRIMARY KEY);", "DROP TABLE foo"},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -46,3 +91,102 @@ func TestStatements(t *testing.T) {
 		})
 	}
 }
+
+func TestParseStatements(t *testing.T) {
+	script := `
+	-- migrate:retry 2
+	CREATE INDEX CONCURRENTLY foo_bar ON foo (bar);
+	CREATE TABLE baz (qux PRIMARY KEY);
+	-- migrate:retry
+	DROP INDEX foo_bar;
+	`
+	want := []Statement{
+		{SQL: "CREATE INDEX CONCURRENTLY foo_bar ON foo (bar);", Retries: 2},
+		{SQL: "CREATE TABLE baz (qux PRIMARY KEY);"},
+		{SQL: "DROP INDEX foo_bar;", Retries: 1},
+	}
+	got := ParseStatements(script)
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %#v, got: %#v", want, got)
+	}
+}
+
+func TestSplitter(t *testing.T) {
+	tests := []struct {
+		name       string
+		splitter   Splitter
+		script     string
+		statements []string
+	}{
+		{
+			"mysql",
+			MySQLSplitter,
+			`
+			DROP PROCEDURE IF EXISTS foo;
+			DELIMITER //
+			CREATE PROCEDURE foo()
+			BEGIN
+			SELECT 1;
+			END //
+			DELIMITER ;
+			CALL foo();
+			`,
+			[]string{"DROP PROCEDURE IF EXISTS foo;", "CREATE PROCEDURE foo()\nBEGIN\nSELECT 1;\nEND", "CALL foo();"},
+		},
+		{
+			"mysql escapes",
+			MySQLSplitter,
+			`INSERT INTO foo VALUES ('it\'s; fine');`,
+			[]string{`INSERT INTO foo VALUES ('it\'s; fine');`},
+		},
+		{
+			"sqlserver",
+			SQLServerSplitter,
+			`
+			CREATE TABLE foo (bar INT);
+			GO
+			CREATE PROCEDURE baz AS
+			SELECT 1;
+			SELECT 2;
+			go
+			EXEC baz;
+			`,
+			[]string{"CREATE TABLE foo (bar INT);", "CREATE PROCEDURE baz AS\nSELECT 1;\nSELECT 2;", "EXEC baz;"},
+		},
+		{
+			"oracle",
+			OracleSplitter,
+			`
+			CREATE TABLE foo (bar NUMBER);
+			CREATE OR REPLACE PROCEDURE baz AS
+			BEGIN
+			  INSERT INTO foo VALUES (1);
+			  UPDATE foo SET bar = 2;
+			END;
+			/
+			BEGIN
+			  baz;
+			END;
+			/
+			INSERT INTO foo VALUES (3)
+			`,
+			[]string{
+				"CREATE TABLE foo (bar NUMBER)",
+				"CREATE OR REPLACE PROCEDURE baz AS\nBEGIN\nINSERT INTO foo VALUES (1);\nUPDATE foo SET bar = 2;\nEND;",
+				"BEGIN\nbaz;\nEND;",
+				"INSERT INTO foo VALUES (3)",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := []string{}
+			for _, stmt := range test.splitter.Parse(test.script) {
+				got = append(got, stmt.SQL)
+			}
+			if !reflect.DeepEqual(test.statements, got) {
+				t.Errorf("want: %#v, got: %#v", test.statements, got)
+			}
+		})
+	}
+}
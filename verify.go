@@ -0,0 +1,98 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultVerifyTimeout limits the verifications of a migration unless configured otherwise.
+const DefaultVerifyTimeout = 30 * time.Second
+
+// ErrVerificationFailed is reported if a verification of a migration did not return the expected result.
+var ErrVerificationFailed = errors.New("verification failed")
+
+// Verification is a query run after a migration that has to return a single value equal to Expected.
+// NULL is compared as "NULL".
+type Verification struct {
+	Query    string
+	Expected string
+}
+
+// Verify adds a verification that fails the migration unless query returns expected, e.g.
+// Verify("SELECT count(*) FROM users WHERE email IS NULL", "0") after a backfill.
+func Verify(query string, expected string) MigrationOption {
+	return func(o *MigrationOptions) {
+		o.Verifications = append(o.Verifications, Verification{Query: query, Expected: expected})
+	}
+}
+
+// VerifyTimeout limits the time of all verifications of a migration.
+func VerifyTimeout(timeout time.Duration) MigrationOption {
+	return func(o *MigrationOptions) {
+		o.VerifyTimeout = timeout
+	}
+}
+
+// Undo sets the function that reverts the migration if a verification fails outside of a transaction.
+func Undo(undo CommandFunc) MigrationOption {
+	return func(o *MigrationOptions) {
+		o.Undo = undo
+	}
+}
+
+// UndoSQL sets the script that reverts the migration if a verification fails outside of a transaction. It is also
+// rendered by GenerateRollbackPlan.
+func UndoSQL(script string) MigrationOption {
+	return func(o *MigrationOptions) {
+		o.Undo = sqlExecutor(script)
+		o.UndoScript = script
+	}
+}
+
+// verify runs the verifications of mig.
+func (m *Migrator) verify(ctx context.Context, q Querier, mig Migration) error {
+	if len(mig.Options.Verifications) == 0 {
+		return nil
+	}
+	timeout := mig.Options.VerifyTimeout
+	if timeout <= 0 {
+		timeout = DefaultVerifyTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for _, v := range mig.Options.Verifications {
+		var value sql.NullString
+		if err := q.QueryRowContext(ctx, v.Query).Scan(&value); err != nil {
+			return &MigrationError{Err: ErrVerificationFailed, Migration: mig, Detail: fmt.Sprintf("%s: %v", v.Query, err)}
+		}
+		got := "NULL"
+		if value.Valid {
+			got = value.String
+		}
+		if got != v.Expected {
+			return &MigrationError{Err: ErrVerificationFailed, Migration: mig, Detail: fmt.Sprintf("%s: want %s, got %s", v.Query, v.Expected, got)}
+		}
+	}
+	return nil
+}
+
+// verifyOrUndo runs the verifications of mig outside of a transaction and reverts mig if one fails.
+func (m *Migrator) verifyOrUndo(ctx context.Context, mig Migration) error {
+	err := m.verify(ctx, m.db, mig)
+	if err == nil || mig.Options.Undo == nil {
+		return err
+	}
+	if cErr := m.confirmed(OpUndo, fmt.Sprintf("%s: %v", mig, err)); cErr != nil {
+		return fmt.Errorf("%v: undo: %+v", err, cErr)
+	}
+	fields := migrationFields(mig)
+	fields["error"] = err
+	m.log(LevelWarn, "undoing migration", fields)
+	if uErr := mig.Options.Undo(m.db); uErr != nil {
+		return fmt.Errorf("%v: undo: %+v", err, uErr)
+	}
+	return err
+}
@@ -0,0 +1,34 @@
+package migrate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMigrateVerify(t *testing.T) {
+	db, log := openFake(t.Name())
+	defer db.Close()
+	log.results = map[string]string{
+		"SELECT count(*) FROM a": "0",
+		"SELECT count(*) FROM b": "3",
+	}
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	m.AddSQLMigration("1", "one", "CREATE TABLE a (id INT);", Verify("SELECT count(*) FROM a", "0"))
+	m.AddSQLMigration("2", "two", "CREATE TABLE b (id INT);", Verify("SELECT count(*) FROM b", "0"), UndoSQL("DROP TABLE b;"))
+	err := m.Migrate()
+	if !errors.Is(err, ErrVerificationFailed) {
+		t.Fatalf("want ErrVerificationFailed, got: %v", err)
+	}
+	want := []string{
+		"CREATE TABLE a (id INT);", "SELECT count(*) FROM a",
+		"CREATE TABLE b (id INT);", "SELECT count(*) FROM b", "DROP TABLE b;",
+	}
+	if got := log.Statements(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	if len(s.migrations) != 2 || s.migrations[1].Status != StatusFailed {
+		t.Errorf("unexpected migrations:\n%s", s.migrations)
+	}
+}
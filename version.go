@@ -0,0 +1,263 @@
+package migrate
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimestampLayout is the layout of timestamp versions like 20240131120000.
+const TimestampLayout = "20060102150405"
+
+// VersionOrdering is the strategy the Migrator orders versions with.
+type VersionOrdering int
+
+const (
+	// OrderNumeric compares versions as integers, so legacy versions like 57 precede timestamps like 20240131120000.
+	// Dotted versions like 1.2.3 or 1_2 are compared part by part.
+	OrderNumeric VersionOrdering = iota
+	// OrderLegacyFirst orders all versions that are not timestamps before the timestamp versions, regardless of their
+	// magnitude, e.g. when legacy versions have been numbered with large integers.
+	OrderLegacyFirst
+)
+
+// VersionComparator defines the order of versions, e.g. for schemes like dates with suffixes or branch-qualified
+// versions like 42.1-hotfix. A VersionOrdering is a VersionComparator.
+type VersionComparator interface {
+	// Compare returns a negative number, zero or a positive number if a is ordered before, equal to or after b.
+	Compare(a Version, b Version) int
+}
+
+// Compare returns -1, 0 or 1 if a is ordered before, equal to or after b. Versions with the same integer value,
+// e.g. "1" and "01", are equal. Versions are compared part by part, the parts being separated by dots or underscores,
+// e.g. "1.10" follows "1.9" and "1.1" follows "1". The leading digits of a part are compared as an integer and the
+// rest of it by its text, e.g. "42.1-hotfix" precedes "42.2-hotfix".
+func (o VersionOrdering) Compare(a Version, b Version) int {
+	if o == OrderLegacyFirst && a.IsTimestamp() != b.IsTimestamp() {
+		if b.IsTimestamp() {
+			return -1
+		}
+		return 1
+	}
+	ap, bp := versionParts(a), versionParts(b)
+	for i := 0; i < len(ap) && i < len(bp); i++ {
+		if c := compareVersionPart(ap[i], bp[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(ap) < len(bp):
+		return -1
+	case len(ap) > len(bp):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// versionParts splits v at dots and underscores.
+func versionParts(v Version) []string {
+	return strings.FieldsFunc(string(v), func(r rune) bool {
+		return r == '.' || r == '_'
+	})
+}
+
+// compareVersionPart compares the leading digits of a and b as integers and the rest by its text.
+func compareVersionPart(a string, b string) int {
+	ad, arest := leadingDigits(a)
+	bd, brest := leadingDigits(b)
+	ad, bd = strings.TrimLeft(ad, "0"), strings.TrimLeft(bd, "0")
+	switch {
+	case len(ad) != len(bd):
+		if len(ad) < len(bd) {
+			return -1
+		}
+		return 1
+	case ad != bd:
+		return strings.Compare(ad, bd)
+	default:
+		return strings.Compare(arest, brest)
+	}
+}
+
+func leadingDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// Less reports whether a is ordered before b. Versions that compare as equal are ordered by their text, so the order
+// does not depend on the order in which migrations have been added.
+func (o VersionOrdering) Less(a Version, b Version) bool {
+	return versionOrder{o}.Less(a, b)
+}
+
+// versionOrder orders versions with a VersionComparator. The zero value orders them with OrderNumeric.
+type versionOrder struct {
+	VersionComparator
+}
+
+func (o versionOrder) compare(a Version, b Version) int {
+	if o.VersionComparator == nil {
+		return OrderNumeric.Compare(a, b)
+	}
+	return o.Compare(a, b)
+}
+
+// Less reports whether a is ordered before b. Versions that compare as equal are ordered by their text.
+func (o versionOrder) Less(a Version, b Version) bool {
+	if c := o.compare(a, b); c != 0 {
+		return c < 0
+	}
+	return a < b
+}
+
+// SetVersionOrdering sets the strategy the versions of migrations are ordered with. The default is OrderNumeric.
+func (m *Migrator) SetVersionOrdering(o VersionOrdering) {
+	m.versionOrdering = versionOrder{o}
+}
+
+// SetVersionComparator makes the Migrator order the versions of migrations with c instead of a VersionOrdering.
+// Versions that c considers equal conflict, see ErrConflict.
+func (m *Migrator) SetVersionComparator(c VersionComparator) {
+	m.versionOrdering = versionOrder{c}
+}
+
+// LatestVersion returns the highest version of the versioned migrations in ms.
+func LatestVersion(ms Migrations) Version {
+	return versionOrder{}.latest(ms)
+}
+
+// latest returns the highest version of the versioned migrations in ms under o.
+func (o versionOrder) latest(ms Migrations) Version {
+	latest := VersionNone
+	for _, mig := range ms {
+		if !mig.IsRepeatable() {
+			latest = o.later(latest, mig.Version)
+		}
+	}
+	return latest
+}
+
+// Sorted returns a copy of ms with the versioned migrations sorted by version, followed by the repeatable migrations
+// sorted by description.
+func (ms Migrations) Sorted() Migrations {
+	return versionOrder{}.sorted(ms)
+}
+
+// sorted returns ms sorted like Sorted with the versions ordered by o.
+func (o versionOrder) sorted(ms Migrations) Migrations {
+	sorted := append(Migrations{}, ms...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.IsRepeatable() && b.IsRepeatable() {
+			return a.Description < b.Description
+		}
+		if a.IsRepeatable() || b.IsRepeatable() {
+			return !a.IsRepeatable() && b.IsRepeatable()
+		}
+		return o.Less(a.Version, b.Version)
+	})
+	return sorted
+}
+
+// Latest returns the versioned migration with the highest version. It reports false if ms has no versioned migration.
+func (ms Migrations) Latest() (Migration, bool) {
+	latest := LatestVersion(ms)
+	for _, mig := range ms {
+		if latest != VersionNone && mig.Version == latest {
+			return mig, true
+		}
+	}
+	return Migration{}, false
+}
+
+// Between returns the versioned migrations with versions from a up to and including b, sorted by version.
+func (ms Migrations) Between(a Version, b Version) Migrations {
+	return versionOrder{}.between(ms, a, b)
+}
+
+// between returns the migrations of ms selected like Between with the versions ordered by o.
+func (o versionOrder) between(ms Migrations, a Version, b Version) Migrations {
+	between := Migrations{}
+	for _, mig := range o.sorted(ms) {
+		if mig.IsRepeatable() || mig.Version == VersionNone {
+			continue
+		}
+		if o.compare(a, mig.Version) <= 0 && o.compare(mig.Version, b) <= 0 {
+			between = append(between, mig)
+		}
+	}
+	return between
+}
+
+// DetectGaps returns the versions missing between the lowest and the highest integer version of ms, e.g. "3" for
+// versions "2" and "4". Timestamp versions have no expected successor, so ms has no gaps if any version is a timestamp.
+func (ms Migrations) DetectGaps() []Version {
+	present := map[int64]bool{}
+	var versions []int64
+	for _, mig := range ms {
+		if mig.IsRepeatable() || mig.Version == VersionNone {
+			continue
+		}
+		if mig.Version.IsTimestamp() {
+			return nil
+		}
+		i, err := strconv.ParseInt(string(mig.Version), 10, 64)
+		if err != nil || present[i] {
+			continue
+		}
+		present[i] = true
+		versions = append(versions, i)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	var gaps []Version
+	for i := 1; i < len(versions); i++ {
+		for v := versions[i-1] + 1; v < versions[i]; v++ {
+			gaps = append(gaps, Version(strconv.FormatInt(v, 10)))
+		}
+	}
+	return gaps
+}
+
+// IsTimestamp reports whether v follows the timestamp scheme.
+func (v Version) IsTimestamp() bool {
+	_, err := time.Parse(TimestampLayout, string(v))
+	return err == nil
+}
+
+// NextVersion returns the version for a migration following the existing ones.
+// If the latest existing version is a timestamp the current UTC time is used, otherwise the latest version is incremented.
+// Without existing migrations the integer scheme starting at 1 is used.
+func NextVersion(existing Migrations) Version {
+	return nextVersion(existing, time.Now())
+}
+
+// NextTimestampVersion returns a timestamp version for t that is higher than any of the existing versions.
+func NextTimestampVersion(existing Migrations, t time.Time) Version {
+	v := Version(t.UTC().Format(TimestampLayout))
+	if latest := LatestVersion(existing); latest != VersionNone && LEQ(v, latest) {
+		return increment(latest)
+	}
+	return v
+}
+
+func nextVersion(existing Migrations, now time.Time) Version {
+	latest := LatestVersion(existing)
+	switch {
+	case latest == VersionNone:
+		return "1"
+	case latest.IsTimestamp():
+		return NextTimestampVersion(existing, now)
+	default:
+		return increment(latest)
+	}
+}
+
+func increment(v Version) Version {
+	i, _ := strconv.ParseInt(string(v), 10, 64)
+	return Version(strconv.FormatInt(i+1, 10))
+}
@@ -0,0 +1,191 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNextVersion(t *testing.T) {
+	now := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		existing Migrations
+		want     Version
+	}{
+		{"empty", nil, "1"},
+		{"integer", Migrations{{Version: "2"}, {Version: "10"}, {Version: VersionRepeatable}}, "11"},
+		{"timestamp", Migrations{{Version: "20230101000000"}}, "20240131120000"},
+		{"timestamp in future", Migrations{{Version: "20250101000000"}}, "20250101000001"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := nextVersion(test.existing, now); got != test.want {
+				t.Errorf("want: %s, got: %s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestMigrationsRange(t *testing.T) {
+	ms := Migrations{{Version: "4"}, {Version: VersionRepeatable, Description: "view"}, {Version: "1"}, {Version: "7"}, {Version: "2"}}
+	if got := versions(ms.Sorted()); !reflect.DeepEqual(got, []Version{"1", "2", "4", "7", VersionRepeatable}) {
+		t.Errorf("unexpected order: %v", got)
+	}
+	if latest, ok := ms.Latest(); !ok || latest.Version != "7" {
+		t.Errorf("want latest 7, got: %v", latest.Version)
+	}
+	if _, ok := (Migrations{{Version: VersionRepeatable}}).Latest(); ok {
+		t.Errorf("want no latest migration")
+	}
+	if got := versions(ms.Between("2", "5")); !reflect.DeepEqual(got, []Version{"2", "4"}) {
+		t.Errorf("unexpected range: %v", got)
+	}
+	if got := ms.DetectGaps(); !reflect.DeepEqual(got, []Version{"3", "5", "6"}) {
+		t.Errorf("unexpected gaps: %v", got)
+	}
+	if got := (Migrations{{Version: "20230101000000"}, {Version: "20240101000000"}}).DetectGaps(); len(got) != 0 {
+		t.Errorf("want no gaps for timestamps, got: %v", got)
+	}
+}
+
+func versions(ms Migrations) []Version {
+	vs := []Version{}
+	for _, mig := range ms {
+		vs = append(vs, mig.Version)
+	}
+	return vs
+}
+
+func TestVersionOrdering(t *testing.T) {
+	vs := []Version{"20240131120000", "57", "01", "1", "100000000000000"}
+	sorted := func(o VersionOrdering) []Version {
+		got := append([]Version{}, vs...)
+		sort.Slice(got, func(i, j int) bool { return o.Less(got[i], got[j]) })
+		return got
+	}
+	if got, want := sorted(OrderNumeric), []Version{"01", "1", "57", "20240131120000", "100000000000000"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("numeric: want: %v, got: %v", want, got)
+	}
+	if got, want := sorted(OrderLegacyFirst), []Version{"01", "1", "57", "100000000000000", "20240131120000"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("legacy first: want: %v, got: %v", want, got)
+	}
+}
+
+func TestVersionOrderingDotted(t *testing.T) {
+	vs := []Version{"1.10", "2", "1.9", "1_2", "1", "1.2.1", "42.2-hotfix", "42.1-hotfix", "42"}
+	got := append([]Version{}, vs...)
+	sort.Slice(got, func(i, j int) bool { return OrderNumeric.Less(got[i], got[j]) })
+	want := []Version{"1", "1_2", "1.2.1", "1.9", "1.10", "2", "42", "42.1-hotfix", "42.2-hotfix"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if c := OrderNumeric.Compare("1.2", "1_2"); c != 0 {
+		t.Errorf("want 1.2 and 1_2 to be equal, got: %d", c)
+	}
+}
+
+// hotfixComparator orders versions like 42, 42.1-hotfix and 43 by their numeric parts.
+type hotfixComparator struct{}
+
+func (hotfixComparator) Compare(a Version, b Version) int {
+	parse := func(v Version) (int64, int64) {
+		s := strings.SplitN(strings.TrimSuffix(string(v), "-hotfix"), ".", 2)
+		major, _ := strconv.ParseInt(s[0], 10, 64)
+		var minor int64
+		if len(s) == 2 {
+			minor, _ = strconv.ParseInt(s[1], 10, 64)
+		}
+		return major, minor
+	}
+	amajor, aminor := parse(a)
+	bmajor, bminor := parse(b)
+	switch {
+	case amajor != bmajor:
+		return int(amajor - bmajor)
+	default:
+		return int(aminor - bminor)
+	}
+}
+
+func TestVersionComparator(t *testing.T) {
+	m := newTestMigrator(t, &memSupport{})
+	m.SetVersionComparator(hotfixComparator{})
+	order := []Version{}
+	step := func(v Version) CommandFunc {
+		return func(con *sql.DB) error {
+			order = append(order, v)
+			return nil
+		}
+	}
+	for _, v := range []Version{"43", "42.1-hotfix", "42", "42.2-hotfix"} {
+		m.AddGoMigration(v, "step "+string(v), step(v))
+	}
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []Version{"42", "42.1-hotfix", "42.2-hotfix", "43"}; !reflect.DeepEqual(want, order) {
+		t.Errorf("want: %v, got: %v", want, order)
+	}
+	m.AddGoMigration("42.1", "duplicate", step("42.1"))
+	if err := m.Migrate(); !errors.Is(err, ErrConflict) {
+		t.Errorf("want conflict, got: %v", err)
+	}
+}
+
+func TestAddDirVersionComparator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"V43__d.sql", "V42.2-hotfix__c.sql", "V42__a.sql", "V42.1-hotfix__b.sql"} {
+		write(name)
+	}
+	m := newTestMigrator(t, &memSupport{})
+	m.SetVersionComparator(hotfixComparator{})
+	if err := m.AddDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	want := []Version{"42", "42.1-hotfix", "42.2-hotfix", "43"}
+	if got := versions(m.Info().Pending()); !reflect.DeepEqual(want, got) {
+		t.Errorf("want pending: %v, got: %v", want, got)
+	}
+	write("V42.1__e.sql")
+	m = newTestMigrator(t, &memSupport{})
+	m.SetVersionComparator(hotfixComparator{})
+	if err := m.AddDir(dir); err == nil || !strings.Contains(err.Error(), "same version") {
+		t.Errorf("want conflict of 42.1 and 42.1-hotfix, got: %v", err)
+	}
+}
+
+func TestInfoLegacyFirst(t *testing.T) {
+	s := &memSupport{}
+	m := newTestMigrator(t, s)
+	m.SetVersionOrdering(OrderLegacyFirst)
+	noop := func(con *sql.DB) error { return nil }
+	m.AddGoMigration("100000000000000", "legacy", noop)
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	m.AddGoMigration("20240131120000", "timestamp", noop)
+	if got, want := versions(m.Info().Pending()), []Version{"20240131120000"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("want pending: %v, got: %v", want, got)
+	}
+	if err := m.Validate(); err != nil {
+		t.Errorf("validate: %v", err)
+	}
+}
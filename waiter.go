@@ -0,0 +1,51 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Waiter blocks until the effects of an executed migration are complete.
+// Engines like BigQuery or Athena execute DDL asynchronously, so the return of Exec does not mean that the migration is done.
+// A migration is only recorded as successful after Wait returned without error.
+type Waiter interface {
+	Wait(con *sql.DB, mig Migration) error
+}
+
+// WaiterFunc is a function that implements Waiter.
+type WaiterFunc func(con *sql.DB, mig Migration) error
+
+func (f WaiterFunc) Wait(con *sql.DB, mig Migration) error {
+	return f(con, mig)
+}
+
+// SetWaiter sets the Waiter that is consulted after every executed migration.
+func (m *Migrator) SetWaiter(waiter Waiter) {
+	m.waiter = waiter
+}
+
+// PollWaiter polls Done every Interval until it reports completion or Timeout elapses.
+// A Timeout of zero waits forever.
+type PollWaiter struct {
+	Interval time.Duration
+	Timeout  time.Duration
+	Done     func(con *sql.DB, mig Migration) (bool, error)
+}
+
+func (w PollWaiter) Wait(con *sql.DB, mig Migration) error {
+	start := time.Now()
+	for {
+		done, err := w.Done(con, mig)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		if w.Timeout > 0 && time.Since(start) >= w.Timeout {
+			return fmt.Errorf("timeout waiting for completion: %s", mig)
+		}
+		time.Sleep(w.Interval)
+	}
+}
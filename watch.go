@@ -0,0 +1,91 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// Watch is meant for development against a local database. It adds the migrations of dir, migrates and then polls dir
+// every pollInterval. Whenever a migration file is added, changed or removed, the migrations of dir are reloaded and
+// Migrate runs again, so changed repeatable migrations, e.g. views and functions, and new versioned migrations are
+// applied right away. Failures of Migrate are logged and watching continues. Watch returns when ctx is done or dir
+// cannot be read. Callbacks of dir are not loaded.
+func (m *Migrator) Watch(ctx context.Context, dir string, pollInterval time.Duration) error {
+	var loaded Migrations
+	last := ""
+	for {
+		fingerprint, err := dirFingerprint(dir)
+		if err != nil {
+			return fmt.Errorf("watch: %s: %+v", dir, err)
+		}
+		if fingerprint != last {
+			last = fingerprint
+			ms, err := LoadDir(dir)
+			if err != nil {
+				m.log(LevelError, "watch", Fields{"dir": dir, "error": err})
+			} else {
+				m.replace(loaded, ms)
+				loaded = ms
+				m.migrateWatched(dir)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// migrateWatched migrates after the migrations of dir have changed and logs the result.
+func (m *Migrator) migrateWatched(dir string) {
+	res, err := m.MigrateWithResult()
+	if err != nil {
+		m.log(LevelError, "watch", Fields{"dir": dir, "error": err})
+		return
+	}
+	m.log(LevelInfo, "watch", Fields{"dir": dir, "applied": len(res.Applied), "version": res.Version})
+}
+
+// replace removes the registered migrations with the versions, or descriptions if repeatable, of old and adds ms.
+func (m *Migrator) replace(old Migrations, ms Migrations) {
+	removed := map[string]bool{}
+	for _, mig := range old {
+		removed[manifestID(mig.Version, mig.Description)] = true
+	}
+	m.mu.Lock()
+	migrations, repeatable := Migrations{}, Migrations{}
+	for _, mig := range m.migrations {
+		if !removed[manifestID(mig.Version, mig.Description)] {
+			migrations = append(migrations, mig)
+		}
+	}
+	for _, mig := range m.repeatable {
+		if !removed[manifestID(mig.Version, mig.Description)] {
+			repeatable = append(repeatable, mig)
+		}
+	}
+	m.migrations, m.repeatable = migrations, repeatable
+	m.mu.Unlock()
+	for _, mig := range ms {
+		m.Add(mig)
+	}
+}
+
+// dirFingerprint returns the names, sizes and modification times of the migration files in dir.
+func dirFingerprint(dir string) (string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	b := &strings.Builder{}
+	for _, info := range infos {
+		if _, ok := ParseMigrationFile(info.Name()); ok && !info.IsDir() {
+			fmt.Fprintf(b, "%s\x00%d\x00%d\n", info.Name(), info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name string, script string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("V1__users.sql", "CREATE TABLE users (id INT);")
+	write("R__active_users.sql", "CREATE VIEW active_users AS SELECT * FROM users;")
+	db, log := openFake(t.Name())
+	defer db.Close()
+	s := &memSupport{}
+	m := NewMigrator(t.Logf, db, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- m.Watch(ctx, dir, time.Millisecond)
+	}()
+	waitFor := func(n int) {
+		deadline := time.Now().Add(5 * time.Second)
+		for len(log.Statements()) < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("want %d statements, got: %q", n, log.Statements())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitFor(2)
+	write("R__active_users.sql", "CREATE OR REPLACE VIEW active_users AS SELECT id FROM users;")
+	write("V2__orders.sql", "CREATE TABLE orders (id INT);")
+	waitFor(4)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("want canceled, got: %v", err)
+	}
+	if got := len(m.local()); got != 3 {
+		t.Errorf("want reloaded migrations, got: %d", got)
+	}
+}